@@ -0,0 +1,157 @@
+// Package checks implements Nagios and Icinga compatible checks for EdgeMAX
+// devices.
+//
+// Each check evaluates data retrieved from a device and produces a Result,
+// which can be printed in the standard plugin output format and converted
+// into a plugin exit code:
+//
+//	r := checks.CPU(ss, checks.Thresholds{Warning: 80, Critical: 95})
+//	fmt.Println(r)
+//	os.Exit(r.Status.ExitCode())
+package checks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Status is the status of a check.
+type Status int
+
+// Possible Status values, ordered by increasing severity.
+const (
+	OK Status = iota
+	Warning
+	Critical
+	Unknown
+)
+
+// String returns the string representation of a Status.
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warning:
+		return "WARNING"
+	case Critical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ExitCode returns the plugin exit code for a Status.
+func (s Status) ExitCode() int {
+	switch s {
+	case OK, Warning, Critical:
+		return int(s)
+	default:
+		return int(Unknown)
+	}
+}
+
+// Thresholds specifies the values at or above which a check's status is
+// Warning or Critical.  A zero threshold is disabled.
+type Thresholds struct {
+	Warning  float64
+	Critical float64
+}
+
+// status returns the Status of v according to t.
+func (t Thresholds) status(v float64) Status {
+	switch {
+	case t.Critical > 0 && v >= t.Critical:
+		return Critical
+	case t.Warning > 0 && v >= t.Warning:
+		return Warning
+	default:
+		return OK
+	}
+}
+
+// A Result is the result of a check.
+type Result struct {
+	// Name is the name of the check, such as "CPU".
+	Name string
+
+	Status  Status
+	Message string
+
+	// Perfdata contains performance data produced by the check.
+	Perfdata []Perfdata
+}
+
+// String returns the result in the standard plugin output format, such as:
+//
+//	CPU OK - 12% used | cpu=12%;80;95;0;100
+func (r *Result) String() string {
+	s := fmt.Sprintf("%s %s - %s", r.Name, r.Status, r.Message)
+	if len(r.Perfdata) == 0 {
+		return s
+	}
+
+	pds := make([]string, 0, len(r.Perfdata))
+	for _, pd := range r.Perfdata {
+		pds = append(pds, pd.String())
+	}
+
+	return s + " | " + strings.Join(pds, " ")
+}
+
+// Perfdata is a single performance data value.
+type Perfdata struct {
+	Label string
+	Value float64
+
+	// Unit is the unit of Value, such as "%", "B", or "c".
+	Unit string
+
+	// Thresholds, Min, and Max are optional, and are omitted from the
+	// output if zero.  Min is always included if Max is set, so that the
+	// value's range is complete.
+	Thresholds Thresholds
+	Min, Max   float64
+}
+
+// String returns the performance data in the standard plugin format.
+func (pd Perfdata) String() string {
+	label := pd.Label
+	if strings.ContainsAny(label, " '=") {
+		label = "'" + strings.Replace(label, "'", "''", -1) + "'"
+	}
+
+	min := optionalFloat(pd.Min)
+	if pd.Max != 0 {
+		min = formatFloat(pd.Min)
+	}
+
+	fields := []string{
+		formatFloat(pd.Value) + pd.Unit,
+		optionalFloat(pd.Thresholds.Warning),
+		optionalFloat(pd.Thresholds.Critical),
+		min,
+		optionalFloat(pd.Max),
+	}
+
+	// Trailing empty fields are omitted
+	for len(fields) > 1 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+
+	return label + "=" + strings.Join(fields, ";")
+}
+
+// formatFloat formats f using the fewest digits necessary.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// optionalFloat formats f, or returns the empty string if f is zero.
+func optionalFloat(f float64) string {
+	if f == 0 {
+		return ""
+	}
+
+	return formatFloat(f)
+}
@@ -0,0 +1,152 @@
+package checks
+
+import (
+	"net"
+	"testing"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestChecks(t *testing.T) {
+	ifis := edgemax.Interfaces{
+		{
+			Name:      "eth0",
+			Up:        true,
+			Addresses: []net.IP{net.IPv4(192, 0, 2, 1)},
+			Stats: edgemax.InterfaceStats{
+				ReceiveBPS:  1000,
+				TransmitBPS: 500,
+			},
+		},
+		{
+			Name: "eth1",
+			Up:   true,
+		},
+		{
+			Name: "eth2",
+		},
+	}
+
+	var tests = []struct {
+		desc   string
+		r      *Result
+		status Status
+		s      string
+	}{
+		{
+			desc:   "CPU OK",
+			r:      CPU(&edgemax.SystemStats{CPU: 12}, Thresholds{Warning: 80, Critical: 95}),
+			status: OK,
+			s:      "CPU OK - 12% used | cpu=12%;80;95;0;100",
+		},
+		{
+			desc:   "CPU critical",
+			r:      CPU(&edgemax.SystemStats{CPU: 95}, Thresholds{Warning: 80, Critical: 95}),
+			status: Critical,
+			s:      "CPU CRITICAL - 95% used | cpu=95%;80;95;0;100",
+		},
+		{
+			desc:   "memory warning, no critical threshold",
+			r:      Memory(&edgemax.SystemStats{Memory: 85}, Thresholds{Warning: 80}),
+			status: Warning,
+			s:      "MEMORY WARNING - 85% used | memory=85%;80;;0;100",
+		},
+		{
+			desc:   "WAN OK",
+			r:      WAN(ifis, "eth0"),
+			status: OK,
+			s:      "WAN OK - interface eth0 is up: 192.0.2.1 | rx_bps=1000 tx_bps=500 rx_errors=0c tx_errors=0c",
+		},
+		{
+			desc:   "WAN no addresses",
+			r:      WAN(ifis, "eth1"),
+			status: Warning,
+			s:      "WAN WARNING - interface eth1 is up with no addresses | rx_bps=0 tx_bps=0 rx_errors=0c tx_errors=0c",
+		},
+		{
+			desc:   "WAN down",
+			r:      WAN(ifis, "eth2"),
+			status: Critical,
+			s:      "WAN CRITICAL - interface eth2 is down | rx_bps=0 tx_bps=0 rx_errors=0c tx_errors=0c",
+		},
+		{
+			desc:   "WAN missing",
+			r:      WAN(ifis, "pppoe0"),
+			status: Critical,
+			s:      "WAN CRITICAL - interface pppoe0 not found",
+		},
+		{
+			desc:   "DHCP pool empty",
+			r:      DHCPPool("LAN", 0, 0, Thresholds{}),
+			status: Unknown,
+			s:      "DHCP UNKNOWN - pool LAN has no addresses",
+		},
+		{
+			desc:   "DHCP pool warning",
+			r:      DHCPPool("LAN pool", 90, 100, Thresholds{Warning: 80, Critical: 95}),
+			status: Warning,
+			s:      "DHCP WARNING - pool LAN pool 90/100 addresses leased (90%) | 'LAN pool'=90;80;95;0;100",
+		},
+		{
+			desc:   "VPN none",
+			r:      VPN(nil),
+			status: Unknown,
+			s:      "VPN UNKNOWN - no IPsec SAs configured",
+		},
+		{
+			desc: "VPN down",
+			r: VPN([]IPsecSA{
+				{Peer: "192.0.2.10", Tunnel: "1", Up: true},
+				{Peer: "192.0.2.20", Tunnel: "2"},
+			}),
+			status: Critical,
+			s:      "VPN CRITICAL - 1/2 SAs up, down: 192.0.2.20 tunnel 2 | sas_up=1;;;0;2",
+		},
+		{
+			desc: "VPN OK",
+			r: VPN([]IPsecSA{
+				{Peer: "192.0.2.10", Tunnel: "1", Up: true},
+			}),
+			status: OK,
+			s:      "VPN OK - 1/1 SAs up | sas_up=1;;;0;1",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		if want, got := tt.status, tt.r.Status; want != got {
+			t.Fatalf("unexpected status:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := tt.s, tt.r.String(); want != got {
+			t.Fatalf("unexpected output:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestStatusExitCode(t *testing.T) {
+	for s, want := range map[Status]int{
+		OK:         0,
+		Warning:    1,
+		Critical:   2,
+		Unknown:    3,
+		Status(-1): 3,
+	} {
+		if got := s.ExitCode(); want != got {
+			t.Fatalf("unexpected exit code for %v:\n- want: %v\n-  got: %v", s, want, got)
+		}
+	}
+}
+
+func TestPerfdataString(t *testing.T) {
+	for pd, want := range map[Perfdata]string{
+		{Label: "load", Value: 1.5}:                                  "load=1.5",
+		{Label: "cpu", Value: 12, Unit: "%", Max: 100}:               "cpu=12%;;;0;100",
+		{Label: "temp", Value: 40, Min: -10}:                         "temp=40;;;-10",
+		{Label: "a b", Value: 1, Thresholds: Thresholds{Warning: 2}}: "'a b'=1;2",
+	} {
+		if got := pd.String(); want != got {
+			t.Fatalf("unexpected perfdata for %q:\n- want: %v\n-  got: %v", pd.Label, want, got)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package checks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// CPU checks the CPU usage percentage reported in ss.
+func CPU(ss *edgemax.SystemStats, t Thresholds) *Result {
+	return percentCheck("CPU", "cpu", ss.CPU, t)
+}
+
+// Memory checks the memory usage percentage reported in ss.
+func Memory(ss *edgemax.SystemStats, t Thresholds) *Result {
+	return percentCheck("MEMORY", "memory", ss.Memory, t)
+}
+
+// percentCheck checks a usage percentage.
+func percentCheck(name string, label string, v int, t Thresholds) *Result {
+	return &Result{
+		Name:    name,
+		Status:  t.status(float64(v)),
+		Message: fmt.Sprintf("%d%% used", v),
+		Perfdata: []Perfdata{{
+			Label:      label,
+			Value:      float64(v),
+			Unit:       "%",
+			Thresholds: t,
+			Max:        100,
+		}},
+	}
+}
+
+// WAN checks the status of the WAN interface named wan in ifis.  The check is
+// Critical if the interface is missing or down, and Warning if it is up but
+// has no addresses, such as when DHCP or PPPoE has not completed.
+func WAN(ifis edgemax.Interfaces, wan string) *Result {
+	r := &Result{Name: "WAN"}
+
+	var ifi *edgemax.Interface
+	for _, i := range ifis {
+		if i.Name == wan {
+			ifi = i
+			break
+		}
+	}
+
+	switch {
+	case ifi == nil:
+		r.Status = Critical
+		r.Message = fmt.Sprintf("interface %s not found", wan)
+		return r
+	case !ifi.Up:
+		r.Status = Critical
+		r.Message = fmt.Sprintf("interface %s is down", wan)
+	case len(ifi.Addresses) == 0:
+		r.Status = Warning
+		r.Message = fmt.Sprintf("interface %s is up with no addresses", wan)
+	default:
+		addrs := make([]string, 0, len(ifi.Addresses))
+		for _, a := range ifi.Addresses {
+			addrs = append(addrs, a.String())
+		}
+
+		r.Status = OK
+		r.Message = fmt.Sprintf("interface %s is up: %s", wan, strings.Join(addrs, ", "))
+	}
+
+	r.Perfdata = []Perfdata{
+		{Label: "rx_bps", Value: float64(ifi.Stats.ReceiveBPS)},
+		{Label: "tx_bps", Value: float64(ifi.Stats.TransmitBPS)},
+		{Label: "rx_errors", Value: float64(ifi.Stats.ReceiveErrors), Unit: "c"},
+		{Label: "tx_errors", Value: float64(ifi.Stats.TransmitErrors), Unit: "c"},
+	}
+
+	return r
+}
+
+// DHCPPool checks the usage of the DHCP server pool named pool, which has
+// leased addresses in use out of size available addresses.  Thresholds are
+// specified as usage percentages.
+func DHCPPool(pool string, leased int, size int, t Thresholds) *Result {
+	r := &Result{Name: "DHCP"}
+	if size <= 0 {
+		r.Status = Unknown
+		r.Message = fmt.Sprintf("pool %s has no addresses", pool)
+		return r
+	}
+
+	pct := float64(leased) / float64(size) * 100
+
+	r.Status = t.status(pct)
+	r.Message = fmt.Sprintf("pool %s %d/%d addresses leased (%.0f%%)", pool, leased, size, pct)
+	r.Perfdata = []Perfdata{{
+		Label: pool,
+		Value: float64(leased),
+		Thresholds: Thresholds{
+			Warning:  t.Warning / 100 * float64(size),
+			Critical: t.Critical / 100 * float64(size),
+		},
+		Max: float64(size),
+	}}
+
+	return r
+}
+
+// An IPsecSA is the state of an IPsec VPN security association, such as
+// those reported by the "show vpn ipsec sa" operational command.
+type IPsecSA struct {
+	Peer   string
+	Tunnel string
+	Up     bool
+}
+
+// VPN checks the state of IPsec security associations.  The check is
+// Critical if any SA is down, and Unknown if sas is empty.
+func VPN(sas []IPsecSA) *Result {
+	r := &Result{Name: "VPN"}
+	if len(sas) == 0 {
+		r.Status = Unknown
+		r.Message = "no IPsec SAs configured"
+		return r
+	}
+
+	var down []string
+	for _, sa := range sas {
+		if !sa.Up {
+			down = append(down, fmt.Sprintf("%s tunnel %s", sa.Peer, sa.Tunnel))
+		}
+	}
+
+	up := len(sas) - len(down)
+	if len(down) == 0 {
+		r.Status = OK
+		r.Message = fmt.Sprintf("%d/%d SAs up", up, len(sas))
+	} else {
+		r.Status = Critical
+		r.Message = fmt.Sprintf("%d/%d SAs up, down: %s", up, len(sas), strings.Join(down, ", "))
+	}
+
+	r.Perfdata = []Perfdata{{
+		Label: "sas_up",
+		Value: float64(up),
+		Max:   float64(len(sas)),
+	}}
+
+	return r
+}
@@ -2,12 +2,20 @@
 package edgemax
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -40,8 +48,43 @@ func InsecureHTTPClient(timeout time.Duration) *http.Client {
 type Client struct {
 	UserAgent string
 
+	// Clock is used for time-dependent behavior.  If nil, the system
+	// clock is used.
+	Clock Clock
+
+	// DecodeWorkers is the number of goroutines used to decode statistics
+	// for each call to Stats.  If zero or one, statistics are decoded by
+	// the goroutine which receives them.  Otherwise, statistics of
+	// different types are decoded concurrently, so that large statistics
+	// such as DPI statistics do not delay others.  Statistics of each type
+	// are always delivered in the order they were received.
+	DecodeWorkers int
+
+	// ReconnectBackoff configures how a StatsStream reconnects after its
+	// connection to the device fails.  The zero value uses defaults.
+	ReconnectBackoff Backoff
+
+	// Reauthenticate enables transparent re-authentication.  If the device
+	// reports that the Client's session has expired, such as after the
+	// device's session timeout, the Client logs in again using the
+	// credentials passed to Login and retries the request once.  Requests
+	// with a body which cannot be sent again, such as firmware uploads, are
+	// not retried.
+	Reauthenticate bool
+
 	apiURL *url.URL
 	client *http.Client
+
+	// loginMu serializes re-authentication, so that concurrent requests
+	// which encounter an expired session only log in once.
+	loginMu sync.Mutex
+
+	// Credentials from Login, used to log in again if a session expires
+	// while Stats is running, and the ID of the session created by Login.
+	mu       sync.Mutex
+	username string
+	password string
+	session  string
 }
 
 // NewClient creates a new Client, using the input EdgeMAX device address
@@ -84,52 +127,650 @@ func NewClient(addr string, client *http.Client) (*Client, error) {
 	return c, nil
 }
 
+// ErrAuthFailed is returned by Client.Login when the EdgeMAX device rejects
+// the username or password.
+var ErrAuthFailed = errors.New("authentication failed")
+
+// An Error is an error reported by an EdgeMAX device in response to an API
+// request, either by an HTTP status code which does not indicate success, or
+// by an API response which does not indicate success.
+//
+// Client.Login reports rejected credentials using ErrAuthFailed, rather than
+// an *Error.
+type Error struct {
+	// StatusCode is the HTTP status code of the response, or zero if the
+	// status code is not known.
+	StatusCode int
+
+	// Endpoint is the path of the API endpoint, such as
+	// "/api/edge/data.json".
+	Endpoint string
+
+	// Message is the error message reported by the device, if any.
+	Message string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("EdgeMAX API request to %s failed: %s", e.Endpoint, msg)
+}
+
+// Unauthorized reports whether the device rejected the request because the
+// Client's session is not authenticated or has expired.  The Client must log
+// in again before the request can succeed.
+func (e *Error) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// Temporary reports whether the request failed due to a temporary condition,
+// such as the device being overloaded or restarting, and may be retried.
+func (e *Error) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+
+	return e.StatusCode >= 500
+}
+
+// newError creates an *Error for the response res to req, with the message
+// msg reported by the device.
+func newError(req *http.Request, res *http.Response, msg string) *Error {
+	// Login requests are sent to the device's root URL, which may have an
+	// empty path
+	endpoint := req.URL.Path
+	if endpoint == "" {
+		endpoint = "/"
+	}
+
+	return &Error{
+		StatusCode: res.StatusCode,
+		Endpoint:   endpoint,
+		Message:    msg,
+	}
+}
+
+// maxErrorResponseLength is the maximum length of an unsuccessful response
+// body which is inspected for an error message.
+const maxErrorResponseLength = 1 << 16
+
+// maxLoginResponseLength is the maximum length of a login response body
+// which is inspected for a login failure.
+const maxLoginResponseLength = 1 << 20
+
 // Login authenticates against the EdgeMAX device using the specified username
 // and password.  Login must be called and return a nil error before any
 // additional actions can be performed.
+//
+// If the device rejects the credentials, ErrAuthFailed is returned.  Other
+// unsuccessful responses are reported using an *Error.
 func (c *Client) Login(username string, password string) error {
+	return c.LoginContext(context.Background(), username, password)
+}
+
+// LoginContext is like Login, but uses ctx for the login request.
+func (c *Client) LoginContext(ctx context.Context, username string, password string) error {
 	v := make(url.Values, 2)
 	v.Set("username", username)
 	v.Set("password", password)
 
-	_, err := c.client.PostForm(c.apiURL.String(), v)
-	return err
+	// Discard the previous session, so that its cookies cannot be mistaken
+	// for those of the new session
+	c.clearSession()
+
+	req, err := http.NewRequest(http.MethodPost, c.apiURL.String(), strings.NewReader(v.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := c.checkLogin(req, res); err != nil {
+		return err
+	}
+
+	session := c.sessionID()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.username = username
+	c.password = password
+	c.session = session
+	return nil
+}
+
+const (
+	// sessionCookie is the name of the session cookie used to authenticate
+	// against EdgeMAX devices.
+	sessionCookie = "PHPSESSID"
+
+	// csrfCookie is the name of the cookie used by newer firmware to
+	// provide a CSRF token for the session.
+	csrfCookie = "X-CSRF-TOKEN"
+
+	// csrfHeader is the name of the header used to return the CSRF token to
+	// newer firmware, which rejects operations and configuration changes
+	// without it.
+	csrfHeader = "X-CSRF-TOKEN"
+)
+
+// clearSession removes the cookies of the current session from the Client's
+// cookie jar.
+func (c *Client) clearSession() {
+	c.mu.Lock()
+	c.session = ""
+	c.mu.Unlock()
+
+	// A cookie is removed by replacing it with an expired cookie
+	expired := make([]*http.Cookie, 0, 2)
+	for _, name := range []string{sessionCookie, csrfCookie} {
+		expired = append(expired, &http.Cookie{
+			Name:   name,
+			Path:   "/",
+			MaxAge: -1,
+		})
+	}
+
+	c.client.Jar.SetCookies(c.apiURL, expired)
 }
 
-// newRequest creates a new HTTP request, using the specified HTTP method and
-// API endpoint.
-func (c *Client) newRequest(method string, endpoint string) (*http.Request, error) {
+// checkLogin inspects the response to a login request for a login failure.
+// The EdgeMAX device responds to a failed login with 200 OK, so the response
+// itself must be checked.
+func (c *Client) checkLogin(req *http.Request, res *http.Response) error {
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return ErrAuthFailed
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return newError(req, res, "")
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, maxLoginResponseLength))
+	if err != nil {
+		return err
+	}
+
+	// A successful login redirects to the dashboard, while a failed login
+	// renders the login form again, or reports an error in JSON
+	redirected := res.Request.Method != http.MethodPost
+	if !redirected && bytes.Contains(b, []byte(`name="password"`)) {
+		return ErrAuthFailed
+	}
+
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		var ar apiResponse
+		if err := json.Unmarshal(b, &ar); err == nil && !ar.Success {
+			return ErrAuthFailed
+		}
+	}
+
+	if c.sessionID() == "" {
+		return ErrAuthFailed
+	}
+
+	return nil
+}
+
+// relogin logs in again using the credentials passed to Login.
+func (c *Client) relogin(ctx context.Context) error {
+	c.mu.Lock()
+	username, password := c.username, c.password
+	c.mu.Unlock()
+
+	if username == "" {
+		return errors.New("cannot log in again without credentials from Login")
+	}
+
+	return c.LoginContext(ctx, username, password)
+}
+
+// send performs the HTTP request req.  If Reauthenticate is enabled and the
+// device reports that the Client's session has expired, send logs in again
+// and retries req once.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	return c.sendClient(c.client, req)
+}
+
+// stream performs the HTTP request req as with send, for a response body
+// which is streamed until the stream ends or req's context is canceled.
+// The Client's http.Client.Timeout also bounds reading the response body,
+// so it does not apply to req.
+func (c *Client) stream(req *http.Request) (*http.Response, error) {
+	hc := *c.client
+	hc.Timeout = 0
+
+	return c.sendClient(&hc, req)
+}
+
+// sendClient performs the HTTP request req using hc, as with send.
+func (c *Client) sendClient(hc *http.Client, req *http.Request) (*http.Response, error) {
+	session := c.sessionID()
+
+	res, err := hc.Do(req)
+	if err != nil || !c.Reauthenticate || !c.sessionExpired(req, res) {
+		return res, err
+	}
+
+	// The request can only be retried if its body can be sent again
+	var body io.ReadCloser
+	if req.Body != nil {
+		if req.GetBody == nil {
+			return res, nil
+		}
+
+		body, err = req.GetBody()
+		if err != nil {
+			_ = res.Body.Close()
+			return nil, err
+		}
+	}
+	_ = res.Body.Close()
+
+	if err := c.reauthenticate(req.Context(), session); err != nil {
+		return nil, err
+	}
+
+	// Cookies are set again from the jar, so those of the expired session,
+	// which older versions of net/http add to req itself, are removed
+	retry := req.WithContext(req.Context())
+	retry.Body = body
+	retry.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		if k != "Cookie" {
+			retry.Header[k] = v
+		}
+	}
+	if token := c.csrfToken(); token != "" {
+		retry.Header.Set(csrfHeader, token)
+	}
+
+	return hc.Do(retry)
+}
+
+// sessionExpired reports whether res, the response to req, indicates that the
+// Client's session has expired.  The device either rejects the request, or
+// redirects it to the login page.
+func (c *Client) sessionExpired(req *http.Request, res *http.Response) bool {
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return true
+	}
+
+	path := res.Request.URL.Path
+	if path == req.URL.Path {
+		return false
+	}
+
+	return path == "" || path == "/" || path == c.apiURL.Path
+}
+
+// reauthenticate logs in again after the session with ID stale expires.  If
+// another request has already logged in again, reauthenticate does nothing.
+func (c *Client) reauthenticate(ctx context.Context, stale string) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	if c.sessionID() != stale {
+		return nil
+	}
+
+	return c.relogin(ctx)
+}
+
+// LoggedIn reports whether the Client's session is authenticated with the
+// EdgeMAX device.  LoggedIn returns false if Login has not been called, or if
+// the session has expired or been terminated, in which case Login must be
+// called again.
+func (c *Client) LoggedIn(ctx context.Context) (bool, error) {
+	switch err := c.heartbeat(ctx); err {
+	case nil:
+		return true, nil
+	case errSessionExpired:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Ping verifies that the EdgeMAX device is reachable and responding to API
+// requests, regardless of whether the Client is logged in.  Use LoggedIn to
+// verify that the Client's session is also authenticated.
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.heartbeat(ctx); err != nil && err != errSessionExpired {
+		return err
+	}
+
+	return nil
+}
+
+// sessionID returns the ID of the Client's current session.  The session
+// created by Login is preferred, but if it is no longer present, the newest
+// session cookie is used.
+func (c *Client) sessionID() string {
+	c.mu.Lock()
+	session := c.session
+	c.mu.Unlock()
+
+	var newest string
+	for _, ck := range c.client.Jar.Cookies(c.apiURL) {
+		if ck.Name != sessionCookie {
+			continue
+		}
+		if session != "" && ck.Value == session {
+			return session
+		}
+
+		// Cookies with the same path are ordered by creation time, so the
+		// last matching cookie is the newest
+		newest = ck.Value
+	}
+
+	return newest
+}
+
+// csrfToken returns the CSRF token of the Client's current session, or an
+// empty string if the device did not provide one.
+func (c *Client) csrfToken() string {
+	var token string
+	for _, ck := range c.client.Jar.Cookies(c.apiURL) {
+		if ck.Name == csrfCookie {
+			token = ck.Value
+		}
+	}
+
+	return token
+}
+
+// newRequest creates a new HTTP request with context ctx, using the specified
+// HTTP method and API endpoint.
+func (c *Client) newRequest(ctx context.Context, method string, endpoint string) (*http.Request, error) {
+	return c.newRequestBody(ctx, method, endpoint, nil)
+}
+
+// newRequestBody creates a new HTTP request with context ctx, using the
+// specified HTTP method, API endpoint, and request body.
+func (c *Client) newRequestBody(ctx context.Context, method string, endpoint string, body io.Reader) (*http.Request, error) {
 	rel, err := url.Parse(endpoint)
 	if err != nil {
 		return nil, err
 	}
 	u := c.apiURL.ResolveReference(rel)
 
-	req, err := http.NewRequest(method, u.String(), nil)
+	req, err := http.NewRequest(method, u.String(), body)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 
 	// Needed to allow authentication to many HTTP endpoints
 	req.Header.Set("Referer", c.apiURL.String())
 
 	req.Header.Add("User-Agent", c.UserAgent)
 
+	if token := c.csrfToken(); token != "" {
+		req.Header.Set(csrfHeader, token)
+	}
+
 	return req, nil
 }
 
 // do performs an HTTP request using req and unmarshals the result onto v, if
-// v is not nil.
+// v is not nil.  If the response has an unsuccessful HTTP status code, an
+// *Error is returned along with the response.
 func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
-	res, err := c.client.Do(req)
+	return c.doSend(c.send, req, v)
+}
+
+// doSend performs an HTTP request as with do, using send to perform the
+// request.
+func (c *Client) doSend(send func(*http.Request) (*http.Response, error), req *http.Request, v interface{}) (*http.Response, error) {
+	res, err := send(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		// The device may report a message in an API response, even when
+		// the status code indicates failure
+		var ar apiResponse
+		_ = json.NewDecoder(io.LimitReader(res.Body, maxErrorResponseLength)).Decode(&ar)
+
+		return res, newError(req, res, ar.Error)
+	}
+
 	if v == nil {
 		return res, nil
 	}
 
 	return res, json.NewDecoder(res.Body).Decode(v)
 }
+
+// getData retrieves the data type specified by name from the EdgeMAX device's
+// data API, and unmarshals the output of the response onto v.
+func (c *Client) getData(ctx context.Context, name string, v interface{}) error {
+	req, err := c.newRequest(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("/api/edge/data.json?data=%s", url.QueryEscape(name)),
+	)
+	if err != nil {
+		return err
+	}
+
+	var ar apiResponse
+	res, err := c.do(req, &ar)
+	if err != nil {
+		return err
+	}
+
+	if !ar.Success {
+		return newError(req, res, ar.Error)
+	}
+
+	return json.Unmarshal(ar.Output, v)
+}
+
+// operation performs the operation specified by name using the EdgeMAX
+// device's operation API.  If in is not nil, it is encoded as JSON and sent
+// as the body of the request.  If out is not nil, the output of the response
+// is unmarshaled onto out.
+func (c *Client) operation(ctx context.Context, name string, in interface{}, out interface{}) error {
+	return c.operationSend(ctx, c.send, name, in, out)
+}
+
+// longOperation performs an operation as with operation, for operations
+// which may take longer than the Client's http.Client.Timeout to complete.
+// The Timeout does not apply, so the operation is bounded only by ctx.
+func (c *Client) longOperation(ctx context.Context, name string, in interface{}, out interface{}) error {
+	return c.operationSend(ctx, c.stream, name, in, out)
+}
+
+// operationSend performs an operation as with operation, using send to
+// perform the request.
+func (c *Client) operationSend(
+	ctx context.Context,
+	send func(*http.Request) (*http.Response, error),
+	name string,
+	in interface{},
+	out interface{},
+) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := c.newRequestBody(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("/api/edge/operation/%s.json", name),
+		body,
+	)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	var ar apiResponse
+	res, err := c.doSend(send, req, &ar)
+	if err != nil {
+		return err
+	}
+
+	if !ar.Success {
+		return newError(req, res, ar.Error)
+	}
+
+	if out == nil || len(ar.Output) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(ar.Output, out)
+}
+
+// An apiResponse is the response envelope returned by the EdgeMAX device's
+// data and operation APIs.
+type apiResponse struct {
+	Success apiBool         `json:"success"`
+	Error   string          `json:"error"`
+	Output  json.RawMessage `json:"output"`
+}
+
+// An apiBool is a boolean value which may be represented as a JSON boolean,
+// or as a string such as "1" or "true", by the EdgeMAX device.
+type apiBool bool
+
+// UnmarshalJSON unmarshals JSON into an apiBool.
+func (b *apiBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true", "1":
+		*b = true
+	default:
+		*b = false
+	}
+
+	return nil
+}
+
+// An apiNumber is an integer value which may be represented as a JSON
+// string or, by newer firmware, as a JSON number.  apiNumber retains the
+// value as a string so it can be parsed like older firmware's output.
+type apiNumber string
+
+// UnmarshalJSON unmarshals JSON into an apiNumber.
+func (n *apiNumber) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+
+		*n = apiNumber(s)
+		return nil
+	}
+
+	var num json.Number
+	if err := json.Unmarshal(data, &num); err != nil {
+		return err
+	}
+
+	*n = apiNumber(num)
+	return nil
+}
+
+// int parses n as a non-negative integer.  If n is empty or a placeholder
+// such as "-" or "n/a", which the EdgeMAX device uses for unknown values,
+// the value is zero and known is false.
+func (n apiNumber) int() (v int, known bool, err error) {
+	u, known, err := n.parse(strconv.IntSize - 1)
+	return int(u), known, err
+}
+
+// uint64 parses n as a 64-bit counter, using the same rules as int.
+func (n apiNumber) uint64() (v uint64, known bool, err error) {
+	return n.parse(64)
+}
+
+// signed parses n as an integer which may be negative, such as a signal
+// strength, using the same rules as int.
+func (n apiNumber) signed() (v int, known bool, err error) {
+	if isPlaceholder(string(n)) {
+		return 0, false, nil
+	}
+
+	i, err := strconv.ParseInt(string(n), 10, strconv.IntSize)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return int(i), true, nil
+}
+
+// parse parses n as an unsigned integer which fits in bitSize bits.
+func (n apiNumber) parse(bitSize int) (v uint64, known bool, err error) {
+	if isPlaceholder(string(n)) {
+		return 0, false, nil
+	}
+
+	v, err = strconv.ParseUint(string(n), 10, bitSize)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return v, true, nil
+}
+
+// isPlaceholder reports whether s is empty or a placeholder which the
+// EdgeMAX device uses for unknown values.
+func isPlaceholder(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "-", "n/a", "null":
+		return true
+	}
+
+	return false
+}
+
+// download performs an HTTP GET request for the file at path on the EdgeMAX
+// device, and copies its contents to w.  Large files may take longer than
+// the Client's http.Client.Timeout to download, so the download is bounded
+// only by ctx.
+func (c *Client) download(ctx context.Context, path string, w io.Writer) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.stream(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return newError(req, res, "")
+	}
+
+	_, err = io.Copy(w, res.Body)
+	return err
+}
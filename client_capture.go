@@ -0,0 +1,143 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+const (
+	// opCaptureStart is the operation used to start a packet capture.
+	opCaptureStart = "capture-start"
+
+	// opCaptureStop is the operation used to stop a packet capture.
+	opCaptureStop = "capture-stop"
+)
+
+// CaptureOptions specifies options for Client.Capture.
+type CaptureOptions struct {
+	// Filter specifies a BPF filter expression, in tcpdump syntax, used
+	// to select packets for capture.  If empty, all packets are captured.
+	Filter string
+
+	// SnapLen specifies the maximum number of bytes captured from each
+	// packet.  If zero, the device's default is used.
+	SnapLen int
+
+	// Count specifies the number of packets after which the device stops
+	// capturing.  If zero, the capture continues until it is closed.
+	Count int
+}
+
+// A Capture is a packet capture running on an EdgeMAX device.  The
+// captured packets are read from a Capture in pcap format, and can be
+// written directly to a file for use with tools such as Wireshark.
+type Capture struct {
+	id   string
+	c    *Client
+	body io.ReadCloser
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var _ io.ReadCloser = &Capture{}
+
+// Capture starts a packet capture on the network interface specified by
+// iface, and returns a Capture which streams the captured packets from the
+// device.  If opts is nil, default options are used.
+//
+// The Timeout of the Client's *http.Client does not apply to the stream of
+// captured packets, which is bounded only by ctx.  Close must be called on
+// the Capture to stop the capture on the device.
+func (c *Client) Capture(ctx context.Context, iface string, opts *CaptureOptions) (*Capture, error) {
+	if iface == "" {
+		return nil, errors.New("capture interface must not be empty")
+	}
+	if opts == nil {
+		opts = &CaptureOptions{}
+	}
+
+	in := struct {
+		Interface string `json:"interface"`
+		Filter    string `json:"filter,omitempty"`
+		SnapLen   int    `json:"snaplen,omitempty"`
+		Count     int    `json:"count,omitempty"`
+	}{
+		Interface: iface,
+		Filter:    opts.Filter,
+		SnapLen:   opts.SnapLen,
+		Count:     opts.Count,
+	}
+
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := c.operation(ctx, opCaptureStart, in, &out); err != nil {
+		return nil, err
+	}
+
+	capture := &Capture{
+		id: out.ID,
+		c:  c,
+	}
+
+	req, err := c.newRequest(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("/api/edge/capture.pcap?id=%s", url.QueryEscape(out.ID)),
+	)
+	if err != nil {
+		_ = capture.stop()
+		return nil, err
+	}
+
+	res, err := c.stream(req)
+	if err != nil {
+		_ = capture.stop()
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		_ = capture.stop()
+		return nil, newError(req, res, "")
+	}
+
+	capture.body = res.Body
+	return capture, nil
+}
+
+// Read implements io.Reader, and reads captured packets in pcap format.
+// Read returns io.EOF once the capture has ended on the device.
+func (c *Capture) Read(b []byte) (int, error) {
+	return c.body.Read(b)
+}
+
+// Close implements io.Closer, and stops the capture on the device.
+func (c *Capture) Close() error {
+	c.closeOnce.Do(func() {
+		bodyErr := c.body.Close()
+		if err := c.stop(); err != nil {
+			c.closeErr = err
+			return
+		}
+
+		c.closeErr = bodyErr
+	})
+
+	return c.closeErr
+}
+
+// stop instructs the device to stop the capture.
+func (c *Capture) stop() error {
+	return c.c.operation(context.Background(), opCaptureStop, struct {
+		ID string `json:"id"`
+	}{
+		ID: c.id,
+	}, nil)
+}
@@ -0,0 +1,117 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientCapture(t *testing.T) {
+	const (
+		id   = "1"
+		pcap = "pcap data"
+	)
+
+	var stopped bool
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/operation/" + opCaptureStart + ".json":
+			var v struct {
+				Interface string `json:"interface"`
+				Filter    string `json:"filter"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+
+			if want, got := "eth0", v.Interface; want != got {
+				t.Fatalf("unexpected interface:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := "port 53", v.Filter; want != got {
+				t.Fatalf("unexpected filter:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			_, _ = w.Write([]byte(`{"success":"1","output":{"id":"` + id + `"}}`))
+		case "/api/edge/capture.pcap":
+			if want, got := id, r.URL.Query().Get("id"); want != got {
+				t.Fatalf("unexpected capture ID:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			_, _ = w.Write([]byte(pcap))
+		case "/api/edge/operation/" + opCaptureStop + ".json":
+			stopped = true
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		default:
+			t.Fatalf("unexpected URL path: %q", r.URL.Path)
+		}
+	})
+	defer done()
+
+	capture, err := c.Capture(context.Background(), "eth0", &CaptureOptions{
+		Filter: "port 53",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error from Client.Capture: %v", err)
+	}
+
+	b, err := ioutil.ReadAll(capture)
+	if err != nil {
+		t.Fatalf("failed to read capture: %v", err)
+	}
+
+	if want, got := pcap, string(b); want != got {
+		t.Fatalf("unexpected capture data:\n- want: %q\n-  got: %q", want, got)
+	}
+
+	if err := capture.Close(); err != nil {
+		t.Fatalf("failed to close capture: %v", err)
+	}
+
+	if !stopped {
+		t.Fatal("capture was not stopped on device")
+	}
+}
+
+func TestClientCaptureIgnoresClientTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/operation/" + opCaptureStart + ".json":
+			_, _ = w.Write([]byte(`{"success":"1","output":{"id":"1"}}`))
+		case "/api/edge/capture.pcap":
+			// Stream packets for several times the client's timeout
+			for i := 0; i < 5; i++ {
+				_, _ = w.Write([]byte("packet"))
+				w.(http.Flusher).Flush()
+				time.Sleep(timeout)
+			}
+		case "/api/edge/operation/" + opCaptureStop + ".json":
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		default:
+			t.Fatalf("unexpected URL path: %q", r.URL.Path)
+		}
+	})
+	defer done()
+
+	c.client.Timeout = timeout
+
+	capture, err := c.Capture(context.Background(), "eth0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error from Client.Capture: %v", err)
+	}
+	defer capture.Close()
+
+	b, err := ioutil.ReadAll(capture)
+	if err != nil {
+		t.Fatalf("failed to read capture: %v", err)
+	}
+
+	if want, got := strings.Repeat("packet", 5), string(b); want != got {
+		t.Fatalf("unexpected capture data:\n- want: %q\n-  got: %q", want, got)
+	}
+}
@@ -0,0 +1,70 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// opGenerateCertificate is the operation used to generate a self-signed
+	// certificate for the web interface.
+	opGenerateCertificate = "generate-cert"
+)
+
+// CertificateOptions specifies options for Client.GenerateCertificate.
+type CertificateOptions struct {
+	// CommonName specifies the common name of the certificate, typically
+	// the device's hostname.
+	CommonName string
+
+	// SANs specifies additional DNS names and IP addresses included as
+	// subject alternative names in the certificate.
+	SANs []string
+
+	// Validity specifies how long the certificate is valid.  If zero, a
+	// default validity of one year is used.
+	Validity time.Duration
+}
+
+// GenerateCertificate instructs an EdgeMAX device to generate a new
+// self-signed certificate for its web interface, replacing the current
+// certificate.  This can be used to refresh the expired default
+// certificates shipped with older firmware.
+//
+// The web interface restarts to begin using the new certificate, so an
+// unexpectedly closed connection is treated as success.  Any existing
+// session may need to be re-established using Login, and clients which
+// verify the device's certificate must trust the new certificate.
+//
+// If opts is nil, default options are used, but a common name is always
+// required.
+func (c *Client) GenerateCertificate(ctx context.Context, opts *CertificateOptions) error {
+	if opts == nil {
+		opts = &CertificateOptions{}
+	}
+
+	if opts.CommonName == "" {
+		return errors.New("certificate common name must not be empty")
+	}
+
+	validity := opts.Validity
+	if validity == 0 {
+		validity = 365 * 24 * time.Hour
+	}
+
+	days := int(validity / (24 * time.Hour))
+	if days < 1 {
+		return errors.New("certificate validity must be at least one day")
+	}
+
+	return c.disruptiveOperation(ctx, opGenerateCertificate, struct {
+		CommonName string   `json:"common_name"`
+		SANs       []string `json:"san,omitempty"`
+		Days       int      `json:"days"`
+	}{
+		CommonName: opts.CommonName,
+		SANs:       opts.SANs,
+		Days:       days,
+	})
+}
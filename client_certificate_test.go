@@ -0,0 +1,82 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientGenerateCertificate(t *testing.T) {
+	var tests = []struct {
+		desc string
+		opts *CertificateOptions
+		days int
+		ok   bool
+	}{
+		{
+			desc: "nil options",
+		},
+		{
+			desc: "no common name",
+			opts: &CertificateOptions{
+				SANs: []string{"router"},
+			},
+		},
+		{
+			desc: "validity too short",
+			opts: &CertificateOptions{
+				CommonName: "router.example.com",
+				Validity:   time.Hour,
+			},
+		},
+		{
+			desc: "OK default validity",
+			opts: &CertificateOptions{
+				CommonName: "router.example.com",
+				SANs:       []string{"router", "192.168.1.1"},
+			},
+			days: 365,
+			ok:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		h := testOperationHandler(t, opGenerateCertificate)
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			h(w, r)
+
+			var v struct {
+				CommonName string   `json:"common_name"`
+				SANs       []string `json:"san"`
+				Days       int      `json:"days"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+
+			if want, got := tt.opts.CommonName, v.CommonName; want != got {
+				t.Fatalf("unexpected common name:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := tt.opts.SANs, v.SANs; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected SANs:\n- want: %v\n-  got: %v", want, got)
+			}
+			if want, got := tt.days, v.Days; want != got {
+				t.Fatalf("unexpected days:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		})
+
+		err := c.GenerateCertificate(context.Background(), tt.opts)
+		done()
+
+		if want, got := tt.ok, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+const (
+	// opConntrackFlush is the operation used to flush the connection
+	// tracking table.
+	opConntrackFlush = "conntrack-flush"
+
+	// opConntrackDelete is the operation used to delete entries from the
+	// connection tracking table.
+	opConntrackDelete = "conntrack-delete"
+)
+
+// A ConntrackFilter selects entries in the connection tracking table of an
+// EdgeMAX device.  An entry must match all non-zero fields of a
+// ConntrackFilter to be selected.
+type ConntrackFilter struct {
+	Source      net.IP
+	Destination net.IP
+
+	// Protocol is a protocol name such as "tcp" or "udp".
+	Protocol string
+
+	// Port is a destination port.  Protocol must be set if Port is set.
+	Port int
+}
+
+// FlushConntrack removes all entries from the connection tracking table of
+// an EdgeMAX device.
+//
+// Flushing the table interrupts all NAT translations, including the one used
+// by the connection to the device itself if it passes through NAT.
+func (c *Client) FlushConntrack(ctx context.Context) error {
+	return c.operation(ctx, opConntrackFlush, nil, nil)
+}
+
+// DeleteConntrack removes entries matching f from the connection tracking
+// table of an EdgeMAX device, and returns the number of entries removed.
+//
+// This is typically used after changing NAT rules, so that existing sessions
+// do not continue to use stale translations.
+func (c *Client) DeleteConntrack(ctx context.Context, f ConntrackFilter) (int, error) {
+	if f.Source == nil && f.Destination == nil && f.Protocol == "" && f.Port == 0 {
+		return 0, errors.New("conntrack filter must not be empty; use FlushConntrack to remove all entries")
+	}
+	if f.Port != 0 && f.Protocol == "" {
+		return 0, errors.New("conntrack filter must specify a protocol with a port")
+	}
+
+	in := struct {
+		Source      string `json:"source,omitempty"`
+		Destination string `json:"destination,omitempty"`
+		Protocol    string `json:"protocol,omitempty"`
+		Port        int    `json:"port,omitempty"`
+	}{
+		Protocol: f.Protocol,
+		Port:     f.Port,
+	}
+	if f.Source != nil {
+		in.Source = f.Source.String()
+	}
+	if f.Destination != nil {
+		in.Destination = f.Destination.String()
+	}
+
+	var out struct {
+		Deleted string `json:"deleted"`
+	}
+	if err := c.operation(ctx, opConntrackDelete, in, &out); err != nil {
+		return 0, err
+	}
+
+	return atoiOrZero(out.Deleted)
+}
@@ -0,0 +1,88 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestClientFlushConntrack(t *testing.T) {
+	h := testOperationHandler(t, opConntrackFlush)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.FlushConntrack(context.Background()); err != nil {
+		t.Fatalf("unexpected error from Client.FlushConntrack: %v", err)
+	}
+}
+
+func TestClientDeleteConntrack(t *testing.T) {
+	var tests = []struct {
+		desc  string
+		f     ConntrackFilter
+		ok    bool
+		body  string
+		count int
+	}{
+		{
+			desc: "empty filter",
+		},
+		{
+			desc: "port without protocol",
+			f: ConntrackFilter{
+				Port: 53,
+			},
+		},
+		{
+			desc: "source and protocol/port",
+			f: ConntrackFilter{
+				Source:   net.IPv4(192, 168, 1, 10),
+				Protocol: "udp",
+				Port:     53,
+			},
+			ok:    true,
+			body:  `{"source":"192.168.1.10","protocol":"udp","port":53}`,
+			count: 4,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		h := testOperationHandler(t, opConntrackDelete)
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			h(w, r)
+
+			var v json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+
+			if want, got := tt.body, string(v); want != got {
+				t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			_, _ = w.Write([]byte(`{"success":"1","output":{"deleted":"4"}}`))
+		})
+
+		n, err := c.DeleteConntrack(context.Background(), tt.f)
+		done()
+
+		if want, got := tt.ok, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.count, n; want != got {
+			t.Fatalf("unexpected deleted count:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
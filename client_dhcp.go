@@ -0,0 +1,102 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+const (
+	// dataDHCPLeases is the data API type used to retrieve DHCPLeases.
+	dataDHCPLeases = "dhcp_leases"
+
+	// opDeleteDHCPLease is the operation used to delete an active DHCP
+	// server lease.
+	opDeleteDHCPLease = "clear-dhcp-lease"
+)
+
+// DHCPLeases retrieves the leases handed out by the DHCP servers on an
+// EdgeMAX device.
+//
+// The device does not report leases for clients with static mappings, so
+// DHCPLeases also retrieves the device's configuration, and includes a lease
+// with Static set for each static mapping.
+func (c *Client) DHCPLeases(ctx context.Context) (DHCPLeases, error) {
+	var ls DHCPLeases
+	if err := c.getData(ctx, dataDHCPLeases, &ls); err != nil {
+		return nil, err
+	}
+
+	t, err := c.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ms, err := dhcpStaticMappings(t)
+	if err != nil {
+		return nil, err
+	}
+
+	ls.addStatic(ms)
+	return ls, nil
+}
+
+// AddDHCPStaticMapping adds the static mapping m to the configuration of a
+// DHCP server on an EdgeMAX device, and commits and saves the change.
+//
+// DHCPLease.StaticMapping can be used to create a static mapping which
+// promotes an active lease to a reservation.
+//
+// m is validated before it is sent to the device.  If the device rejects the
+// mapping, such as when its address is already reserved by another mapping,
+// the returned error is a *ConfigError.
+func (c *Client) AddDHCPStaticMapping(ctx context.Context, m *DHCPStaticMapping) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	return c.configBatch(ctx, m.configTree(), nil)
+}
+
+// DHCPStaticMappings retrieves the static mappings configured on the DHCP
+// servers of an EdgeMAX device, sorted by pool, subnet, and name.
+func (c *Client) DHCPStaticMappings(ctx context.Context) ([]*DHCPStaticMapping, error) {
+	t, err := c.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return dhcpStaticMappings(t)
+}
+
+// RemoveDHCPStaticMapping removes the static mapping specified by the Pool,
+// Subnet, and Name of m from the configuration of a DHCP server on an
+// EdgeMAX device, and commits and saves the change.  The IP and MAC of m
+// are not used.
+func (c *Client) RemoveDHCPStaticMapping(ctx context.Context, m *DHCPStaticMapping) error {
+	if err := m.validatePath(); err != nil {
+		return err
+	}
+
+	return c.configBatch(ctx, nil, m.configPath(nil))
+}
+
+// DeleteDHCPLease deletes the active lease for the address ip from the DHCP
+// server shared network specified by pool.  The client which held the lease
+// will obtain a new lease the next time it contacts the DHCP server.
+func (c *Client) DeleteDHCPLease(ctx context.Context, pool string, ip net.IP) error {
+	if pool == "" {
+		return errors.New("DHCP pool name must not be empty")
+	}
+	if ip == nil {
+		return errors.New("DHCP lease IP address must not be empty")
+	}
+
+	return c.operation(ctx, opDeleteDHCPLease, struct {
+		Pool string `json:"pool"`
+		IP   string `json:"ip"`
+	}{
+		Pool: pool,
+		IP:   ip.String(),
+	}, nil)
+}
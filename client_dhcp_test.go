@@ -0,0 +1,230 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClientDHCPLeases(t *testing.T) {
+	h := testDataHandler(t, dataDHCPLeases)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/edge/get.json" {
+			_, _ = w.Write([]byte(`{"success":true,"GET":{"service":{"dhcp-server":{"shared-network-name":{"LAN":{"subnet":{"192.168.1.0/24":{"static-mapping":{
+				"printer":{"ip-address":"192.168.1.5","mac-address":"de:ad:be:ef:00:05"},
+				"nas":{"ip-address":"192.168.1.10","mac-address":"de:ad:be:ef:00:10"}
+			}}}}}}}}}`))
+			return
+		}
+
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"dhcp-server-leases":{"LAN":{
+			"192.168.1.10":{"expiration":"2017/05/12 01:02:03","pool":"LAN","mac":"de:ad:be:ef:00:10","client-hostname":"nas"},
+			"192.168.1.100":{"expiration":"2017/05/12 01:02:03","pool":"LAN","mac":"de:ad:be:ef:01:00","client-hostname":"laptop"}
+		}}}}`))
+	})
+	defer done()
+
+	ls, err := c.DHCPLeases(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error from Client.DHCPLeases: %v", err)
+	}
+
+	type lease struct {
+		IP       string
+		Hostname string
+		Static   bool
+	}
+
+	got := make([]lease, 0, len(ls))
+	for _, l := range ls {
+		got = append(got, lease{
+			IP:       l.IP.String(),
+			Hostname: l.Hostname,
+			Static:   l.Static,
+		})
+	}
+
+	want := []lease{
+		{IP: "192.168.1.5", Hostname: "printer", Static: true},
+		{IP: "192.168.1.10", Hostname: "nas", Static: true},
+		{IP: "192.168.1.100", Hostname: "laptop"},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected DHCP leases:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientAddDHCPStaticMapping(t *testing.T) {
+	const wantBody = `{"SET":{"service":{"dhcp-server":{"shared-network-name":{"LAN":{"subnet":{"192.168.1.0/24":{"static-mapping":{"foo":{"ip-address":"192.168.1.10","mac-address":"de:ad:be:ef:de:ad"}}}}}}}}}}`
+
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse subnet: %v", err)
+	}
+
+	h := testHandler(t, http.MethodPost, "/api/edge/batch.json")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := wantBody, string(v); want != got {
+			t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","SET":{"success":"1"},"COMMIT":{"success":"1"},"SAVE":{"success":"1"}}`))
+	})
+	defer done()
+
+	l := &DHCPLease{
+		IP:       net.IPv4(192, 168, 1, 10),
+		MAC:      net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		Hostname: "foo",
+		Pool:     "LAN",
+	}
+
+	m, err := l.StaticMapping("", subnet)
+	if err != nil {
+		t.Fatalf("unexpected error creating static mapping: %v", err)
+	}
+
+	if err := c.AddDHCPStaticMapping(context.Background(), m); err != nil {
+		t.Fatalf("unexpected error from Client.AddDHCPStaticMapping: %v", err)
+	}
+}
+
+func TestClientAddDHCPStaticMappingCommitError(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse subnet: %v", err)
+	}
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":"0","SET":{"success":"1"},"COMMIT":{"success":"0","error":{"service dhcp-server shared-network-name LAN subnet 192.168.1.0/24 static-mapping foo":"Static mapping IP address is already in use"}}}`))
+	})
+	defer done()
+
+	err = c.AddDHCPStaticMapping(context.Background(), &DHCPStaticMapping{
+		Name:   "foo",
+		Pool:   "LAN",
+		Subnet: subnet,
+		IP:     net.IPv4(192, 168, 1, 10),
+		MAC:    net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+	})
+
+	cerr, ok := err.(*ConfigError)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := "commit", cerr.Stage; want != got {
+		t.Fatalf("unexpected stage:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientDHCPStaticMappings(t *testing.T) {
+	h := testHandler(t, http.MethodGet, "/api/edge/get.json")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":true,"GET":{"service":{"dhcp-server":{"shared-network-name":{"LAN":{"subnet":{"192.168.1.0/24":{"static-mapping":{
+			"foo":{"ip-address":"192.168.1.10","mac-address":"de:ad:be:ef:de:ad"}
+		}}}}}}}}}`))
+	})
+	defer done()
+
+	ms, err := c.DHCPStaticMappings(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error from Client.DHCPStaticMappings: %v", err)
+	}
+
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse subnet: %v", err)
+	}
+
+	want := []*DHCPStaticMapping{{
+		Name:   "foo",
+		Pool:   "LAN",
+		Subnet: subnet,
+		IP:     net.IPv4(192, 168, 1, 10),
+		MAC:    net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+	}}
+
+	if got := ms; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected static mappings:\n- want: %+v\n-  got: %+v", want, got)
+	}
+}
+
+func TestClientRemoveDHCPStaticMapping(t *testing.T) {
+	const wantBody = `{"DELETE":{"service":{"dhcp-server":{"shared-network-name":{"LAN":{"subnet":{"192.168.1.0/24":{"static-mapping":{"foo":null}}}}}}}}}`
+
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse subnet: %v", err)
+	}
+
+	h := testHandler(t, http.MethodPost, "/api/edge/batch.json")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := wantBody, string(v); want != got {
+			t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","DELETE":{"success":"1"},"COMMIT":{"success":"1"},"SAVE":{"success":"1"}}`))
+	})
+	defer done()
+
+	m := &DHCPStaticMapping{
+		Name:   "foo",
+		Pool:   "LAN",
+		Subnet: subnet,
+	}
+
+	if err := c.RemoveDHCPStaticMapping(context.Background(), m); err != nil {
+		t.Fatalf("unexpected error from Client.RemoveDHCPStaticMapping: %v", err)
+	}
+}
+
+func TestClientDeleteDHCPLease(t *testing.T) {
+	h := testOperationHandler(t, opDeleteDHCPLease)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			Pool string `json:"pool"`
+			IP   string `json:"ip"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := "LAN", v.Pool; want != got {
+			t.Fatalf("unexpected pool:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := "192.168.1.10", v.IP; want != got {
+			t.Fatalf("unexpected IP:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.DeleteDHCPLease(context.Background(), "LAN", net.IPv4(192, 168, 1, 10)); err != nil {
+		t.Fatalf("unexpected error from Client.DeleteDHCPLease: %v", err)
+	}
+}
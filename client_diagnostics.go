@@ -0,0 +1,250 @@
+package edgemax
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// opPing is the operation used to ping a host from an EdgeMAX device.
+	opPing = "ping"
+
+	// opTraceroute is the operation used to trace the route to a host from
+	// an EdgeMAX device.
+	opTraceroute = "traceroute"
+
+	// Operations used to perform bandwidth tests between EdgeMAX devices.
+	opBandwidthTest            = "bwtest"
+	opBandwidthTestServerStart = "bwtest-server-start"
+	opBandwidthTestServerStop  = "bwtest-server-stop"
+)
+
+// PingOptions specifies options for Client.PingFrom.
+type PingOptions struct {
+	// Count specifies the number of echo requests to send.  If zero,
+	// a default count of 5 is used.
+	Count int
+
+	// Size specifies the size of the echo request payload in bytes.  If
+	// zero, the device's default size is used.
+	Size int
+
+	// Source specifies an interface name or IP address on the device from
+	// which echo requests are sent.  If empty, the device chooses a source
+	// using its routing table.
+	Source string
+}
+
+// PingFrom instructs an EdgeMAX device to ping target, and returns the
+// packet loss and latency observed from the device's perspective.  If opts
+// is nil, default options are used.
+//
+// Unlike pinging target from the local machine, PingFrom is subject to the
+// device's own routing and policy routing rules.
+func (c *Client) PingFrom(ctx context.Context, target string, opts *PingOptions) (*PingResult, error) {
+	if target == "" {
+		return nil, errors.New("ping target must not be empty")
+	}
+
+	if opts == nil {
+		opts = &PingOptions{}
+	}
+
+	count := opts.Count
+	if count == 0 {
+		count = 5
+	}
+
+	in := struct {
+		Target string `json:"target"`
+		Count  int    `json:"count"`
+		Size   int    `json:"size,omitempty"`
+		Source string `json:"source,omitempty"`
+	}{
+		Target: target,
+		Count:  count,
+		Size:   opts.Size,
+		Source: opts.Source,
+	}
+
+	var out struct {
+		Output string `json:"output"`
+	}
+	if err := c.operation(ctx, opPing, in, &out); err != nil {
+		return nil, err
+	}
+
+	return parsePing(out.Output)
+}
+
+// Traceroute instructs an EdgeMAX device to trace the route to target, and
+// delivers each hop on hopC as it is reported by the device.
+//
+// hopC is closed when the traceroute completes or ctx is canceled.  Once
+// hopC is closed, wait must be invoked to retrieve any error which occurred
+// while the traceroute was in progress; wait may be invoked any number of
+// times, and returns the same error each time.  The Timeout of the Client's
+// *http.Client does not apply to the traceroute, which is bounded only by
+// ctx.
+func (c *Client) Traceroute(ctx context.Context, target string) (hopC <-chan *TracerouteHop, wait func() error, err error) {
+	if target == "" {
+		return nil, nil, errors.New("traceroute target must not be empty")
+	}
+
+	b, err := json.Marshal(struct {
+		Target string `json:"target"`
+	}{
+		Target: target,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The device streams the output of traceroute as plain text, one hop
+	// per line, as each hop completes
+	req, err := c.newRequestBody(
+		ctx,
+		http.MethodPost,
+		fmt.Sprintf("/api/edge/operation/%s.json", opTraceroute),
+		bytes.NewReader(b),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.stream(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, nil, newError(req, res, "")
+	}
+
+	var (
+		hops  = make(chan *TracerouteHop)
+		doneC = make(chan struct{})
+		rerr  error
+	)
+
+	go func() {
+		defer close(doneC)
+		defer close(hops)
+		defer res.Body.Close()
+
+		rerr = readTraceroute(ctx, bufio.NewScanner(res.Body), hops)
+	}()
+
+	wait = func() error {
+		<-doneC
+		return rerr
+	}
+
+	return hops, wait, nil
+}
+
+// readTraceroute parses traceroute hops from s and sends them on hopC until
+// s is exhausted or ctx is canceled.
+func readTraceroute(ctx context.Context, s *bufio.Scanner, hopC chan<- *TracerouteHop) error {
+	for s.Scan() {
+		hop, ok, err := parseTracerouteHop(s.Text())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		select {
+		case hopC <- hop:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return s.Err()
+}
+
+// BandwidthTestOptions specifies options for Client.BandwidthTest.
+type BandwidthTestOptions struct {
+	// Duration specifies how long the test runs, in whole seconds.  If
+	// zero, a default duration of 10 seconds is used.
+	Duration time.Duration
+
+	// Streams specifies the number of parallel streams used for the test.
+	// If zero, a single stream is used.
+	Streams int
+}
+
+// StartBandwidthTestServer starts the bandwidth test server on an EdgeMAX
+// device, so that other devices can perform a bandwidth test against it
+// using Client.BandwidthTest.  StopBandwidthTestServer should be called
+// once testing is complete.
+func (c *Client) StartBandwidthTestServer(ctx context.Context) error {
+	return c.longOperation(ctx, opBandwidthTestServerStart, nil, nil)
+}
+
+// StopBandwidthTestServer stops the bandwidth test server on an EdgeMAX
+// device.
+func (c *Client) StopBandwidthTestServer(ctx context.Context) error {
+	return c.longOperation(ctx, opBandwidthTestServerStop, nil, nil)
+}
+
+// BandwidthTest instructs an EdgeMAX device to perform a bandwidth test
+// against the bandwidth test server running on another EdgeMAX device at
+// server, and returns the measured throughput.  If opts is nil, default
+// options are used.
+//
+// The device at server must be running a bandwidth test server, which can
+// be started using StartBandwidthTestServer.
+//
+// The device reports a result only once the test completes, so the
+// Client's http.Client.Timeout does not apply, and the test is bounded only
+// by ctx.
+func (c *Client) BandwidthTest(ctx context.Context, server string, opts *BandwidthTestOptions) (*BandwidthTestResult, error) {
+	if server == "" {
+		return nil, errors.New("bandwidth test server must not be empty")
+	}
+
+	if opts == nil {
+		opts = &BandwidthTestOptions{}
+	}
+
+	duration := opts.Duration
+	if duration == 0 {
+		duration = 10 * time.Second
+	}
+	if duration < time.Second {
+		return nil, errors.New("bandwidth test duration must be at least one second")
+	}
+
+	streams := opts.Streams
+	if streams == 0 {
+		streams = 1
+	}
+
+	in := struct {
+		Server   string `json:"server"`
+		Duration int    `json:"duration"`
+		Streams  int    `json:"streams"`
+	}{
+		Server:   server,
+		Duration: int(duration / time.Second),
+		Streams:  streams,
+	}
+
+	r := new(BandwidthTestResult)
+	if err := c.longOperation(ctx, opBandwidthTest, in, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,231 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientPingFrom(t *testing.T) {
+	h := testOperationHandler(t, opPing)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			Target string `json:"target"`
+			Count  int    `json:"count"`
+			Source string `json:"source"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := "8.8.8.8", v.Target; want != got {
+			t.Fatalf("unexpected target:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := 5, v.Count; want != got {
+			t.Fatalf("unexpected count:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := "eth0", v.Source; want != got {
+			t.Fatalf("unexpected source:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"output":"5 packets transmitted, 5 received, 0% packet loss, time 4005ms\nrtt min/avg/max/mdev = 1.0/2.0/3.0/0.5 ms\n"}}`))
+	})
+	defer done()
+
+	pr, err := c.PingFrom(context.Background(), "8.8.8.8", &PingOptions{
+		Source: "eth0",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error from Client.PingFrom: %v", err)
+	}
+
+	if want, got := 5, pr.Received; want != got {
+		t.Fatalf("unexpected received packets:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientTraceroute(t *testing.T) {
+	h := testOperationHandler(t, opTraceroute)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte("traceroute to 8.8.8.8 (8.8.8.8), 30 hops max, 60 byte packets\n"))
+		_, _ = w.Write([]byte(" 1  192.168.1.1  0.500 ms  0.250 ms  0.750 ms\n"))
+		_, _ = w.Write([]byte(" 2  * * *\n"))
+		_, _ = w.Write([]byte(" 3  8.8.8.8  10.000 ms  11.000 ms  12.000 ms\n"))
+	})
+	defer done()
+
+	hopC, wait, err := c.Traceroute(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatalf("unexpected error from Client.Traceroute: %v", err)
+	}
+
+	var numbers []int
+	for hop := range hopC {
+		numbers = append(numbers, hop.Number)
+	}
+
+	if err := wait(); err != nil {
+		t.Fatalf("unexpected error while waiting for traceroute: %v", err)
+	}
+
+	if want, got := []int{1, 2, 3}, numbers; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected hop numbers:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientTracerouteWaitRepeated(t *testing.T) {
+	h := testOperationHandler(t, opTraceroute)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(" 1  192.168.1.1  0.500 ms  0.250 ms  0.750 ms\n"))
+	})
+	defer done()
+
+	hopC, wait, err := c.Traceroute(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatalf("unexpected error from Client.Traceroute: %v", err)
+	}
+
+	for range hopC {
+	}
+
+	errC := make(chan error, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			errC <- wait()
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errC:
+			if err != nil {
+				t.Fatalf("unexpected error while waiting for traceroute: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for traceroute, call %d", i+1)
+		}
+	}
+}
+
+func TestClientTracerouteIgnoresClientTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	h := testOperationHandler(t, opTraceroute)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		// Report unresponsive hops for several times the client's timeout
+		for i := 1; i <= 5; i++ {
+			_, _ = fmt.Fprintf(w, " %d  * * *\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(timeout)
+		}
+	})
+	defer done()
+
+	c.client.Timeout = timeout
+
+	hopC, wait, err := c.Traceroute(context.Background(), "8.8.8.8")
+	if err != nil {
+		t.Fatalf("unexpected error from Client.Traceroute: %v", err)
+	}
+
+	var hops int
+	for range hopC {
+		hops++
+	}
+
+	if err := wait(); err != nil {
+		t.Fatalf("unexpected error while waiting for traceroute: %v", err)
+	}
+
+	if want, got := 5, hops; want != got {
+		t.Fatalf("unexpected number of hops:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientBandwidthTest(t *testing.T) {
+	h := testOperationHandler(t, opBandwidthTest)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			Server   string `json:"server"`
+			Duration int    `json:"duration"`
+			Streams  int    `json:"streams"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := "192.168.1.2", v.Server; want != got {
+			t.Fatalf("unexpected server:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := 10, v.Duration; want != got {
+			t.Fatalf("unexpected duration:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := 1, v.Streams; want != got {
+			t.Fatalf("unexpected streams:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"tx_bps":"100","rx_bps":"200","duration":"10"}}`))
+	})
+	defer done()
+
+	r, err := c.BandwidthTest(context.Background(), "192.168.1.2", nil)
+	if err != nil {
+		t.Fatalf("unexpected error from Client.BandwidthTest: %v", err)
+	}
+
+	if want, got := 200, r.ReceiveBPS; want != got {
+		t.Fatalf("unexpected receive bps:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientBandwidthTestShortDuration(t *testing.T) {
+	c := &Client{}
+
+	_, err := c.BandwidthTest(context.Background(), "192.168.1.2", &BandwidthTestOptions{
+		Duration: 500 * time.Millisecond,
+	})
+	if want, got := "bandwidth test duration must be at least one second", errStr(err); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientBandwidthTestIgnoresClientTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	h := testOperationHandler(t, opBandwidthTest)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		// Report the result only after the client's timeout has elapsed
+		time.Sleep(4 * timeout)
+		_, _ = w.Write([]byte(`{"success":"1","output":{"tx_bps":"100","rx_bps":"200","duration":"1"}}`))
+	})
+	defer done()
+
+	c.client.Timeout = timeout
+
+	r, err := c.BandwidthTest(context.Background(), "192.168.1.2", &BandwidthTestOptions{
+		Duration: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error from Client.BandwidthTest: %v", err)
+	}
+
+	if want, got := 100, r.TransmitBPS; want != got {
+		t.Fatalf("unexpected transmit bps:\n- want: %v\n-  got: %v", want, got)
+	}
+}
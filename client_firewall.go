@@ -0,0 +1,97 @@
+package edgemax
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// FirewallRulesets retrieves the IPv4 firewall rulesets configured on an
+// EdgeMAX device.
+func (c *Client) FirewallRulesets(ctx context.Context) (FirewallRulesets, error) {
+	t, err := c.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return firewallRulesets(t)
+}
+
+// SetFirewallRuleset creates the firewall ruleset rs, or replaces the
+// ruleset with the same name and all of its rules, and commits and saves
+// the change.  rs is validated before it is sent to the device.
+func (c *Client) SetFirewallRuleset(ctx context.Context, rs *FirewallRuleset) error {
+	if err := rs.Validate(); err != nil {
+		return err
+	}
+
+	t, err := c.GetConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := []string{"firewall", "name", rs.Name}
+	return c.configBatch(ctx, configPath(rs.configTree(), path...), replaceConfig(t, path...))
+}
+
+// DeleteFirewallRuleset deletes the firewall ruleset with the specified
+// name, and commits and saves the change.  The device rejects the deletion
+// of a ruleset which is applied to an interface.
+func (c *Client) DeleteFirewallRuleset(ctx context.Context, name string) error {
+	if err := validateConfigName(name); err != nil {
+		return fmt.Errorf("invalid firewall ruleset name: %v", err)
+	}
+
+	return c.configBatch(ctx, nil, configPath(nil, "firewall", "name", name))
+}
+
+// SetFirewallRule creates the rule r within the existing firewall ruleset
+// specified by ruleset, or replaces the rule with the same number, and
+// commits and saves the change.  r is validated before it is sent to the
+// device.
+func (c *Client) SetFirewallRule(ctx context.Context, ruleset string, r *FirewallRule) error {
+	if err := validateConfigName(ruleset); err != nil {
+		return fmt.Errorf("invalid firewall ruleset name: %v", err)
+	}
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	t, err := c.GetConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	// A rule cannot be added to a ruleset without a default action
+	if t.Child("firewall", "name", ruleset) == nil {
+		return fmt.Errorf("firewall ruleset %q does not exist", ruleset)
+	}
+
+	path := []string{"firewall", "name", ruleset, "rule", strconv.Itoa(r.Number)}
+	return c.configBatch(ctx, configPath(r.configTree(), path...), replaceConfig(t, path...))
+}
+
+// DeleteFirewallRule deletes the rule with the specified number from the
+// firewall ruleset specified by ruleset, and commits and saves the change.
+func (c *Client) DeleteFirewallRule(ctx context.Context, ruleset string, number int) error {
+	if err := validateConfigName(ruleset); err != nil {
+		return fmt.Errorf("invalid firewall ruleset name: %v", err)
+	}
+	if number < 1 || number > 9999 {
+		return fmt.Errorf("firewall rule number %d must be between 1 and 9999", number)
+	}
+
+	return c.configBatch(ctx, nil, configPath(nil, "firewall", "name", ruleset, "rule", strconv.Itoa(number)))
+}
+
+// replaceConfig returns a configuration tree which deletes the node at path
+// if it exists in t, so that the node is replaced rather than merged with a
+// new value set in the same batch.  If the node does not exist,
+// replaceConfig returns nil.
+func replaceConfig(t *ConfigTree, path ...string) interface{} {
+	if t.Child(path...) == nil {
+		return nil
+	}
+
+	return configPath(nil, path...)
+}
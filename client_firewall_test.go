@@ -0,0 +1,94 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestClientSetFirewallRuleset(t *testing.T) {
+	const wantBody = `{"DELETE":{"firewall":{"name":{"WAN_IN":null}}},"SET":{"firewall":{"name":{"WAN_IN":{"default-action":"drop","rule":{"10":{"action":"accept","log":"disable","state":{"established":"enable"}}}}}}}}`
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/edge/get.json" {
+			_, _ = w.Write([]byte(`{"success":true,"GET":{"firewall":{"name":{"WAN_IN":{"default-action":"accept"}}}}}`))
+			return
+		}
+
+		testHandler(t, http.MethodPost, "/api/edge/batch.json")(w, r)
+
+		var v json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := wantBody, string(v); want != got {
+			t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","DELETE":{"success":"1"},"SET":{"success":"1"},"COMMIT":{"success":"1"},"SAVE":{"success":"1"}}`))
+	})
+	defer done()
+
+	if err := c.SetFirewallRuleset(context.Background(), &FirewallRuleset{Name: "WAN_IN"}); err == nil {
+		t.Fatal("expected an error for invalid ruleset, but none occurred")
+	}
+
+	rs := &FirewallRuleset{
+		Name:          "WAN_IN",
+		DefaultAction: "drop",
+		Rules: []*FirewallRule{{
+			Number: 10,
+			Action: "accept",
+			States: []string{"established"},
+		}},
+	}
+
+	if err := c.SetFirewallRuleset(context.Background(), rs); err != nil {
+		t.Fatalf("unexpected error from Client.SetFirewallRuleset: %v", err)
+	}
+}
+
+func TestClientSetFirewallRuleNoRuleset(t *testing.T) {
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		testHandler(t, http.MethodGet, "/api/edge/get.json")(w, r)
+
+		_, _ = w.Write([]byte(`{"success":true,"GET":{"firewall":{}}}`))
+	})
+	defer done()
+
+	err := c.SetFirewallRule(context.Background(), "WAN_IN", &FirewallRule{Number: 10, Action: "drop"})
+	if err == nil {
+		t.Fatal("expected an error for missing ruleset, but none occurred")
+	}
+}
+
+func TestClientDeleteFirewallRule(t *testing.T) {
+	const wantBody = `{"DELETE":{"firewall":{"name":{"WAN_IN":{"rule":{"20":null}}}}}}`
+
+	h := testHandler(t, http.MethodPost, "/api/edge/batch.json")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := wantBody, string(v); want != got {
+			t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","DELETE":{"success":"1"},"COMMIT":{"success":"1"},"SAVE":{"success":"1"}}`))
+	})
+	defer done()
+
+	if err := c.DeleteFirewallRule(context.Background(), "WAN_IN", 0); err == nil {
+		t.Fatal("expected an error for invalid rule number, but none occurred")
+	}
+
+	if err := c.DeleteFirewallRule(context.Background(), "WAN_IN", 20); err != nil {
+		t.Fatalf("unexpected error from Client.DeleteFirewallRule: %v", err)
+	}
+}
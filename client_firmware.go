@@ -0,0 +1,234 @@
+package edgemax
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// dataUpgradeStatus is the data API type used to retrieve UpgradeStatus.
+	dataUpgradeStatus = "upgrade_status"
+
+	// dataFirmwareUpdate is the data API type used to retrieve
+	// FirmwareUpdate.
+	dataFirmwareUpdate = "fw_latest"
+
+	// opFirmwareDownload is the operation used to download the latest
+	// firmware onto an EdgeMAX device.
+	opFirmwareDownload = "fw-download"
+)
+
+// UpgradeOptions specifies options for Client.UpgradeFirmware.
+type UpgradeOptions struct {
+	// Reboot specifies if the device should be rebooted once the firmware
+	// image is installed, so that the new firmware takes effect.
+	Reboot bool
+
+	// PollInterval specifies how often the device is queried for the
+	// progress of the install.  If zero, a default interval is used.
+	PollInterval time.Duration
+
+	// Progress, if not nil, is invoked with each UpgradeStatus reported
+	// by the device while the firmware image is installed.
+	Progress func(UpgradeStatus)
+
+	// UploadProgress, if not nil, is invoked with the total number of bytes
+	// of the firmware image uploaded so far, as the image is uploaded to the
+	// device.
+	UploadProgress func(n int64)
+}
+
+// UpgradeFirmware uploads the firmware image read from r to an EdgeMAX
+// device and waits for the device to install it.  If opts is nil, default
+// options are used and the device is not rebooted.
+//
+// Uploading a large image can take longer than the Client's
+// http.Client.Timeout, so the Timeout does not apply to the upload, which is
+// bounded only by ctx.
+//
+// The install can take several minutes on some models; ctx can be used to
+// stop waiting, but an install which has already begun on the device will
+// not be interrupted.
+func (c *Client) UpgradeFirmware(ctx context.Context, r io.Reader, opts *UpgradeOptions) error {
+	if opts != nil && opts.UploadProgress != nil {
+		r = &progressReader{
+			r:  r,
+			fn: opts.UploadProgress,
+		}
+	}
+
+	if err := c.upload(ctx, "/api/edge/upgrade.json", "firmware.tar", r); err != nil {
+		return err
+	}
+
+	return c.waitUpgrade(ctx, opts)
+}
+
+// UpgradeFirmwareFromURL downloads the firmware image at the HTTP or HTTPS
+// URL u, and upgrades an EdgeMAX device using the image as with
+// UpgradeFirmware.  The image is downloaded by the caller rather than the
+// device, and is streamed to the device as it is downloaded.
+func (c *Client) UpgradeFirmwareFromURL(ctx context.Context, u string, opts *UpgradeOptions) error {
+	iu, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	if (iu.Scheme != "http" && iu.Scheme != "https") || iu.Host == "" {
+		return fmt.Errorf("firmware image URL must be an HTTP or HTTPS URL: %q", u)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, iu.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	// The device's HTTP client may skip TLS verification, and is not used
+	// for the download
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download firmware image: %s", res.Status)
+	}
+
+	return c.UpgradeFirmware(ctx, res.Body, opts)
+}
+
+// A progressReader is an io.Reader which reports the total number of bytes
+// read from r to fn.
+type progressReader struct {
+	r  io.Reader
+	fn func(n int64)
+	n  int64
+}
+
+// Read implements io.Reader.
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	if n > 0 {
+		pr.n += int64(n)
+		pr.fn(pr.n)
+	}
+
+	return n, err
+}
+
+// waitUpgrade polls an EdgeMAX device until it reports that a firmware
+// install is complete, and optionally reboots the device.
+func (c *Client) waitUpgrade(ctx context.Context, opts *UpgradeOptions) error {
+	if opts == nil {
+		opts = &UpgradeOptions{}
+	}
+
+	interval := opts.PollInterval
+	if interval == 0 {
+		interval = 2 * time.Second
+	}
+
+	for {
+		var us UpgradeStatus
+		if err := c.getData(ctx, dataUpgradeStatus, &us); err != nil {
+			return err
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(us)
+		}
+
+		switch us.State {
+		case UpgradeStateDone:
+			if !opts.Reboot {
+				return nil
+			}
+
+			return c.Reboot(ctx)
+		case UpgradeStateFailed:
+			return fmt.Errorf("failed to install firmware: %s", us.Message)
+		}
+
+		select {
+		case <-c.clock().After(interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// CheckFirmwareUpdate asks an EdgeMAX device to check with Ubiquiti for
+// the latest available firmware, mirroring the "Check for update" action
+// in the web interface.
+func (c *Client) CheckFirmwareUpdate(ctx context.Context) (*FirmwareUpdate, error) {
+	fu := new(FirmwareUpdate)
+	if err := c.getData(ctx, dataFirmwareUpdate, fu); err != nil {
+		return nil, err
+	}
+
+	return fu, nil
+}
+
+// DownloadFirmwareUpdate instructs an EdgeMAX device to download and install
+// the latest firmware reported by CheckFirmwareUpdate.  The install proceeds
+// on the device after DownloadFirmwareUpdate returns; the same options used
+// with UpgradeFirmware can be used to wait for it to complete and to reboot
+// the device.
+func (c *Client) DownloadFirmwareUpdate(ctx context.Context, opts *UpgradeOptions) error {
+	if err := c.operation(ctx, opFirmwareDownload, nil, nil); err != nil {
+		return err
+	}
+
+	return c.waitUpgrade(ctx, opts)
+}
+
+// upload streams the file read from r to the specified API endpoint of an
+// EdgeMAX device as a multipart form upload, using the file name filename.
+// The Client's http.Client.Timeout does not apply, so the upload is bounded
+// only by ctx.
+func (c *Client) upload(ctx context.Context, endpoint string, filename string, r io.Reader) error {
+	// Stream the file through a pipe so that large files need not be
+	// buffered in memory
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		fw, err := mw.CreateFormFile("file", filename)
+		if err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+
+		if _, err := io.Copy(fw, r); err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+
+		_ = pw.CloseWithError(mw.Close())
+	}()
+
+	req, err := c.newRequestBody(ctx, http.MethodPost, endpoint, pr)
+	if err != nil {
+		_ = pr.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var ar apiResponse
+	res, err := c.doSend(c.stream, req, &ar)
+	if err != nil {
+		_ = pr.Close()
+		return err
+	}
+
+	if !ar.Success {
+		return newError(req, res, ar.Error)
+	}
+
+	return nil
+}
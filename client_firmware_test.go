@@ -0,0 +1,276 @@
+package edgemax
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientUpgradeFirmware(t *testing.T) {
+	wantImage := []byte("firmware image")
+
+	var (
+		polls  int
+		reboot bool
+	)
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/upgrade.json":
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("failed to retrieve uploaded file: %v", err)
+			}
+
+			b, err := ioutil.ReadAll(f)
+			if err != nil {
+				t.Fatalf("failed to read uploaded file: %v", err)
+			}
+
+			if want, got := wantImage, b; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected firmware image:\n- want: %q\n-  got: %q", want, got)
+			}
+
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		case "/api/edge/data.json":
+			defer func() { polls++ }()
+
+			state := "installing"
+			if polls > 0 {
+				state = "done"
+			}
+
+			_, _ = w.Write([]byte(`{"success":"1","output":{"state":"` + state + `"}}`))
+		case "/api/edge/operation/" + opReboot + ".json":
+			reboot = true
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		default:
+			t.Fatalf("unexpected URL path: %q", r.URL.Path)
+		}
+	})
+	defer done()
+
+	var states []UpgradeState
+	opts := &UpgradeOptions{
+		Reboot:       true,
+		PollInterval: 1,
+		Progress: func(us UpgradeStatus) {
+			states = append(states, us.State)
+		},
+	}
+
+	if err := c.UpgradeFirmware(context.Background(), bytes.NewReader(wantImage), opts); err != nil {
+		t.Fatalf("unexpected error from Client.UpgradeFirmware: %v", err)
+	}
+
+	wantStates := []UpgradeState{UpgradeStateInstalling, UpgradeStateDone}
+	if want, got := wantStates, states; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected upgrade states:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if !reboot {
+		t.Fatal("device was not rebooted after upgrade")
+	}
+}
+
+func TestClientUpgradeFirmwareIgnoresClientTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/upgrade.json":
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatalf("failed to read upload: %v", err)
+			}
+			if !bytes.Contains(b, []byte("xxxx")) {
+				t.Fatalf("firmware image not found in upload: %q", b)
+			}
+
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		case "/api/edge/data.json":
+			_, _ = w.Write([]byte(`{"success":"1","output":{"state":"done"}}`))
+		default:
+			t.Fatalf("unexpected URL path: %q", r.URL.Path)
+		}
+	})
+	defer done()
+
+	c.client.Timeout = timeout
+
+	// Upload the image for several times the client's timeout
+	r := &slowReader{
+		n:     4,
+		delay: timeout,
+	}
+
+	if err := c.UpgradeFirmware(context.Background(), r, nil); err != nil {
+		t.Fatalf("unexpected error from Client.UpgradeFirmware: %v", err)
+	}
+}
+
+func TestClientUpgradeFirmwareFromURL(t *testing.T) {
+	wantImage := bytes.Repeat([]byte("firmware image"), 1024)
+
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/edgeos.tar" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write(wantImage)
+	}))
+	defer images.Close()
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/upgrade.json":
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("failed to retrieve uploaded file: %v", err)
+			}
+
+			b, err := ioutil.ReadAll(f)
+			if err != nil {
+				t.Fatalf("failed to read uploaded file: %v", err)
+			}
+
+			if want, got := wantImage, b; !bytes.Equal(want, got) {
+				t.Fatalf("unexpected firmware image:\n- want: %d bytes\n-  got: %d bytes", len(want), len(got))
+			}
+
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		case "/api/edge/data.json":
+			_, _ = w.Write([]byte(`{"success":"1","output":{"state":"done"}}`))
+		default:
+			t.Fatalf("unexpected URL path: %q", r.URL.Path)
+		}
+	})
+	defer done()
+
+	var uploaded int64
+	opts := &UpgradeOptions{
+		UploadProgress: func(n int64) {
+			if n <= uploaded {
+				t.Fatalf("upload progress did not increase: %d -> %d", uploaded, n)
+			}
+
+			uploaded = n
+		},
+	}
+
+	if err := c.UpgradeFirmwareFromURL(context.Background(), "ftp://example.com/edgeos.tar", opts); err == nil {
+		t.Fatal("expected an error for non-HTTP URL, but none occurred")
+	}
+	if err := c.UpgradeFirmwareFromURL(context.Background(), images.URL+"/missing.tar", opts); err == nil {
+		t.Fatal("expected an error for missing image, but none occurred")
+	}
+
+	if err := c.UpgradeFirmwareFromURL(context.Background(), images.URL+"/edgeos.tar", opts); err != nil {
+		t.Fatalf("unexpected error from Client.UpgradeFirmwareFromURL: %v", err)
+	}
+
+	if want, got := int64(len(wantImage)), uploaded; want != got {
+		t.Fatalf("unexpected number of bytes uploaded:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientUpgradeFirmwareFailed(t *testing.T) {
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/upgrade.json":
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		case "/api/edge/data.json":
+			_, _ = w.Write([]byte(`{"success":"1","output":{"state":"failed","message":"bad image"}}`))
+		default:
+			t.Fatalf("unexpected URL path: %q", r.URL.Path)
+		}
+	})
+	defer done()
+
+	err := c.UpgradeFirmware(context.Background(), strings.NewReader("foo"), nil)
+	if err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	if want, got := "bad image", err.Error(); !strings.Contains(got, want) {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientCheckFirmwareUpdate(t *testing.T) {
+	wantFU := &FirmwareUpdate{
+		Current:   "v1.8.5",
+		Latest:    "v1.9.0",
+		Available: true,
+		URL:       "https://dl.ubnt.com/firmwares/edgemax/v1.9.0/ER-e100.v1.9.0.tar",
+	}
+
+	h := testDataHandler(t, dataFirmwareUpdate)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"current":"v1.8.5","latest":"v1.9.0","available":true,"url":"https://dl.ubnt.com/firmwares/edgemax/v1.9.0/ER-e100.v1.9.0.tar"}}`))
+	})
+	defer done()
+
+	fu, err := c.CheckFirmwareUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error from Client.CheckFirmwareUpdate: %v", err)
+	}
+
+	if want, got := wantFU, fu; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected FirmwareUpdate:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientDownloadFirmwareUpdate(t *testing.T) {
+	var download bool
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/operation/" + opFirmwareDownload + ".json":
+			download = true
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		case "/api/edge/data.json":
+			_, _ = w.Write([]byte(`{"success":"1","output":{"state":"done"}}`))
+		default:
+			t.Fatalf("unexpected URL path: %q", r.URL.Path)
+		}
+	})
+	defer done()
+
+	if err := c.DownloadFirmwareUpdate(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error from Client.DownloadFirmwareUpdate: %v", err)
+	}
+
+	if !download {
+		t.Fatal("firmware download was not triggered")
+	}
+}
+
+// A slowReader reads n bytes, one at a time, waiting for delay before each.
+type slowReader struct {
+	n     int
+	delay time.Duration
+}
+
+func (r *slowReader) Read(b []byte) (int, error) {
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	time.Sleep(r.delay)
+	r.n--
+	b[0] = 'x'
+	return 1, nil
+}
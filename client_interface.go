@@ -0,0 +1,161 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// dataInterfaces is the data API type used to retrieve Interfaces.
+	dataInterfaces = "interfaces"
+
+	// Operations used to change the administrative state of a network
+	// interface until the next reboot.
+	opInterfaceDown = "interface-down"
+	opInterfaceUp   = "interface-up"
+
+	// Operations used to control PPPoE client sessions.
+	opPPPoEDisconnect = "pppoe-disconnect"
+	opPPPoEConnect    = "pppoe-connect"
+)
+
+// ErrManagementInterface is returned when an operation would disable the
+// network interface used by a Client to manage an EdgeMAX device.
+var ErrManagementInterface = errors.New("refusing to disable management interface")
+
+// SetInterfaceAdminState sets the administrative state of the network
+// interface specified by name to up or down.  Unlike disabling an interface
+// in the device's configuration, the change does not persist across a
+// reboot, which makes it suitable for temporarily isolating a misbehaving
+// network segment.
+//
+// As with BounceInterface, SetInterfaceAdminState returns
+// ErrManagementInterface instead of disabling the interface which carries
+// the address used by c to reach the device.
+func (c *Client) SetInterfaceAdminState(ctx context.Context, name string, up bool) error {
+	if up {
+		return c.interfaceOperation(ctx, opInterfaceUp, name)
+	}
+
+	if err := c.checkManagementInterface(ctx, name); err != nil {
+		return err
+	}
+
+	return c.interfaceOperation(ctx, opInterfaceDown, name)
+}
+
+// BounceInterface disables the network interface specified by name, waits
+// for the duration specified by downFor, and enables the interface again.
+// This is a common remedy for links which are stuck, such as DSL or ONT
+// uplinks.
+//
+// BounceInterface returns ErrManagementInterface without disabling the
+// interface if it carries the address used by c to reach the device, since
+// the device could not be reached to enable the interface again.
+//
+// If ctx is canceled while the interface is disabled, the interface is
+// still enabled before BounceInterface returns.
+func (c *Client) BounceInterface(ctx context.Context, name string, downFor time.Duration) error {
+	if err := c.checkManagementInterface(ctx, name); err != nil {
+		return err
+	}
+
+	if err := c.interfaceOperation(ctx, opInterfaceDown, name); err != nil {
+		return err
+	}
+
+	var waitErr error
+	select {
+	case <-c.clock().After(downFor):
+	case <-ctx.Done():
+		waitErr = ctx.Err()
+	}
+
+	// Always attempt to bring the interface back up, even if ctx has been
+	// canceled, so the interface is not left disabled
+	upCtx := ctx
+	if waitErr != nil {
+		upCtx = context.Background()
+	}
+
+	if err := c.interfaceOperation(upCtx, opInterfaceUp, name); err != nil {
+		return fmt.Errorf("failed to enable interface %q: %v", name, err)
+	}
+
+	return waitErr
+}
+
+// DisconnectPPPoE drops the PPPoE session on the PPPoE interface specified
+// by iface, such as "pppoe0".  The session remains down until ConnectPPPoE
+// is called.
+func (c *Client) DisconnectPPPoE(ctx context.Context, iface string) error {
+	return c.interfaceOperation(ctx, opPPPoEDisconnect, iface)
+}
+
+// ConnectPPPoE establishes the PPPoE session on the PPPoE interface
+// specified by iface.
+//
+// Disconnecting and reconnecting a session using DisconnectPPPoE and
+// ConnectPPPoE is commonly used to obtain a new public address from an ISP.
+func (c *Client) ConnectPPPoE(ctx context.Context, iface string) error {
+	return c.interfaceOperation(ctx, opPPPoEConnect, iface)
+}
+
+// deviceIPs returns the IP addresses used by c to reach the EdgeMAX device.
+// A device address which is an IP literal is not resolved.
+func (c *Client) deviceIPs(ctx context.Context) ([]net.IP, error) {
+	host := c.apiURL.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+
+	return ips, nil
+}
+
+// checkManagementInterface returns ErrManagementInterface if the network
+// interface specified by name carries the address used by c to reach the
+// EdgeMAX device.
+func (c *Client) checkManagementInterface(ctx context.Context, name string) error {
+	if name == "" {
+		return errors.New("interface name must not be empty")
+	}
+
+	ips, err := c.deviceIPs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve device address to check management interface: %v", err)
+	}
+
+	var ifis Interfaces
+	if err := c.getData(ctx, dataInterfaces, &ifis); err != nil {
+		return err
+	}
+
+	for _, ifi := range ifis {
+		if ifi.Name != name {
+			continue
+		}
+
+		for _, addr := range ifi.Addresses {
+			for _, ip := range ips {
+				if addr.Equal(ip) {
+					return ErrManagementInterface
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package edgemax
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientBounceInterface(t *testing.T) {
+	var tests = []struct {
+		desc  string
+		iface string
+		ops   []string
+		err   error
+	}{
+		{
+			desc:  "management interface",
+			iface: "eth0",
+			err:   ErrManagementInterface,
+		},
+		{
+			desc:  "OK",
+			iface: "eth1",
+			ops:   []string{opInterfaceDown, opInterfaceUp},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var ops []string
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/edge/data.json":
+				// httptest servers listen on the loopback address
+				_, _ = w.Write([]byte(`{"success":"1","output":{"eth0":{"addresses":["127.0.0.1/8"]},"eth1":{"addresses":["192.168.1.1/24"]}}}`))
+			case "/api/edge/operation/" + opInterfaceDown + ".json":
+				ops = append(ops, opInterfaceDown)
+				_, _ = w.Write([]byte(`{"success":"1"}`))
+			case "/api/edge/operation/" + opInterfaceUp + ".json":
+				ops = append(ops, opInterfaceUp)
+				_, _ = w.Write([]byte(`{"success":"1"}`))
+			default:
+				t.Fatalf("unexpected URL path: %q", r.URL.Path)
+			}
+		})
+
+		err := c.BounceInterface(context.Background(), tt.iface, time.Millisecond)
+		done()
+
+		if want, got := errStr(tt.err), errStr(err); want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		if want, got := tt.ops, ops; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected operations:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestClientBounceInterfaceContextCanceled(t *testing.T) {
+	var up bool
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/data.json":
+			_, _ = w.Write([]byte(`{"success":"1","output":{}}`))
+		case "/api/edge/operation/" + opInterfaceUp + ".json":
+			up = true
+			fallthrough
+		default:
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		}
+	})
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := c.BounceInterface(ctx, "eth1", time.Minute); err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !up {
+		t.Fatal("interface was not enabled after context was canceled")
+	}
+}
+
+func TestClientDeviceIPs(t *testing.T) {
+	var tests = []struct {
+		desc string
+		addr string
+		ips  []net.IP
+	}{
+		{
+			desc: "IPv4",
+			addr: "https://192.168.1.1",
+			ips:  []net.IP{net.IPv4(192, 168, 1, 1)},
+		},
+		{
+			desc: "IPv6 with port",
+			addr: "https://[fd00::1]:8443",
+			ips:  []net.IP{net.ParseIP("fd00::1")},
+		},
+	}
+
+	// IP literals must not be resolved, so a canceled context has no effect
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		c, err := NewClient(tt.addr, nil)
+		if err != nil {
+			t.Fatalf("error creating Client: %v", err)
+		}
+
+		ips, err := c.deviceIPs(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want, got := tt.ips, ips; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected IP addresses:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestClientPPPoEOperations(t *testing.T) {
+	var tests = []struct {
+		desc string
+		op   string
+		fn   func(c *Client, iface string) error
+	}{
+		{
+			desc: "disconnect",
+			op:   opPPPoEDisconnect,
+			fn: func(c *Client, iface string) error {
+				return c.DisconnectPPPoE(context.Background(), iface)
+			},
+		},
+		{
+			desc: "connect",
+			op:   opPPPoEConnect,
+			fn: func(c *Client, iface string) error {
+				return c.ConnectPPPoE(context.Background(), iface)
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		h := testInterfaceOperationHandler(t, tt.op, "pppoe0")
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			h(w, r)
+
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		})
+
+		err := tt.fn(c, "pppoe0")
+		done()
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestClientSetInterfaceAdminState(t *testing.T) {
+	var tests = []struct {
+		desc  string
+		iface string
+		up    bool
+		op    string
+		err   error
+	}{
+		{
+			desc:  "down management interface",
+			iface: "eth0",
+			err:   ErrManagementInterface,
+		},
+		{
+			desc:  "up management interface",
+			iface: "eth0",
+			up:    true,
+			op:    opInterfaceUp,
+		},
+		{
+			desc:  "down",
+			iface: "eth1",
+			op:    opInterfaceDown,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var op string
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/edge/data.json":
+				_, _ = w.Write([]byte(`{"success":"1","output":{"eth0":{"addresses":["127.0.0.1/8"]}}}`))
+			case "/api/edge/operation/" + opInterfaceDown + ".json":
+				op = opInterfaceDown
+				_, _ = w.Write([]byte(`{"success":"1"}`))
+			case "/api/edge/operation/" + opInterfaceUp + ".json":
+				op = opInterfaceUp
+				_, _ = w.Write([]byte(`{"success":"1"}`))
+			default:
+				t.Fatalf("unexpected URL path: %q", r.URL.Path)
+			}
+		})
+
+		err := c.SetInterfaceAdminState(context.Background(), tt.iface, tt.up)
+		done()
+
+		if want, got := errStr(tt.err), errStr(err); want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		if want, got := tt.op, op; want != got {
+			t.Fatalf("unexpected operation:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
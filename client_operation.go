@@ -0,0 +1,369 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// opReboot is the operation used to reboot an EdgeMAX device.
+	opReboot = "reboot"
+
+	// opShutdown is the operation used to power off an EdgeMAX device.
+	opShutdown = "shutdown"
+
+	// opFactoryReset is the operation used to load the default configuration
+	// and reboot an EdgeMAX device.
+	opFactoryReset = "reset-default-config"
+
+	// opTechSupport is the operation used to generate a tech support file.
+	opTechSupport = "tech-support"
+
+	// opRenewDHCP is the operation used to renew a DHCP client lease.
+	opRenewDHCP = "renew-dhcp"
+
+	// opReleaseDHCP is the operation used to release a DHCP client lease.
+	opReleaseDHCP = "release-dhcp"
+
+	// opClearDPI is the operation used to reset traffic analysis counters.
+	opClearDPI = "clear-traffic-analysis"
+
+	// opWakeOnLAN is the operation used to send a Wake-on-LAN packet.
+	opWakeOnLAN = "wake-on-lan"
+
+	// opRestartService is the operation used to restart a Service.
+	opRestartService = "restart-service"
+
+	// opSyncTime is the operation used to synchronize the system clock
+	// using NTP.
+	opSyncTime = "ntp-sync"
+
+	// opSetTime is the operation used to set the system clock manually.
+	opSetTime = "set-time"
+
+	// opRunOp is the operation used to run an op-mode command.
+	opRunOp = "cli"
+
+	// opClearLogs is the operation used to clear system logs.
+	opClearLogs = "clear-logs"
+
+	// opResetIPsec is the operation used to reset an IPsec peer.
+	opResetIPsec = "reset-ipsec-peer"
+)
+
+// A Service is a service running on an EdgeMAX device, which can be
+// restarted using Client.RestartService.
+type Service string
+
+const (
+	// ServiceDHCPServer is the DHCP server.
+	ServiceDHCPServer Service = "dhcp-server"
+
+	// ServiceDNSForwarding is the DNS forwarder.
+	ServiceDNSForwarding Service = "dns-forwarding"
+
+	// ServiceVPN is the IPsec VPN service.
+	ServiceVPN Service = "vpn"
+
+	// ServicePPPoE is the PPPoE client service.
+	ServicePPPoE Service = "pppoe"
+
+	// ServiceGUI is the web interface and API service.
+	ServiceGUI Service = "gui"
+)
+
+// Reboot reboots an EdgeMAX device.
+//
+// The device may drop its connection before replying to the request, so
+// an unexpectedly closed connection is treated as a successful reboot.  Any
+// Stats subscriptions on c will stop receiving statistics once the device
+// begins its reboot.
+func (c *Client) Reboot(ctx context.Context) error {
+	return c.disruptiveOperation(ctx, opReboot, nil)
+}
+
+// Shutdown powers off an EdgeMAX device.  Once powered off, the device
+// cannot be started again remotely: it must be power cycled manually.
+//
+// As with Reboot, an unexpectedly closed connection is treated as a
+// successful shutdown.
+func (c *Client) Shutdown(ctx context.Context) error {
+	return c.disruptiveOperation(ctx, opShutdown, nil)
+}
+
+// ErrNotConfirmed is returned when a destructive operation is invoked
+// without explicit confirmation.
+var ErrNotConfirmed = errors.New("destructive operation was not confirmed")
+
+// FactoryReset erases the configuration of an EdgeMAX device, loads the
+// default configuration, and reboots the device.  confirm must be true,
+// or ErrNotConfirmed is returned and the device is not modified.
+//
+// After the reset, the device uses its default address and credentials,
+// so c will no longer be able to reach it unless those match its previous
+// configuration.  As with Reboot, an unexpectedly closed connection is
+// treated as a successful reset.
+func (c *Client) FactoryReset(ctx context.Context, confirm bool) error {
+	if !confirm {
+		return ErrNotConfirmed
+	}
+
+	return c.disruptiveOperation(ctx, opFactoryReset, nil)
+}
+
+// TechSupport instructs an EdgeMAX device to generate a tech support file,
+// and writes the resulting archive to w.  The archive is suitable for
+// attaching to bug reports sent to Ubiquiti.
+//
+// Generating the archive can take a minute or more on slower models, so the
+// Client's http.Client.Timeout does not apply, and the operation is bounded
+// only by ctx.  ctx should allow ample time for the operation to complete.
+func (c *Client) TechSupport(ctx context.Context, w io.Writer) error {
+	var v struct {
+		Path string `json:"path"`
+	}
+	if err := c.longOperation(ctx, opTechSupport, nil, &v); err != nil {
+		return err
+	}
+
+	if v.Path == "" {
+		return errors.New("no tech support file path returned by device")
+	}
+
+	return c.download(ctx, v.Path, w)
+}
+
+// RenewDHCP renews the DHCP client lease on the network interface specified
+// by iface, such as a WAN interface which obtains its address from an ISP.
+func (c *Client) RenewDHCP(ctx context.Context, iface string) error {
+	return c.interfaceOperation(ctx, opRenewDHCP, iface)
+}
+
+// ReleaseDHCP releases the DHCP client lease on the network interface
+// specified by iface.  The interface will have no address until the lease
+// is renewed using RenewDHCP.
+func (c *Client) ReleaseDHCP(ctx context.Context, iface string) error {
+	return c.interfaceOperation(ctx, opReleaseDHCP, iface)
+}
+
+// ClearDPI resets the deep packet inspection counters on an EdgeMAX device.
+// Otherwise, the cumulative counters reported in DPIStats are only reset
+// when the device reboots.
+func (c *Client) ClearDPI(ctx context.Context) error {
+	return c.operation(ctx, opClearDPI, nil, nil)
+}
+
+// WakeOnLAN instructs an EdgeMAX device to send a Wake-on-LAN magic packet
+// for the host with hardware address mac, on the network interface specified
+// by iface.  This allows sleeping hosts on a LAN to be woken from anywhere
+// the device is reachable.
+func (c *Client) WakeOnLAN(ctx context.Context, iface string, mac net.HardwareAddr) error {
+	if iface == "" {
+		return errors.New("interface name must not be empty")
+	}
+	if len(mac) != 6 {
+		return fmt.Errorf("invalid Wake-on-LAN hardware address: %q", mac)
+	}
+
+	return c.operation(ctx, opWakeOnLAN, struct {
+		Interface string `json:"interface"`
+		MAC       string `json:"mac"`
+	}{
+		Interface: iface,
+		MAC:       mac.String(),
+	}, nil)
+}
+
+// RestartService restarts the service specified by s on an EdgeMAX device.
+// This is often needed after applying configuration which a service does
+// not fully apply without a restart.
+//
+// Restarting ServiceGUI causes the device to drop its connection, so an
+// unexpectedly closed connection is treated as a successful restart.  Any
+// existing session may need to be re-established using Login.
+func (c *Client) RestartService(ctx context.Context, s Service) error {
+	switch s {
+	case ServiceDHCPServer, ServiceDNSForwarding, ServiceVPN, ServicePPPoE:
+	case ServiceGUI:
+		return c.disruptiveOperation(ctx, opRestartService, serviceRequest{Service: s})
+	default:
+		return fmt.Errorf("unknown service: %q", s)
+	}
+
+	return c.operation(ctx, opRestartService, serviceRequest{Service: s}, nil)
+}
+
+// A serviceRequest is the request body for Service operations.
+type serviceRequest struct {
+	Service Service `json:"service"`
+}
+
+// SyncTime forces an EdgeMAX device to immediately synchronize its system
+// clock using its configured NTP servers, and returns the offset which was
+// corrected.  A positive offset indicates the device's clock was behind.
+//
+// Devices which have been powered off for long periods may have enough
+// clock skew to cause certificate validation and VPN failures.
+func (c *Client) SyncTime(ctx context.Context) (time.Duration, error) {
+	var out struct {
+		Offset string `json:"offset"`
+	}
+	if err := c.operation(ctx, opSyncTime, nil, &out); err != nil {
+		return 0, err
+	}
+
+	if out.Offset == "" {
+		return 0, nil
+	}
+
+	// Offset is reported in fractional seconds
+	f, err := strconv.ParseFloat(out.Offset, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(f * float64(time.Second)), nil
+}
+
+// SetTime sets the system clock of an EdgeMAX device to t.  SetTime is
+// typically only needed if the device cannot reach an NTP server; otherwise
+// SyncTime should be used.
+func (c *Client) SetTime(ctx context.Context, t time.Time) error {
+	if t.IsZero() {
+		return errors.New("time must not be zero")
+	}
+
+	return c.operation(ctx, opSetTime, struct {
+		Time string `json:"time"`
+	}{
+		Time: t.UTC().Format(time.RFC3339),
+	}, nil)
+}
+
+// ClearLogs truncates the system logs stored on an EdgeMAX device.  This is
+// useful on models with limited storage, after logs have been collected
+// elsewhere.  Logs which have been cleared cannot be recovered.
+func (c *Client) ClearLogs(ctx context.Context) error {
+	return c.operation(ctx, opClearLogs, nil, nil)
+}
+
+// ResetIPsec resets the IPsec tunnels to the VPN peer specified by peer,
+// equivalent to the "reset vpn ipsec-peer" op-mode command.  peer must match
+// the name of a configured peer, which is typically its address.
+//
+// Resetting a peer tears down its security associations and forces them to
+// be negotiated again, which can recover tunnels to a peer which has
+// stopped passing traffic.
+func (c *Client) ResetIPsec(ctx context.Context, peer string) error {
+	if peer == "" {
+		return errors.New("IPsec peer must not be empty")
+	}
+
+	return c.operation(ctx, opResetIPsec, struct {
+		Peer string `json:"peer"`
+	}{
+		Peer: peer,
+	}, nil)
+}
+
+// OpOutput contains the output of an op-mode command run using Client.RunOp.
+type OpOutput struct {
+	Stdout     string
+	Stderr     string
+	ExitStatus int
+}
+
+// RunOp runs the op-mode command specified by args on an EdgeMAX device, such
+// as "show", "ip", "route", and returns its output.  A command which exits
+// with a non-zero status does not cause RunOp to return an error; callers
+// should inspect OpOutput.ExitStatus.
+//
+// RunOp is an escape hatch for retrieving data which is not yet modeled by
+// this package.  Its output is intended for humans, and may change between
+// firmware versions.
+func (c *Client) RunOp(ctx context.Context, args ...string) (*OpOutput, error) {
+	if len(args) == 0 {
+		return nil, errors.New("op-mode command must not be empty")
+	}
+
+	var out struct {
+		Stdout string `json:"stdout"`
+		Stderr string `json:"stderr"`
+		Status string `json:"status"`
+	}
+	if err := c.operation(ctx, opRunOp, struct {
+		Args []string `json:"args"`
+	}{
+		Args: args,
+	}, &out); err != nil {
+		return nil, err
+	}
+
+	status, err := atoiOrZero(out.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OpOutput{
+		Stdout:     out.Stdout,
+		Stderr:     out.Stderr,
+		ExitStatus: status,
+	}, nil
+}
+
+// interfaceOperation performs an operation which acts on a single network
+// interface specified by iface.
+func (c *Client) interfaceOperation(ctx context.Context, name string, iface string) error {
+	if iface == "" {
+		return errors.New("interface name must not be empty")
+	}
+
+	return c.operation(ctx, name, struct {
+		Interface string `json:"interface"`
+	}{
+		Interface: iface,
+	}, nil)
+}
+
+// disruptiveOperation performs an operation which is expected to cause the
+// EdgeMAX device to drop its connection before replying, and treats such a
+// connection drop as success.
+func (c *Client) disruptiveOperation(ctx context.Context, name string, in interface{}) error {
+	err := c.operation(ctx, name, in, nil)
+	if err == nil {
+		return nil
+	}
+
+	// Errors caused by the caller's context must always be reported
+	if ctx.Err() != nil {
+		return err
+	}
+
+	if isConnectionDrop(err) {
+		return nil
+	}
+
+	return err
+}
+
+// isConnectionDrop determines if err was caused by a remote host closing
+// or resetting its connection during an HTTP request.
+func isConnectionDrop(err error) bool {
+	if uerr, ok := err.(*url.Error); ok {
+		err = uerr.Err
+	}
+
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+
+	operr, ok := err.(*net.OpError)
+	return ok && operr.Op == "read"
+}
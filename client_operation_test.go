@@ -0,0 +1,500 @@
+package edgemax
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientReboot(t *testing.T) {
+	h := testOperationHandler(t, opReboot)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.Reboot(context.Background()); err != nil {
+		t.Fatalf("unexpected error from Client.Reboot: %v", err)
+	}
+}
+
+func TestClientRebootCSRFToken(t *testing.T) {
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "session"})
+			http.SetCookie(w, &http.Cookie{Name: csrfCookie, Value: "token"})
+			return
+		}
+
+		testOperationHandler(t, opReboot)(w, r)
+
+		// Newer firmware requires the CSRF token provided at login
+		if want, got := "token", r.Header.Get(csrfHeader); want != got {
+			t.Fatalf("unexpected CSRF token:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.Login("ubnt", "ubnt"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	if err := c.Reboot(context.Background()); err != nil {
+		t.Fatalf("unexpected error from Client.Reboot: %v", err)
+	}
+}
+
+func TestClientRebootConnectionDrop(t *testing.T) {
+	h := testOperationHandler(t, opReboot)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		// Simulate a device which begins rebooting immediately
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatalf("failed to hijack connection: %v", err)
+		}
+		_ = conn.Close()
+	})
+	defer done()
+
+	if err := c.Reboot(context.Background()); err != nil {
+		t.Fatalf("unexpected error from Client.Reboot: %v", err)
+	}
+}
+
+func TestClientRebootFailure(t *testing.T) {
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":"0","error":"permission denied"}`))
+	})
+	defer done()
+
+	if err := c.Reboot(context.Background()); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestClientRebootContextCanceled(t *testing.T) {
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.Reboot(ctx); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestClientShutdown(t *testing.T) {
+	h := testOperationHandler(t, opShutdown)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error from Client.Shutdown: %v", err)
+	}
+}
+
+func TestClientTechSupport(t *testing.T) {
+	const (
+		path = "/files/tech-support/ts.tgz"
+		want = "tech support archive"
+	)
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/operation/" + opTechSupport + ".json":
+			_, _ = w.Write([]byte(`{"success":"1","output":{"path":"` + path + `"}}`))
+		case path:
+			_, _ = w.Write([]byte(want))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer done()
+
+	buf := bytes.NewBuffer(nil)
+	if err := c.TechSupport(context.Background(), buf); err != nil {
+		t.Fatalf("unexpected error from Client.TechSupport: %v", err)
+	}
+
+	if got := buf.String(); want != got {
+		t.Fatalf("unexpected tech support file:\n- want: %q\n-  got: %q", want, got)
+	}
+}
+
+func TestClientTechSupportIgnoresClientTimeout(t *testing.T) {
+	const (
+		path    = "/files/tech-support/ts.tgz"
+		timeout = 50 * time.Millisecond
+	)
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/operation/" + opTechSupport + ".json":
+			// Generate the archive for longer than the client's timeout
+			time.Sleep(2 * timeout)
+			_, _ = w.Write([]byte(`{"success":"1","output":{"path":"` + path + `"}}`))
+		case path:
+			// Download the archive for longer than the client's timeout
+			for i := 0; i < 4; i++ {
+				_, _ = w.Write([]byte("x"))
+				w.(http.Flusher).Flush()
+				time.Sleep(timeout)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer done()
+
+	c.client.Timeout = timeout
+
+	buf := bytes.NewBuffer(nil)
+	if err := c.TechSupport(context.Background(), buf); err != nil {
+		t.Fatalf("unexpected error from Client.TechSupport: %v", err)
+	}
+
+	if want, got := "xxxx", buf.String(); want != got {
+		t.Fatalf("unexpected tech support file:\n- want: %q\n-  got: %q", want, got)
+	}
+}
+
+func TestClientTechSupportNotFound(t *testing.T) {
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/edge/operation/" + opTechSupport + ".json":
+			_, _ = w.Write([]byte(`{"success":"1","output":{"path":"/files/foo"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	defer done()
+
+	if err := c.TechSupport(context.Background(), ioutil.Discard); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestClientDHCPOperations(t *testing.T) {
+	var tests = []struct {
+		desc string
+		op   string
+		fn   func(c *Client, iface string) error
+	}{
+		{
+			desc: "renew",
+			op:   opRenewDHCP,
+			fn: func(c *Client, iface string) error {
+				return c.RenewDHCP(context.Background(), iface)
+			},
+		},
+		{
+			desc: "release",
+			op:   opReleaseDHCP,
+			fn: func(c *Client, iface string) error {
+				return c.ReleaseDHCP(context.Background(), iface)
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		h := testInterfaceOperationHandler(t, tt.op, "eth0")
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			h(w, r)
+
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		})
+
+		if err := tt.fn(c, ""); err == nil {
+			t.Fatal("expected an error for empty interface, but none occurred")
+		}
+
+		if err := tt.fn(c, "eth0"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		done()
+	}
+}
+
+func testInterfaceOperationHandler(t *testing.T, name string, iface string) http.HandlerFunc {
+	h := testOperationHandler(t, name)
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			Interface string `json:"interface"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := iface, v.Interface; want != got {
+			t.Fatalf("unexpected interface:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestClientClearDPI(t *testing.T) {
+	h := testOperationHandler(t, opClearDPI)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.ClearDPI(context.Background()); err != nil {
+		t.Fatalf("unexpected error from Client.ClearDPI: %v", err)
+	}
+}
+
+func TestClientWakeOnLAN(t *testing.T) {
+	mac := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	h := testInterfaceOperationHandler(t, opWakeOnLAN, "eth1")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.WakeOnLAN(context.Background(), "eth1", mac[:4]); err == nil {
+		t.Fatal("expected an error for invalid MAC, but none occurred")
+	}
+
+	if err := c.WakeOnLAN(context.Background(), "eth1", mac); err != nil {
+		t.Fatalf("unexpected error from Client.WakeOnLAN: %v", err)
+	}
+}
+
+func TestClientRestartService(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    Service
+		ok   bool
+	}{
+		{
+			desc: "unknown service",
+			s:    "foo",
+		},
+		{
+			desc: "DHCP server",
+			s:    ServiceDHCPServer,
+			ok:   true,
+		},
+		{
+			desc: "GUI",
+			s:    ServiceGUI,
+			ok:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		h := testOperationHandler(t, opRestartService)
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			h(w, r)
+
+			var v struct {
+				Service Service `json:"service"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+
+			if want, got := tt.s, v.Service; want != got {
+				t.Fatalf("unexpected service:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		})
+
+		err := c.RestartService(context.Background(), tt.s)
+		done()
+
+		if want, got := tt.ok, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestClientSyncTime(t *testing.T) {
+	h := testOperationHandler(t, opSyncTime)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"offset":"-1.5"}}`))
+	})
+	defer done()
+
+	offset, err := c.SyncTime(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error from Client.SyncTime: %v", err)
+	}
+
+	if want, got := -1500*time.Millisecond, offset; want != got {
+		t.Fatalf("unexpected offset:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientSetTime(t *testing.T) {
+	h := testOperationHandler(t, opSetTime)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			Time string `json:"time"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := "2016-01-02T03:04:05Z", v.Time; want != got {
+			t.Fatalf("unexpected time:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.SetTime(context.Background(), time.Time{}); err == nil {
+		t.Fatal("expected an error for zero time, but none occurred")
+	}
+
+	tm := time.Date(2016, time.January, 2, 3, 4, 5, 0, time.UTC)
+	if err := c.SetTime(context.Background(), tm); err != nil {
+		t.Fatalf("unexpected error from Client.SetTime: %v", err)
+	}
+}
+
+func TestClientFactoryReset(t *testing.T) {
+	var reset bool
+	h := testOperationHandler(t, opFactoryReset)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		reset = true
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if want, got := ErrNotConfirmed, c.FactoryReset(context.Background(), false); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+	if reset {
+		t.Fatal("device was reset without confirmation")
+	}
+
+	if err := c.FactoryReset(context.Background(), true); err != nil {
+		t.Fatalf("unexpected error from Client.FactoryReset: %v", err)
+	}
+	if !reset {
+		t.Fatal("device was not reset")
+	}
+}
+
+func TestClientRunOp(t *testing.T) {
+	wantArgs := []string{"show", "version"}
+	wantOut := &OpOutput{
+		Stdout:     "Version: v1.9.0\n",
+		ExitStatus: 0,
+	}
+
+	h := testOperationHandler(t, opRunOp)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			Args []string `json:"args"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := wantArgs, v.Args; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected arguments:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"stdout":"Version: v1.9.0\n","stderr":"","status":"0"}}`))
+	})
+	defer done()
+
+	if _, err := c.RunOp(context.Background()); err == nil {
+		t.Fatal("expected an error for empty command, but none occurred")
+	}
+
+	out, err := c.RunOp(context.Background(), wantArgs...)
+	if err != nil {
+		t.Fatalf("unexpected error from Client.RunOp: %v", err)
+	}
+
+	if want, got := wantOut, out; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected OpOutput:\n- want: %+v\n-  got: %+v", want, got)
+	}
+}
+
+func TestClientClearLogs(t *testing.T) {
+	h := testOperationHandler(t, opClearLogs)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.ClearLogs(context.Background()); err != nil {
+		t.Fatalf("unexpected error from Client.ClearLogs: %v", err)
+	}
+}
+
+func TestClientResetIPsec(t *testing.T) {
+	h := testOperationHandler(t, opResetIPsec)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			Peer string `json:"peer"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := "203.0.113.1", v.Peer; want != got {
+			t.Fatalf("unexpected peer:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.ResetIPsec(context.Background(), ""); err == nil {
+		t.Fatal("expected an error for empty peer, but none occurred")
+	}
+
+	if err := c.ResetIPsec(context.Background(), "203.0.113.1"); err != nil {
+		t.Fatalf("unexpected error from Client.ResetIPsec: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package edgemax
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// PortForwards retrieves the port forwarding rules configured on an EdgeMAX
+// device, sorted by number.
+func (c *Client) PortForwards(ctx context.Context) ([]*PortForward, error) {
+	t, err := c.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return portForwards(t)
+}
+
+// SetPortForward creates the port forwarding rule pf, or replaces the rule
+// with the same number, and commits and saves the change.  pf is validated
+// before it is sent to the device.
+//
+// Port forwarding must be configured with WAN and LAN interfaces on the
+// device, or the device rejects the rule with a *ConfigError.
+func (c *Client) SetPortForward(ctx context.Context, pf *PortForward) error {
+	if err := pf.Validate(); err != nil {
+		return err
+	}
+
+	t, err := c.GetConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := pf.configPath()
+	return c.configBatch(ctx, configPath(pf.configTree(), path...), replaceConfig(t, path...))
+}
+
+// DeletePortForward deletes the port forwarding rule with the specified
+// number, and commits and saves the change.
+func (c *Client) DeletePortForward(ctx context.Context, number int) error {
+	if number < 1 {
+		return fmt.Errorf("port forward rule number %d must be greater than zero", number)
+	}
+
+	return c.configBatch(ctx, nil, configPath(nil, "port-forward", "rule", strconv.Itoa(number)))
+}
@@ -0,0 +1,77 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestClientSetPortForward(t *testing.T) {
+	const wantBody = `{"SET":{"port-forward":{"rule":{"2":{"description":"ssh","forward-to":{"address":"192.168.1.10","port":"22"},"original-port":"2222","protocol":"tcp"}}}}}`
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/edge/get.json" {
+			_, _ = w.Write([]byte(`{"success":true,"GET":{"port-forward":{"rule":{"1":{}}}}}`))
+			return
+		}
+
+		testHandler(t, http.MethodPost, "/api/edge/batch.json")(w, r)
+
+		var v json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		// Rule 2 does not exist, so it is not deleted before it is set
+		if want, got := wantBody, string(v); want != got {
+			t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","SET":{"success":"1"},"COMMIT":{"success":"1"},"SAVE":{"success":"1"}}`))
+	})
+	defer done()
+
+	pf := &PortForward{
+		Number:         2,
+		Description:    "ssh",
+		Protocol:       "tcp",
+		OriginalPort:   "2222",
+		ForwardAddress: net.IPv4(192, 168, 1, 10),
+		ForwardPort:    "22",
+	}
+
+	if err := c.SetPortForward(context.Background(), pf); err != nil {
+		t.Fatalf("unexpected error from Client.SetPortForward: %v", err)
+	}
+}
+
+func TestClientDeletePortForward(t *testing.T) {
+	const wantBody = `{"DELETE":{"port-forward":{"rule":{"2":null}}}}`
+
+	h := testHandler(t, http.MethodPost, "/api/edge/batch.json")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := wantBody, string(v); want != got {
+			t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","DELETE":{"success":"1"},"COMMIT":{"success":"1"},"SAVE":{"success":"1"}}`))
+	})
+	defer done()
+
+	if err := c.DeletePortForward(context.Background(), 0); err == nil {
+		t.Fatal("expected an error for invalid rule number, but none occurred")
+	}
+
+	if err := c.DeletePortForward(context.Background(), 2); err != nil {
+		t.Fatalf("unexpected error from Client.DeletePortForward: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// dataSessions is the data API type used to retrieve Sessions.
+	dataSessions = "sessions"
+
+	// opTerminateSession is the operation used to terminate a web interface
+	// session.
+	opTerminateSession = "terminate-session"
+)
+
+// Sessions retrieves the web interface sessions which are active on an
+// EdgeMAX device, including the session used by c.
+func (c *Client) Sessions(ctx context.Context) (Sessions, error) {
+	var ss Sessions
+	if err := c.getData(ctx, dataSessions, &ss); err != nil {
+		return nil, err
+	}
+
+	return ss, nil
+}
+
+// TerminateSession forcibly logs out the web interface session specified by
+// id, as reported by Sessions.  This can be used to clear a stale session
+// left behind by another administrator before making configuration changes.
+func (c *Client) TerminateSession(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("session ID must not be empty")
+	}
+
+	return c.operation(ctx, opTerminateSession, struct {
+		ID string `json:"id"`
+	}{
+		ID: id,
+	}, nil)
+}
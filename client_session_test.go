@@ -0,0 +1,56 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestClientSessions(t *testing.T) {
+	h := testDataHandler(t, dataSessions)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"abc":{"username":"foo"}}}`))
+	})
+	defer done()
+
+	ss, err := c.Sessions(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error from Client.Sessions: %v", err)
+	}
+
+	if want, got := 1, len(ss); want != got {
+		t.Fatalf("unexpected number of sessions:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientTerminateSession(t *testing.T) {
+	h := testOperationHandler(t, opTerminateSession)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			ID string `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := "abc", v.ID; want != got {
+			t.Fatalf("unexpected session ID:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.TerminateSession(context.Background(), ""); err == nil {
+		t.Fatal("expected an error for empty session ID, but none occurred")
+	}
+
+	if err := c.TerminateSession(context.Background(), "abc"); err != nil {
+		t.Fatalf("unexpected error from Client.TerminateSession: %v", err)
+	}
+}
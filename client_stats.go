@@ -1,8 +1,11 @@
 package edgemax
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -13,7 +16,77 @@ import (
 // Stats opens a websocket connection to an EdgeMAX device to retrieve
 // statistics which are sent using the socket.  The done closure must
 // be invoked to clean up resources from Stats.
+//
+// Temporary errors receiving statistics are retried with a backoff.  If
+// the connection fails, Stats reconnects as described for StatsStream.  If
+// it cannot reconnect, statC is closed, and done returns the error which
+// caused the failure.
+//
+// Stats is equivalent to StatsStream, for callers which do not need a
+// StatsStream's delivery counters.
 func (c *Client) Stats(stats ...StatType) (statC chan Stat, done func() error, err error) {
+	return c.StatsContext(context.Background(), stats...)
+}
+
+// StatsContext is like Stats, but ctx bounds the lifetime of the stream, as
+// with StatsStreamContext.
+func (c *Client) StatsContext(ctx context.Context, stats ...StatType) (statC chan Stat, done func() error, err error) {
+	s, err := c.StatsStreamContext(ctx, stats...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.statC, s.Close, nil
+}
+
+// A StatsStream is a stream of statistics from an EdgeMAX device, created
+// using Client.StatsStream.
+type StatsStream struct {
+	// C receives statistics from the device.  C is closed after Close is
+	// called, or if the connection to the device fails and cannot be
+	// reestablished.
+	C <-chan Stat
+
+	c        *Client
+	stats    []StatType
+	statC    chan Stat
+	parent   context.Context
+	ctx      context.Context
+	cancel   func()
+	runDoneC chan struct{}
+	counters *statCounters
+
+	// The current connection, which is nil while reconnecting.
+	mu   sync.Mutex
+	conn *statsConn
+
+	// Set by run before it returns: the error which stopped the stream, if
+	// any, and the result of closing the final connection.
+	err      error
+	closeErr error
+}
+
+// StatsStream opens a websocket connection to an EdgeMAX device to retrieve
+// statistics of the specified types, or all types if none are specified.
+// Close must be called to clean up resources from StatsStream.
+//
+// Temporary errors receiving statistics are retried with a backoff.  If the
+// connection fails, such as during a network outage, StatsStream dials the
+// device again using the Client's ReconnectBackoff, logs in again if the
+// session has expired, subscribes to the same statistics, and sends a
+// *Reconnected on C before resuming.  If the device becomes unavailable,
+// such as when it reboots, a *DeviceRestarting is also sent on C while
+// waiting for it to return.  If StatsStream cannot reconnect, C is closed,
+// and Close returns the error which caused the failure.
+func (c *Client) StatsStream(stats ...StatType) (*StatsStream, error) {
+	return c.StatsStreamContext(context.Background(), stats...)
+}
+
+// StatsStreamContext is like StatsStream, but ctx is used to open the
+// connection, and for all further requests made by the StatsStream.  If ctx
+// is canceled, the StatsStream stops, C is closed, and Close returns
+// ctx.Err().
+func (c *Client) StatsStreamContext(ctx context.Context, stats ...StatType) (*StatsStream, error) {
 	if stats == nil {
 		stats = []StatType{
 			StatTypeDPIStats,
@@ -22,55 +95,433 @@ func (c *Client) Stats(stats ...StatType) (statC chan Stat, done func() error, e
 		}
 	}
 
-	doneC := make(chan struct{})
-	errC := make(chan error)
-	wg := new(sync.WaitGroup)
+	conn, err := c.dialStats(ctx, stats)
+	if err != nil {
+		return nil, err
+	}
 
-	wg.Add(1)
-	go func() {
-		defer func() {
-			close(errC)
-			wg.Done()
-		}()
+	statC := make(chan Stat)
+	sctx, cancel := context.WithCancel(ctx)
+	s := &StatsStream{
+		C: statC,
+
+		c:        c,
+		stats:    stats,
+		statC:    statC,
+		parent:   ctx,
+		ctx:      sctx,
+		cancel:   cancel,
+		runDoneC: make(chan struct{}),
+		counters: newStatCounters(),
+
+		conn: conn,
+	}
+
+	go s.run(conn)
+
+	return s, nil
+}
+
+// Close stops the StatsStream and cleans up its resources.  Close must be
+// called exactly once.
+func (s *StatsStream) Close() error {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+
+	// Unsubscribe before stopping the stream, which closes the connection
+	var sendErr error
+	if conn != nil {
+		sendErr = conn.unsubscribe()
+	}
+
+	s.cancel()
+	<-s.runDoneC
+
+	// If the stream already failed, report the error which caused the
+	// failure
+	if s.err != nil {
+		return s.err
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	return s.closeErr
+}
+
+// Stats returns a snapshot of the StatsStream's delivery counters for each
+// StatType received from the device.  Comparing the counters can reveal
+// malformed statistics, or a consumer which cannot keep up with the device.
+func (s *StatsStream) Stats() map[StatType]StreamStats {
+	return s.counters.snapshot()
+}
+
+// StreamStats contains counters of statistics of a single StatType which
+// have passed through a StatsStream.
+type StreamStats struct {
+	// Received is the number of statistics received from the device.
+	Received uint64
+
+	// Decoded is the number of statistics which were decoded successfully.
+	// Statistics which are received but not decoded are malformed.
+	Decoded uint64
+
+	// Delivered is the number of statistics sent on StatsStream.C.
+	Delivered uint64
+
+	// Dropped is the number of decoded statistics which were discarded
+	// rather than delivered, such as when the stream is closed while
+	// statistics are waiting to be read.
+	Dropped uint64
+}
+
+// statCounters tracks StreamStats for each StatType.
+type statCounters struct {
+	mu sync.Mutex
+	m  map[StatType]*StreamStats
+}
+
+func newStatCounters() *statCounters {
+	return &statCounters{
+		m: make(map[StatType]*StreamStats),
+	}
+}
+
+// add applies fn to the StreamStats for t.
+func (c *statCounters) add(t StatType, fn func(s *StreamStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.m[t]
+	if !ok {
+		s = new(StreamStats)
+		c.m[t] = s
+	}
+
+	fn(s)
+}
+
+// snapshot returns a copy of the counters.
+func (c *statCounters) snapshot() map[StatType]StreamStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	m := make(map[StatType]StreamStats, len(c.m))
+	for t, s := range c.m {
+		m[t] = *s
+	}
 
-		if err := c.keepalive(doneC); err != nil {
-			errC <- err
+	return m
+}
+
+// run collects stats from conn, and from new connections after the
+// connection fails, until the stream is closed or cannot reconnect.
+func (s *StatsStream) run(conn *statsConn) {
+	defer close(s.runDoneC)
+	defer close(s.statC)
+
+	backoff := s.c.ReconnectBackoff.withDefaults()
+	for {
+		err := s.session(conn)
+		if err == nil {
+			// The stream was stopped, either by Close or by the caller's
+			// context
+			s.err = s.parent.Err()
+			return
 		}
-	}()
 
-	statC, wsDone, err := c.initWebsocket(stats)
-	if err != nil {
-		return nil, nil, err
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+		_ = conn.wsc.Close()
+
+		// Rejected credentials will not be accepted by a new connection
+		if s.ctx.Err() != nil || backoff.Timeout < 0 || err == ErrAuthFailed {
+			s.err = err
+			return
+		}
+
+		start := s.c.clock().Now()
+		restarting := s.c.deviceRestarting(s.ctx)
+		if restarting && !s.send(&DeviceRestarting{Err: err}) {
+			s.err = s.parent.Err()
+			return
+		}
+
+		var (
+			attempts int
+			rerr     error
+		)
+		conn, attempts, rerr = s.c.reconnect(s.ctx, s.stats, backoff, restarting)
+		if rerr != nil {
+			// Report cancelation by the caller rather than the error it
+			// caused
+			s.err = rerr
+			if perr := s.parent.Err(); perr != nil || s.ctx.Err() != nil {
+				s.err = perr
+			}
+			return
+		}
+
+		// The stream may have been stopped as the connection was
+		// established, in which case Close cannot unsubscribe
+		s.mu.Lock()
+		if s.ctx.Err() != nil {
+			s.mu.Unlock()
+			_ = conn.wsc.Close()
+			s.err = s.parent.Err()
+			return
+		}
+		s.conn = conn
+		s.mu.Unlock()
+
+		ok := s.send(&Reconnected{
+			Err:      err,
+			Attempts: attempts,
+			Downtime: s.c.clock().Now().Sub(start),
+		})
+		if !ok {
+			s.closeErr = conn.wsc.Close()
+			s.err = s.parent.Err()
+			return
+		}
+	}
+}
+
+// send sends st on C, reporting false if the stream is stopped first.
+func (s *StatsStream) send(st Stat) bool {
+	select {
+	case s.statC <- st:
+		return true
+	case <-s.ctx.Done():
+		return false
 	}
+}
 
-	done = func() error {
-		close(doneC)
-		wg.Wait()
+// session collects stats from conn, while sending heartbeats to keep the
+// Client's session active.  session returns nil when the stream is stopped,
+// or an error if the connection or heartbeats fail.
+func (s *StatsStream) session(conn *statsConn) error {
+	ctx, cancel := context.WithCancel(s.ctx)
 
-		if err := <-errC; err != nil {
-			return err
+	var (
+		kaErrC    = make(chan error, 1)
+		closeErrC = make(chan error, 1)
+	)
+
+	go func() {
+		// Subscribe again after the session is renewed, so the device
+		// continues to send stats
+		err := s.c.keepalive(ctx, conn.subscribe)
+		if err != nil {
+			// The session will expire, so halt stats collection
+			_ = conn.wsc.Close()
 		}
 
-		if err := wsDone(); err != nil {
-			return err
+		kaErrC <- err
+	}()
+
+	go func() {
+		// Close the connection when the stream is stopped, which halts
+		// stats collection if it is blocked receiving
+		<-ctx.Done()
+
+		var err error
+		if s.ctx.Err() != nil {
+			err = conn.wsc.Close()
 		}
+		closeErrC <- err
+	}()
 
-		close(statC)
-		return nil
+	err := collectStats(s.c.clock(), conn.wsc, s.statC, s.ctx.Done(), s.c.DecodeWorkers, s.counters)
+	cancel()
+
+	s.closeErr = <-closeErrC
+
+	// A heartbeat failure causes the connection to be closed, so report the
+	// heartbeat failure rather than the resulting error
+	if kaErr := <-kaErrC; kaErr != nil {
+		return kaErr
 	}
 
-	return statC, done, nil
+	return err
+}
+
+// A DeviceRestarting is sent by a StatsStream when the EdgeMAX device
+// becomes unavailable, such as when it reboots.  The StatsStream waits for
+// the device to become available, and then sends a *Reconnected before
+// resuming statistics.
+type DeviceRestarting struct {
+	// Err is the error which stopped the stream when the device became
+	// unavailable.
+	Err error
 }
 
+var _ Stat = &DeviceRestarting{}
+
+// StatTypeDeviceRestarting is the StatType of a DeviceRestarting.  It is
+// sent by a StatsStream regardless of the StatTypes requested.
+const StatTypeDeviceRestarting StatType = "device-restarting"
+
+// StatType implements the Stats interface.
+func (*DeviceRestarting) StatType() StatType {
+	return StatTypeDeviceRestarting
+}
+
+// deviceRestarting reports whether the EdgeMAX device appears to be
+// restarting, because it does not respond successfully to a heartbeat.
+// An expired session indicates that the device is available.
+func (c *Client) deviceRestarting(ctx context.Context) bool {
+	err := c.heartbeat(ctx)
+	return err != nil && err != errSessionExpired
+}
+
+// A Reconnected is sent by a StatsStream after its connection to the
+// EdgeMAX device fails and a new connection is established.  Statistics
+// from the new connection follow it on the stream.
+type Reconnected struct {
+	// Err is the error which caused the previous connection to fail.
+	Err error
+
+	// Attempts is the number of attempts made to reconnect.
+	Attempts int
+
+	// Downtime is the time between the failure of the previous connection
+	// and the establishment of the new one.
+	Downtime time.Duration
+}
+
+var _ Stat = &Reconnected{}
+
+// StatTypeReconnected is the StatType of a Reconnected.  It is sent by a
+// StatsStream regardless of the StatTypes requested.
+const StatTypeReconnected StatType = "reconnected"
+
+// StatType implements the Stats interface.
+func (*Reconnected) StatType() StatType {
+	return StatTypeReconnected
+}
+
+// A Backoff configures the exponential backoff used by a StatsStream to
+// reconnect to an EdgeMAX device.  Zero fields are replaced by defaults.
+type Backoff struct {
+	// Min is the delay before the first attempt to reconnect, which
+	// doubles after each failed attempt.  If zero, one second is used.
+	Min time.Duration
+
+	// Max is the maximum delay between attempts.  If zero, 30 seconds is
+	// used.
+	Max time.Duration
+
+	// Timeout is the maximum time spent reconnecting before the
+	// StatsStream fails.  If zero, 10 minutes is used.  If negative, the
+	// StatsStream does not reconnect, and fails as soon as its connection
+	// fails.
+	Timeout time.Duration
+}
+
+// Defaults for Backoff fields.
 const (
-	// sessionCookie is the name of the session cookie used to authenticate
-	// against EdgeMAX devices.
-	sessionCookie = "PHPSESSID"
+	defaultMinBackoff       = 1 * time.Second
+	defaultMaxBackoff       = 30 * time.Second
+	defaultReconnectTimeout = 10 * time.Minute
 )
 
-// initWebsocket initializes the websocket used for Client.Stats, and provides
-// a closure which can be used to clean it up.
-func (c *Client) initWebsocket(stats []StatType) (chan Stat, func() error, error) {
+// withDefaults returns a copy of b with zero fields replaced by defaults.
+func (b Backoff) withDefaults() Backoff {
+	if b.Min <= 0 {
+		b.Min = defaultMinBackoff
+	}
+	if b.Max <= 0 {
+		b.Max = defaultMaxBackoff
+	}
+	if b.Max < b.Min {
+		b.Max = b.Min
+	}
+	if b.Timeout == 0 {
+		b.Timeout = defaultReconnectTimeout
+	}
+
+	return b
+}
+
+// reconnect opens a new connection subscribed to stats after a connection
+// fails, retrying with backoff b until b.Timeout elapses.  If restarting is
+// true, or the device stops responding or reports that the session has
+// expired, reconnect logs in again before dialing.  reconnect returns the
+// number of attempts made.  If ctx is canceled while waiting, reconnect
+// returns ctx.Err().
+func (c *Client) reconnect(ctx context.Context, stats []StatType, b Backoff, restarting bool) (*statsConn, int, error) {
+	clock := c.clock()
+	deadline := clock.Now().Add(b.Timeout)
+
+	var (
+		delay = b.Min
+		login = restarting
+		err   error
+	)
+
+	for attempts := 1; ; attempts++ {
+		select {
+		case <-clock.After(delay):
+		case <-ctx.Done():
+			return nil, attempts - 1, ctx.Err()
+		}
+
+		// A device which does not respond has likely restarted, and will
+		// not recognize the previous session once it returns
+		switch err = c.heartbeat(ctx); err {
+		case nil:
+		case errSessionExpired:
+			login, err = true, nil
+		default:
+			login = true
+		}
+
+		if err == nil && login {
+			if err = c.relogin(ctx); err == nil {
+				login = false
+			}
+		}
+
+		if err == nil {
+			var conn *statsConn
+			if conn, err = c.dialStats(ctx, stats); err == nil {
+				return conn, attempts, nil
+			}
+		}
+
+		// Rejected credentials will not be accepted after waiting longer
+		if err == ErrAuthFailed || ctx.Err() != nil {
+			return nil, attempts, err
+		}
+		if !clock.Now().Before(deadline) {
+			if restarting {
+				return nil, attempts, fmt.Errorf("device did not become available after restarting: %v", err)
+			}
+
+			return nil, attempts, fmt.Errorf("failed to reconnect after %d attempts: %v", attempts, err)
+		}
+
+		if delay *= 2; delay > b.Max {
+			delay = b.Max
+		}
+	}
+}
+
+// A statsConn is a websocket connection subscribed to stats.
+type statsConn struct {
+	wsc   *websocket.Conn
+	names []wsName
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// dialStats opens a websocket connection to the EdgeMAX device using ctx, and
+// subscribes to stats using the Client's current session.
+func (c *Client) dialStats(ctx context.Context, stats []StatType) (*statsConn, error) {
 	// Websocket URL is adapted from HTTP URL
 	wsURL := *c.apiURL
 	wsURL.Scheme = "wss"
@@ -78,7 +529,7 @@ func (c *Client) initWebsocket(stats []StatType) (chan Stat, func() error, error
 
 	cfg, err := websocket.NewConfig(wsURL.String(), c.apiURL.String())
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Copy TLS config from client if using standard *http.Transport, so that
@@ -87,165 +538,428 @@ func (c *Client) initWebsocket(stats []StatType) (chan Stat, func() error, error
 		cfg.TlsConfig = tr.TLSClientConfig
 	}
 
-	// Need session ID from cookie to pass as part of websocket subscription
-	var sessionID string
-	for _, c := range c.client.Jar.Cookies(c.apiURL) {
-		if c.Name == sessionCookie {
-			sessionID = c.Value
-			break
-		}
-	}
-
-	wsc, err := websocket.DialConfig(cfg)
+	wsc, err := cfg.DialContext(ctx)
 	if err != nil {
-		return nil, nil, err
-	}
+		// The websocket package does not expose the status code of an
+		// unsuccessful handshake response
+		if derr, ok := err.(*websocket.DialError); ok && derr.Err == websocket.ErrBadStatus {
+			return nil, &Error{
+				Endpoint: wsURL.Path,
+				Message:  "unexpected websocket handshake status",
+			}
+		}
 
-	wsCodec := &websocket.Codec{
-		Marshal:   wsMarshal,
-		Unmarshal: wsUnmarshal,
+		return nil, err
 	}
 
-	wsns := make([]wsName, 0, len(stats))
+	names := make([]wsName, 0, len(stats))
 	for _, stat := range stats {
-		wsns = append(wsns, wsName{Name: stat})
+		names = append(names, wsName{Name: stat})
 	}
 
-	// Subscribe to stats named in StatType slice, authenticate using session
-	// cookie value
-	sub := &wsRequest{
-		Subscribe: wsns,
-		SessionID: sessionID,
+	conn := &statsConn{
+		wsc:   wsc,
+		names: names,
 	}
 
-	if err := wsCodec.Send(wsc, sub); err != nil {
-		return nil, nil, err
+	// Need session ID from cookie to pass as part of websocket subscription
+	if err := conn.subscribe(c.sessionID()); err != nil {
+		_ = wsc.Close()
+		return nil, err
 	}
 
-	statC := make(chan Stat)
-	doneC := make(chan struct{})
+	return conn, nil
+}
 
-	wg := new(sync.WaitGroup)
+// subscribe subscribes to the connection's stats, authenticating using the
+// session ID.
+func (sc *statsConn) subscribe(sessionID string) error {
+	sc.mu.Lock()
+	sc.sessionID = sessionID
+	sc.mu.Unlock()
 
-	// Unsubscribe and clean up websocket on completion using clsosure
-	done := statsDone(wg, sub, wsCodec, wsc, doneC)
+	return statsCodec.Send(sc.wsc, &wsRequest{
+		Subscribe: sc.names,
+		SessionID: sessionID,
+	})
+}
 
-	// Collect raw stats from websocket, parse them, and send them into statC
-	wg.Add(1)
-	go collectStats(wg, wsCodec, wsc, statC, doneC)
+// unsubscribe unsubscribes from the connection's stats, using the session ID
+// of the most recent subscription.
+func (sc *statsConn) unsubscribe() error {
+	sc.mu.Lock()
+	sessionID := sc.sessionID
+	sc.mu.Unlock()
+
+	return statsCodec.Send(sc.wsc, &wsRequest{
+		Unsubscribe: sc.names,
+		SessionID:   sessionID,
+	})
+}
 
-	return statC, done, nil
+// statsCodec sends stats websocket messages.  Messages are received using
+// receiveStats, which reuses buffers between messages.
+var statsCodec = websocket.Codec{
+	Marshal: wsMarshal,
 }
 
-// keepalive sends heartbeat requests at regular intervals to the EdgeMAX
-// device to keep a session active while Client.Stats is running.
-func (c *Client) keepalive(doneC <-chan struct{}) error {
-	var v struct {
-		Success bool `json:"success"`
-		Ping    bool `json:"PING"`
-		Session bool `json:"SESSION"`
-	}
+// heartbeatInterval is the interval at which heartbeats are sent while
+// Client.Stats is running.
+const heartbeatInterval = 5 * time.Second
 
+// Heartbeats which fail are retried after heartbeatRetryBackoff, doubling
+// for each consecutive failure, until maxHeartbeatFailures consecutive
+// heartbeats have failed.
+const (
+	heartbeatRetryBackoff = 1 * time.Second
+	maxHeartbeatFailures  = 3
+)
+
+// keepalive sends heartbeat requests at regular intervals to the EdgeMAX
+// device to keep a session active while Client.Stats is running.  Failed
+// heartbeats are retried, so that a momentary network outage does not
+// stop Client.Stats, but an error is returned if heartbeats fail
+// persistently.
+//
+// If the device reports that the session has expired, keepalive logs in
+// again, and invokes resubscribe, if not nil, with the new session ID.
+// keepalive returns nil when ctx is canceled.
+func (c *Client) keepalive(ctx context.Context, resubscribe func(sessionID string) error) error {
+	var failures int
 	for {
-		req, err := c.newRequest(
-			http.MethodGet,
-			fmt.Sprintf("/api/edge/heartbeat.json?_=%d", time.Now().UnixNano()),
-		)
-		if err != nil {
-			return err
+		err := c.heartbeat(ctx)
+		if err == errSessionExpired {
+			err = c.renewSession(ctx, resubscribe)
+		}
+
+		// Requests fail once ctx is canceled
+		if ctx.Err() != nil {
+			return nil
 		}
 
-		_, err = c.do(req, &v)
+		wait := heartbeatInterval
 		if err != nil {
-			return err
+			failures++
+			if failures >= maxHeartbeatFailures {
+				return fmt.Errorf("heartbeat failed %d times: %v", failures, err)
+			}
+
+			wait = heartbeatRetryBackoff << uint(failures-1)
+		} else {
+			failures = 0
 		}
 
 		select {
-		case <-time.After(5 * time.Second):
-		case <-doneC:
+		case <-c.clock().After(wait):
+		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
-// statsDone creates a closure which can be invoked to unsubscribe from the
-// stats websocket and close the connection gracefully.
-func statsDone(
-	wg *sync.WaitGroup,
-	sub *wsRequest,
-	wsCodec *websocket.Codec,
-	wsc *websocket.Conn,
-	doneC chan<- struct{},
-) func() error {
-	return func() error {
-		// Unsubscribe from the same stats that were subscribed to
-		names := make([]wsName, len(sub.Subscribe))
-		copy(names, sub.Subscribe)
-		sub.Unsubscribe = names
-		sub.Subscribe = nil
+// errSessionExpired is returned by heartbeat when the device reports that
+// the Client's session has expired.
+var errSessionExpired = errors.New("session expired")
 
-		if err := wsCodec.Send(wsc, sub); err != nil {
-			return err
-		}
+// heartbeat sends a single heartbeat request to the EdgeMAX device.
+func (c *Client) heartbeat(ctx context.Context) error {
+	var v struct {
+		Success bool `json:"success"`
+		Ping    bool `json:"PING"`
+		Session bool `json:"SESSION"`
+	}
 
-		if err := wsc.Close(); err != nil {
-			return err
-		}
+	req, err := c.newRequest(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf("/api/edge/heartbeat.json?_=%d", c.clock().Now().UnixNano()),
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.do(req, &v); err != nil {
+		return err
+	}
 
-		// Halt stats collection goroutine
-		close(doneC)
-		wg.Wait()
+	switch {
+	case !v.Session:
+		return errSessionExpired
+	case !v.Success:
+		return errors.New("heartbeat request was not successful")
+	}
+
+	return nil
+}
 
+// renewSession logs in again after a session expires, and invokes
+// resubscribe, if not nil, with the new session ID.
+func (c *Client) renewSession(ctx context.Context, resubscribe func(sessionID string) error) error {
+	if err := c.relogin(ctx); err != nil {
+		return err
+	}
+
+	if resubscribe == nil {
 		return nil
 	}
+
+	return resubscribe(c.sessionID())
 }
 
+// Bounds for the backoff used when temporary errors occur while receiving
+// stats.
+const (
+	minStatsBackoff = 100 * time.Millisecond
+	maxStatsBackoff = 5 * time.Second
+)
+
 // collectStats receives raw stats from a websocket and decodes them into
-// Stat structs of various types.
+// Stat structs of various types.  collectStats returns nil when doneC is
+// closed, or an error if the websocket connection fails.
+//
+// If workers is greater than one, stats are decoded concurrently by a pool
+// of workers.  Delivery of each stat is recorded in counters.
 func collectStats(
-	wg *sync.WaitGroup,
-	wsCodec *websocket.Codec,
+	clock Clock,
 	wsc *websocket.Conn,
 	statC chan<- Stat,
-	doneC chan struct{},
-) {
+	doneC <-chan struct{},
+	workers int,
+	counters *statCounters,
+) error {
+	send := func(k StatType, b json.RawMessage) bool {
+		return sendStat(k, b, statC, doneC, counters)
+	}
+
+	if workers > 1 {
+		p := newDecodePool(workers, statC, doneC, counters)
+		defer p.close()
+
+		send = p.send
+	}
+
+	var backoff time.Duration
+
+	// Reuse the same map for each message, as only its values are retained
+	m := make(map[StatType]json.RawMessage)
 	for {
 		select {
 		case <-doneC:
-			wg.Done()
-			return
+			return nil
 		default:
 		}
 
-		m := make(map[StatType]json.RawMessage)
-		if err := wsCodec.Receive(wsc, &m); err != nil {
-			continue
+		for k := range m {
+			delete(m, k)
 		}
+		if err := receiveStats(wsc, &m); err != nil {
+			// Errors are expected once the connection is closed by done
+			select {
+			case <-doneC:
+				return nil
+			default:
+			}
 
-		for k, v := range m {
-			switch k {
-			case StatTypeDPIStats:
-				var ds DPIStats
-				if err := ds.UnmarshalJSON(v); err != nil {
-					break
-				}
-
-				statC <- ds
-			case StatTypeInterfaces:
-				var is Interfaces
-				if err := is.UnmarshalJSON(v); err != nil {
-					break
+			switch {
+			case isDecodeError(err):
+				// Skip a malformed message, as the messages which follow
+				// it are unaffected
+				continue
+			case isTemporary(err):
+				if backoff == 0 {
+					backoff = minStatsBackoff
+				} else if backoff *= 2; backoff > maxStatsBackoff {
+					backoff = maxStatsBackoff
 				}
 
-				statC <- is
-			case StatTypeSystemStats:
-				ss := new(SystemStats)
-				if err := ss.UnmarshalJSON(v); err != nil {
-					break
+				select {
+				case <-clock.After(backoff):
+					continue
+				case <-doneC:
+					return nil
 				}
+			default:
+				return err
+			}
+		}
+		backoff = 0
 
-				statC <- ss
+		for k, v := range m {
+			counters.add(k, func(s *StreamStats) { s.Received++ })
+			if !send(k, v) {
+				return nil
 			}
 		}
 	}
 }
+
+// sendStat decodes the raw stat b of type k, and sends it on statC.
+// Unknown or malformed stats are skipped.  sendStat returns false if doneC
+// is closed before the stat can be sent.
+func sendStat(k StatType, b json.RawMessage, statC chan<- Stat, doneC <-chan struct{}, counters *statCounters) bool {
+	var (
+		st  Stat
+		err error
+	)
+
+	switch k {
+	case StatTypeDPIStats:
+		var ds DPIStats
+		err = ds.UnmarshalJSON(b)
+		st = ds
+	case StatTypeInterfaces:
+		var is Interfaces
+		err = is.UnmarshalJSON(b)
+		st = is
+	case StatTypeSystemStats:
+		ss := new(SystemStats)
+		err = ss.UnmarshalJSON(b)
+		st = ss
+	case StatTypeRouteCounts:
+		rc := new(RouteCounts)
+		err = rc.UnmarshalJSON(b)
+		st = rc
+	case StatTypeConfigChange:
+		cc := new(ConfigChange)
+		err = cc.UnmarshalJSON(b)
+		st = cc
+	case StatTypeUsers:
+		var us Users
+		err = us.UnmarshalJSON(b)
+		st = us
+	case StatTypeDiscoveredDevices:
+		var ds DiscoveredDevices
+		err = ds.UnmarshalJSON(b)
+		st = ds
+	case StatTypePONStats:
+		var ps PONStats
+		err = ps.UnmarshalJSON(b)
+		st = ps
+	case StatTypeLTEStats:
+		var ls LTEStats
+		err = ls.UnmarshalJSON(b)
+		st = ls
+	case StatTypeNATStats:
+		var ns NATStats
+		err = ns.UnmarshalJSON(b)
+		st = ns
+	case StatTypeFirewallStats:
+		var fs FirewallStats
+		err = fs.UnmarshalJSON(b)
+		st = fs
+	default:
+		return true
+	}
+	if err != nil {
+		return true
+	}
+	counters.add(k, func(s *StreamStats) { s.Decoded++ })
+
+	// The consumer may stop reading before calling done, so never block a
+	// send after done is called
+	select {
+	case statC <- st:
+		counters.add(k, func(s *StreamStats) { s.Delivered++ })
+		return true
+	case <-doneC:
+		counters.add(k, func(s *StreamStats) { s.Dropped++ })
+		return false
+	}
+}
+
+// A decodePool decodes stats concurrently using a pool of workers.  Each
+// StatType is always decoded by the same worker, so stats of each type are
+// sent in the order they were received, while a large stat of one type
+// does not delay stats of other types.
+type decodePool struct {
+	jobCs []chan decodeJob
+	doneC <-chan struct{}
+	wg    sync.WaitGroup
+}
+
+// A decodeJob is a raw stat to be decoded by a decodePool.
+type decodeJob struct {
+	k StatType
+	b json.RawMessage
+}
+
+// newDecodePool starts a decodePool with the specified number of workers,
+// which send decoded stats on statC until doneC is closed.
+func newDecodePool(workers int, statC chan<- Stat, doneC <-chan struct{}, counters *statCounters) *decodePool {
+	p := &decodePool{
+		jobCs: make([]chan decodeJob, workers),
+		doneC: doneC,
+	}
+
+	p.wg.Add(workers)
+	for i := range p.jobCs {
+		jobC := make(chan decodeJob, 1)
+		p.jobCs[i] = jobC
+
+		go func() {
+			defer p.wg.Done()
+
+			// Once done is called, continue receiving jobs so that send
+			// never blocks, but discard them
+			for j := range jobC {
+				_ = sendStat(j.k, j.b, statC, doneC, counters)
+			}
+		}()
+	}
+
+	return p
+}
+
+// send queues the raw stat b of type k for decoding.  send returns false if
+// doneC is closed.
+func (p *decodePool) send(k StatType, b json.RawMessage) bool {
+	var i int
+	switch k {
+	case StatTypeDPIStats:
+		i = 0
+	case StatTypeInterfaces:
+		i = 1
+	case StatTypeSystemStats:
+		i = 2
+	case StatTypeRouteCounts:
+		i = 3
+	case StatTypeConfigChange:
+		i = 4
+	case StatTypeUsers:
+		i = 5
+	case StatTypeDiscoveredDevices:
+		i = 6
+	case StatTypePONStats:
+		i = 7
+	case StatTypeLTEStats:
+		i = 8
+	case StatTypeNATStats:
+		i = 9
+	case StatTypeFirewallStats:
+		i = 10
+	default:
+		return true
+	}
+
+	select {
+	case p.jobCs[i%len(p.jobCs)] <- decodeJob{k: k, b: b}:
+		return true
+	case <-p.doneC:
+		return false
+	}
+}
+
+// close stops the workers after they finish any queued jobs.
+func (p *decodePool) close() {
+	for _, jobC := range p.jobCs {
+		close(jobC)
+	}
+
+	p.wg.Wait()
+}
+
+// isTemporary reports whether err is a temporary network error.
+func isTemporary(err error) bool {
+	nerr, ok := err.(net.Error)
+	return ok && nerr.Temporary()
+}
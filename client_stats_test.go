@@ -0,0 +1,756 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestClientKeepalive(t *testing.T) {
+	now := time.Unix(1, 0)
+	clock := &testClock{
+		now:    now,
+		afterC: make(chan time.Time),
+	}
+
+	h := testHandler(t, http.MethodGet, "/api/edge/heartbeat.json")
+	heartbeatC := make(chan string)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":true,"PING":true,"SESSION":true}`))
+		heartbeatC <- r.URL.Query().Get("_")
+	})
+	defer done()
+	c.Clock = clock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errC := make(chan error)
+	go func() {
+		errC <- c.keepalive(ctx, nil)
+	}()
+
+	// Each heartbeat is sent only after the clock advances
+	for i := 0; i < 3; i++ {
+		if want, got := "1000000000", <-heartbeatC; want != got {
+			t.Fatalf("unexpected cache-busting parameter:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		if i < 2 {
+			clock.afterC <- now
+		}
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("unexpected error from keepalive: %v", err)
+	}
+
+	if want, got := heartbeatInterval, clock.d; want != got {
+		t.Fatalf("unexpected heartbeat interval:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientKeepaliveRetry(t *testing.T) {
+	clock := &testClock{
+		now:    time.Unix(1, 0),
+		afterC: make(chan time.Time),
+		durC:   make(chan time.Duration),
+	}
+
+	// The second heartbeat succeeds, and then heartbeats fail persistently
+	var n int
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n++
+		if n == 2 {
+			_, _ = w.Write([]byte(`{"success":true,"PING":true,"SESSION":true}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer done()
+	c.Clock = clock
+
+	errC := make(chan error)
+	go func() {
+		errC <- c.keepalive(context.Background(), nil)
+	}()
+
+	// Each consecutive failure backs off further, and a success resets
+	// the backoff
+	for _, d := range []time.Duration{
+		heartbeatRetryBackoff,
+		heartbeatInterval,
+		heartbeatRetryBackoff,
+		2 * heartbeatRetryBackoff,
+	} {
+		if want, got := d, <-clock.durC; want != got {
+			t.Fatalf("unexpected heartbeat wait:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		clock.afterC <- clock.now
+	}
+
+	err := <-errC
+	if want, got := "heartbeat failed 3 times: EdgeMAX API request to /api/edge/heartbeat.json failed: 500 Internal Server Error", errStr(err); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientKeepaliveSessionExpired(t *testing.T) {
+	clock := &testClock{
+		now:    time.Unix(1, 0),
+		afterC: make(chan time.Time),
+		durC:   make(chan time.Duration),
+	}
+
+	var logins int
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			logins++
+			http.SetCookie(w, &http.Cookie{
+				Name:  sessionCookie,
+				Value: strconv.Itoa(logins),
+			})
+		case "/api/edge/heartbeat.json":
+			// The session expires after the first login
+			session := logins != 1
+			_, _ = fmt.Fprintf(w, `{"success":true,"PING":true,"SESSION":%t}`, session)
+		}
+	})
+	defer done()
+	c.Clock = clock
+
+	if err := c.Login("ubnt", "ubnt"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	sessionC := make(chan string, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	errC := make(chan error)
+	go func() {
+		errC <- c.keepalive(ctx, func(sessionID string) error {
+			sessionC <- sessionID
+			return nil
+		})
+	}()
+
+	// The expired session is renewed immediately, and heartbeats then
+	// continue at the regular interval
+	if want, got := heartbeatInterval, <-clock.durC; want != got {
+		t.Fatalf("unexpected heartbeat wait:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := "2", <-sessionC; want != got {
+		t.Fatalf("unexpected session ID:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	cancel()
+	if err := <-errC; err != nil {
+		t.Fatalf("unexpected error from keepalive: %v", err)
+	}
+
+	if want, got := 2, logins; want != got {
+		t.Fatalf("unexpected number of logins:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientKeepaliveSessionExpiredNoCredentials(t *testing.T) {
+	clock := &testClock{
+		now:    time.Unix(1, 0),
+		afterC: make(chan time.Time),
+		durC:   make(chan time.Duration),
+	}
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"PING":true,"SESSION":false}`))
+	})
+	defer done()
+	c.Clock = clock
+
+	errC := make(chan error)
+	go func() {
+		errC <- c.keepalive(context.Background(), nil)
+	}()
+
+	for i := 0; i < maxHeartbeatFailures-1; i++ {
+		<-clock.durC
+		clock.afterC <- clock.now
+	}
+
+	err := <-errC
+	if want, got := "heartbeat failed 3 times: cannot log in again without credentials from Login", errStr(err); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestCollectStatsConnectionClosed(t *testing.T) {
+	wsc, done := testStatsWebsocket(t, []string{
+		// Malformed messages are skipped
+		"garbage",
+		`{"system-stats":{"cpu":"10","uptime":"60","mem":"20"}}`,
+	})
+	defer done()
+
+	statC := make(chan Stat, 1)
+	err := collectStats(systemClock{}, wsc, statC, make(chan struct{}), 0, newStatCounters())
+	if want, got := io.EOF, err; want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	want := &SystemStats{CPU: 10, Uptime: time.Minute, Memory: 20}
+	if got := <-statC; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected stat:\n- want: %#v\n-  got: %#v", want, got)
+	}
+}
+
+func TestCollectStatsCounters(t *testing.T) {
+	wsc, done := testStatsWebsocket(t, []string{
+		`{"system-stats":{"cpu":"10","uptime":"60","mem":"20"},"interfaces":"bogus"}`,
+		`{"system-stats":{"cpu":"20","uptime":"120","mem":"30"}}`,
+	})
+	defer done()
+
+	counters := newStatCounters()
+	err := collectStats(systemClock{}, wsc, make(chan Stat, 2), make(chan struct{}), 0, counters)
+	if want, got := io.EOF, err; want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	want := map[StatType]StreamStats{
+		StatTypeSystemStats: {
+			Received:  2,
+			Decoded:   2,
+			Delivered: 2,
+		},
+		// Malformed stats are received but never decoded
+		StatTypeInterfaces: {
+			Received: 1,
+		},
+	}
+
+	if got := counters.snapshot(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected counters:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestCollectStatsDone(t *testing.T) {
+	wsc, done := testStatsWebsocket(t, nil)
+	defer done()
+
+	doneC := make(chan struct{})
+	errC := make(chan error)
+	go func() {
+		errC <- collectStats(systemClock{}, wsc, make(chan Stat), doneC, 0, newStatCounters())
+	}()
+
+	// Errors caused by closing the connection are not reported
+	close(doneC)
+	_ = wsc.Close()
+
+	if err := <-errC; err != nil {
+		t.Fatalf("unexpected error from collectStats: %v", err)
+	}
+}
+
+func TestCollectStatsDoneWithoutReader(t *testing.T) {
+	for _, workers := range []int{0, 3} {
+		t.Logf("workers: %d", workers)
+
+		wsc, done := testStatsWebsocket(t, []string{
+			`{"system-stats":{"cpu":"10","uptime":"60","mem":"20"}}`,
+		})
+
+		doneC := make(chan struct{})
+		errC := make(chan error)
+		go func() {
+			// statC is never read, so collectStats blocks sending a stat
+			errC <- collectStats(systemClock{}, wsc, make(chan Stat), doneC, workers, newStatCounters())
+		}()
+
+		close(doneC)
+
+		select {
+		case err := <-errC:
+			if err != nil {
+				t.Fatalf("unexpected error from collectStats: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for collectStats to stop")
+		}
+
+		done()
+	}
+}
+
+func TestCollectStatsWorkersPreserveOrder(t *testing.T) {
+	const n = 20
+
+	msgs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		msgs = append(msgs, fmt.Sprintf(
+			`{"system-stats":{"cpu":"%d","uptime":"0","mem":"0"},"interfaces":{"eth%d":{}}}`,
+			i, i,
+		))
+	}
+
+	wsc, done := testStatsWebsocket(t, msgs)
+	defer done()
+
+	statC := make(chan Stat)
+	errC := make(chan error, 1)
+	go func() {
+		errC <- collectStats(systemClock{}, wsc, statC, make(chan struct{}), 3, newStatCounters())
+		close(statC)
+	}()
+
+	var cpus, names []string
+	for s := range statC {
+		switch s := s.(type) {
+		case *SystemStats:
+			cpus = append(cpus, strconv.Itoa(s.CPU))
+		case Interfaces:
+			names = append(names, s[0].Name)
+		}
+	}
+
+	if want, got := io.EOF, <-errC; want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	for i := 0; i < n; i++ {
+		if want, got := strconv.Itoa(i), cpus[i]; want != got {
+			t.Fatalf("unexpected CPU order:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := fmt.Sprintf("eth%d", i), names[i]; want != got {
+			t.Fatalf("unexpected interface order:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestStatsStreamDeviceRestarting(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		restarting bool
+		conns      int
+
+		// Signaled when a request is rejected while restarting
+		rejectC = make(chan struct{}, 1)
+	)
+	isRestarting := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if restarting {
+			select {
+			case rejectC <- struct{}{}:
+			default:
+			}
+		}
+
+		return restarting
+	}
+	setRestarting := func(v bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		restarting = v
+	}
+
+	rebootC := make(chan struct{})
+	c, done := testStatsDevice(t, isRestarting, func(ws *websocket.Conn) {
+		mu.Lock()
+		conns++
+		n := conns
+		mu.Unlock()
+
+		msg := fmt.Sprintf(`{"system-stats":{"cpu":"%d","uptime":"60","mem":"20"}}`, n)
+		if err := websocket.Message.Send(ws, msg); err != nil {
+			return
+		}
+
+		// The device reboots after the first connection sends a stat
+		if n == 1 {
+			<-rebootC
+			setRestarting(true)
+			return
+		}
+
+		var b []byte
+		_ = websocket.Message.Receive(ws, &b)
+	})
+	defer done()
+
+	clock := &testClock{
+		now:    time.Unix(1, 0),
+		afterC: make(chan time.Time),
+	}
+	c.Clock = clock
+
+	s, err := c.StatsStream(StatTypeSystemStats)
+	if err != nil {
+		t.Fatalf("failed to start stats: %v", err)
+	}
+
+	if want, got := 1, (<-s.C).(*SystemStats).CPU; want != got {
+		t.Fatalf("unexpected CPU from first connection:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	close(rebootC)
+	if _, ok := (<-s.C).(*DeviceRestarting); !ok {
+		t.Fatal("expected device restarting notification")
+	}
+
+	// The first attempt to reconnect fails while the device is restarting,
+	// and the second succeeds once it has returned
+	select {
+	case <-rejectC:
+	default:
+	}
+	clock.afterC <- clock.now
+	<-rejectC
+	setRestarting(false)
+	clock.afterC <- clock.now
+
+	r, ok := (<-s.C).(*Reconnected)
+	if !ok {
+		t.Fatal("expected reconnected notification")
+	}
+	if want, got := 2, r.Attempts; want != got {
+		t.Fatalf("unexpected reconnect attempts:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if want, got := 2, (<-s.C).(*SystemStats).CPU; want != got {
+		t.Fatalf("unexpected CPU from second connection:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close stream: %v", err)
+	}
+}
+
+func TestStatsStreamReconnect(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns int
+	)
+
+	c, done := testStatsDevice(t, func() bool { return false }, func(ws *websocket.Conn) {
+		mu.Lock()
+		conns++
+		n := conns
+		mu.Unlock()
+
+		msg := fmt.Sprintf(`{"system-stats":{"cpu":"%d","uptime":"60","mem":"20"}}`, n)
+		if err := websocket.Message.Send(ws, msg); err != nil {
+			return
+		}
+
+		// The first connection drops after sending a stat
+		if n == 1 {
+			return
+		}
+
+		var b []byte
+		_ = websocket.Message.Receive(ws, &b)
+	})
+	defer done()
+
+	clock := &testClock{
+		now:    time.Unix(1, 0),
+		afterC: make(chan time.Time),
+	}
+	c.Clock = clock
+
+	s, err := c.StatsStream(StatTypeSystemStats)
+	if err != nil {
+		t.Fatalf("failed to start stats: %v", err)
+	}
+
+	if want, got := 1, (<-s.C).(*SystemStats).CPU; want != got {
+		t.Fatalf("unexpected CPU from first connection:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// The device is still available, so the stream reconnects after a
+	// single backoff without a restart notification
+	clock.afterC <- clock.now
+
+	r, ok := (<-s.C).(*Reconnected)
+	if !ok {
+		t.Fatal("expected reconnected notification")
+	}
+	if want, got := 1, r.Attempts; want != got {
+		t.Fatalf("unexpected reconnect attempts:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := io.EOF, r.Err; want != got {
+		t.Fatalf("unexpected reconnect error:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if want, got := 2, (<-s.C).(*SystemStats).CPU; want != got {
+		t.Fatalf("unexpected CPU from second connection:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close stream: %v", err)
+	}
+}
+
+func TestStatsStreamReconnectDisabled(t *testing.T) {
+	c, done := testStatsDevice(t, func() bool { return false }, func(ws *websocket.Conn) {
+		_ = websocket.Message.Send(ws, `{"system-stats":{"cpu":"10","uptime":"60","mem":"20"}}`)
+	})
+	defer done()
+
+	c.ReconnectBackoff = Backoff{Timeout: -1}
+
+	s, err := c.StatsStream(StatTypeSystemStats)
+	if err != nil {
+		t.Fatalf("failed to start stats: %v", err)
+	}
+
+	<-s.C
+
+	// Reconnecting is disabled, so the stream fails
+	if _, ok := <-s.C; ok {
+		t.Fatal("expected stats channel to be closed")
+	}
+
+	if want, got := io.EOF, s.Close(); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientReconnectBackoff(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		restarting bool
+	)
+	isRestarting := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return restarting
+	}
+
+	c, done := testStatsDevice(t, isRestarting, func(ws *websocket.Conn) {})
+	defer done()
+
+	// The device stops responding after login
+	mu.Lock()
+	restarting = true
+	mu.Unlock()
+
+	clock := &testClock{
+		now:    time.Unix(1, 0),
+		afterC: make(chan time.Time),
+		durC:   make(chan time.Duration),
+	}
+	c.Clock = clock
+
+	b := Backoff{
+		Min:     2 * time.Second,
+		Max:     5 * time.Second,
+		Timeout: 10 * time.Second,
+	}
+
+	errC := make(chan error, 1)
+	go func() {
+		_, _, err := c.reconnect(context.Background(), []StatType{StatTypeSystemStats}, b, false)
+		errC <- err
+	}()
+
+	var delays []time.Duration
+	for {
+		select {
+		case d := <-clock.durC:
+			delays = append(delays, d)
+			clock.now = clock.now.Add(d)
+			clock.afterC <- clock.now
+			continue
+		case err := <-errC:
+			if err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+		}
+
+		break
+	}
+
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second}
+	if got := delays; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected backoff delays:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestStatsStreamContextCanceled(t *testing.T) {
+	c, done := testStatsDevice(t, func() bool { return false }, func(ws *websocket.Conn) {
+		_ = websocket.Message.Send(ws, `{"system-stats":{"cpu":"10","uptime":"60","mem":"20"}}`)
+
+		// Hold the connection open until the client closes it
+		var b []byte
+		for websocket.Message.Receive(ws, &b) == nil {
+		}
+	})
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := c.StatsStreamContext(ctx, StatTypeSystemStats)
+	if err != nil {
+		t.Fatalf("failed to start stats: %v", err)
+	}
+
+	<-s.C
+	cancel()
+
+	// Canceling the context stops the stream without a call to Close
+	if _, ok := <-s.C; ok {
+		t.Fatal("expected stats channel to be closed")
+	}
+
+	if want, got := context.Canceled, s.Close(); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func Test_isTemporary(t *testing.T) {
+	var tests = []struct {
+		desc string
+		err  error
+		ok   bool
+	}{
+		{
+			desc: "EOF",
+			err:  io.EOF,
+		},
+		{
+			desc: "closed connection",
+			err:  &net.OpError{Op: "read", Err: errors.New("use of closed network connection")},
+		},
+		{
+			desc: "temporary",
+			err:  &net.DNSError{IsTemporary: true},
+			ok:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		if want, got := tt.ok, isTemporary(tt.err); want != got {
+			t.Fatalf("unexpected temporary result:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+// testStatsWebsocket creates a websocket connection to a server which sends
+// each message in msgs, and then closes the connection.
+func testStatsWebsocket(t *testing.T, msgs []string) (*websocket.Conn, func()) {
+	s := httptest.NewServer(websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		for _, m := range msgs {
+			if err := websocket.Message.Send(ws, m); err != nil {
+				return
+			}
+		}
+
+		// Wait for the client when no messages are sent
+		if len(msgs) == 0 {
+			var b []byte
+			_ = websocket.Message.Receive(ws, &b)
+		}
+	}})
+
+	wsc, err := websocket.Dial(strings.Replace(s.URL, "http", "ws", 1), "", s.URL)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+
+	return wsc, func() {
+		_ = wsc.Close()
+		s.Close()
+	}
+}
+
+// testStatsDevice starts a server which imitates an EdgeMAX device, and
+// returns a Client which is logged in to it.  While restarting returns true,
+// the device fails login and heartbeat requests.  fn is invoked for each
+// stats websocket connection after its subscription is received.
+func testStatsDevice(t *testing.T, restarting func() bool, fn func(ws *websocket.Conn)) (*Client, func()) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if restarting() {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "deadbeef"})
+	})
+
+	mux.HandleFunc("/api/edge/heartbeat.json", func(w http.ResponseWriter, r *http.Request) {
+		if restarting() {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"success":true,"PING":true,"SESSION":true}`))
+	})
+
+	mux.Handle("/ws/stats", websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		var sub []byte
+		if err := websocket.Message.Receive(ws, &sub); err != nil {
+			return
+		}
+
+		fn(ws)
+	}})
+
+	s := httptest.NewTLSServer(mux)
+
+	c, err := NewClient(s.URL, InsecureHTTPClient(5*time.Second))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.Login("ubnt", "ubnt"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	return c, s.Close
+}
+
+// A testClock is a Clock whose timers fire when a value is sent on afterC.
+// If durC is not nil, the duration passed to each call to After is sent on
+// durC.
+type testClock struct {
+	now    time.Time
+	afterC chan time.Time
+	durC   chan time.Duration
+	d      time.Duration
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	if c.durC != nil {
+		c.durC <- d
+		return c.afterC
+	}
+
+	c.d = d
+	return c.afterC
+}
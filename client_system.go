@@ -0,0 +1,114 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// dataSystemImages is the data API type used to retrieve SystemImages.
+	dataSystemImages = "sys_images"
+
+	// dataMemoryInfo is the data API type used to retrieve MemoryInfo.
+	dataMemoryInfo = "mem_info"
+
+	// dataCPUCores is the data API type used to retrieve CPUCores.
+	dataCPUCores = "cpu_cores"
+
+	// dataSystemInfo is the data API type used to retrieve SystemInfo.
+	dataSystemInfo = "sys_info"
+
+	// opDeleteSystemImage is the operation used to delete the system image
+	// which is not currently running.
+	opDeleteSystemImage = "delete-image"
+
+	// opSetDefaultSystemImage is the operation used to set the default boot
+	// image.
+	opSetDefaultSystemImage = "set-default-image"
+)
+
+// SystemInfo retrieves information about the hardware and running firmware
+// of an EdgeMAX device.
+func (c *Client) SystemInfo(ctx context.Context) (*SystemInfo, error) {
+	si := new(SystemInfo)
+	if err := c.getData(ctx, dataSystemInfo, si); err != nil {
+		return nil, err
+	}
+
+	return si, nil
+}
+
+// SystemImages retrieves information about the system images installed on
+// an EdgeMAX device, including the currently running image, the default boot
+// image, and free space available on the image partition.
+func (c *Client) SystemImages() (*SystemImages, error) {
+	return c.SystemImagesContext(context.Background())
+}
+
+// SystemImagesContext is like SystemImages, but uses ctx for the HTTP request.
+func (c *Client) SystemImagesContext(ctx context.Context) (*SystemImages, error) {
+	si := new(SystemImages)
+	if err := c.getData(ctx, dataSystemImages, si); err != nil {
+		return nil, err
+	}
+
+	return si, nil
+}
+
+// DeleteSystemImage deletes the system image which is not currently running
+// from an EdgeMAX device, reported as SystemImages.Previous.  The running
+// image cannot be deleted.
+//
+// On models with limited storage, deleting the previous image is often
+// required before a new firmware image can be uploaded.
+func (c *Client) DeleteSystemImage(ctx context.Context) error {
+	return c.operation(ctx, opDeleteSystemImage, nil, nil)
+}
+
+// SetDefaultSystemImage sets the system image which an EdgeMAX device will
+// use on its next boot.  version must match the version of an installed
+// image, as reported by SystemImages.
+func (c *Client) SetDefaultSystemImage(ctx context.Context, version string) error {
+	if version == "" {
+		return errors.New("system image version must not be empty")
+	}
+
+	return c.operation(ctx, opSetDefaultSystemImage, struct {
+		Version string `json:"version"`
+	}{
+		Version: version,
+	}, nil)
+}
+
+// MemoryInfo retrieves a detailed breakdown of memory utilization from an
+// EdgeMAX device, including per-zone memory information.
+func (c *Client) MemoryInfo() (*MemoryInfo, error) {
+	return c.MemoryInfoContext(context.Background())
+}
+
+// MemoryInfoContext is like MemoryInfo, but uses ctx for the HTTP request.
+func (c *Client) MemoryInfoContext(ctx context.Context) (*MemoryInfo, error) {
+	mi := new(MemoryInfo)
+	if err := c.getData(ctx, dataMemoryInfo, mi); err != nil {
+		return nil, err
+	}
+
+	return mi, nil
+}
+
+// CPUCores retrieves a snapshot of per-core CPU utilization and interrupt
+// load from an EdgeMAX device.  Unlike the aggregate CPU value reported in
+// SystemStats, this can be used to detect saturation of a single core.
+func (c *Client) CPUCores() (CPUCores, error) {
+	return c.CPUCoresContext(context.Background())
+}
+
+// CPUCoresContext is like CPUCores, but uses ctx for the HTTP request.
+func (c *Client) CPUCoresContext(ctx context.Context) (CPUCores, error) {
+	var cc CPUCores
+	if err := c.getData(ctx, dataCPUCores, &cc); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
@@ -0,0 +1,143 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClientSystemInfo(t *testing.T) {
+	wantSI := &SystemInfo{
+		Model:   "ER-X",
+		Version: "EdgeRouter.ER-e50.v1.9.7+hotfix.4.5024279.171006.0255",
+	}
+
+	h := testDataHandler(t, dataSystemInfo)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"model":"ER-X","sw_ver":"EdgeRouter.ER-e50.v1.9.7+hotfix.4.5024279.171006.0255"}}`))
+	})
+	defer done()
+
+	si, err := c.SystemInfo(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error from Client.SystemInfo: %v", err)
+	}
+
+	if want, got := wantSI, si; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected SystemInfo:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientSystemImages(t *testing.T) {
+	wantSI := &SystemImages{
+		Current:  "v1.9.0",
+		Previous: "v1.8.5",
+		Default:  "v1.9.0",
+		Free:     2048,
+	}
+
+	h := testDataHandler(t, dataSystemImages)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"current":"v1.9.0","previous":"v1.8.5","default":"v1.9.0","free":"2048"}}`))
+	})
+	defer done()
+
+	si, err := c.SystemImages()
+	if err != nil {
+		t.Fatalf("unexpected error from Client.SystemImages: %v", err)
+	}
+
+	if want, got := wantSI, si; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected SystemImages:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientSystemImagesFailure(t *testing.T) {
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":"0","error":"permission denied"}`))
+	})
+	defer done()
+
+	if _, err := c.SystemImages(); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestClientMemoryInfo(t *testing.T) {
+	wantMI := &MemoryInfo{
+		Total: 100,
+		Free:  20,
+		Zones: []*MemoryZone{{
+			Name: "Normal",
+			Free: 20,
+		}},
+	}
+
+	h := testDataHandler(t, dataMemoryInfo)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"total":"100","free":"20","zones":[{"name":"Normal","free":"20"}]}}`))
+	})
+	defer done()
+
+	mi, err := c.MemoryInfo()
+	if err != nil {
+		t.Fatalf("unexpected error from Client.MemoryInfo: %v", err)
+	}
+
+	if want, got := wantMI, mi; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected MemoryInfo:\n- want: %+v\n-  got: %+v", want, got)
+	}
+}
+
+func TestClientDeleteSystemImage(t *testing.T) {
+	h := testOperationHandler(t, opDeleteSystemImage)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.DeleteSystemImage(context.Background()); err != nil {
+		t.Fatalf("unexpected error from Client.DeleteSystemImage: %v", err)
+	}
+}
+
+func TestClientSetDefaultSystemImage(t *testing.T) {
+	const wantVersion = "v1.9.0"
+
+	h := testOperationHandler(t, opSetDefaultSystemImage)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			Version string `json:"version"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := wantVersion, v.Version; want != got {
+			t.Fatalf("unexpected version:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.SetDefaultSystemImage(context.Background(), ""); err == nil {
+		t.Fatal("expected an error for empty version, but none occurred")
+	}
+
+	if err := c.SetDefaultSystemImage(context.Background(), wantVersion); err != nil {
+		t.Fatalf("unexpected error from Client.SetDefaultSystemImage: %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package edgemax
 
 import (
+	"context"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -39,7 +42,7 @@ func TestClientRetainsCookies(t *testing.T) {
 	defer done()
 
 	for i := 0; i < 2; i++ {
-		req, err := c.newRequest(http.MethodGet, "/")
+		req, err := c.newRequest(context.Background(), http.MethodGet, "/")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -71,6 +74,8 @@ func TestClientLogin(t *testing.T) {
 		if want, got := wantPassword, password; want != got {
 			t.Fatalf("unexpected password:\n- want: %v\n-  got: %v", want, got)
 		}
+
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "deadbeef"})
 	})
 	defer done()
 
@@ -79,6 +84,363 @@ func TestClientLogin(t *testing.T) {
 	}
 }
 
+func TestClientLoginAuthFailed(t *testing.T) {
+	var tests = []struct {
+		desc string
+		fn   http.HandlerFunc
+		err  error
+	}{
+		{
+			desc: "unauthorized",
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			err: ErrAuthFailed,
+		},
+		{
+			desc: "login form",
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "deadbeef"})
+				_, _ = w.Write([]byte(`<form method="post"><input type="password" name="password"></form>`))
+			},
+			err: ErrAuthFailed,
+		},
+		{
+			desc: "JSON error",
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "deadbeef"})
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"success":false,"error":"invalid credentials"}`))
+			},
+			err: ErrAuthFailed,
+		},
+		{
+			desc: "no session cookie",
+			fn:   func(w http.ResponseWriter, r *http.Request) {},
+			err:  ErrAuthFailed,
+		},
+		{
+			desc: "OK redirect",
+			fn: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "deadbeef"})
+					http.Redirect(w, r, "/", http.StatusSeeOther)
+					return
+				}
+
+				// The dashboard may contain its own login form for expired
+				// sessions
+				_, _ = w.Write([]byte(`<input type="password" name="password">`))
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		c, done := testClient(t, tt.fn)
+
+		err := c.Login("ubnt", "ubnt")
+		done()
+
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestClientLoginContextCanceled(t *testing.T) {
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("request should not be sent with a canceled context")
+	})
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := c.LoginContext(ctx, "ubnt", "ubnt"); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestClientLoginClearsSession(t *testing.T) {
+	var logins int
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		logins++
+
+		// The second login does not create a session
+		if logins == 2 {
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: strconv.Itoa(logins)})
+		http.SetCookie(w, &http.Cookie{Name: csrfCookie, Value: "token"})
+	})
+	defer done()
+
+	if err := c.Login("ubnt", "ubnt"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+	if want, got := "1", c.sessionID(); want != got {
+		t.Fatalf("unexpected session ID:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// The stale session must not be mistaken for a new one
+	if want, got := ErrAuthFailed, c.Login("ubnt", "ubnt"); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+	if cks := c.client.Jar.Cookies(c.apiURL); len(cks) > 0 {
+		t.Fatalf("stale cookies were not cleared: %v", cks)
+	}
+
+	if err := c.Login("ubnt", "ubnt"); err != nil {
+		t.Fatalf("failed to log in again: %v", err)
+	}
+	if want, got := "3", c.sessionID(); want != got {
+		t.Fatalf("unexpected session ID:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientLoggedIn(t *testing.T) {
+	var tests = []struct {
+		desc     string
+		body     string
+		loggedIn bool
+		ok       bool
+	}{
+		{
+			desc:     "logged in",
+			body:     `{"success":true,"PING":true,"SESSION":true}`,
+			loggedIn: true,
+			ok:       true,
+		},
+		{
+			desc: "session expired",
+			body: `{"success":true,"PING":true,"SESSION":false}`,
+			ok:   true,
+		},
+		{
+			desc: "unsuccessful",
+			body: `{"success":false,"PING":false,"SESSION":true}`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			testHandler(t, http.MethodGet, "/api/edge/heartbeat.json")(w, r)
+			_, _ = w.Write([]byte(tt.body))
+		})
+
+		loggedIn, lerr := c.LoggedIn(context.Background())
+		perr := c.Ping(context.Background())
+		done()
+
+		if want, got := tt.ok, lerr == nil; want != got {
+			t.Fatalf("unexpected error from Client.LoggedIn: %v", lerr)
+		}
+		if want, got := tt.ok, perr == nil; want != got {
+			t.Fatalf("unexpected error from Client.Ping: %v", perr)
+		}
+
+		if want, got := tt.loggedIn, loggedIn; want != got {
+			t.Fatalf("unexpected logged in state:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestClientError(t *testing.T) {
+	var tests = []struct {
+		desc         string
+		status       int
+		body         string
+		call         func(c *Client) error
+		err          *Error
+		unauthorized bool
+		temporary    bool
+	}{
+		{
+			desc:   "data unsuccessful",
+			status: http.StatusOK,
+			body:   `{"success":"0","error":"no such data"}`,
+			call: func(c *Client) error {
+				return c.getData(context.Background(), "foo", nil)
+			},
+			err: &Error{
+				StatusCode: http.StatusOK,
+				Endpoint:   "/api/edge/data.json",
+				Message:    "no such data",
+			},
+		},
+		{
+			desc:   "operation internal server error with message",
+			status: http.StatusInternalServerError,
+			body:   `{"success":"0","error":"operation failed"}`,
+			call: func(c *Client) error {
+				return c.operation(context.Background(), "foo", nil, nil)
+			},
+			err: &Error{
+				StatusCode: http.StatusInternalServerError,
+				Endpoint:   "/api/edge/operation/foo.json",
+				Message:    "operation failed",
+			},
+			temporary: true,
+		},
+		{
+			desc:   "configuration unauthorized",
+			status: http.StatusUnauthorized,
+			call: func(c *Client) error {
+				_, err := c.GetConfig(context.Background())
+				return err
+			},
+			err: &Error{
+				StatusCode: http.StatusUnauthorized,
+				Endpoint:   "/api/edge/get.json",
+			},
+			unauthorized: true,
+		},
+		{
+			desc:   "login service unavailable",
+			status: http.StatusServiceUnavailable,
+			call: func(c *Client) error {
+				return c.Login("ubnt", "ubnt")
+			},
+			err: &Error{
+				StatusCode: http.StatusServiceUnavailable,
+				Endpoint:   "/",
+			},
+			temporary: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			_, _ = w.Write([]byte(tt.body))
+		})
+
+		err := tt.call(c)
+		done()
+
+		aerr, ok := err.(*Error)
+		if !ok {
+			t.Fatalf("expected *Error, but got: %#v", err)
+		}
+
+		if want, got := tt.err, aerr; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected Error:\n- want: %+v\n-  got: %+v", want, got)
+		}
+		if want, got := tt.unauthorized, aerr.Unauthorized(); want != got {
+			t.Fatalf("unexpected Error.Unauthorized:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := tt.temporary, aerr.Temporary(); want != got {
+			t.Fatalf("unexpected Error.Temporary:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestClientReauthenticate(t *testing.T) {
+	forbidden := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}
+	redirect := func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+
+	var tests = []struct {
+		desc           string
+		reauthenticate bool
+		expire         http.HandlerFunc
+		logins         int
+		ok             bool
+	}{
+		{
+			desc:   "disabled",
+			expire: forbidden,
+			logins: 1,
+		},
+		{
+			desc:           "forbidden",
+			reauthenticate: true,
+			expire:         forbidden,
+			logins:         2,
+			ok:             true,
+		},
+		{
+			desc:           "redirect to login page",
+			reauthenticate: true,
+			expire:         redirect,
+			logins:         2,
+			ok:             true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var logins int
+		c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/" && r.Method == http.MethodPost:
+				logins++
+				http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: strconv.Itoa(logins)})
+				return
+			case r.URL.Path == "/":
+				_, _ = w.Write([]byte(`<input type="password" name="password">`))
+				return
+			}
+
+			testOperationHandler(t, "foo")(w, r)
+
+			var sessions []string
+			for _, ck := range r.Cookies() {
+				if ck.Name == sessionCookie {
+					sessions = append(sessions, ck.Value)
+				}
+			}
+
+			// The first session expires, and only the new session may be
+			// used when the request is retried
+			if len(sessions) == 1 && sessions[0] == "1" {
+				tt.expire(w, r)
+				return
+			}
+			if want, got := []string{"2"}, sessions; !reflect.DeepEqual(want, got) {
+				t.Fatalf("unexpected session cookies:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			b, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatalf("failed to read request body: %v", err)
+			}
+			if want, got := `{"foo":"bar"}`, string(b); want != got {
+				t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			_, _ = w.Write([]byte(`{"success":"1"}`))
+		})
+
+		c.Reauthenticate = tt.reauthenticate
+
+		if err := c.Login("ubnt", "ubnt"); err != nil {
+			t.Fatalf("failed to log in: %v", err)
+		}
+
+		err := c.operation(context.Background(), "foo", map[string]string{"foo": "bar"}, nil)
+		done()
+
+		if want, got := tt.ok, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want, got := tt.logins, logins; want != got {
+			t.Fatalf("unexpected number of logins:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
 func TestInsecureHTTPClient(t *testing.T) {
 	timeout := 5 * time.Second
 	c := InsecureHTTPClient(timeout)
@@ -117,3 +479,18 @@ func testHandler(t *testing.T, method string, path string) http.HandlerFunc {
 		}
 	}
 }
+
+func testDataHandler(t *testing.T, name string) http.HandlerFunc {
+	h := testHandler(t, http.MethodGet, "/api/edge/data.json")
+	return func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		if want, got := name, r.URL.Query().Get("data"); want != got {
+			t.Fatalf("unexpected data type:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func testOperationHandler(t *testing.T, name string) http.HandlerFunc {
+	return testHandler(t, http.MethodPost, "/api/edge/operation/"+name+".json")
+}
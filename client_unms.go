@@ -0,0 +1,33 @@
+package edgemax
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	// opReconnectUNMS is the operation used to reconnect the UNMS agent.
+	opReconnectUNMS = "unms-reconnect"
+)
+
+// SetUNMSConnection sets the UNMS (UISP) connection string used by an
+// EdgeMAX device's UNMS agent, and commits and saves the change.  The
+// connection string is provided by the UNMS controller when adding a
+// device.
+//
+// The agent does not use a new connection string until ReconnectUNMS is
+// called.
+func (c *Client) SetUNMSConnection(ctx context.Context, conn string) error {
+	if conn == "" {
+		return errors.New("UNMS connection string must not be empty")
+	}
+
+	return c.configBatch(ctx, configPath(conn, "service", "unms", "connection"), nil)
+}
+
+// ReconnectUNMS forces the UNMS agent on an EdgeMAX device to disconnect
+// from its controller and connect again using its current connection
+// string, re-keying the connection if needed.
+func (c *Client) ReconnectUNMS(ctx context.Context) error {
+	return c.operation(ctx, opReconnectUNMS, nil, nil)
+}
@@ -0,0 +1,52 @@
+package edgemax
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestClientSetUNMSConnection(t *testing.T) {
+	const wantBody = `{"SET":{"service":{"unms":{"connection":"wss://unms.example.com:443+abc+allowUntrustedCertificate"}}}}`
+
+	h := testHandler(t, http.MethodPost, "/api/edge/batch.json")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode request body: %v", err)
+		}
+
+		if want, got := wantBody, string(v); want != got {
+			t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","SET":{"success":"1"}}`))
+	})
+	defer done()
+
+	if err := c.SetUNMSConnection(context.Background(), ""); err == nil {
+		t.Fatal("expected an error for empty connection string, but none occurred")
+	}
+
+	conn := "wss://unms.example.com:443+abc+allowUntrustedCertificate"
+	if err := c.SetUNMSConnection(context.Background(), conn); err != nil {
+		t.Fatalf("unexpected error from Client.SetUNMSConnection: %v", err)
+	}
+}
+
+func TestClientReconnectUNMS(t *testing.T) {
+	h := testOperationHandler(t, opReconnectUNMS)
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`{"success":"1"}`))
+	})
+	defer done()
+
+	if err := c.ReconnectUNMS(context.Background()); err != nil {
+		t.Fatalf("unexpected error from Client.ReconnectUNMS: %v", err)
+	}
+}
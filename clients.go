@@ -0,0 +1,34 @@
+package edgemax
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// A StatsClient retrieves statistics from an EdgeMAX device.  *Client
+// implements StatsClient.
+//
+// Code which only consumes statistics can accept a StatsClient rather than
+// a *Client, so that tests can substitute a mock implementation.
+type StatsClient interface {
+	Stats(stats ...StatType) (statC chan Stat, done func() error, err error)
+}
+
+// A ConfigClient retrieves and modifies the configuration of an EdgeMAX
+// device.  *Client implements ConfigClient.
+//
+// Code which only manages configuration can accept a ConfigClient rather
+// than a *Client, so that tests can substitute a mock implementation.
+type ConfigClient interface {
+	GetConfig(ctx context.Context) (*ConfigTree, error)
+	SetConfig(ctx context.Context, ops ...ConfigOp) error
+	SetConfigConfirm(ctx context.Context, timeout time.Duration, ops ...ConfigOp) error
+	ConfirmConfig(ctx context.Context) error
+	BackupConfig(ctx context.Context, w io.Writer) error
+}
+
+var (
+	_ StatsClient  = &Client{}
+	_ ConfigClient = &Client{}
+)
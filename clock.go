@@ -0,0 +1,34 @@
+package edgemax
+
+import "time"
+
+// A Clock provides the current time and timers to a Client.  Clients use
+// a Clock for time-dependent behavior, such as sending heartbeats while
+// Client.Stats is running and polling a device while it installs firmware.
+//
+// Tests can set Client.Clock to a fake Clock, such as the one provided by
+// package edgemaxtest, to advance time deterministically rather than
+// sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration d to elapse, and then sends the
+	// current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is a Clock which uses the system clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clock returns the Clock used by c.
+func (c *Client) clock() Clock {
+	if c.Clock == nil {
+		return systemClock{}
+	}
+
+	return c.Clock
+}
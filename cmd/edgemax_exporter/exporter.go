@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+const (
+	// staleTimeout is the amount of time after which a device which has
+	// sent no statistics is considered to be down, and is reconnected.
+	staleTimeout = 30 * time.Second
+
+	// retryInterval is the amount of time between attempts to connect to
+	// a device which is down.
+	retryInterval = 10 * time.Second
+)
+
+// An exporter collects statistics from EdgeMAX devices and serves them as
+// Prometheus metrics.
+type exporter struct {
+	mu      sync.RWMutex
+	devices map[string]*deviceStats
+}
+
+// deviceStats contains the latest statistics retrieved from a device.
+type deviceStats struct {
+	Up     bool
+	System *edgemax.SystemStats
+	Ifaces edgemax.Interfaces
+	DPI    edgemax.DPIStats
+}
+
+// newExporter creates an exporter with no devices.
+func newExporter() *exporter {
+	return &exporter{
+		devices: make(map[string]*deviceStats),
+	}
+}
+
+// watch connects to the device specified by d, and continuously collects
+// statistics of the specified types from it, reconnecting as needed.
+func (e *exporter) watch(d deviceConfig, types []edgemax.StatType) {
+	e.update(d.Name, func(ds *deviceStats) {})
+
+	for {
+		if err := e.stream(d, types); err != nil {
+			log.Printf("device %q: %v", d.Name, err)
+		}
+
+		e.update(d.Name, func(ds *deviceStats) {
+			ds.Up = false
+		})
+
+		time.Sleep(retryInterval)
+	}
+}
+
+// stream collects statistics from the device specified by d until an error
+// occurs or the device stops sending statistics.
+func (e *exporter) stream(d deviceConfig, types []edgemax.StatType) error {
+	c, err := edgemax.NewClient(d.Address, d.httpClient())
+	if err != nil {
+		return err
+	}
+
+	// Log in again if the session expires while statistics are collected
+	c.Reauthenticate = true
+
+	if err := c.Login(d.Username, d.Password); err != nil {
+		return err
+	}
+
+	statC, done, err := c.Stats(types...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Drain statistics so the subscription can be torn down
+		go func() {
+			for range statC {
+			}
+		}()
+
+		if err := done(); err != nil {
+			log.Printf("device %q: failed to close statistics: %v", d.Name, err)
+		}
+	}()
+
+	for {
+		select {
+		case s, ok := <-statC:
+			if !ok {
+				return errors.New("statistics stream closed")
+			}
+
+			e.update(d.Name, func(ds *deviceStats) {
+				ds.Up = true
+
+				switch s := s.(type) {
+				case *edgemax.SystemStats:
+					ds.System = s
+				case edgemax.Interfaces:
+					ds.Ifaces = s
+				case edgemax.DPIStats:
+					ds.DPI = s
+				}
+			})
+		case <-time.After(staleTimeout):
+			return fmt.Errorf("no statistics received in %s", staleTimeout)
+		}
+	}
+}
+
+// update applies fn to the statistics for the device specified by name.
+func (e *exporter) update(name string, fn func(ds *deviceStats)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ds, ok := e.devices[name]
+	if !ok {
+		ds = &deviceStats{}
+		e.devices[name] = ds
+	}
+
+	fn(ds)
+}
+
+// ServeHTTP implements http.Handler, and serves metrics in the Prometheus
+// text exposition format.
+func (e *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	names := make([]string, 0, len(e.devices))
+	for name := range e.devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	mw := newMetricWriter()
+	for _, name := range names {
+		writeDeviceMetrics(mw, name, e.devices[name])
+	}
+
+	_ = mw.flush(w)
+}
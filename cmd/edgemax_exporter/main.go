@@ -0,0 +1,154 @@
+// Command edgemax_exporter is a Prometheus exporter for Ubiquiti EdgeMAX
+// devices, built on package edgemax.
+//
+// Devices are specified using flags for a single device, or using a YAML
+// configuration file for one or more devices:
+//
+//	devices:
+//	  - name: gateway
+//	    address: https://192.168.1.1
+//	    username: ubnt
+//	    password: ubnt
+//	    insecure: true
+//	    types: [interfaces, system-stats, export]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+	"gopkg.in/yaml.v2"
+)
+
+func main() {
+	var (
+		listenFlag   = flag.String("listen", ":9130", "address on which to serve Prometheus metrics")
+		pathFlag     = flag.String("path", "/metrics", "URL path on which to serve Prometheus metrics")
+		configFlag   = flag.String("config", "", "YAML configuration file listing EdgeMAX devices")
+		addrFlag     = flag.String("a", "", "address of a single EdgeMAX device, such as https://192.168.1.1")
+		usernameFlag = flag.String("u", "ubnt", "username for a single EdgeMAX device")
+		passwordFlag = flag.String("p", "", "password for a single EdgeMAX device (default $EDGEMAX_PASSWORD)")
+		insecureFlag = flag.Bool("insecure", false, "skip verification of a single EdgeMAX device's TLS certificate")
+	)
+
+	flag.Parse()
+
+	var cfg *config
+	switch {
+	case *configFlag != "":
+		var err error
+		cfg, err = loadConfig(*configFlag)
+		if err != nil {
+			log.Fatalf("failed to load configuration: %v", err)
+		}
+	case *addrFlag != "":
+		password := *passwordFlag
+		if password == "" {
+			password = os.Getenv("EDGEMAX_PASSWORD")
+		}
+
+		cfg = &config{
+			Devices: []deviceConfig{{
+				Name:     *addrFlag,
+				Address:  *addrFlag,
+				Username: *usernameFlag,
+				Password: password,
+				Insecure: *insecureFlag,
+			}},
+		}
+	default:
+		log.Fatal("must specify a configuration file using -config, or a device using -a")
+	}
+
+	e := newExporter()
+	for _, d := range cfg.Devices {
+		types, err := d.statTypes()
+		if err != nil {
+			log.Fatalf("device %q: %v", d.Name, err)
+		}
+
+		go e.watch(d, types)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(*pathFlag, e)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, *pathFlag, http.StatusFound)
+	})
+
+	log.Printf("serving metrics for %d device(s) on %q", len(cfg.Devices), *listenFlag)
+	if err := http.ListenAndServe(*listenFlag, mux); err != nil {
+		log.Fatalf("failed to serve metrics: %v", err)
+	}
+}
+
+// config is the configuration for edgemax_exporter.
+type config struct {
+	Devices []deviceConfig `yaml:"devices"`
+}
+
+// deviceConfig is the configuration for a single EdgeMAX device.
+type deviceConfig struct {
+	Name     string   `yaml:"name"`
+	Address  string   `yaml:"address"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Insecure bool     `yaml:"insecure"`
+	Types    []string `yaml:"types"`
+}
+
+// statTypes returns the StatTypes specified by the device configuration.
+// If none are specified, nil is returned, and all types are used.
+func (d deviceConfig) statTypes() ([]edgemax.StatType, error) {
+	var types []edgemax.StatType
+	for _, t := range d.Types {
+		switch st := edgemax.StatType(t); st {
+		case edgemax.StatTypeDPIStats, edgemax.StatTypeInterfaces, edgemax.StatTypeSystemStats:
+			types = append(types, st)
+		default:
+			return nil, fmt.Errorf("unknown statistic type: %q", t)
+		}
+	}
+
+	return types, nil
+}
+
+// loadConfig loads a YAML configuration file from path.
+func loadConfig(path string) (*config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, err
+	}
+
+	for i, d := range cfg.Devices {
+		if d.Address == "" {
+			return nil, fmt.Errorf("device %d has no address", i)
+		}
+		if d.Name == "" {
+			cfg.Devices[i].Name = d.Address
+		}
+	}
+
+	return &cfg, nil
+}
+
+// httpClient creates the HTTP client used for a device.
+func (d deviceConfig) httpClient() *http.Client {
+	const timeout = 10 * time.Second
+	if d.Insecure {
+		return edgemax.InsecureHTTPClient(timeout)
+	}
+
+	return &http.Client{Timeout: timeout}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const namespace = "edgemax"
+
+// A metricWriter buffers metrics and writes them in the Prometheus text
+// exposition format, which requires all samples of a metric to be grouped
+// together beneath a single HELP and TYPE line.
+type metricWriter struct {
+	names    []string
+	families map[string]*family
+}
+
+// A family is a group of samples for a single metric.
+type family struct {
+	typ     string
+	help    string
+	samples []string
+}
+
+// newMetricWriter creates an empty metricWriter.
+func newMetricWriter() *metricWriter {
+	return &metricWriter{
+		families: make(map[string]*family),
+	}
+}
+
+// write adds a single sample for a metric.  labels are specified as
+// alternating label names and values.
+func (mw *metricWriter) write(name string, typ string, help string, v float64, labels ...string) {
+	name = namespace + "_" + name
+
+	f, ok := mw.families[name]
+	if !ok {
+		f = &family{
+			typ:  typ,
+			help: help,
+		}
+		mw.families[name] = f
+		mw.names = append(mw.names, name)
+	}
+
+	ls := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		ls = append(ls, fmt.Sprintf("%s=%q", labels[i], labels[i+1]))
+	}
+
+	f.samples = append(f.samples, fmt.Sprintf("%s{%s} %v", name, strings.Join(ls, ","), v))
+}
+
+// flush writes all buffered metrics to w, in the order in which each metric
+// was first written.
+func (mw *metricWriter) flush(w io.Writer) error {
+	for _, name := range mw.names {
+		f := mw.families[name]
+
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, f.help, name, f.typ); err != nil {
+			return err
+		}
+
+		for _, s := range f.samples {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeDeviceMetrics writes all metrics for the device specified by name.
+func writeDeviceMetrics(mw *metricWriter, name string, ds *deviceStats) {
+	up := 0.0
+	if ds.Up {
+		up = 1
+	}
+	mw.write("up", "gauge", "Whether statistics are being received from the device.", up, "device", name)
+
+	if ss := ds.System; ss != nil {
+		mw.write("system_cpu_percent", "gauge", "CPU utilization of the device.", float64(ss.CPU), "device", name)
+		mw.write("system_memory_percent", "gauge", "Memory utilization of the device.", float64(ss.Memory), "device", name)
+		mw.write("system_uptime_seconds", "gauge", "Uptime of the device.", ss.Uptime.Seconds(), "device", name)
+	}
+
+	for _, ifi := range ds.Ifaces {
+		labels := []string{"device", name, "interface", ifi.Name}
+
+		ifUp := 0.0
+		if ifi.Up {
+			ifUp = 1
+		}
+		mw.write("interface_up", "gauge", "Whether the interface is up.", ifUp, labels...)
+
+		s := ifi.Stats
+		counters := []struct {
+			name string
+			help string
+			v    uint64
+		}{
+			{"interface_receive_packets_total", "Packets received by the interface.", s.ReceivePackets},
+			{"interface_transmit_packets_total", "Packets transmitted by the interface.", s.TransmitPackets},
+			{"interface_receive_bytes_total", "Bytes received by the interface.", s.ReceiveBytes},
+			{"interface_transmit_bytes_total", "Bytes transmitted by the interface.", s.TransmitBytes},
+			{"interface_receive_errors_total", "Receive errors on the interface.", s.ReceiveErrors},
+			{"interface_transmit_errors_total", "Transmit errors on the interface.", s.TransmitErrors},
+			{"interface_receive_dropped_total", "Received packets dropped by the interface.", s.ReceiveDropped},
+			{"interface_transmit_dropped_total", "Transmitted packets dropped by the interface.", s.TransmitDropped},
+		}
+		for _, c := range counters {
+			mw.write(c.name, "counter", c.help, float64(c.v), labels...)
+		}
+	}
+
+	for _, d := range ds.DPI {
+		labels := []string{"device", name, "ip", d.IP.String(), "type", d.Type, "category", d.Category}
+
+		mw.write("dpi_receive_bytes_total", "counter", "Bytes received by a client for a traffic category.", float64(d.ReceiveBytes), labels...)
+		mw.write("dpi_transmit_bytes_total", "counter", "Bytes transmitted by a client for a traffic category.", float64(d.TransmitBytes), labels...)
+	}
+}
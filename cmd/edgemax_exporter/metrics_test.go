@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func Test_writeDeviceMetrics(t *testing.T) {
+	devices := []struct {
+		name string
+		ds   *deviceStats
+	}{
+		{
+			name: "a",
+			ds: &deviceStats{
+				Up: true,
+				System: &edgemax.SystemStats{
+					CPU:    10,
+					Memory: 20,
+					Uptime: 30 * time.Second,
+				},
+				DPI: edgemax.DPIStats{{
+					IP:            net.IPv4(192, 168, 1, 10),
+					Type:          "Web",
+					Category:      "Web - Other",
+					ReceiveBytes:  1,
+					TransmitBytes: 2,
+				}},
+			},
+		},
+		{
+			name: "b",
+			ds:   &deviceStats{},
+		},
+	}
+
+	mw := newMetricWriter()
+	for _, d := range devices {
+		writeDeviceMetrics(mw, d.name, d.ds)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := mw.flush(buf); err != nil {
+		t.Fatalf("failed to flush metrics: %v", err)
+	}
+
+	want := `# HELP edgemax_up Whether statistics are being received from the device.
+# TYPE edgemax_up gauge
+edgemax_up{device="a"} 1
+edgemax_up{device="b"} 0
+# HELP edgemax_system_cpu_percent CPU utilization of the device.
+# TYPE edgemax_system_cpu_percent gauge
+edgemax_system_cpu_percent{device="a"} 10
+# HELP edgemax_system_memory_percent Memory utilization of the device.
+# TYPE edgemax_system_memory_percent gauge
+edgemax_system_memory_percent{device="a"} 20
+# HELP edgemax_system_uptime_seconds Uptime of the device.
+# TYPE edgemax_system_uptime_seconds gauge
+edgemax_system_uptime_seconds{device="a"} 30
+# HELP edgemax_dpi_receive_bytes_total Bytes received by a client for a traffic category.
+# TYPE edgemax_dpi_receive_bytes_total counter
+edgemax_dpi_receive_bytes_total{device="a",ip="192.168.1.10",type="Web",category="Web - Other"} 1
+# HELP edgemax_dpi_transmit_bytes_total Bytes transmitted by a client for a traffic category.
+# TYPE edgemax_dpi_transmit_bytes_total counter
+edgemax_dpi_transmit_bytes_total{device="a",ip="192.168.1.10",type="Web",category="Web - Other"} 2
+`
+
+	if got := buf.String(); want != got {
+		t.Fatalf("unexpected metrics:\n- want:\n%s\n-  got:\n%s", want, got)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// backupTimeFormat is the time format used in backup file names, which
+// sorts chronologically.
+const backupTimeFormat = "20060102-150405"
+
+func cmdBackup(ctx context.Context, c *edgemax.Client, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	var (
+		outFlag  = fs.String("out", ".", "directory in which backups are stored")
+		keepFlag = fs.Int("keep", 0, "number of backups to keep for the device; older backups are removed (0 keeps all)")
+	)
+	_ = fs.Parse(args)
+
+	if *keepFlag < 0 {
+		return errors.New("-keep must not be negative")
+	}
+
+	tree, err := c.GetConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	host, ok := tree.Get("system", "host-name")
+	hostname, _ := host.(string)
+	if !ok || hostname == "" {
+		return errors.New("device has no configured host name")
+	}
+
+	if err := os.MkdirAll(*outFlag, 0755); err != nil {
+		return err
+	}
+
+	name := backupName(hostname, time.Now())
+	path := filepath.Join(*outFlag, name)
+
+	// Write to a temporary file first so that a failed backup never
+	// replaces or appears to be a complete backup
+	f, err := ioutil.TempFile(*outFlag, "."+name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := c.BackupConfig(ctx, f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	if isJSON() {
+		if err := writeJSON(w, struct {
+			Path string `json:"path"`
+		}{
+			Path: path,
+		}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(w, path)
+	}
+
+	if *keepFlag == 0 {
+		return nil
+	}
+
+	return pruneBackups(*outFlag, hostname, *keepFlag)
+}
+
+// backupName returns the file name of a backup for the device with the
+// specified hostname, taken at time t.
+func backupName(hostname string, t time.Time) string {
+	return fmt.Sprintf("%s-%s.tar.gz", hostname, t.UTC().Format(backupTimeFormat))
+}
+
+// pruneBackups removes all but the newest keep backups for the device with
+// the specified hostname from dir.
+func pruneBackups(dir string, hostname string, keep int) error {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, fi := range fis {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, hostname+"-") || !strings.HasSuffix(name, ".tar.gz") {
+			continue
+		}
+
+		// Only consider files which exactly match the backup name format,
+		// so that backups of a device whose hostname is a prefix of this
+		// one's are not removed
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, hostname+"-"), ".tar.gz")
+		if _, err := time.Parse(backupTimeFormat, ts); err != nil {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	if len(names) <= keep {
+		return nil
+	}
+
+	sort.Strings(names)
+	for _, name := range names[:len(names)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_pruneBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edgemaxctl")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var files []string
+	for i := 0; i < 5; i++ {
+		files = append(files, backupName("router", start.Add(time.Duration(i)*time.Hour)))
+	}
+
+	// Files which are not backups of "router" must not be removed
+	others := []string{
+		backupName("router-2", start),
+		"router-notes.tar.gz",
+		"unrelated.txt",
+	}
+
+	for _, f := range append(files, others...) {
+		if err := ioutil.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+
+	if err := pruneBackups(dir, "router", 2); err != nil {
+		t.Fatalf("failed to prune backups: %v", err)
+	}
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read directory: %v", err)
+	}
+
+	var got []string
+	for _, fi := range fis {
+		got = append(got, fi.Name())
+	}
+
+	want := append(others, files[3:]...)
+	sort.Strings(want)
+	sort.Strings(got)
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected files after pruning:\n- want: %v\n-  got: %v", want, got)
+	}
+}
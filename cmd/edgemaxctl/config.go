@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// defaultUsername is the username used when none is configured.
+const defaultUsername = "ubnt"
+
+// config is the configuration for edgemaxctl.
+type config struct {
+	Username string         `yaml:"username"`
+	Insecure bool           `yaml:"insecure"`
+	Devices  []deviceConfig `yaml:"devices"`
+}
+
+// deviceConfig is the configuration for a single EdgeMAX device.
+type deviceConfig struct {
+	Name     string `yaml:"name"`
+	Address  string `yaml:"address"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Insecure bool   `yaml:"insecure"`
+}
+
+// defaultConfigPath returns the default location of the edgemaxctl
+// configuration file.
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+
+	return filepath.Join(dir, "edgemaxctl", "config.yaml")
+}
+
+// loadConfig loads a YAML configuration file from path.  If the file does
+// not exist, an empty configuration is returned.
+func loadConfig(path string) (*config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &config{}, nil
+		}
+
+		return nil, err
+	}
+
+	var cfg config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, err
+	}
+
+	for i, d := range cfg.Devices {
+		if d.Address == "" {
+			return nil, fmt.Errorf("device %d has no address", i)
+		}
+		if d.Name == "" {
+			cfg.Devices[i].Name = d.Address
+		}
+	}
+
+	return &cfg, nil
+}
+
+// device returns the configuration of the device with the specified name
+// or address.  If no device matches, a configuration containing only the
+// address is returned.  The returned configuration has a username set,
+// inheriting from the top level configuration if necessary.
+func (c *config) device(nameOrAddr string) deviceConfig {
+	d := deviceConfig{
+		Name:    nameOrAddr,
+		Address: nameOrAddr,
+	}
+
+	for _, dd := range c.Devices {
+		if dd.Name == nameOrAddr || dd.Address == nameOrAddr {
+			d = dd
+			break
+		}
+	}
+
+	if d.Username == "" {
+		d.Username = c.Username
+	}
+	if d.Username == "" {
+		d.Username = defaultUsername
+	}
+
+	d.Insecure = d.Insecure || c.Insecure
+
+	return d
+}
+
+// names returns the names of all configured devices.
+func (c *config) names() []string {
+	names := make([]string, 0, len(c.Devices))
+	for _, d := range c.Devices {
+		names = append(names, d.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_configDevice(t *testing.T) {
+	cfg := &config{
+		Username: "admin",
+		Devices: []deviceConfig{
+			{
+				Name:    "home",
+				Address: "https://192.168.1.1",
+			},
+			{
+				Name:     "office",
+				Address:  "https://10.0.0.1",
+				Username: "ops",
+				Password: "secret",
+				Insecure: true,
+			},
+		},
+	}
+
+	var tests = []struct {
+		desc string
+		cfg  *config
+		in   string
+		d    deviceConfig
+	}{
+		{
+			desc: "unknown device, default username",
+			cfg:  &config{},
+			in:   "https://192.168.1.1",
+			d: deviceConfig{
+				Name:     "https://192.168.1.1",
+				Address:  "https://192.168.1.1",
+				Username: defaultUsername,
+			},
+		},
+		{
+			desc: "unknown device, configured username",
+			cfg:  cfg,
+			in:   "https://192.168.2.1",
+			d: deviceConfig{
+				Name:     "https://192.168.2.1",
+				Address:  "https://192.168.2.1",
+				Username: "admin",
+			},
+		},
+		{
+			desc: "by name",
+			cfg:  cfg,
+			in:   "home",
+			d: deviceConfig{
+				Name:     "home",
+				Address:  "https://192.168.1.1",
+				Username: "admin",
+			},
+		},
+		{
+			desc: "by address, device settings",
+			cfg:  cfg,
+			in:   "https://10.0.0.1",
+			d: deviceConfig{
+				Name:     "office",
+				Address:  "https://10.0.0.1",
+				Username: "ops",
+				Password: "secret",
+				Insecure: true,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		if want, got := tt.d, tt.cfg.device(tt.in); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected device:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
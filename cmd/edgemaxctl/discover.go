@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+	"github.com/mdlayher/edgemax/discovery"
+)
+
+func cmdDiscover(ctx context.Context, _ *edgemax.Client, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("discover", flag.ExitOnError)
+	waitFlag := fs.Duration("wait", discovery.DefaultTimeout, "amount of time to wait for replies")
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(ctx, *waitFlag)
+	defer cancel()
+
+	devices, err := discoverDevices(ctx, fs.Args())
+	if err != nil {
+		return err
+	}
+
+	if isJSON() {
+		return writeJSON(w, newJSONDevices(devices))
+	}
+
+	printDevices(w, devices)
+	return nil
+}
+
+// discoverDevices queries each of hosts directly, or broadcasts a discovery
+// request on the local network if no hosts are specified.
+func discoverDevices(ctx context.Context, hosts []string) ([]*discovery.Device, error) {
+	if len(hosts) == 0 {
+		return discovery.Discover(ctx)
+	}
+
+	devices := make([]*discovery.Device, 0, len(hosts))
+	for _, h := range hosts {
+		d, err := discovery.Query(ctx, h)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", h, err)
+		}
+
+		devices = append(devices, d)
+	}
+
+	return devices, nil
+}
+
+func printDevices(w io.Writer, devices []*discovery.Device) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "MAC\tHOSTNAME\tMODEL\tFIRMWARE\tUPTIME\tADDRESSES")
+	for _, d := range devices {
+		model := d.Platform
+		if d.Model != "" {
+			model = d.Model
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%v\n",
+			d.MAC,
+			d.Hostname,
+			model,
+			d.Firmware,
+			d.Uptime,
+			d.Addresses,
+		)
+	}
+	_ = tw.Flush()
+}
+
+// A jsonDevice is the JSON representation of a discovery.Device.
+type jsonDevice struct {
+	MAC       string   `json:"mac"`
+	Hostname  string   `json:"hostname"`
+	Platform  string   `json:"platform"`
+	Model     string   `json:"model"`
+	Firmware  string   `json:"firmware"`
+	Uptime    int      `json:"uptime_seconds"`
+	Addresses []string `json:"addresses"`
+}
+
+// newJSONDevices creates a slice of jsonDevice values from devices.
+func newJSONDevices(devices []*discovery.Device) []jsonDevice {
+	out := make([]jsonDevice, 0, len(devices))
+	for _, d := range devices {
+		addrs := make([]string, 0, len(d.Addresses))
+		for _, a := range d.Addresses {
+			addrs = append(addrs, a.String())
+		}
+
+		out = append(out, jsonDevice{
+			MAC:       d.MAC.String(),
+			Hostname:  d.Hostname,
+			Platform:  d.Platform,
+			Model:     d.Model,
+			Firmware:  d.Firmware,
+			Uptime:    int(d.Uptime / time.Second),
+			Addresses: addrs,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// Fields which DPI top rows can be grouped by.
+const (
+	groupHost     = "host"
+	groupCategory = "category"
+)
+
+// Fields which DPI top rows can be sorted by.
+const (
+	sortRX    = "rx"
+	sortTX    = "tx"
+	sortTotal = "total"
+)
+
+// A topRow is a single row of the DPI top view, containing bandwidth computed
+// from the deltas between two successive DPIStats.
+type topRow struct {
+	Name        string `json:"name"`
+	ReceiveBPS  int    `json:"rx_bps"`
+	TransmitBPS int    `json:"tx_bps"`
+}
+
+// A dpiKey uniquely identifies a DPIStat.
+type dpiKey struct {
+	IP       string
+	Type     string
+	Category string
+}
+
+// A dpiTop computes bandwidth from successive DPIStats.
+type dpiTop struct {
+	prev  map[dpiKey]*edgemax.DPIStat
+	prevT time.Time
+}
+
+// update computes bandwidth using the deltas between ds and the previous
+// DPIStats passed to update, grouped by the field specified by group.
+// The first call to update returns no rows.
+func (dt *dpiTop) update(ds edgemax.DPIStats, now time.Time, group string) []topRow {
+	cur := make(map[dpiKey]*edgemax.DPIStat, len(ds))
+	for _, d := range ds {
+		cur[dpiKey{IP: d.IP.String(), Type: d.Type, Category: d.Category}] = d
+	}
+
+	prev, elapsed := dt.prev, now.Sub(dt.prevT)
+	dt.prev, dt.prevT = cur, now
+
+	if prev == nil || elapsed <= 0 {
+		return nil
+	}
+
+	sums := make(map[string]*topRow)
+	for k, d := range cur {
+		p, ok := prev[k]
+		if !ok {
+			// New traffic has no baseline to compute a delta against
+			continue
+		}
+
+		name := k.IP
+		if group == groupCategory {
+			name = k.Category
+		}
+
+		r, ok := sums[name]
+		if !ok {
+			r = &topRow{Name: name}
+			sums[name] = r
+		}
+
+		r.ReceiveBPS += bitRate(p.ReceiveBytes, d.ReceiveBytes, elapsed)
+		r.TransmitBPS += bitRate(p.TransmitBytes, d.TransmitBytes, elapsed)
+	}
+
+	rows := make([]topRow, 0, len(sums))
+	for _, r := range sums {
+		rows = append(rows, *r)
+	}
+
+	return rows
+}
+
+// bitRate computes a rate in bits per second from two cumulative byte
+// counters.  Counters which decrease, such as after the counters are
+// cleared, are treated as no traffic.
+func bitRate(prev uint64, cur uint64, elapsed time.Duration) int {
+	if cur < prev {
+		return 0
+	}
+
+	return int(float64(cur-prev) * 8 / elapsed.Seconds())
+}
+
+// sortTopRows sorts rows in descending order by the field specified by by,
+// breaking ties by name.
+func sortTopRows(rows []topRow, by string) {
+	key := func(r topRow) int {
+		switch by {
+		case sortRX:
+			return r.ReceiveBPS
+		case sortTX:
+			return r.TransmitBPS
+		default:
+			return r.ReceiveBPS + r.TransmitBPS
+		}
+	}
+
+	sort.Slice(rows, func(i int, j int) bool {
+		ki, kj := key(rows[i]), key(rows[j])
+		if ki != kj {
+			return ki > kj
+		}
+
+		return rows[i].Name < rows[j].Name
+	})
+}
+
+// topDPIStats returns the n statistics in ds with the most total traffic,
+// sorted in descending order.  If n is 0, all statistics are returned.
+func topDPIStats(ds edgemax.DPIStats, n int) edgemax.DPIStats {
+	if n == 0 {
+		return ds
+	}
+
+	// Sort a copy so the caller's statistics are left unmodified
+	out := make(edgemax.DPIStats, len(ds))
+	copy(out, ds)
+
+	total := func(d *edgemax.DPIStat) uint64 {
+		return d.ReceiveBytes + d.TransmitBytes
+	}
+
+	sort.SliceStable(out, func(i int, j int) bool {
+		return total(out[i]) > total(out[j])
+	})
+
+	if len(out) > n {
+		out = out[:n]
+	}
+
+	return out
+}
+
+func cmdDPITop(ctx context.Context, c *edgemax.Client, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("dpi top", flag.ExitOnError)
+	var (
+		groupFlag = fs.String("group", groupHost, "group traffic by host or category")
+		sortFlag  = fs.String("sort", sortTotal, "sort by rx, tx, or total bandwidth")
+		nFlag     = fs.Int("n", 20, "maximum number of rows to display")
+	)
+	_ = fs.Parse(args)
+
+	if fleet {
+		return errFleet
+	}
+
+	switch *groupFlag {
+	case groupHost, groupCategory:
+	default:
+		return fmt.Errorf("unknown group: %q", *groupFlag)
+	}
+
+	switch *sortFlag {
+	case sortRX, sortTX, sortTotal:
+	default:
+		return fmt.Errorf("unknown sort field: %q", *sortFlag)
+	}
+
+	statC, done, err := c.Stats(edgemax.StatTypeDPIStats)
+	if err != nil {
+		return err
+	}
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, os.Interrupt)
+	defer signal.Stop(sigC)
+
+	// Keyboard input is optional: if the terminal cannot be switched to
+	// unbuffered mode, keys are only processed after a newline
+	var keyC <-chan byte
+	if !isJSON() {
+		restore := stty("cbreak", "-echo")
+		defer restore()
+		keyC = readKeys(os.Stdin)
+	}
+
+	var (
+		dt    dpiTop
+		rows  []topRow
+		group = *groupFlag
+		by    = *sortFlag
+	)
+
+	draw := func() {
+		sortTopRows(rows, by)
+		renderTop(w, rows, group, by, *nFlag)
+	}
+
+	// JSON output has no interactive view: each update is written as a
+	// single line, until interrupted
+	if isJSON() {
+		draw = func() {
+			if rows == nil {
+				return
+			}
+
+			sortTopRows(rows, by)
+			if *nFlag > 0 && len(rows) > *nFlag {
+				rows = rows[:*nFlag]
+			}
+
+			_ = writeJSONLine(w, struct {
+				Time  time.Time `json:"time"`
+				Group string    `json:"group"`
+				Rows  []topRow  `json:"rows"`
+			}{
+				Time:  time.Now().UTC(),
+				Group: group,
+				Rows:  rows,
+			})
+		}
+	}
+
+	for {
+		select {
+		case s, ok := <-statC:
+			if !ok {
+				// The stream failed, and done reports why
+				return done()
+			}
+
+			ds, ok := s.(edgemax.DPIStats)
+			if !ok {
+				continue
+			}
+
+			rows = dt.update(ds, time.Now(), group)
+			draw()
+		case k := <-keyC:
+			switch k {
+			case 'h':
+				group = groupHost
+			case 'c':
+				group = groupCategory
+			case 'r':
+				by = sortRX
+			case 't':
+				by = sortTX
+			case 'a':
+				by = sortTotal
+			case 'q':
+				return stop(statC, done)
+			default:
+				continue
+			}
+
+			// Changing the grouping requires a new baseline, since rows
+			// are aggregated when they are computed
+			if k == 'h' || k == 'c' {
+				dt, rows = dpiTop{}, nil
+			}
+			draw()
+		case <-sigC:
+			return stop(statC, done)
+		case <-ctx.Done():
+			_ = stop(statC, done)
+			return ctx.Err()
+		}
+	}
+}
+
+// renderTop clears the terminal and displays up to n rows of the DPI top view.
+func renderTop(w io.Writer, rows []topRow, group string, by string, n int) {
+	fmt.Fprint(w, "\033[H\033[2J")
+	fmt.Fprintf(w, "%s  group: %s  sort: %s\n", time.Now().Format(time.RFC1123), group, by)
+	fmt.Fprintln(w, "keys: [h]ost [c]ategory  sort [r]x [t]x [a]ll  [q]uit")
+	fmt.Fprintln(w)
+
+	if rows == nil {
+		fmt.Fprintln(w, "waiting for statistics...")
+		return
+	}
+
+	if n > 0 && len(rows) > n {
+		rows = rows[:n]
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tRX BPS\tTX BPS\tTOTAL BPS\n", strings.ToUpper(group))
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n",
+			r.Name,
+			r.ReceiveBPS,
+			r.TransmitBPS,
+			r.ReceiveBPS+r.TransmitBPS,
+		)
+	}
+	_ = tw.Flush()
+}
+
+// readKeys reads single bytes from r and sends them on the returned channel
+// until r returns an error.
+func readKeys(r io.Reader) <-chan byte {
+	keyC := make(chan byte)
+	go func() {
+		b := make([]byte, 1)
+		for {
+			if _, err := r.Read(b); err != nil {
+				return
+			}
+
+			keyC <- b[0]
+		}
+	}()
+
+	return keyC
+}
+
+// stty attempts to apply the settings in args to the terminal attached to
+// stdin, such as "cbreak" for unbuffered input, and returns a function which
+// restores the terminal's previous state.  If stdin is not a terminal, stty
+// has no effect.
+func stty(args ...string) (restore func()) {
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		return cmd.Output()
+	}
+
+	state, err := run("-g")
+	if err != nil {
+		return func() {}
+	}
+
+	if _, err := run(args...); err != nil {
+		return func() {}
+	}
+
+	return func() {
+		_, _ = run(strings.TrimSpace(string(state)))
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func Test_dpiTopUpdate(t *testing.T) {
+	var (
+		ip1 = net.IPv4(192, 168, 1, 10)
+		ip2 = net.IPv4(192, 168, 1, 11)
+
+		start = time.Unix(0, 0)
+	)
+
+	prev := edgemax.DPIStats{
+		{IP: ip1, Type: "YouTube", Category: "Media streaming services", ReceiveBytes: 1000, TransmitBytes: 100},
+		{IP: ip1, Type: "DNS", Category: "Network protocols", ReceiveBytes: 100, TransmitBytes: 100},
+		{IP: ip2, Type: "Netflix", Category: "Media streaming services", ReceiveBytes: 5000, TransmitBytes: 500},
+	}
+
+	cur := edgemax.DPIStats{
+		// 1000 bytes received and 100 bytes transmitted over 2 seconds
+		{IP: ip1, Type: "YouTube", Category: "Media streaming services", ReceiveBytes: 2000, TransmitBytes: 200},
+		// No change
+		{IP: ip1, Type: "DNS", Category: "Network protocols", ReceiveBytes: 100, TransmitBytes: 100},
+		// Counters were cleared
+		{IP: ip2, Type: "Netflix", Category: "Media streaming services", ReceiveBytes: 10, TransmitBytes: 10},
+		// No baseline
+		{IP: ip2, Type: "SSH", Category: "Network protocols", ReceiveBytes: 9999, TransmitBytes: 9999},
+	}
+
+	var tests = []struct {
+		desc  string
+		group string
+		by    string
+		rows  []topRow
+	}{
+		{
+			desc:  "host, total",
+			group: groupHost,
+			by:    sortTotal,
+			rows: []topRow{
+				{Name: "192.168.1.10", ReceiveBPS: 4000, TransmitBPS: 400},
+				{Name: "192.168.1.11"},
+			},
+		},
+		{
+			desc:  "category, tx",
+			group: groupCategory,
+			by:    sortTX,
+			rows: []topRow{
+				{Name: "Media streaming services", ReceiveBPS: 4000, TransmitBPS: 400},
+				{Name: "Network protocols"},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var dt dpiTop
+		if rows := dt.update(prev, start, tt.group); rows != nil {
+			t.Fatalf("unexpected rows for first update: %v", rows)
+		}
+
+		rows := dt.update(cur, start.Add(2*time.Second), tt.group)
+		sortTopRows(rows, tt.by)
+
+		if want, got := tt.rows, rows; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected rows:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func Test_sortTopRows(t *testing.T) {
+	rows := []topRow{
+		{Name: "a", ReceiveBPS: 1, TransmitBPS: 30},
+		{Name: "b", ReceiveBPS: 20, TransmitBPS: 2},
+		{Name: "c", ReceiveBPS: 10, TransmitBPS: 10},
+		{Name: "d", ReceiveBPS: 10, TransmitBPS: 10},
+	}
+
+	var tests = []struct {
+		by    string
+		names []string
+	}{
+		{by: sortRX, names: []string{"b", "c", "d", "a"}},
+		{by: sortTX, names: []string{"a", "c", "d", "b"}},
+		{by: sortTotal, names: []string{"a", "b", "c", "d"}},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.by)
+
+		sortTopRows(rows, tt.by)
+
+		names := make([]string, 0, len(rows))
+		for _, r := range rows {
+			names = append(names, r.Name)
+		}
+
+		if want, got := tt.names, names; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected order:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func Test_topDPIStats(t *testing.T) {
+	var (
+		a = &edgemax.DPIStat{Type: "DNS", ReceiveBytes: 100, TransmitBytes: 100}
+		b = &edgemax.DPIStat{Type: "YouTube", ReceiveBytes: 5000, TransmitBytes: 100}
+		c = &edgemax.DPIStat{Type: "SSH", ReceiveBytes: 100, TransmitBytes: 1000}
+	)
+
+	ds := edgemax.DPIStats{a, b, c}
+
+	var tests = []struct {
+		desc string
+		n    int
+		want edgemax.DPIStats
+	}{
+		{
+			desc: "all",
+			want: edgemax.DPIStats{a, b, c},
+		},
+		{
+			desc: "top 2",
+			n:    2,
+			want: edgemax.DPIStats{b, c},
+		},
+		{
+			desc: "more than available",
+			n:    10,
+			want: edgemax.DPIStats{b, c, a},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		if want, got := tt.want, topDPIStats(ds, tt.n); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected DPIStats:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+
+	// The input statistics must not be reordered
+	if want, got := (edgemax.DPIStats{a, b, c}), ds; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected input DPIStats:\n- want: %v\n-  got: %v", want, got)
+	}
+}
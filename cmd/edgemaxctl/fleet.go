@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// errFleet is returned by commands which cannot be run against many devices.
+var errFleet = errors.New("cannot be used with -devices")
+
+// loadDevices reads a list of device addresses from the file at path, or
+// from stdin if path is "-".
+func loadDevices(path string) ([]string, error) {
+	if path == "-" {
+		return readDevices(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readDevices(f)
+}
+
+// readDevices reads a list of device addresses from r, one per line.  Blank
+// lines and lines beginning with '#' are ignored.
+func readDevices(r io.Reader) ([]string, error) {
+	var addrs []string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		addrs = append(addrs, line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(addrs) == 0 {
+		return nil, errors.New("no devices specified")
+	}
+
+	return addrs, nil
+}
+
+// A fleetResult is the result of running a command against a single device.
+type fleetResult struct {
+	addr string
+	out  bytes.Buffer
+	err  error
+}
+
+// runFleet runs cmd against each device in addrs, with at most parallel
+// devices at a time, and writes each device's output to w as the device
+// completes.  It returns the number of devices on which cmd failed.
+func runFleet(
+	ctx context.Context,
+	w io.Writer,
+	cmd command,
+	addrs []string,
+	parallel int,
+	connect func(addr string) (*edgemax.Client, error),
+	args []string,
+) int {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	addrC := make(chan string)
+	resC := make(chan *fleetResult)
+
+	var wg sync.WaitGroup
+	wg.Add(parallel)
+	for i := 0; i < parallel; i++ {
+		go func() {
+			defer wg.Done()
+
+			for addr := range addrC {
+				res := &fleetResult{addr: addr}
+
+				c, err := connect(addr)
+				if err == nil {
+					err = cmd.run(ctx, c, &res.out, args)
+				}
+				res.err = err
+
+				resC <- res
+			}
+		}()
+	}
+
+	go func() {
+		for _, addr := range addrs {
+			addrC <- addr
+		}
+		close(addrC)
+
+		wg.Wait()
+		close(resC)
+	}()
+
+	var failed int
+	for res := range resC {
+		if res.err != nil {
+			failed++
+		}
+
+		writeFleetResult(w, res)
+	}
+
+	return failed
+}
+
+// writeFleetResult writes the output of res to w, labeled with its device.
+func writeFleetResult(w io.Writer, res *fleetResult) {
+	if isJSON() {
+		v := struct {
+			Device string      `json:"device"`
+			Error  string      `json:"error,omitempty"`
+			Output interface{} `json:"output,omitempty"`
+		}{
+			Device: res.addr,
+		}
+
+		if res.err != nil {
+			v.Error = res.err.Error()
+		}
+
+		// Embed output directly if the command produced a single JSON
+		// document, and otherwise as a string
+		if out := bytes.TrimSpace(res.out.Bytes()); len(out) > 0 {
+			var raw json.RawMessage
+			if err := json.Unmarshal(out, &raw); err == nil {
+				v.Output = raw
+			} else {
+				v.Output = string(out)
+			}
+		}
+
+		_ = writeJSONLine(w, v)
+		return
+	}
+
+	result := "ok"
+	if res.err != nil {
+		result = "error: " + res.err.Error()
+	}
+
+	fmt.Fprintf(w, "==> %s: %s\n", res.addr, result)
+	_, _ = res.out.WriteTo(w)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func Test_readDevices(t *testing.T) {
+	var tests = []struct {
+		desc  string
+		s     string
+		addrs []string
+		ok    bool
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "only comments",
+			s:    "# routers\n\n",
+		},
+		{
+			desc:  "OK",
+			s:     "# routers\nhttps://192.168.1.1\n\n  https://192.168.2.1  \n",
+			addrs: []string{"https://192.168.1.1", "https://192.168.2.1"},
+			ok:    true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		addrs, err := readDevices(strings.NewReader(tt.s))
+		if err != nil && tt.ok {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err == nil && !tt.ok {
+			t.Fatal("expected an error, but none occurred")
+		}
+
+		if want, got := tt.addrs, addrs; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected addresses:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func Test_runFleet(t *testing.T) {
+	addrs := []string{"a", "b", "c", "d", "e"}
+
+	var (
+		mu          sync.Mutex
+		active, max int
+	)
+
+	cmd := command{
+		run: func(_ context.Context, _ *edgemax.Client, w io.Writer, _ []string) error {
+			mu.Lock()
+			active++
+			if active > max {
+				max = active
+			}
+			mu.Unlock()
+
+			defer func() {
+				mu.Lock()
+				active--
+				mu.Unlock()
+			}()
+
+			_, err := io.WriteString(w, "hello\n")
+			return err
+		},
+	}
+
+	connect := func(addr string) (*edgemax.Client, error) {
+		if addr == "c" {
+			return nil, errors.New("failed to log in")
+		}
+
+		return nil, nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if want, got := 1, runFleet(context.Background(), buf, cmd, addrs, 2, connect, nil); want != got {
+		t.Fatalf("unexpected number of failures:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if max > 2 {
+		t.Fatalf("too many concurrent commands: %d", max)
+	}
+
+	// Results are written as devices complete, so sort them to compare
+	got := strings.Split(buf.String(), "==> ")[1:]
+	sort.Strings(got)
+
+	want := []string{
+		"a: ok\nhello\n",
+		"b: ok\nhello\n",
+		"c: error: failed to log in\n",
+		"d: ok\nhello\n",
+		"e: ok\nhello\n",
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected output:\n- want: %q\n-  got: %q", want, got)
+	}
+}
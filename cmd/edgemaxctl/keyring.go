@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// keyringService is the service name under which passwords are stored in
+// the OS keyring.
+const keyringService = "edgemaxctl"
+
+// errKeyringUnsupported is returned when the OS keyring is not supported on
+// the current platform.
+var errKeyringUnsupported = errors.New("OS keyring is not supported on " + runtime.GOOS)
+
+// keyringAccount returns the keyring account name for a device.
+func keyringAccount(d deviceConfig) string {
+	return d.Username + "@" + d.Address
+}
+
+// keyringGet retrieves the password for account from the OS keyring.
+func keyringGet(account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", account)
+	default:
+		return "", errKeyringUnsupported
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// keyringSet stores password for account in the OS keyring.
+func keyringSet(account string, password string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// The security tool prompts for the password when -w is the last
+		// argument, so that it need not be passed on the command line
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keyringService, "-a", account, "-w")
+		cmd.Stdin = strings.NewReader(password + "\n" + password + "\n")
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "store", "--label", keyringService+" "+account,
+			"service", keyringService, "account", account)
+		cmd.Stdin = strings.NewReader(password)
+	default:
+		return errKeyringUnsupported
+	}
+
+	return runKeyring(cmd)
+}
+
+// keyringDelete removes the password for account from the OS keyring.
+func keyringDelete(account string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", keyringService, "-a", account)
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("secret-tool", "clear", "service", keyringService, "account", account)
+	default:
+		return errKeyringUnsupported
+	}
+
+	return runKeyring(cmd)
+}
+
+// runKeyring runs a keyring tool, including its error output in any error.
+func runKeyring(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func cmdKeyring(_ context.Context, _ *edgemax.Client, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("keyring", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		return errors.New("must specify set or delete, and a device name or address")
+	}
+
+	action, d := fs.Arg(0), device(fs.Arg(1))
+	account := keyringAccount(d)
+
+	switch action {
+	case "set":
+		password, err := readPassword(fmt.Sprintf("password for %s: ", account))
+		if err != nil {
+			return err
+		}
+
+		if err := keyringSet(account, password); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "stored password for %s\n", account)
+		return nil
+	case "delete":
+		if err := keyringDelete(account); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "deleted password for %s\n", account)
+		return nil
+	default:
+		return fmt.Errorf("unknown keyring action: %q", action)
+	}
+}
+
+// readPassword prompts for a password on stderr and reads it from stdin,
+// disabling echo if stdin is a terminal.
+func readPassword(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	restore := stty("-echo")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	restore()
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		return "", errors.New("password must not be empty")
+	}
+
+	return password, nil
+}
@@ -0,0 +1,707 @@
+// Command edgemaxctl is a command-line tool for Ubiquiti EdgeMAX devices,
+// built on package edgemax.
+//
+// Usage:
+//
+//	edgemaxctl [flags] <command> [command flags]
+//
+// The password used to log in to a device may be specified using the
+// EDGEMAX_PASSWORD environment variable instead of a flag.
+//
+// Devices and their credentials may also be listed in a YAML configuration
+// file, by default ~/.config/edgemaxctl/config.yaml, and then referred to by
+// name using -a:
+//
+//	username: ubnt
+//	devices:
+//	  - name: home
+//	    address: https://192.168.1.1
+//	    insecure: true
+//
+// Passwords which are not specified by flag, environment, or configuration
+// are retrieved from the OS keyring, where they can be stored using the
+// keyring command.
+//
+// The -json flag displays the output of any command as JSON instead of
+// human-readable tables.  Commands which stream output, such as stats,
+// display one JSON document per line, as does any command when the -ndjson
+// flag is used.
+//
+// The -devices flag runs a command against each device listed in a file,
+// one address or configured name per line, and the -all flag runs a command
+// against every configured device.  The output of each device is labeled
+// with its address, and edgemaxctl exits with a non-zero status if the
+// command fails on any device.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// A command is an edgemaxctl subcommand.
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *edgemax.Client, w io.Writer, args []string) error
+
+	// local commands do not connect to a device, and are passed a nil
+	// *edgemax.Client.
+	local bool
+
+	// interactive commands run until interrupted, and cannot be run
+	// against many devices.
+	interactive bool
+}
+
+// commands contains all edgemaxctl subcommands, keyed by name.
+var commands = map[string]command{
+	"login": {
+		usage: "verify that the device accepts the specified credentials",
+		run:   cmdLogin,
+	},
+	"keyring": {
+		usage: "store or delete a device's password in the OS keyring: keyring set|delete <device>",
+		run:   cmdKeyring,
+		local: true,
+	},
+	"interfaces": {
+		usage: "display network interface information: interfaces [watch <name>]",
+		run:   cmdInterfaces,
+	},
+	"discover": {
+		usage: "find EdgeMAX devices on the local network, or query hosts: discover [host...]",
+		run:   cmdDiscover,
+		local: true,
+	},
+	"dpi": {
+		usage: "display deep packet inspection statistics: dpi [-top N] | dpi top",
+		run:   cmdDPI,
+	},
+	"system": {
+		usage: "display system information and statistics",
+		run:   cmdSystem,
+	},
+	"backup": {
+		usage: "download a configuration backup, and optionally prune old backups",
+		run:   cmdBackup,
+	},
+	"config": {
+		usage: "get, set, or delete configuration: config get|set|delete <path...> [value]",
+		run:   cmdConfig,
+	},
+	"upgrade": {
+		usage: "upload and install a firmware image, and reboot the device",
+		run:   cmdUpgrade,
+	},
+	"stats": {
+		usage:       "display continuously updating statistics",
+		run:         cmdStats,
+		interactive: true,
+	},
+}
+
+var (
+	// device returns the configuration of the device with the specified
+	// name or address, with any settings from flags applied.  It is set
+	// once flags are parsed.
+	device func(nameOrAddr string) deviceConfig
+
+	// fleet reports whether a command is being run against many devices.
+	fleet bool
+)
+
+// logins stores the configuration used to log in to each Client, so that
+// commands which reboot a device can log in again.
+var logins = struct {
+	sync.Mutex
+	m map[*edgemax.Client]deviceConfig
+}{
+	m: make(map[*edgemax.Client]deviceConfig),
+}
+
+// login logs in to a device created by connect.
+func login(c *edgemax.Client) error {
+	logins.Lock()
+	d := logins.m[c]
+	logins.Unlock()
+
+	return c.Login(d.Username, d.Password)
+}
+
+func main() {
+	var (
+		addrFlag     = flag.String("a", "", "address or configured name of EdgeMAX device, such as https://192.168.1.1")
+		usernameFlag = flag.String("u", "", "username for EdgeMAX device (default from configuration, or "+defaultUsername+")")
+		passwordFlag = flag.String("p", "", "password for EdgeMAX device (default $EDGEMAX_PASSWORD, configuration, or OS keyring)")
+		insecureFlag = flag.Bool("insecure", false, "skip verification of EdgeMAX device's TLS certificate")
+		timeoutFlag  = flag.Duration("timeout", 10*time.Second, "timeout for HTTP requests to EdgeMAX device")
+		configFlag   = flag.String("config", defaultConfigPath(), "path to YAML configuration file")
+		jsonFlag     = flag.Bool("json", false, "display output as JSON")
+		ndjsonFlag   = flag.Bool("ndjson", false, "display output as newline-delimited JSON")
+		devicesFlag  = flag.String("devices", "", "file containing addresses or names of many EdgeMAX devices to run a command against, or - for stdin")
+		allFlag      = flag.Bool("all", false, "run a command against all devices in the configuration file")
+		parallelFlag = flag.Int("parallel", 8, "maximum number of devices to run a command against concurrently with -devices or -all")
+	)
+
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := flag.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		log.Printf("unknown command: %q", name)
+		usage()
+		os.Exit(2)
+	}
+
+	switch {
+	case *ndjsonFlag:
+		format = formatNDJSON
+	case *jsonFlag:
+		format = formatJSON
+	}
+
+	cfg, err := loadConfig(*configFlag)
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+
+	device = func(nameOrAddr string) deviceConfig {
+		d := cfg.device(nameOrAddr)
+		if *usernameFlag != "" {
+			d.Username = *usernameFlag
+		}
+		d.Insecure = d.Insecure || *insecureFlag
+
+		return d
+	}
+
+	args := flag.Args()[1:]
+
+	if cmd.local {
+		if err := cmd.run(context.Background(), nil, os.Stdout, args); err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		return
+	}
+
+	// password determines the password for a device, preferring flags and
+	// the environment over configuration and the OS keyring
+	password := func(d deviceConfig) (string, error) {
+		if *passwordFlag != "" {
+			return *passwordFlag, nil
+		}
+		if p := os.Getenv("EDGEMAX_PASSWORD"); p != "" {
+			return p, nil
+		}
+		if d.Password != "" {
+			return d.Password, nil
+		}
+
+		p, err := keyringGet(keyringAccount(d))
+		if err != nil {
+			return "", fmt.Errorf("no password for %s, and none found in OS keyring: %v", keyringAccount(d), err)
+		}
+
+		return p, nil
+	}
+
+	// connect creates a Client for the device with the specified name or
+	// address, and logs in
+	connect := func(nameOrAddr string) (*edgemax.Client, error) {
+		d := device(nameOrAddr)
+
+		p, err := password(d)
+		if err != nil {
+			return nil, err
+		}
+		d.Password = p
+
+		// Each Client requires its own HTTP client to store its session
+		var hc *http.Client
+		if d.Insecure {
+			hc = edgemax.InsecureHTTPClient(*timeoutFlag)
+		} else {
+			hc = &http.Client{Timeout: *timeoutFlag}
+		}
+
+		c, err := edgemax.NewClient(d.Address, hc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client: %v", err)
+		}
+
+		logins.Lock()
+		logins.m[c] = d
+		logins.Unlock()
+
+		if err := login(c); err != nil {
+			return nil, fmt.Errorf("failed to log in: %v", err)
+		}
+
+		return c, nil
+	}
+
+	if *devicesFlag != "" || *allFlag {
+		if cmd.interactive {
+			log.Fatalf("%s: cannot be used with many devices", name)
+		}
+
+		addrs := cfg.names()
+		if *devicesFlag != "" {
+			addrs, err = loadDevices(*devicesFlag)
+			if err != nil {
+				log.Fatalf("failed to read devices: %v", err)
+			}
+		}
+		if len(addrs) == 0 {
+			log.Fatal("no devices in configuration file")
+		}
+
+		fleet = true
+		if failed := runFleet(context.Background(), os.Stdout, cmd, addrs, *parallelFlag, connect, args); failed > 0 {
+			log.Fatalf("%s: failed on %d of %d devices", name, failed, len(addrs))
+		}
+		return
+	}
+
+	addr := *addrFlag
+	if addr == "" && len(cfg.Devices) == 1 {
+		// A single configured device needs no flag
+		addr = cfg.Devices[0].Name
+	}
+	if addr == "" {
+		log.Fatal("must specify EdgeMAX device address or name using -a, or many devices using -devices or -all")
+	}
+
+	c, err := connect(addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := cmd.run(context.Background(), c, os.Stdout, args); err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+}
+
+// usage prints usage information for edgemaxctl.
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s [flags] <command> [command flags]\n\ncommands:\n", os.Args[0])
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].usage)
+	}
+
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	flag.PrintDefaults()
+}
+
+func cmdLogin(_ context.Context, _ *edgemax.Client, w io.Writer, _ []string) error {
+	// Login is performed for all commands before they run
+	if isJSON() {
+		return writeJSON(w, struct {
+			Success bool `json:"success"`
+		}{
+			Success: true,
+		})
+	}
+
+	fmt.Fprintln(w, "login successful")
+	return nil
+}
+
+func cmdInterfaces(ctx context.Context, c *edgemax.Client, w io.Writer, args []string) error {
+	if len(args) > 0 && args[0] == "watch" {
+		return cmdInterfacesWatch(ctx, c, w, args[1:])
+	}
+
+	stats, err := collect(c, edgemax.StatTypeInterfaces)
+	if err != nil {
+		return err
+	}
+
+	ifis := stats[0].(edgemax.Interfaces)
+	if isJSON() {
+		return writeJSON(w, newJSONInterfaces(ifis))
+	}
+
+	printInterfaces(w, ifis)
+	return nil
+}
+
+func cmdDPI(ctx context.Context, c *edgemax.Client, w io.Writer, args []string) error {
+	if len(args) > 0 && args[0] == "top" {
+		return cmdDPITop(ctx, c, w, args[1:])
+	}
+
+	fs := flag.NewFlagSet("dpi", flag.ExitOnError)
+	topFlag := fs.Int("top", 0, "display only the N statistics with the most total traffic (0 displays all)")
+	_ = fs.Parse(args)
+
+	if *topFlag < 0 {
+		return errors.New("-top must not be negative")
+	}
+
+	stats, err := collect(c, edgemax.StatTypeDPIStats)
+	if err != nil {
+		return err
+	}
+
+	ds := topDPIStats(stats[0].(edgemax.DPIStats), *topFlag)
+	if isJSON() {
+		return writeJSON(w, newJSONDPIStats(ds))
+	}
+
+	printDPIStats(w, ds)
+	return nil
+}
+
+func cmdSystem(_ context.Context, c *edgemax.Client, w io.Writer, _ []string) error {
+	si, err := c.SystemImages()
+	if err != nil {
+		return err
+	}
+
+	stats, err := collect(c, edgemax.StatTypeSystemStats)
+	if err != nil {
+		return err
+	}
+
+	ss := stats[0].(*edgemax.SystemStats)
+	if isJSON() {
+		return writeJSON(w, jsonSystem{
+			Images: newJSONSystemImages(si),
+			Stats:  newJSONSystemStats(ss),
+		})
+	}
+
+	printSystem(w, si, ss)
+	return nil
+}
+
+func cmdStats(ctx context.Context, c *edgemax.Client, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	typesFlag := fs.String("types", "interfaces,system-stats", "comma-separated list of statistic types to display")
+	_ = fs.Parse(args)
+
+	var types []edgemax.StatType
+	for _, t := range strings.Split(*typesFlag, ",") {
+		switch st := edgemax.StatType(strings.TrimSpace(t)); st {
+		case edgemax.StatTypeInterfaces, edgemax.StatTypeSystemStats, edgemax.StatTypeDPIStats:
+			types = append(types, st)
+		default:
+			return fmt.Errorf("unknown statistic type: %q", t)
+		}
+	}
+
+	statC, done, err := c.Stats(types...)
+	if err != nil {
+		return err
+	}
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, os.Interrupt)
+	defer signal.Stop(sigC)
+
+	// Retain the latest statistic of each type, and redraw all of them
+	// whenever any one is updated
+	latest := make(map[edgemax.StatType]edgemax.Stat, len(types))
+
+	for {
+		select {
+		case s, ok := <-statC:
+			if !ok {
+				// The stream failed, and done reports why
+				return done()
+			}
+
+			if isJSON() {
+				if err := writeJSONLine(w, newJSONStat(s, time.Now())); err != nil {
+					_ = stop(statC, done)
+					return err
+				}
+				continue
+			}
+
+			// Statistics resume once the device has restarted or the
+			// connection is reestablished
+			switch r := s.(type) {
+			case *edgemax.DeviceRestarting:
+				fmt.Fprintf(w, "device restarting, waiting for it to return: %v\n", r.Err)
+				continue
+			case *edgemax.Reconnected:
+				fmt.Fprintf(w, "reconnected after %d attempts (%v): %v\n",
+					r.Attempts, r.Downtime, r.Err)
+				continue
+			}
+
+			latest[s.StatType()] = s
+			render(w, types, latest)
+		case <-sigC:
+			return stop(statC, done)
+		case <-ctx.Done():
+			_ = stop(statC, done)
+			return ctx.Err()
+		}
+	}
+}
+
+// render clears the terminal and displays the latest statistics of each
+// type, in the order the types are specified.
+func render(w io.Writer, types []edgemax.StatType, latest map[edgemax.StatType]edgemax.Stat) {
+	// Move the cursor to the top left and clear the screen
+	fmt.Fprint(w, "\033[H\033[2J")
+	fmt.Fprintf(w, "%s\n\n", time.Now().Format(time.RFC1123))
+
+	for _, t := range types {
+		s, ok := latest[t]
+		if !ok {
+			continue
+		}
+
+		switch s := s.(type) {
+		case *edgemax.SystemStats:
+			printSystemStats(w, s)
+		case edgemax.Interfaces:
+			printInterfaces(w, s)
+		case edgemax.DPIStats:
+			printDPIStats(w, s)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func cmdConfig(ctx context.Context, c *edgemax.Client, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	confirmFlag := fs.Duration("commit-confirm", 0, "revert set or delete changes unless confirmed within this duration")
+	_ = fs.Parse(args)
+
+	args = fs.Args()
+	if len(args) == 0 {
+		return errors.New("must specify get, set, or delete")
+	}
+
+	action, path := args[0], args[1:]
+	switch action {
+	case "get":
+		tree, err := c.GetConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		v, ok := tree.Get(path...)
+		if !ok {
+			return fmt.Errorf("no configuration at %q", strings.Join(path, " "))
+		}
+
+		// Configuration is always displayed as JSON
+		if format == formatNDJSON {
+			return writeJSONLine(w, v)
+		}
+
+		b, err := json.MarshalIndent(v, "", "\t")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	case "set", "delete":
+	default:
+		return fmt.Errorf("unknown config action: %q", action)
+	}
+
+	op := edgemax.ConfigOp{
+		Action: edgemax.ConfigDelete,
+		Path:   path,
+	}
+	if action == "set" {
+		if len(path) < 2 {
+			return errors.New("must specify a configuration path and value")
+		}
+
+		op = edgemax.ConfigOp{
+			Action: edgemax.ConfigSet,
+			Path:   path[:len(path)-1],
+			Value:  path[len(path)-1],
+		}
+	}
+
+	if *confirmFlag == 0 {
+		return c.SetConfig(ctx, op)
+	}
+
+	// Changes cannot be confirmed interactively for many devices at once
+	if fleet {
+		return errFleet
+	}
+
+	// The confirmation prompt must be displayed on stderr so that it does
+	// not corrupt JSON output
+	prompt := os.Stdout
+	if isJSON() {
+		prompt = os.Stderr
+	}
+
+	if err := c.SetConfigConfirm(ctx, *confirmFlag, op); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(prompt, "configuration committed; it will be reverted unless confirmed within %s\n", *confirmFlag)
+	fmt.Fprint(prompt, "confirm changes? [y/N] ")
+
+	var answer string
+	_, _ = fmt.Scanln(&answer)
+	if answer != "y" && answer != "Y" {
+		return errors.New("changes not confirmed; they will be reverted")
+	}
+
+	if err := c.ConfirmConfig(ctx); err != nil {
+		return err
+	}
+
+	if isJSON() {
+		return writeJSON(w, struct {
+			Confirmed bool `json:"confirmed"`
+		}{
+			Confirmed: true,
+		})
+	}
+
+	return nil
+}
+
+// collect retrieves one Stat of each of the specified types from an EdgeMAX
+// device, in the order the types are specified.  Other stats, such as
+// DeviceRestarting and Reconnected events, are ignored.
+func collect(c edgemax.StatsClient, types ...edgemax.StatType) ([]edgemax.Stat, error) {
+	statC, done, err := c.Stats(types...)
+	if err != nil {
+		return nil, err
+	}
+
+	want := make(map[edgemax.StatType]bool, len(types))
+	for _, t := range types {
+		want[t] = true
+	}
+
+	got := make(map[edgemax.StatType]edgemax.Stat, len(types))
+	for s := range statC {
+		if !want[s.StatType()] {
+			continue
+		}
+
+		got[s.StatType()] = s
+		if len(got) == len(types) {
+			break
+		}
+	}
+
+	if err := stop(statC, done); err != nil {
+		return nil, err
+	}
+
+	for _, t := range types {
+		if got[t] == nil {
+			return nil, fmt.Errorf("statistics stream closed before %q statistics were received", t)
+		}
+	}
+
+	stats := make([]edgemax.Stat, 0, len(types))
+	for _, t := range types {
+		stats = append(stats, got[t])
+	}
+
+	return stats, nil
+}
+
+// stop tears down a Stats subscription using done, while draining statC so
+// that the collection goroutine is never blocked on a send.
+func stop(statC chan edgemax.Stat, done func() error) error {
+	go func() {
+		for range statC {
+		}
+	}()
+
+	return done()
+}
+
+func printSystem(w io.Writer, si *edgemax.SystemImages, ss *edgemax.SystemStats) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "version:\t%s\n", si.Current)
+	fmt.Fprintf(tw, "default image:\t%s\n", si.Default)
+	if si.Previous != "" {
+		fmt.Fprintf(tw, "previous image:\t%s\n", si.Previous)
+	}
+	fmt.Fprintf(tw, "image space free:\t%d bytes\n", si.Free)
+	_ = tw.Flush()
+
+	printSystemStats(w, ss)
+}
+
+func printSystemStats(w io.Writer, ss *edgemax.SystemStats) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "uptime:\t%s\n", ss.Uptime)
+	fmt.Fprintf(tw, "cpu:\t%d%%\n", ss.CPU)
+	fmt.Fprintf(tw, "memory:\t%d%%\n", ss.Memory)
+	_ = tw.Flush()
+}
+
+func printInterfaces(w io.Writer, ifis edgemax.Interfaces) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tUP\tMAC\tMTU\tADDRESSES\tRX BPS\tTX BPS")
+	for _, ifi := range ifis {
+		fmt.Fprintf(tw, "%s\t%t\t%s\t%d\t%v\t%d\t%d\n",
+			ifi.Name,
+			ifi.Up,
+			ifi.MAC,
+			ifi.MTU,
+			ifi.Addresses,
+			ifi.Stats.ReceiveBPS,
+			ifi.Stats.TransmitBPS,
+		)
+	}
+	_ = tw.Flush()
+}
+
+func printDPIStats(w io.Writer, ds edgemax.DPIStats) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "IP\tTYPE\tCATEGORY\tRX BYTES\tTX BYTES\tRX RATE\tTX RATE")
+	for _, d := range ds {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n",
+			d.IP,
+			d.Type,
+			d.Category,
+			d.ReceiveBytes,
+			d.TransmitBytes,
+			d.ReceiveRate,
+			d.TransmitRate,
+		)
+	}
+	_ = tw.Flush()
+}
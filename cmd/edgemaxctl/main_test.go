@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// A mockStats is an edgemax.StatsClient which sends a fixed set of
+// statistics, and then repeats them until done is called.
+type mockStats struct {
+	stats []edgemax.Stat
+
+	// once closes the statistics channel after sending stats once.
+	once bool
+}
+
+func (m *mockStats) Stats(_ ...edgemax.StatType) (chan edgemax.Stat, func() error, error) {
+	statC := make(chan edgemax.Stat)
+	doneC := make(chan struct{})
+	stoppedC := make(chan struct{})
+
+	go func() {
+		defer close(stoppedC)
+		if m.once {
+			defer close(statC)
+		}
+
+		for {
+			for _, s := range m.stats {
+				select {
+				case statC <- s:
+				case <-doneC:
+					return
+				}
+			}
+
+			if m.once {
+				return
+			}
+		}
+	}()
+
+	done := func() error {
+		close(doneC)
+		<-stoppedC
+		if !m.once {
+			close(statC)
+		}
+		return nil
+	}
+
+	return statC, done, nil
+}
+
+func Test_collect(t *testing.T) {
+	ss := &edgemax.SystemStats{CPU: 10, Uptime: time.Minute}
+	ifis := edgemax.Interfaces{{Name: "eth0"}}
+
+	var tests = []struct {
+		desc  string
+		c     *mockStats
+		stats []edgemax.Stat
+		ok    bool
+	}{
+		{
+			desc:  "OK",
+			c:     &mockStats{stats: []edgemax.Stat{ifis, ss}},
+			stats: []edgemax.Stat{ss, ifis},
+			ok:    true,
+		},
+		{
+			desc: "device restarting and reconnected first",
+			c: &mockStats{stats: []edgemax.Stat{
+				&edgemax.DeviceRestarting{},
+				&edgemax.Reconnected{},
+				ifis,
+				ss,
+			}},
+			stats: []edgemax.Stat{ss, ifis},
+			ok:    true,
+		},
+		{
+			desc: "closed early",
+			c: &mockStats{
+				stats: []edgemax.Stat{&edgemax.DeviceRestarting{}, ifis},
+				once:  true,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		stats, err := collect(tt.c, edgemax.StatTypeSystemStats, edgemax.StatTypeInterfaces)
+		if want, got := tt.ok, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want, got := tt.stats, stats; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected stats:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// An outputFormat is a format used to display command output.
+type outputFormat int
+
+// Possible outputFormat values.
+const (
+	// formatText displays human-readable tables.
+	formatText outputFormat = iota
+
+	// formatJSON displays a single indented JSON document, or one compact
+	// JSON document per line for streaming commands.
+	formatJSON
+
+	// formatNDJSON displays one compact JSON document per line.
+	formatNDJSON
+)
+
+// format is the outputFormat used by all commands, set using flags.
+var format = formatText
+
+// isJSON reports whether command output should be JSON.
+func isJSON() bool {
+	return format != formatText
+}
+
+// writeJSON writes v to w as a single JSON document in the current format.
+// Streaming commands should use writeJSONLine instead.
+func writeJSON(w io.Writer, v interface{}) error {
+	if format == formatNDJSON {
+		return writeJSONLine(w, v)
+	}
+
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// writeJSONLine writes v to w as a single line of compact JSON.
+func writeJSONLine(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// A jsonStat is the JSON representation of a Stat received from a stream.
+type jsonStat struct {
+	Time time.Time        `json:"time"`
+	Type edgemax.StatType `json:"type"`
+	Data interface{}      `json:"data"`
+}
+
+// newJSONStat creates a jsonStat from s, received at time t.
+func newJSONStat(s edgemax.Stat, t time.Time) jsonStat {
+	var data interface{}
+	switch s := s.(type) {
+	case *edgemax.SystemStats:
+		data = newJSONSystemStats(s)
+	case edgemax.Interfaces:
+		data = newJSONInterfaces(s)
+	case edgemax.DPIStats:
+		data = newJSONDPIStats(s)
+	}
+
+	return jsonStat{
+		Time: t.UTC(),
+		Type: s.StatType(),
+		Data: data,
+	}
+}
+
+// A jsonSystem is the JSON representation of system information.
+type jsonSystem struct {
+	Images jsonSystemImages `json:"images"`
+	Stats  jsonSystemStats  `json:"stats"`
+}
+
+// A jsonSystemImages is the JSON representation of SystemImages.
+type jsonSystemImages struct {
+	Current  string `json:"current"`
+	Previous string `json:"previous,omitempty"`
+	Default  string `json:"default"`
+	Free     int    `json:"free_bytes"`
+}
+
+// newJSONSystemImages creates a jsonSystemImages from si.
+func newJSONSystemImages(si *edgemax.SystemImages) jsonSystemImages {
+	return jsonSystemImages{
+		Current:  si.Current,
+		Previous: si.Previous,
+		Default:  si.Default,
+		Free:     si.Free,
+	}
+}
+
+// A jsonSystemStats is the JSON representation of SystemStats.
+type jsonSystemStats struct {
+	Uptime int `json:"uptime_seconds"`
+	CPU    int `json:"cpu_percent"`
+	Memory int `json:"memory_percent"`
+}
+
+// newJSONSystemStats creates a jsonSystemStats from ss.
+func newJSONSystemStats(ss *edgemax.SystemStats) jsonSystemStats {
+	return jsonSystemStats{
+		Uptime: int(ss.Uptime / time.Second),
+		CPU:    ss.CPU,
+		Memory: ss.Memory,
+	}
+}
+
+// A jsonInterface is the JSON representation of an Interface.
+type jsonInterface struct {
+	Name            string   `json:"name"`
+	Up              bool     `json:"up"`
+	Autonegotiation bool     `json:"autonegotiation"`
+	Duplex          string   `json:"duplex"`
+	Speed           int      `json:"speed"`
+	MAC             string   `json:"mac"`
+	MTU             int      `json:"mtu"`
+	Addresses       []string `json:"addresses"`
+
+	ReceivePackets  uint64 `json:"rx_packets"`
+	TransmitPackets uint64 `json:"tx_packets"`
+	ReceiveBytes    uint64 `json:"rx_bytes"`
+	TransmitBytes   uint64 `json:"tx_bytes"`
+	ReceiveErrors   uint64 `json:"rx_errors"`
+	TransmitErrors  uint64 `json:"tx_errors"`
+	ReceiveDropped  uint64 `json:"rx_dropped"`
+	TransmitDropped uint64 `json:"tx_dropped"`
+	Multicast       uint64 `json:"multicast"`
+	ReceiveBPS      uint64 `json:"rx_bps"`
+	TransmitBPS     uint64 `json:"tx_bps"`
+}
+
+// newJSONInterfaces creates a slice of jsonInterface values from ifis.
+func newJSONInterfaces(ifis edgemax.Interfaces) []jsonInterface {
+	out := make([]jsonInterface, 0, len(ifis))
+	for _, ifi := range ifis {
+		addrs := make([]string, 0, len(ifi.Addresses))
+		for _, a := range ifi.Addresses {
+			addrs = append(addrs, a.String())
+		}
+
+		out = append(out, jsonInterface{
+			Name:            ifi.Name,
+			Up:              ifi.Up,
+			Autonegotiation: ifi.Autonegotiation,
+			Duplex:          ifi.Duplex,
+			Speed:           ifi.Speed,
+			MAC:             ifi.MAC.String(),
+			MTU:             ifi.MTU,
+			Addresses:       addrs,
+
+			ReceivePackets:  ifi.Stats.ReceivePackets,
+			TransmitPackets: ifi.Stats.TransmitPackets,
+			ReceiveBytes:    ifi.Stats.ReceiveBytes,
+			TransmitBytes:   ifi.Stats.TransmitBytes,
+			ReceiveErrors:   ifi.Stats.ReceiveErrors,
+			TransmitErrors:  ifi.Stats.TransmitErrors,
+			ReceiveDropped:  ifi.Stats.ReceiveDropped,
+			TransmitDropped: ifi.Stats.TransmitDropped,
+			Multicast:       ifi.Stats.Multicast,
+			ReceiveBPS:      ifi.Stats.ReceiveBPS,
+			TransmitBPS:     ifi.Stats.TransmitBPS,
+		})
+	}
+
+	return out
+}
+
+// A jsonDPIStat is the JSON representation of a DPIStat.
+type jsonDPIStat struct {
+	IP            string `json:"ip"`
+	Type          string `json:"type"`
+	Category      string `json:"category"`
+	ReceiveBytes  uint64 `json:"rx_bytes"`
+	ReceiveRate   uint64 `json:"rx_rate"`
+	TransmitBytes uint64 `json:"tx_bytes"`
+	TransmitRate  uint64 `json:"tx_rate"`
+}
+
+// newJSONDPIStats creates a slice of jsonDPIStat values from ds.
+func newJSONDPIStats(ds edgemax.DPIStats) []jsonDPIStat {
+	out := make([]jsonDPIStat, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, jsonDPIStat{
+			IP:            d.IP.String(),
+			Type:          d.Type,
+			Category:      d.Category,
+			ReceiveBytes:  d.ReceiveBytes,
+			ReceiveRate:   d.ReceiveRate,
+			TransmitBytes: d.TransmitBytes,
+			TransmitRate:  d.TransmitRate,
+		})
+	}
+
+	return out
+}
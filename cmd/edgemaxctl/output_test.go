@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func Test_newJSONStat(t *testing.T) {
+	now := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		desc string
+		s    edgemax.Stat
+		out  string
+	}{
+		{
+			desc: "system stats",
+			s: &edgemax.SystemStats{
+				CPU:    10,
+				Uptime: 90 * time.Second,
+				Memory: 20,
+			},
+			out: `{"time":"2016-01-01T00:00:00Z","type":"system-stats","data":{"uptime_seconds":90,"cpu_percent":10,"memory_percent":20}}`,
+		},
+		{
+			desc: "interfaces",
+			s: edgemax.Interfaces{{
+				Name:      "eth0",
+				Up:        true,
+				MAC:       net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+				MTU:       1500,
+				Addresses: []net.IP{net.IPv4(192, 168, 1, 1)},
+				Stats: edgemax.InterfaceStats{
+					ReceiveBPS: 100,
+				},
+			}},
+			out: `{"time":"2016-01-01T00:00:00Z","type":"interfaces","data":[{"name":"eth0","up":true,"autonegotiation":false,"duplex":"","speed":0,"mac":"de:ad:be:ef:de:ad","mtu":1500,"addresses":["192.168.1.1"],"rx_packets":0,"tx_packets":0,"rx_bytes":0,"tx_bytes":0,"rx_errors":0,"tx_errors":0,"rx_dropped":0,"tx_dropped":0,"multicast":0,"rx_bps":100,"tx_bps":0}]}`,
+		},
+		{
+			desc: "DPI stats",
+			s: edgemax.DPIStats{{
+				IP:            net.IPv4(192, 168, 1, 10),
+				Type:          "DNS",
+				Category:      "Network protocols",
+				ReceiveBytes:  1,
+				TransmitBytes: 2,
+			}},
+			out: `{"time":"2016-01-01T00:00:00Z","type":"export","data":[{"ip":"192.168.1.10","type":"DNS","category":"Network protocols","rx_bytes":1,"rx_rate":0,"tx_bytes":2,"tx_rate":0}]}`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		buf := bytes.NewBuffer(nil)
+		if err := writeJSONLine(buf, newJSONStat(tt.s, now)); err != nil {
+			t.Fatalf("failed to write JSON: %v", err)
+		}
+
+		if want, got := tt.out+"\n", buf.String(); want != got {
+			t.Fatalf("unexpected JSON:\n- want: %s\n-  got: %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func cmdUpgrade(ctx context.Context, c *edgemax.Client, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	var (
+		imageFlag    = fs.String("image", "", "path to firmware image, such as edgeos.tar")
+		deleteFlag   = fs.Bool("delete-previous", false, "delete the previous system image if needed to free space")
+		noRebootFlag = fs.Bool("no-reboot", false, "install the image without rebooting the device")
+		waitFlag     = fs.Duration("wait", 10*time.Minute, "amount of time to wait for the device to return after rebooting")
+		installFlag  = fs.Duration("install-timeout", 30*time.Minute, "amount of time to allow for uploading and installing the firmware image")
+	)
+	_ = fs.Parse(args)
+
+	if *imageFlag == "" {
+		return errors.New("must specify firmware image using -image")
+	}
+
+	f, err := os.Open(*imageFlag)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := checkImageSpace(ctx, c, int(fi.Size()), *deleteFlag); err != nil {
+		return err
+	}
+
+	before, err := c.SystemInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	status("uploading %s (%d bytes), current version: %s", *imageFlag, fi.Size(), before.Version)
+
+	// The upload is not bounded by the HTTP request timeout, which is far too
+	// short for a firmware image on a slow link
+	uctx, cancel := context.WithTimeout(ctx, *installFlag)
+	defer cancel()
+
+	var last edgemax.UpgradeStatus
+	if err := c.UpgradeFirmware(uctx, f, &edgemax.UpgradeOptions{
+		Progress: func(us edgemax.UpgradeStatus) {
+			if us == last {
+				return
+			}
+			last = us
+
+			status("install %s: %d%% %s", us.State, us.Progress, us.Message)
+		},
+	}); err != nil {
+		return err
+	}
+
+	// The newly installed image becomes the default boot image
+	si, err := c.SystemImages()
+	if err != nil {
+		return err
+	}
+	want := si.Default
+
+	if *noRebootFlag {
+		status("installed %s; it will run after the next reboot", want)
+		return nil
+	}
+
+	status("installed %s; rebooting", want)
+	if err := c.Reboot(ctx); err != nil {
+		return err
+	}
+
+	after, err := waitReboot(ctx, c, *waitFlag)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasSuffix(after.Version, want) {
+		return fmt.Errorf("device is running %q after upgrade, expected %q", after.Version, want)
+	}
+
+	if isJSON() {
+		return writeJSON(w, struct {
+			Previous string `json:"previous"`
+			Current  string `json:"current"`
+		}{
+			Previous: before.Version,
+			Current:  after.Version,
+		})
+	}
+
+	fmt.Fprintf(w, "upgrade complete: %s\n", after.Version)
+	return nil
+}
+
+// checkImageSpace verifies that a device has room for an image of the
+// specified size, optionally deleting the previous system image to make room.
+func checkImageSpace(ctx context.Context, c *edgemax.Client, size int, deletePrevious bool) error {
+	si, err := c.SystemImages()
+	if err != nil {
+		return err
+	}
+
+	if si.Free >= size {
+		return nil
+	}
+
+	if !deletePrevious || si.Previous == "" {
+		return fmt.Errorf("image requires %d bytes, but only %d bytes are free; use -delete-previous to remove image %q",
+			size, si.Free, si.Previous)
+	}
+
+	status("deleting previous image %s to free space", si.Previous)
+	if err := c.DeleteSystemImage(ctx); err != nil {
+		return err
+	}
+
+	si, err = c.SystemImages()
+	if err != nil {
+		return err
+	}
+
+	if si.Free < size {
+		return fmt.Errorf("image requires %d bytes, but only %d bytes are free", size, si.Free)
+	}
+
+	return nil
+}
+
+// waitReboot waits up to timeout for a device to return after a reboot, logs
+// in again, and returns its SystemInfo.
+func waitReboot(ctx context.Context, c *edgemax.Client, timeout time.Duration) (*edgemax.SystemInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Give the device time to go down before polling, so that the old
+	// firmware is not mistaken for the new
+	interval := 10 * time.Second
+
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("device did not return within %s after rebooting", timeout)
+		}
+
+		if err := login(c); err != nil {
+			continue
+		}
+
+		si, err := c.SystemInfo(ctx)
+		if err != nil {
+			continue
+		}
+
+		return si, nil
+	}
+}
+
+// status prints a progress message to stderr, so that it does not interfere
+// with command output.
+func status(format string, v ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", v...)
+}
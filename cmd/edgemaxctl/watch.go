@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// An ifaceSample is a set of rates computed from two successive snapshots of
+// a network interface's statistics.
+type ifaceSample struct {
+	Time           time.Time `json:"time"`
+	Name           string    `json:"name"`
+	ReceiveBPS     int       `json:"rx_bps"`
+	TransmitBPS    int       `json:"tx_bps"`
+	ReceivePPS     int       `json:"rx_pps"`
+	TransmitPPS    int       `json:"tx_pps"`
+	ReceiveErrors  uint64    `json:"rx_errors"`
+	TransmitErrors uint64    `json:"tx_errors"`
+}
+
+// newIfaceSample computes rates for an interface using the deltas between
+// its statistics prev and cur, taken elapsed apart.  Errors are reported as
+// the number of new errors, rather than a rate.
+func newIfaceSample(name string, prev edgemax.InterfaceStats, cur edgemax.InterfaceStats, now time.Time, elapsed time.Duration) ifaceSample {
+	return ifaceSample{
+		Time:           now.UTC(),
+		Name:           name,
+		ReceiveBPS:     bitRate(prev.ReceiveBytes, cur.ReceiveBytes, elapsed),
+		TransmitBPS:    bitRate(prev.TransmitBytes, cur.TransmitBytes, elapsed),
+		ReceivePPS:     rate(prev.ReceivePackets, cur.ReceivePackets, elapsed),
+		TransmitPPS:    rate(prev.TransmitPackets, cur.TransmitPackets, elapsed),
+		ReceiveErrors:  delta(prev.ReceiveErrors, cur.ReceiveErrors),
+		TransmitErrors: delta(prev.TransmitErrors, cur.TransmitErrors),
+	}
+}
+
+// rate computes a per-second rate from two cumulative counters.  Counters
+// which decrease are treated as no change.
+func rate(prev uint64, cur uint64, elapsed time.Duration) int {
+	return int(float64(delta(prev, cur)) / elapsed.Seconds())
+}
+
+// delta computes the change between two cumulative counters.  Counters which
+// decrease, such as after a reboot, are treated as no change.
+func delta(prev uint64, cur uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+
+	return cur - prev
+}
+
+func cmdInterfacesWatch(ctx context.Context, c *edgemax.Client, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("interfaces watch", flag.ExitOnError)
+	sparkFlag := fs.Int("spark", 0, "render sparklines of the last N bandwidth samples (0 disables)")
+	_ = fs.Parse(args)
+
+	if fleet {
+		return errFleet
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("must specify a single interface name")
+	}
+	name := fs.Arg(0)
+
+	statC, done, err := c.Stats(edgemax.StatTypeInterfaces)
+	if err != nil {
+		return err
+	}
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, os.Interrupt)
+	defer signal.Stop(sigC)
+
+	var (
+		prev     *edgemax.Interface
+		prevT    time.Time
+		rxH, txH []int
+	)
+
+	if !isJSON() {
+		fmt.Fprintf(w, "%-8s  %12s  %12s  %8s  %8s  %6s  %6s\n",
+			"TIME", "RX BPS", "TX BPS", "RX PPS", "TX PPS", "RX ERR", "TX ERR")
+	}
+
+	for {
+		select {
+		case s, ok := <-statC:
+			if !ok {
+				// The stream failed, and done reports why
+				return done()
+			}
+
+			ifis, ok := s.(edgemax.Interfaces)
+			if !ok {
+				continue
+			}
+
+			ifi := findInterface(ifis, name)
+			if ifi == nil {
+				_ = stop(statC, done)
+				return fmt.Errorf("interface %q not found", name)
+			}
+
+			now := time.Now()
+			if prev == nil {
+				prev, prevT = ifi, now
+				continue
+			}
+
+			sample := newIfaceSample(name, prev.Stats, ifi.Stats, now, now.Sub(prevT))
+			prev, prevT = ifi, now
+
+			if isJSON() {
+				if err := writeJSONLine(w, sample); err != nil {
+					_ = stop(statC, done)
+					return err
+				}
+				continue
+			}
+
+			fmt.Fprintf(w, "%-8s  %12d  %12d  %8d  %8d  %6d  %6d",
+				sample.Time.Local().Format("15:04:05"),
+				sample.ReceiveBPS,
+				sample.TransmitBPS,
+				sample.ReceivePPS,
+				sample.TransmitPPS,
+				sample.ReceiveErrors,
+				sample.TransmitErrors,
+			)
+
+			if *sparkFlag > 0 {
+				rxH = appendHistory(rxH, sample.ReceiveBPS, *sparkFlag)
+				txH = appendHistory(txH, sample.TransmitBPS, *sparkFlag)
+				fmt.Fprintf(w, "  rx %s  tx %s", sparkline(rxH), sparkline(txH))
+			}
+
+			fmt.Fprintln(w)
+		case <-sigC:
+			return stop(statC, done)
+		case <-ctx.Done():
+			_ = stop(statC, done)
+			return ctx.Err()
+		}
+	}
+}
+
+// findInterface returns the interface with the specified name from ifis, or
+// nil if none exists.
+func findInterface(ifis edgemax.Interfaces, name string) *edgemax.Interface {
+	for _, ifi := range ifis {
+		if ifi.Name == name {
+			return ifi
+		}
+	}
+
+	return nil
+}
+
+// appendHistory appends v to h, retaining at most n values.
+func appendHistory(h []int, v int, n int) []int {
+	h = append(h, v)
+	if len(h) > n {
+		h = h[len(h)-n:]
+	}
+
+	return h
+}
+
+// sparkTicks are the characters used to render sparklines, from lowest to
+// highest.
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders vs as a sparkline, scaled between zero and the maximum
+// value in vs.
+func sparkline(vs []int) string {
+	var max int
+	for _, v := range vs {
+		if v > max {
+			max = v
+		}
+	}
+
+	out := make([]rune, 0, len(vs))
+	for _, v := range vs {
+		i := 0
+		if max > 0 {
+			i = v * (len(sparkTicks) - 1) / max
+		}
+
+		out = append(out, sparkTicks[i])
+	}
+
+	return string(out)
+}
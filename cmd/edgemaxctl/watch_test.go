@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func Test_newIfaceSample(t *testing.T) {
+	now := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		desc      string
+		prev, cur edgemax.InterfaceStats
+		s         ifaceSample
+	}{
+		{
+			desc: "no change",
+			s:    ifaceSample{Time: now, Name: "eth0"},
+		},
+		{
+			desc: "counters reset",
+			prev: edgemax.InterfaceStats{
+				ReceiveBytes:  1000,
+				ReceiveErrors: 10,
+			},
+			s: ifaceSample{Time: now, Name: "eth0"},
+		},
+		{
+			desc: "OK",
+			prev: edgemax.InterfaceStats{
+				ReceivePackets:  10,
+				TransmitPackets: 20,
+				ReceiveBytes:    1000,
+				TransmitBytes:   2000,
+				ReceiveErrors:   1,
+			},
+			cur: edgemax.InterfaceStats{
+				ReceivePackets:  30,
+				TransmitPackets: 60,
+				ReceiveBytes:    2000,
+				TransmitBytes:   4000,
+				ReceiveErrors:   3,
+				TransmitErrors:  1,
+			},
+			s: ifaceSample{
+				Time:           now,
+				Name:           "eth0",
+				ReceiveBPS:     4000,
+				TransmitBPS:    8000,
+				ReceivePPS:     10,
+				TransmitPPS:    20,
+				ReceiveErrors:  2,
+				TransmitErrors: 1,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		s := newIfaceSample("eth0", tt.prev, tt.cur, now, 2*time.Second)
+		if want, got := tt.s, s; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected sample:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
+
+func Test_sparkline(t *testing.T) {
+	var tests = []struct {
+		vs []int
+		s  string
+	}{
+		{s: ""},
+		{vs: []int{0, 0}, s: "▁▁"},
+		{vs: []int{0, 7, 14}, s: "▁▄█"},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %v", i, tt.vs)
+
+		if want, got := tt.s, sparkline(tt.vs); want != got {
+			t.Fatalf("unexpected sparkline:\n- want: %q\n-  got: %q", want, got)
+		}
+	}
+}
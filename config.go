@@ -0,0 +1,391 @@
+package edgemax
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// A ConfigTree is the configuration tree of an EdgeMAX device, as retrieved
+// by Client.GetConfig.
+//
+// The configuration of a large device can be several megabytes, so nodes are
+// only decoded as they are reached by Get.  A ConfigTree is safe for
+// concurrent use.
+type ConfigTree struct {
+	root *ConfigNode
+}
+
+// UnmarshalJSON unmarshals JSON into a ConfigTree.
+func (t *ConfigTree) UnmarshalJSON(b []byte) error {
+	// Unmarshaling into a json.RawMessage validates and copies b
+	var raw json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	if string(raw) == "null" {
+		*t = ConfigTree{}
+		return nil
+	}
+	if raw[0] != '{' {
+		return errors.New("configuration tree must be a JSON object")
+	}
+
+	*t = ConfigTree{root: &ConfigNode{raw: raw}}
+	return nil
+}
+
+// MarshalJSON marshals a ConfigTree into JSON.
+func (t *ConfigTree) MarshalJSON() ([]byte, error) {
+	if t.root == nil {
+		return []byte("null"), nil
+	}
+
+	return t.root.raw, nil
+}
+
+// Root returns the root node of t.  The root node of an empty ConfigTree
+// has no children.
+func (t *ConfigTree) Root() *ConfigNode {
+	if t.root == nil {
+		return &ConfigNode{raw: json.RawMessage("{}")}
+	}
+
+	return t.root
+}
+
+// Child returns the configuration node at path, such as "interfaces",
+// "ethernet", "eth0", or nil if no such node exists.  Child is shorthand for
+// t.Root().Child(path...).
+func (t *ConfigTree) Child(path ...string) *ConfigNode {
+	return t.Root().Child(path...)
+}
+
+// Get retrieves the value of the configuration node at path, such as
+// "interfaces", "ethernet", "eth0".  Inner nodes are returned as
+// map[string]interface{}, and leaf nodes are returned as string,
+// []interface{} for multi-value nodes, or nil for valueless nodes.
+//
+// If no node exists at path, ok is false.  If path is empty, the entire
+// tree is returned.
+func (t *ConfigTree) Get(path ...string) (v interface{}, ok bool) {
+	n := t.Child(path...)
+	if n == nil {
+		return nil, false
+	}
+
+	if err := json.Unmarshal(n.raw, &v); err != nil {
+		return nil, false
+	}
+
+	return v, true
+}
+
+// Equal reports whether t and u contain the same configuration.
+func (t *ConfigTree) Equal(u *ConfigTree) bool {
+	tb, _ := t.MarshalJSON()
+	ub, _ := u.MarshalJSON()
+	if bytes.Equal(tb, ub) {
+		return true
+	}
+
+	// Fall back to comparing the decoded trees, which ignores formatting
+	// and the order of keys
+	tv, _ := t.Get()
+	uv, _ := u.Get()
+	return reflect.DeepEqual(tv, uv)
+}
+
+// A ConfigNode is a node of a ConfigTree, whose children are decoded from
+// its raw JSON when first needed.  Inner nodes contain named children, and
+// leaf nodes contain a single value, multiple values, or no value.
+//
+// Methods on a nil *ConfigNode report that the node does not exist, so
+// calls to Child can be chained without checking each result.
+type ConfigNode struct {
+	raw json.RawMessage
+
+	once     sync.Once
+	children map[string]*ConfigNode
+}
+
+// Child returns the descendant of n at path, such as "ethernet", "eth0", or
+// nil if no such node exists.  If path is empty, n is returned.
+func (n *ConfigNode) Child(path ...string) *ConfigNode {
+	for _, p := range path {
+		if n == nil {
+			return nil
+		}
+
+		n, _ = n.child(p)
+	}
+
+	return n
+}
+
+// Children returns the sorted names of the children of n.  If n is a leaf
+// node or nil, Children returns nil.
+func (n *ConfigNode) Children() []string {
+	if n == nil {
+		return nil
+	}
+	n.decode()
+
+	if len(n.children) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(n.children))
+	for k := range n.children {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// IsLeaf reports whether n is a leaf node, which contains a value rather
+// than children.  If n is nil, IsLeaf returns false.
+func (n *ConfigNode) IsLeaf() bool {
+	return n != nil && (len(n.raw) == 0 || n.raw[0] != '{')
+}
+
+// Value returns the value of a single-value leaf node, such as the "mtu" of
+// an interface.  If n is nil, is an inner node, or does not contain a
+// single value, ok is false.
+func (n *ConfigNode) Value() (v string, ok bool) {
+	if !n.IsLeaf() || string(n.raw) == "null" {
+		return "", false
+	}
+
+	if err := json.Unmarshal(n.raw, &v); err != nil {
+		return "", false
+	}
+
+	return v, true
+}
+
+// Values returns the values of a multi-value leaf node, such as the
+// "address" of an interface with several addresses.  A single-value leaf
+// node is returned as one value.  If n is nil, is an inner node, or contains
+// no values, Values returns nil.
+func (n *ConfigNode) Values() []string {
+	if v, ok := n.Value(); ok {
+		return []string{v}
+	}
+	if !n.IsLeaf() {
+		return nil
+	}
+
+	var vs []string
+	if err := json.Unmarshal(n.raw, &vs); err != nil {
+		return nil
+	}
+
+	return vs
+}
+
+// Int returns the value of a single-value leaf node as an integer.  If the
+// node has no such value, ok is false.
+func (n *ConfigNode) Int() (v int, ok bool) {
+	s, ok := n.Value()
+	if !ok {
+		return 0, false
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// Bool returns the value of a single-value leaf node which is "enable" or
+// "true" as true, and "disable" or "false" as false.  If the node has no
+// such value, ok is false.
+func (n *ConfigNode) Bool() (v bool, ok bool) {
+	s, ok := n.Value()
+	if !ok {
+		return false, false
+	}
+
+	switch s {
+	case "enable", "true":
+		return true, true
+	case "disable", "false":
+		return false, true
+	}
+
+	return false, false
+}
+
+// child returns the child node of n with the specified name.  If n is a
+// leaf node, or has no such child, ok is false.
+func (n *ConfigNode) child(name string) (c *ConfigNode, ok bool) {
+	n.decode()
+
+	c, ok = n.children[name]
+	return c, ok
+}
+
+// decode decodes the children of n from its raw JSON, once.
+func (n *ConfigNode) decode() {
+	n.once.Do(func() {
+		if len(n.raw) == 0 || n.raw[0] != '{' {
+			return
+		}
+
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(n.raw, &m); err != nil {
+			return
+		}
+
+		n.children = make(map[string]*ConfigNode, len(m))
+		for k, v := range m {
+			n.children[k] = &ConfigNode{raw: v}
+		}
+	})
+}
+
+// A ConfigAction is an action performed by a ConfigOp.
+type ConfigAction int
+
+const (
+	// ConfigSet sets the value of a configuration node.
+	ConfigSet ConfigAction = iota
+
+	// ConfigDelete deletes a configuration node and all of its children.
+	ConfigDelete
+)
+
+// A ConfigOp is a single operation applied to the configuration of an
+// EdgeMAX device by Client.SetConfig.
+type ConfigOp struct {
+	Action ConfigAction
+
+	// Path specifies the configuration node which is set or deleted, such
+	// as "interfaces", "ethernet", "eth0", "description".
+	Path []string
+
+	// Value specifies the value set by a ConfigSet operation.  If empty,
+	// a valueless node is created, such as "service", "ssh".
+	//
+	// Multiple ConfigSet operations with different values for the same
+	// node set each of the values, for nodes which accept multiple values
+	// such as "system", "name-server".
+	Value string
+}
+
+// configTrees converts ops into the nested configuration trees used for
+// the set and delete stages of the configuration batch API.
+func configTrees(ops []ConfigOp) (set map[string]interface{}, del map[string]interface{}, err error) {
+	for _, op := range ops {
+		if len(op.Path) == 0 {
+			return nil, nil, errors.New("configuration operation path must not be empty")
+		}
+
+		var tree *map[string]interface{}
+		var v interface{}
+
+		switch op.Action {
+		case ConfigSet:
+			tree = &set
+			if op.Value != "" {
+				v = op.Value
+			}
+		case ConfigDelete:
+			tree = &del
+		default:
+			return nil, nil, fmt.Errorf("unknown configuration action: %d", op.Action)
+		}
+
+		if *tree == nil {
+			*tree = make(map[string]interface{})
+		}
+
+		if err := mergeConfigPath(*tree, v, op.Path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return set, del, nil
+}
+
+// mergeConfigPath merges the value v at path into the configuration tree m.
+func mergeConfigPath(m map[string]interface{}, v interface{}, path []string) error {
+	for i, p := range path {
+		if i == len(path)-1 {
+			cur, ok := m[p]
+			if !ok {
+				m[p] = v
+				return nil
+			}
+
+			merged, ok := mergeConfigValue(cur, v)
+			if !ok {
+				return fmt.Errorf("conflicting configuration operations at %v", path)
+			}
+
+			m[p] = merged
+			return nil
+		}
+
+		next, ok := m[p]
+		if !ok || next == nil {
+			child := make(map[string]interface{})
+			m[p] = child
+			m = child
+			continue
+		}
+
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("conflicting configuration operations at %v", path[:i+1])
+		}
+
+		m = child
+	}
+
+	return nil
+}
+
+// mergeConfigValue merges the value v into the existing value cur of a
+// configuration node.  Distinct values are collected into a []string, as
+// the configuration batch API expects for nodes with multiple values.
+// mergeConfigValue returns false if the values cannot be merged.
+func mergeConfigValue(cur interface{}, v interface{}) (interface{}, bool) {
+	if cur == nil && v == nil {
+		return nil, true
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, false
+	}
+
+	switch cur := cur.(type) {
+	case string:
+		if cur == s {
+			return cur, true
+		}
+
+		return []string{cur, s}, true
+	case []string:
+		for _, c := range cur {
+			if c == s {
+				return cur, true
+			}
+		}
+
+		return append(cur, s), true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,274 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestConfigTreeGet(t *testing.T) {
+	var tree ConfigTree
+	b := []byte(`{"interfaces":{"ethernet":{"eth0":{"address":["192.168.1.1/24"],"description":"LAN"}}},"service":{"ssh":null}}`)
+	if err := json.Unmarshal(b, &tree); err != nil {
+		t.Fatalf("failed to unmarshal ConfigTree: %v", err)
+	}
+
+	var tests = []struct {
+		desc string
+		path []string
+		v    interface{}
+		ok   bool
+	}{
+		{
+			desc: "missing node",
+			path: []string{"interfaces", "ethernet", "eth1"},
+		},
+		{
+			desc: "path through leaf node",
+			path: []string{"interfaces", "ethernet", "eth0", "description", "foo"},
+		},
+		{
+			desc: "leaf node",
+			path: []string{"interfaces", "ethernet", "eth0", "description"},
+			v:    "LAN",
+			ok:   true,
+		},
+		{
+			desc: "multi-value leaf node",
+			path: []string{"interfaces", "ethernet", "eth0", "address"},
+			v:    []interface{}{"192.168.1.1/24"},
+			ok:   true,
+		},
+		{
+			desc: "valueless node",
+			path: []string{"service", "ssh"},
+			ok:   true,
+		},
+		{
+			desc: "inner node",
+			path: []string{"service"},
+			v:    map[string]interface{}{"ssh": nil},
+			ok:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		v, ok := tree.Get(tt.path...)
+		if want, got := tt.ok, ok; want != got {
+			t.Fatalf("unexpected ok:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		if want, got := tt.v, v; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected value:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestConfigNode(t *testing.T) {
+	var tree ConfigTree
+	b := []byte(`{"interfaces":{"ethernet":{"eth1":{"mtu":"9000","disable":null},"eth0":{"address":["192.168.1.1/24","fd00::1/64"],"description":"LAN","mtu":"auto"}}},"service":{"gui":{"older-ciphers":"enable"}}}`)
+	if err := json.Unmarshal(b, &tree); err != nil {
+		t.Fatalf("failed to unmarshal ConfigTree: %v", err)
+	}
+
+	ethernet := tree.Child("interfaces", "ethernet")
+	if want, got := []string{"eth0", "eth1"}, ethernet.Children(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected children:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if v, ok := ethernet.Child("eth0", "description").Value(); !ok || v != "LAN" {
+		t.Fatalf("unexpected description: %q, %v", v, ok)
+	}
+	if want, got := []string{"192.168.1.1/24", "fd00::1/64"}, ethernet.Child("eth0", "address").Values(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected addresses:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := []string{"LAN"}, ethernet.Child("eth0", "description").Values(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected single value:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if v, ok := ethernet.Child("eth1", "mtu").Int(); !ok || v != 9000 {
+		t.Fatalf("unexpected MTU: %d, %v", v, ok)
+	}
+	if _, ok := ethernet.Child("eth0", "mtu").Int(); ok {
+		t.Fatal("non-integer MTU should not be reported as an integer")
+	}
+
+	if v, ok := tree.Child("service", "gui", "older-ciphers").Bool(); !ok || !v {
+		t.Fatalf("unexpected boolean: %v, %v", v, ok)
+	}
+
+	// Valueless nodes exist, but have no value
+	disable := ethernet.Child("eth1", "disable")
+	if disable == nil || !disable.IsLeaf() {
+		t.Fatal("valueless node should exist as a leaf node")
+	}
+	if _, ok := disable.Value(); ok {
+		t.Fatal("valueless node should not have a value")
+	}
+
+	// Missing nodes can be chained without checking each result
+	missing := tree.Child("interfaces", "bridge").Child("br0", "mtu")
+	if missing != nil {
+		t.Fatalf("unexpected node: %v", missing)
+	}
+	if _, ok := missing.Value(); ok {
+		t.Fatal("missing node should not have a value")
+	}
+	if missing.Children() != nil || missing.IsLeaf() {
+		t.Fatal("missing node should have no children and not be a leaf")
+	}
+}
+
+func TestConfigTreeGetLazy(t *testing.T) {
+	var tree ConfigTree
+	b := []byte(`{"interfaces":{"ethernet":{"eth0":{"description":"LAN"}}},"service":{"ssh":null}}`)
+	if err := json.Unmarshal(b, &tree); err != nil {
+		t.Fatalf("failed to unmarshal ConfigTree: %v", err)
+	}
+
+	if _, ok := tree.Get("interfaces", "ethernet", "eth0", "description"); !ok {
+		t.Fatal("description not found in configuration")
+	}
+
+	// Only the nodes along the path should have been decoded
+	service, ok := tree.root.child("service")
+	if !ok {
+		t.Fatal("service not found in configuration")
+	}
+	if service.children != nil {
+		t.Fatalf("unrelated node was decoded: %v", service.children)
+	}
+}
+
+func TestConfigTreeEqual(t *testing.T) {
+	var tests = []struct {
+		desc  string
+		a, b  string
+		equal bool
+	}{
+		{
+			desc:  "identical",
+			a:     `{"system":{"host-name":"router"}}`,
+			b:     `{"system":{"host-name":"router"}}`,
+			equal: true,
+		},
+		{
+			desc:  "different formatting and key order",
+			a:     `{"service":{"ssh":null},"system":{"host-name":"router"}}`,
+			b:     `{ "system": {"host-name": "router"}, "service": {"ssh": null} }`,
+			equal: true,
+		},
+		{
+			desc: "different value",
+			a:    `{"system":{"host-name":"router"}}`,
+			b:    `{"system":{"host-name":"gw"}}`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var a, b ConfigTree
+		if err := json.Unmarshal([]byte(tt.a), &a); err != nil {
+			t.Fatalf("failed to unmarshal ConfigTree: %v", err)
+		}
+		if err := json.Unmarshal([]byte(tt.b), &b); err != nil {
+			t.Fatalf("failed to unmarshal ConfigTree: %v", err)
+		}
+
+		if want, got := tt.equal, a.Equal(&b); want != got {
+			t.Fatalf("unexpected equality:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func Test_configTrees(t *testing.T) {
+	var tests = []struct {
+		desc string
+		ops  []ConfigOp
+		set  string
+		del  string
+		ok   bool
+	}{
+		{
+			desc: "empty path",
+			ops:  []ConfigOp{{Action: ConfigSet}},
+		},
+		{
+			desc: "unknown action",
+			ops:  []ConfigOp{{Action: 100, Path: []string{"foo"}}},
+		},
+		{
+			desc: "conflicting operations",
+			ops: []ConfigOp{
+				{Action: ConfigSet, Path: []string{"system", "host-name"}, Value: "foo"},
+				{Action: ConfigSet, Path: []string{"system", "host-name", "bar"}, Value: "baz"},
+			},
+		},
+		{
+			desc: "conflicting valueless and value",
+			ops: []ConfigOp{
+				{Action: ConfigSet, Path: []string{"service", "ssh"}},
+				{Action: ConfigSet, Path: []string{"service", "ssh"}, Value: "foo"},
+			},
+		},
+		{
+			desc: "OK repeated values",
+			ops: []ConfigOp{
+				{Action: ConfigSet, Path: []string{"system", "name-server"}, Value: "192.168.1.1"},
+				{Action: ConfigSet, Path: []string{"system", "name-server"}, Value: "8.8.8.8"},
+				{Action: ConfigSet, Path: []string{"system", "name-server"}, Value: "8.8.4.4"},
+				{Action: ConfigSet, Path: []string{"system", "name-server"}, Value: "8.8.8.8"},
+				{Action: ConfigSet, Path: []string{"system", "host-name"}, Value: "router"},
+				{Action: ConfigSet, Path: []string{"system", "host-name"}, Value: "router"},
+			},
+			set: `{"system":{"host-name":"router","name-server":["192.168.1.1","8.8.8.8","8.8.4.4"]}}`,
+			del: `null`,
+			ok:  true,
+		},
+		{
+			desc: "OK set and delete",
+			ops: []ConfigOp{
+				{Action: ConfigSet, Path: []string{"system", "host-name"}, Value: "router"},
+				{Action: ConfigSet, Path: []string{"system", "domain-name"}, Value: "example.com"},
+				{Action: ConfigSet, Path: []string{"service", "ssh"}},
+				{Action: ConfigDelete, Path: []string{"service", "telnet"}},
+			},
+			set: `{"service":{"ssh":null},"system":{"domain-name":"example.com","host-name":"router"}}`,
+			del: `{"service":{"telnet":null}}`,
+			ok:  true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		set, del, err := configTrees(tt.ops)
+		if want, got := tt.ok, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err != nil {
+			continue
+		}
+
+		for _, c := range []struct {
+			name string
+			want string
+			tree map[string]interface{}
+		}{
+			{name: "set", want: tt.set, tree: set},
+			{name: "delete", want: tt.del, tree: del},
+		} {
+			b, err := json.Marshal(c.tree)
+			if err != nil {
+				t.Fatalf("failed to marshal %s tree: %v", c.name, err)
+			}
+
+			if want, got := c.want, string(b); want != got {
+				t.Fatalf("unexpected %s tree:\n- want: %v\n-  got: %v", c.name, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DHCPLeases is a slice of DHCPLease values, sorted by pool and IP address.
+type DHCPLeases []*DHCPLease
+
+// A DHCPLease is a lease handed out by a DHCP server on an EdgeMAX device.
+type DHCPLease struct {
+	IP       net.IP
+	MAC      net.HardwareAddr
+	Hostname string
+
+	// Pool is the name of the DHCP server shared network which handed out
+	// the lease.
+	Pool string
+
+	// Expires is the time the lease expires, or the zero time for a lease
+	// which does not expire.
+	Expires time.Time
+
+	// Static reports whether the lease's address is reserved for its client
+	// by a static mapping.  For static mappings which the client has not
+	// yet used, Expires is zero and Hostname is the name of the mapping.
+	Static bool
+}
+
+// dhcpExpirationLayout is the layout of DHCP lease expiration times
+// reported by an EdgeMAX device.
+const dhcpExpirationLayout = "2006/01/02 15:04:05"
+
+// UnmarshalJSON unmarshals JSON into a DHCPLeases.
+func (l *DHCPLeases) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Leases map[string]json.RawMessage `json:"dhcp-server-leases"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	var ls DHCPLeases
+	for pool, raw := range v.Leases {
+		// Pools with no leases are reported as an empty string rather than
+		// an empty object
+		var leases map[string]struct {
+			Expiration string `json:"expiration"`
+			Pool       string `json:"pool"`
+			MAC        string `json:"mac"`
+			Hostname   string `json:"client-hostname"`
+		}
+		if string(raw) != `""` {
+			if err := json.Unmarshal(raw, &leases); err != nil {
+				return err
+			}
+		}
+
+		for addr, vv := range leases {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				return fmt.Errorf("invalid DHCP lease IP address: %q", addr)
+			}
+
+			mac, err := net.ParseMAC(vv.MAC)
+			if err != nil {
+				return err
+			}
+
+			// Expiration times have no time zone, and are interpreted as
+			// UTC
+			var expires time.Time
+			if exp := strings.TrimSpace(vv.Expiration); !isPlaceholder(exp) && exp != "never" {
+				expires, err = time.Parse(dhcpExpirationLayout, exp)
+				if err != nil {
+					return err
+				}
+			}
+
+			if vv.Pool != "" {
+				pool = vv.Pool
+			}
+
+			ls = append(ls, &DHCPLease{
+				IP:       normalizeIP(ip),
+				MAC:      mac,
+				Hostname: vv.Hostname,
+				Pool:     pool,
+				Expires:  expires,
+			})
+		}
+	}
+
+	ls.sort()
+	*l = ls
+	return nil
+}
+
+// addStatic marks the leases of l which match a static mapping in ms as
+// static, and adds a lease for each static mapping which matches no lease.
+func (l *DHCPLeases) addStatic(ms []*DHCPStaticMapping) {
+	for _, m := range ms {
+		var found bool
+		for _, ls := range *l {
+			if ls.Pool == m.Pool && ls.IP.Equal(m.IP) {
+				ls.Static, found = true, true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		*l = append(*l, &DHCPLease{
+			IP:       normalizeIP(m.IP),
+			MAC:      m.MAC,
+			Hostname: m.Name,
+			Pool:     m.Pool,
+			Static:   true,
+		})
+	}
+
+	l.sort()
+}
+
+// sort sorts l by pool and IP address.
+func (l DHCPLeases) sort() {
+	sort.Slice(l, func(i int, j int) bool {
+		if l[i].Pool != l[j].Pool {
+			return l[i].Pool < l[j].Pool
+		}
+
+		return ipLess(l[i].IP, l[j].IP)
+	})
+}
+
+// A DHCPStaticMapping is a static MAC address to IP address mapping, or
+// reservation, configured on a DHCP server on an EdgeMAX device.
+type DHCPStaticMapping struct {
+	// Name is the unique name of the mapping.
+	Name string
+
+	// Pool is the name of the DHCP server shared network, and Subnet is the
+	// subnet within that network, which contain the mapping.
+	Pool   string
+	Subnet *net.IPNet
+
+	IP  net.IP
+	MAC net.HardwareAddr
+}
+
+// StaticMapping creates a DHCPStaticMapping which reserves the address of
+// the lease for its client, within the specified subnet of the lease's
+// pool.  If name is empty, the hostname of the lease's client is used.
+func (l *DHCPLease) StaticMapping(name string, subnet *net.IPNet) (*DHCPStaticMapping, error) {
+	if name == "" {
+		name = l.Hostname
+	}
+	if name == "" {
+		return nil, errors.New("static mapping name must not be empty for lease with no hostname")
+	}
+
+	if subnet == nil || !subnet.Contains(l.IP) {
+		return nil, fmt.Errorf("lease address %s is not within subnet %s", l.IP, subnet)
+	}
+
+	return &DHCPStaticMapping{
+		Name:   name,
+		Pool:   l.Pool,
+		Subnet: subnet,
+		IP:     l.IP,
+		MAC:    l.MAC,
+	}, nil
+}
+
+// Validate reports whether m is a valid static mapping: it must specify a
+// pool, subnet, and name, an IPv4 address within the subnet, and a 48-bit
+// MAC address.
+func (m *DHCPStaticMapping) Validate() error {
+	if err := m.validatePath(); err != nil {
+		return err
+	}
+
+	if m.IP == nil || m.MAC == nil {
+		return errors.New("static mapping must specify an IP address and MAC address")
+	}
+	if m.IP.To4() == nil {
+		return fmt.Errorf("static mapping IP address %s must be an IPv4 address", m.IP)
+	}
+	if !m.Subnet.Contains(m.IP) {
+		return fmt.Errorf("static mapping IP address %s is not within subnet %s", m.IP, m.Subnet)
+	}
+	if len(m.MAC) != 6 {
+		return fmt.Errorf("static mapping MAC address %s must be a 48-bit MAC address", m.MAC)
+	}
+
+	return nil
+}
+
+// validatePath reports whether m specifies the path of a static mapping.
+func (m *DHCPStaticMapping) validatePath() error {
+	if m.Pool == "" || m.Subnet == nil || m.Name == "" {
+		return errors.New("static mapping must specify a pool, subnet, and name")
+	}
+
+	if err := validateConfigName(m.Name); err != nil {
+		return fmt.Errorf("invalid static mapping name: %v", err)
+	}
+	if err := validateConfigName(m.Pool); err != nil {
+		return fmt.Errorf("invalid DHCP pool name: %v", err)
+	}
+
+	return nil
+}
+
+// configTree creates the configuration tree used to set m using the
+// configuration batch API.
+func (m *DHCPStaticMapping) configTree() map[string]interface{} {
+	return m.configPath(map[string]interface{}{
+		"ip-address":  m.IP.String(),
+		"mac-address": m.MAC.String(),
+	})
+}
+
+// configPath creates a configuration tree with v as the value of the node
+// of m.
+func (m *DHCPStaticMapping) configPath(v interface{}) map[string]interface{} {
+	return configPath(v,
+		"service", "dhcp-server", "shared-network-name", m.Pool,
+		"subnet", m.Subnet.String(),
+		"static-mapping", m.Name,
+	)
+}
+
+// dhcpStaticMappings retrieves the DHCP server static mappings from the
+// configuration tree t, sorted by pool, subnet, and name.
+func dhcpStaticMappings(t *ConfigTree) ([]*DHCPStaticMapping, error) {
+	pools := t.Child("service", "dhcp-server", "shared-network-name")
+
+	var ms []*DHCPStaticMapping
+	for _, pool := range pools.Children() {
+		subnets := pools.Child(pool, "subnet")
+		for _, cidr := range subnets.Children() {
+			_, subnet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, err
+			}
+
+			mappings := subnets.Child(cidr, "static-mapping")
+			for _, name := range mappings.Children() {
+				ipStr, _ := mappings.Child(name, "ip-address").Value()
+				macStr, _ := mappings.Child(name, "mac-address").Value()
+
+				ip := net.ParseIP(ipStr)
+				if ip == nil {
+					return nil, fmt.Errorf("invalid IP address for static mapping %q: %q", name, ipStr)
+				}
+
+				mac, err := net.ParseMAC(macStr)
+				if err != nil {
+					return nil, err
+				}
+
+				ms = append(ms, &DHCPStaticMapping{
+					Name:   name,
+					Pool:   pool,
+					Subnet: subnet,
+					IP:     ip,
+					MAC:    mac,
+				})
+			}
+		}
+	}
+
+	return ms, nil
+}
@@ -0,0 +1,248 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDHCPLeasesUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		l       DHCPLeases
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid MAC",
+			b:       []byte(`{"dhcp-server-leases":{"LAN":{"192.168.1.10":{"mac":"foo"}}}}`),
+			errType: reflect.TypeOf(&net.AddrError{}),
+		},
+		{
+			desc:    "invalid expiration",
+			b:       []byte(`{"dhcp-server-leases":{"LAN":{"192.168.1.10":{"mac":"de:ad:be:ef:de:ad","expiration":"foo"}}}}`),
+			errType: reflect.TypeOf(&time.ParseError{}),
+		},
+		{
+			desc: "OK no leases",
+			b:    []byte(`{"dhcp-server-leases":{"LAN":""}}`),
+		},
+		{
+			desc: "OK",
+			b: []byte(`{"dhcp-server-leases":{
+				"LAN":{
+					"192.168.1.20":{
+						"expiration":"2017/05/12 01:02:03",
+						"pool":"LAN",
+						"mac":"de:ad:be:ef:de:ad",
+						"client-hostname":"foo"
+					},
+					"192.168.1.3":{
+						"expiration":"never",
+						"pool":"LAN",
+						"mac":"de:ad:be:ef:de:ae",
+						"client-hostname":""
+					}
+				},
+				"GUEST":""
+			}}`),
+			l: DHCPLeases{
+				{
+					IP:   net.IPv4(192, 168, 1, 3).To4(),
+					MAC:  net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xae},
+					Pool: "LAN",
+				},
+				{
+					IP:       net.IPv4(192, 168, 1, 20).To4(),
+					MAC:      net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+					Hostname: "foo",
+					Pool:     "LAN",
+					Expires:  time.Date(2017, time.May, 12, 1, 2, 3, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var l DHCPLeases
+		err := l.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.l, l; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected DHCPLeases:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestDHCPLeaseStaticMapping(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse subnet: %v", err)
+	}
+
+	mac := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+
+	var tests = []struct {
+		desc   string
+		l      *DHCPLease
+		name   string
+		subnet *net.IPNet
+		m      *DHCPStaticMapping
+	}{
+		{
+			desc:   "no name or hostname",
+			l:      &DHCPLease{IP: net.IPv4(192, 168, 1, 10)},
+			subnet: subnet,
+		},
+		{
+			desc: "no subnet",
+			l: &DHCPLease{
+				IP:       net.IPv4(192, 168, 1, 10),
+				Hostname: "foo",
+			},
+		},
+		{
+			desc: "address outside subnet",
+			l: &DHCPLease{
+				IP:       net.IPv4(192, 168, 2, 10),
+				Hostname: "foo",
+			},
+			subnet: subnet,
+		},
+		{
+			desc: "OK hostname",
+			l: &DHCPLease{
+				IP:       net.IPv4(192, 168, 1, 10),
+				MAC:      mac,
+				Hostname: "foo",
+				Pool:     "LAN",
+			},
+			subnet: subnet,
+			m: &DHCPStaticMapping{
+				Name:   "foo",
+				Pool:   "LAN",
+				Subnet: subnet,
+				IP:     net.IPv4(192, 168, 1, 10),
+				MAC:    mac,
+			},
+		},
+		{
+			desc: "OK name",
+			l: &DHCPLease{
+				IP:       net.IPv4(192, 168, 1, 10),
+				MAC:      mac,
+				Hostname: "foo",
+				Pool:     "LAN",
+			},
+			name:   "bar",
+			subnet: subnet,
+			m: &DHCPStaticMapping{
+				Name:   "bar",
+				Pool:   "LAN",
+				Subnet: subnet,
+				IP:     net.IPv4(192, 168, 1, 10),
+				MAC:    mac,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		m, err := tt.l.StaticMapping(tt.name, tt.subnet)
+		if want, got := tt.m != nil, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.m, m; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected DHCPStaticMapping:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
+
+func TestDHCPStaticMappingValidate(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("192.168.1.0/24")
+	if err != nil {
+		t.Fatalf("failed to parse subnet: %v", err)
+	}
+
+	mapping := func(fn func(m *DHCPStaticMapping)) *DHCPStaticMapping {
+		m := &DHCPStaticMapping{
+			Name:   "foo",
+			Pool:   "LAN",
+			Subnet: subnet,
+			IP:     net.IPv4(192, 168, 1, 10),
+			MAC:    net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		}
+		if fn != nil {
+			fn(m)
+		}
+
+		return m
+	}
+
+	var tests = []struct {
+		desc string
+		m    *DHCPStaticMapping
+		ok   bool
+	}{
+		{
+			desc: "no pool",
+			m:    mapping(func(m *DHCPStaticMapping) { m.Pool = "" }),
+		},
+		{
+			desc: "name with whitespace",
+			m:    mapping(func(m *DHCPStaticMapping) { m.Name = "foo bar" }),
+		},
+		{
+			desc: "no IP address",
+			m:    mapping(func(m *DHCPStaticMapping) { m.IP = nil }),
+		},
+		{
+			desc: "IPv6 address",
+			m:    mapping(func(m *DHCPStaticMapping) { m.IP = net.ParseIP("fd00::10") }),
+		},
+		{
+			desc: "address outside subnet",
+			m:    mapping(func(m *DHCPStaticMapping) { m.IP = net.IPv4(192, 168, 2, 10) }),
+		},
+		{
+			desc: "EUI-64 MAC address",
+			m: mapping(func(m *DHCPStaticMapping) {
+				m.MAC = net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad, 0xbe, 0xef}
+			}),
+		},
+		{
+			desc: "OK",
+			m:    mapping(nil),
+			ok:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		err := tt.m.Validate()
+		if want, got := tt.ok, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PingResult contains the result of a ping performed by an EdgeMAX device.
+type PingResult struct {
+	Transmitted int
+	Received    int
+
+	// Loss is the percentage of packets which did not receive a reply.
+	Loss float64
+
+	// Round trip time statistics for replies.  These values are zero if
+	// no replies were received.
+	Min    time.Duration
+	Avg    time.Duration
+	Max    time.Duration
+	StdDev time.Duration
+}
+
+var (
+	// errPingNoSummary is returned when ping output contains no summary.
+	errPingNoSummary = errors.New("no summary found in ping output")
+
+	pingPacketsRe = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received.*?, ([\d.]+)% packet loss`)
+	pingRTTRe     = regexp.MustCompile(`= ([\d.]+)/([\d.]+)/([\d.]+)/([\d.]+) ms`)
+)
+
+// parsePing parses the summary of the output of the ping utility into a
+// PingResult.
+func parsePing(s string) (*PingResult, error) {
+	m := pingPacketsRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, errPingNoSummary
+	}
+
+	tx, err := strconv.Atoi(m[1])
+	if err != nil {
+		return nil, err
+	}
+	rx, err := strconv.Atoi(m[2])
+	if err != nil {
+		return nil, err
+	}
+	loss, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := &PingResult{
+		Transmitted: tx,
+		Received:    rx,
+		Loss:        loss,
+	}
+
+	// No round trip time summary is printed if no replies were received
+	m = pingRTTRe.FindStringSubmatch(s)
+	if m == nil {
+		return pr, nil
+	}
+
+	rtts := make([]time.Duration, 0, 4)
+	for _, str := range m[1:] {
+		d, err := parseMilliseconds(str)
+		if err != nil {
+			return nil, err
+		}
+
+		rtts = append(rtts, d)
+	}
+
+	pr.Min = rtts[0]
+	pr.Avg = rtts[1]
+	pr.Max = rtts[2]
+	pr.StdDev = rtts[3]
+
+	return pr, nil
+}
+
+// parseMilliseconds parses a fractional number of milliseconds, such as
+// "1.234", into a time.Duration.
+func parseMilliseconds(s string) (time.Duration, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(f * float64(time.Millisecond)), nil
+}
+
+// A TracerouteHop is a single hop reported by a traceroute performed by an
+// EdgeMAX device.
+type TracerouteHop struct {
+	// Number is the hop number, starting at 1.
+	Number int
+
+	// Host and IP identify the host which replied to probes for this hop.
+	// Host is empty if no reverse DNS name was reported, and both are
+	// empty if no replies were received.
+	Host string
+	IP   net.IP
+
+	// RTTs contains the round trip time of each probe which received a
+	// reply, and Lost is the number of probes which did not.
+	RTTs []time.Duration
+	Lost int
+}
+
+// parseTracerouteHop parses a single line of traceroute output into a
+// TracerouteHop.  ok is false if the line does not describe a hop, such as
+// the header line of the output.
+func parseTracerouteHop(s string) (hop *TracerouteHop, ok bool, err error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, false, nil
+	}
+
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		// Not a hop line
+		return nil, false, nil
+	}
+
+	hop = &TracerouteHop{
+		Number: n,
+		RTTs:   make([]time.Duration, 0),
+	}
+
+	fields = fields[1:]
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case f == "*":
+			hop.Lost++
+		case i+1 < len(fields) && fields[i+1] == "ms":
+			d, err := parseMilliseconds(f)
+			if err != nil {
+				return nil, false, err
+			}
+
+			hop.RTTs = append(hop.RTTs, d)
+			i++
+		case strings.HasPrefix(f, "(") && strings.HasSuffix(f, ")"):
+			// Address following a host name
+			hop.IP = net.ParseIP(strings.Trim(f, "()"))
+		default:
+			if ip := net.ParseIP(f); ip != nil {
+				hop.IP = ip
+				continue
+			}
+
+			hop.Host = f
+		}
+	}
+
+	return hop, true, nil
+}
+
+// A BandwidthTestResult contains the throughput measured by a bandwidth
+// test between two EdgeMAX devices.  Throughput values are in bits per
+// second.
+type BandwidthTestResult struct {
+	TransmitBPS int
+	ReceiveBPS  int
+	Duration    time.Duration
+}
+
+// UnmarshalJSON unmarshals JSON into a BandwidthTestResult.
+func (r *BandwidthTestResult) UnmarshalJSON(b []byte) error {
+	var v struct {
+		TXBPS    string `json:"tx_bps"`
+		RXBPS    string `json:"rx_bps"`
+		Duration string `json:"duration"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	ints, err := atoisOrZero(v.TXBPS, v.RXBPS, v.Duration)
+	if err != nil {
+		return err
+	}
+
+	*r = BandwidthTestResult{
+		TransmitBPS: ints[0],
+		ReceiveBPS:  ints[1],
+		Duration:    time.Duration(ints[2]) * time.Second,
+	}
+
+	return nil
+}
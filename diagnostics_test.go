@@ -0,0 +1,193 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_parsePing(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    string
+		pr   *PingResult
+		err  error
+	}{
+		{
+			desc: "no summary",
+			s:    "ping: unknown host foo",
+			err:  errPingNoSummary,
+		},
+		{
+			desc: "all packets lost",
+			s: `PING 192.0.2.1 (192.0.2.1) 56(84) bytes of data.
+
+--- 192.0.2.1 ping statistics ---
+5 packets transmitted, 0 received, 100% packet loss, time 4032ms
+`,
+			pr: &PingResult{
+				Transmitted: 5,
+				Loss:        100,
+			},
+		},
+		{
+			desc: "OK",
+			s: `PING 8.8.8.8 (8.8.8.8) 56(84) bytes of data.
+64 bytes from 8.8.8.8: icmp_req=1 ttl=57 time=10.5 ms
+64 bytes from 8.8.8.8: icmp_req=2 ttl=57 time=11.5 ms
+
+--- 8.8.8.8 ping statistics ---
+3 packets transmitted, 2 received, 33% packet loss, time 2003ms
+rtt min/avg/max/mdev = 10.500/11.000/11.500/0.500 ms
+`,
+			pr: &PingResult{
+				Transmitted: 3,
+				Received:    2,
+				Loss:        33,
+				Min:         10500 * time.Microsecond,
+				Avg:         11 * time.Millisecond,
+				Max:         11500 * time.Microsecond,
+				StdDev:      500 * time.Microsecond,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		pr, err := parsePing(tt.s)
+		if want, got := errStr(tt.err), errStr(err); want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.pr, pr; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected PingResult:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
+
+func Test_parseTracerouteHop(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    string
+		hop  *TracerouteHop
+		ok   bool
+	}{
+		{
+			desc: "empty line",
+		},
+		{
+			desc: "header line",
+			s:    "traceroute to 8.8.8.8 (8.8.8.8), 30 hops max, 60 byte packets",
+		},
+		{
+			desc: "all probes lost",
+			s:    " 2  * * *",
+			hop: &TracerouteHop{
+				Number: 2,
+				RTTs:   []time.Duration{},
+				Lost:   3,
+			},
+			ok: true,
+		},
+		{
+			desc: "numeric address",
+			s:    " 1  192.168.1.1  0.500 ms  0.250 ms  0.750 ms",
+			hop: &TracerouteHop{
+				Number: 1,
+				IP:     net.ParseIP("192.168.1.1"),
+				RTTs: []time.Duration{
+					500 * time.Microsecond,
+					250 * time.Microsecond,
+					750 * time.Microsecond,
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "host name and one lost probe",
+			s:    "10  host.example.com (2001:db8::1)  10.000 ms *  12.000 ms",
+			hop: &TracerouteHop{
+				Number: 10,
+				Host:   "host.example.com",
+				IP:     net.ParseIP("2001:db8::1"),
+				RTTs: []time.Duration{
+					10 * time.Millisecond,
+					12 * time.Millisecond,
+				},
+				Lost: 1,
+			},
+			ok: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		hop, ok, err := parseTracerouteHop(tt.s)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want, got := tt.ok, ok; want != got {
+			t.Fatalf("unexpected ok:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		if want, got := tt.hop, hop; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected TracerouteHop:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
+
+func TestBandwidthTestResultUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		r       *BandwidthTestResult
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid transmit bps",
+			b:       []byte(`{"tx_bps":"foo"}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK",
+			b:    []byte(`{"tx_bps":"940000000","rx_bps":"930000000","duration":"10"}`),
+			r: &BandwidthTestResult{
+				TransmitBPS: 940000000,
+				ReceiveBPS:  930000000,
+				Duration:    10 * time.Second,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		r := new(BandwidthTestResult)
+		err := r.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.r, r; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected BandwidthTestResult:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
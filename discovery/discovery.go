@@ -0,0 +1,283 @@
+// Package discovery implements the Ubiquiti discovery protocol, which can be
+// used to find EdgeMAX and other Ubiquiti devices on a local network.
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Port is the UDP port used by the Ubiquiti discovery protocol.
+const Port = 10001
+
+// DefaultTimeout is the amount of time Discover waits for replies when its
+// context has no deadline.
+const DefaultTimeout = 3 * time.Second
+
+// protocolVersionOne is version 1 of the discovery protocol.
+const protocolVersionOne = 0x01
+
+// Field types used in discovery replies.
+const (
+	fieldMAC      = 0x01
+	fieldMACIP    = 0x02
+	fieldFirmware = 0x03
+	fieldUptime   = 0x0a
+	fieldHostname = 0x0b
+	fieldPlatform = 0x0c
+	fieldModel    = 0x14
+)
+
+var (
+	// errInvalidPacket is returned when a discovery packet is malformed.
+	errInvalidPacket = errors.New("invalid discovery packet")
+
+	// request is the discovery request packet, which contains protocol
+	// version 1 and an empty payload.
+	request = []byte{protocolVersionOne, 0x00, 0x00, 0x00}
+)
+
+// A Device is a device which replied to a discovery request.
+type Device struct {
+	// MAC is the hardware address of the device.
+	MAC net.HardwareAddr
+
+	// Addresses are the IP addresses of the device's interfaces.
+	Addresses []net.IP
+
+	// Hostname is the configured hostname of the device.
+	Hostname string
+
+	// Platform is the short model identifier of the device, such
+	// as "ER-X".
+	Platform string
+
+	// Model is the full model name of the device, such as
+	// "EdgeRouter X 5-Port".
+	Model string
+
+	// Firmware is the firmware version string reported by the device.
+	Firmware string
+
+	// Uptime is the amount of time the device has been running.
+	Uptime time.Duration
+}
+
+// UnmarshalBinary unmarshals a discovery reply into a Device.
+func (d *Device) UnmarshalBinary(b []byte) error {
+	// Header: version, command, and payload length
+	if len(b) < 4 || b[0] != protocolVersionOne {
+		return errInvalidPacket
+	}
+
+	l := int(binary.BigEndian.Uint16(b[2:4]))
+	if l == 0 || len(b[4:]) < l {
+		return errInvalidPacket
+	}
+	b = b[4 : 4+l]
+
+	var dd Device
+	for len(b) > 0 {
+		// Each field is a type, 16-bit length, and value
+		if len(b) < 3 {
+			return errInvalidPacket
+		}
+
+		t, fl := b[0], int(binary.BigEndian.Uint16(b[1:3]))
+		if len(b[3:]) < fl {
+			return errInvalidPacket
+		}
+		v := b[3 : 3+fl]
+		b = b[3+fl:]
+
+		switch t {
+		case fieldMAC:
+			if len(v) != 6 {
+				return errInvalidPacket
+			}
+			dd.MAC = copyBytes(v)
+		case fieldMACIP:
+			if len(v) != 10 {
+				return errInvalidPacket
+			}
+			if dd.MAC == nil {
+				dd.MAC = copyBytes(v[:6])
+			}
+			dd.addAddress(net.IP(copyBytes(v[6:])))
+		case fieldFirmware:
+			dd.Firmware = string(v)
+		case fieldUptime:
+			if len(v) != 4 {
+				return errInvalidPacket
+			}
+			dd.Uptime = time.Duration(binary.BigEndian.Uint32(v)) * time.Second
+		case fieldHostname:
+			dd.Hostname = string(v)
+		case fieldPlatform:
+			dd.Platform = string(v)
+		case fieldModel:
+			dd.Model = string(v)
+		}
+	}
+
+	if dd.MAC == nil {
+		return errInvalidPacket
+	}
+
+	*d = dd
+	return nil
+}
+
+// addAddress adds ip to d's addresses, if it is not already present.
+func (d *Device) addAddress(ip net.IP) {
+	for _, a := range d.Addresses {
+		if a.Equal(ip) {
+			return
+		}
+	}
+
+	d.Addresses = append(d.Addresses, ip)
+}
+
+// Discover broadcasts a discovery request on the local network, and returns
+// all devices which reply before ctx is canceled or its deadline expires.
+// If ctx has no deadline, DefaultTimeout is used.
+//
+// Devices are returned in order of hardware address.  Replies from the same
+// device, such as from several of its interfaces, are merged.
+func Discover(ctx context.Context) ([]*Device, error) {
+	return discover(ctx, &net.UDPAddr{
+		IP:   net.IPv4bcast,
+		Port: Port,
+	}, false)
+}
+
+// ErrNoReply is returned by Query when a host does not reply to a discovery
+// request before its context is canceled or its deadline expires.
+var ErrNoReply = errors.New("no reply to discovery request")
+
+// Query sends a discovery request directly to the device at host, and returns
+// its reply.  host may be a hostname or IP address, with an optional port;
+// if no port is specified, Port is used.  If ctx has no deadline,
+// DefaultTimeout is used.
+//
+// Query is useful for identifying a device which is not on the local network,
+// where broadcast discovery requests cannot reach it.
+func Query(ctx context.Context, host string) (*Device, error) {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, strconv.Itoa(Port))
+	}
+
+	addr, err := net.ResolveUDPAddr("udp4", host)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := discover(ctx, addr, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) == 0 {
+		return nil, ErrNoReply
+	}
+
+	return devices[0], nil
+}
+
+// discover sends a discovery request to addr and collects replies.  If first
+// is true, discover returns as soon as a single device replies.
+func discover(ctx context.Context, addr *net.UDPAddr, first bool) ([]*Device, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Unblock reads early if ctx is canceled before its deadline
+	doneC := make(chan struct{})
+	defer close(doneC)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetReadDeadline(time.Unix(1, 0))
+		case <-doneC:
+		}
+	}()
+
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.WriteToUDP(request, addr); err != nil {
+		return nil, err
+	}
+
+	devices := make(map[string]*Device)
+	b := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFromUDP(b)
+		if err != nil {
+			if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+				break
+			}
+
+			return nil, err
+		}
+
+		var d Device
+		if err := d.UnmarshalBinary(b[:n]); err != nil {
+			// Ignore replies which cannot be parsed, since any host may
+			// send traffic to this port
+			continue
+		}
+
+		if dd, ok := devices[d.MAC.String()]; ok {
+			for _, a := range d.Addresses {
+				dd.addAddress(a)
+			}
+			continue
+		}
+
+		devices[d.MAC.String()] = &d
+
+		if first {
+			break
+		}
+	}
+
+	out := make([]*Device, 0, len(devices))
+	for _, d := range devices {
+		out = append(out, d)
+	}
+	sort.Sort(byMAC(out))
+
+	return out, nil
+}
+
+// byMAC is used to sort Devices by hardware address.
+type byMAC []*Device
+
+func (b byMAC) Len() int               { return len(b) }
+func (b byMAC) Less(i int, j int) bool { return bytes.Compare(b[i].MAC, b[j].MAC) < 0 }
+func (b byMAC) Swap(i int, j int)      { b[i], b[j] = b[j], b[i] }
+
+// copyBytes returns a copy of b, so that values do not retain references
+// to a packet buffer.
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
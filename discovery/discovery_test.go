@@ -0,0 +1,227 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDeviceUnmarshalBinary(t *testing.T) {
+	var tests = []struct {
+		desc string
+		b    []byte
+		d    *Device
+		err  error
+	}{
+		{
+			desc: "empty",
+			err:  errInvalidPacket,
+		},
+		{
+			desc: "bad version",
+			b:    []byte{0x02, 0x00, 0x00, 0x00},
+			err:  errInvalidPacket,
+		},
+		{
+			desc: "request",
+			b:    request,
+			err:  errInvalidPacket,
+		},
+		{
+			desc: "short payload",
+			b:    []byte{0x01, 0x00, 0x00, 0x10, 0x01},
+			err:  errInvalidPacket,
+		},
+		{
+			desc: "short field",
+			b: []byte{
+				0x01, 0x00, 0x00, 0x04,
+				0x0b, 0x00, 0x05, 'a',
+			},
+			err: errInvalidPacket,
+		},
+		{
+			desc: "bad MAC length",
+			b: []byte{
+				0x01, 0x00, 0x00, 0x04,
+				0x01, 0x00, 0x01, 0xde,
+			},
+			err: errInvalidPacket,
+		},
+		{
+			desc: "no MAC",
+			b: []byte{
+				0x01, 0x00, 0x00, 0x04,
+				0x0b, 0x00, 0x01, 'a',
+			},
+			err: errInvalidPacket,
+		},
+		{
+			desc: "OK",
+			b: []byte{
+				0x01, 0x00, 0x00, 0x4c,
+				// MAC
+				0x01, 0x00, 0x06, 0xde, 0xad, 0xbe, 0xef, 0xde, 0xad,
+				// MAC and IP, twice for the same address
+				0x02, 0x00, 0x0a, 0xde, 0xad, 0xbe, 0xef, 0xde, 0xad, 192, 168, 1, 1,
+				0x02, 0x00, 0x0a, 0xde, 0xad, 0xbe, 0xef, 0xde, 0xad, 192, 168, 1, 1,
+				// Firmware
+				0x03, 0x00, 0x08, 'v', '1', '.', '9', '.', '7', '+', '1',
+				// Uptime
+				0x0a, 0x00, 0x04, 0x00, 0x00, 0x00, 0x3c,
+				// Hostname
+				0x0b, 0x00, 0x02, 'g', 'w',
+				// Platform
+				0x0c, 0x00, 0x04, 'E', 'R', '-', 'X',
+				// Unknown
+				0xff, 0x00, 0x01, 0x00,
+				// Model
+				0x14, 0x00, 0x04, 'E', 'R', ' ', 'X',
+			},
+			d: &Device{
+				MAC:       net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+				Addresses: []net.IP{{192, 168, 1, 1}},
+				Hostname:  "gw",
+				Platform:  "ER-X",
+				Model:     "ER X",
+				Firmware:  "v1.9.7+1",
+				Uptime:    60 * time.Second,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		d := new(Device)
+		err := d.UnmarshalBinary(tt.b)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.d, d; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected Device:\n- want: %#v\n-  got: %#v", want, got)
+		}
+	}
+}
+
+func Test_discover(t *testing.T) {
+	pc, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	reply := func(mac byte, ip byte) []byte {
+		return []byte{
+			0x01, 0x00, 0x00, 0x0d,
+			0x02, 0x00, 0x0a, 0x00, 0x00, 0x00, 0x00, 0x00, mac, 192, 168, 1, ip,
+		}
+	}
+
+	// Reply as two devices, one of which has two addresses, and also send
+	// a malformed reply which must be ignored
+	go func() {
+		b := make([]byte, 128)
+		_, addr, err := pc.ReadFromUDP(b)
+		if err != nil {
+			panic(err)
+		}
+
+		for _, r := range [][]byte{
+			reply(2, 2),
+			{0xff},
+			reply(1, 1),
+			reply(2, 3),
+		} {
+			if _, err := pc.WriteToUDP(r, addr); err != nil {
+				panic(err)
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	devices, err := discover(ctx, pc.LocalAddr().(*net.UDPAddr), false)
+	if err != nil {
+		t.Fatalf("failed to discover: %v", err)
+	}
+
+	want := []*Device{
+		{
+			MAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+			Addresses: []net.IP{{192, 168, 1, 1}},
+		},
+		{
+			MAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+			Addresses: []net.IP{{192, 168, 1, 2}, {192, 168, 1, 3}},
+		},
+	}
+
+	if got := devices; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Devices:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	pc, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	go func() {
+		b := make([]byte, 128)
+		_, addr, err := pc.ReadFromUDP(b)
+		if err != nil {
+			panic(err)
+		}
+
+		r := []byte{
+			0x01, 0x00, 0x00, 0x14,
+			0x01, 0x00, 0x06, 0xde, 0xad, 0xbe, 0xef, 0xde, 0xad,
+			0x0b, 0x00, 0x08, 'g', 'a', 't', 'e', 'w', 'a', 'y', '1',
+		}
+		if _, err := pc.WriteToUDP(r, addr); err != nil {
+			panic(err)
+		}
+	}()
+
+	// A deadline much longer than the test is expected to take ensures that
+	// Query returns as soon as the device replies
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	d, err := Query(ctx, pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+
+	if want, got := "gateway1", d.Hostname; want != got {
+		t.Fatalf("unexpected hostname:\n- want: %q\n-  got: %q", want, got)
+	}
+}
+
+func TestQueryNoReply(t *testing.T) {
+	pc, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if want, got := ErrNoReply, func() error {
+		_, err := Query(ctx, pc.LocalAddr().String())
+		return err
+	}(); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
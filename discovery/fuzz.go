@@ -0,0 +1,15 @@
+//go:build gofuzz
+// +build gofuzz
+
+package discovery
+
+// Fuzz is a fuzz target for use with github.com/dvyukov/go-fuzz, which
+// tests Device.UnmarshalBinary.
+func Fuzz(data []byte) int {
+	var d Device
+	if err := d.UnmarshalBinary(data); err != nil {
+		return 0
+	}
+
+	return 1
+}
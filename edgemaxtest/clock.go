@@ -0,0 +1,99 @@
+package edgemaxtest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// A Clock is a fake edgemax.Clock whose time only changes when Advance is
+// called.  Clock can be assigned to edgemax.Client.Clock so that tests can
+// control time-dependent behavior deterministically.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+var _ edgemax.Clock = &Clock{}
+
+// A waiter is a pending call to Clock.After.
+type waiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewClock creates a Clock whose current time is now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now implements edgemax.Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After implements edgemax.Clock.  The returned channel receives a value once
+// Advance moves the Clock's time at least d into the future.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w := &waiter{
+		at: c.now.Add(d),
+		c:  make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		w.c <- c.now
+		return w.c
+	}
+
+	c.waiters = append(c.waiters, w)
+	return w.c
+}
+
+// Advance moves the Clock's time forward by d, and fires any calls to After
+// which expire as a result, in order of expiry.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	sort.Sort(byExpiry(c.waiters))
+
+	var pending []*waiter
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+
+		w.c <- w.at
+	}
+
+	c.waiters = pending
+}
+
+// Waiters returns the number of calls to After which have not yet expired.
+// Tests can poll Waiters to determine when code under test is waiting on
+// the Clock, before calling Advance.
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.waiters)
+}
+
+// byExpiry is used to sort waiters by expiry time.
+type byExpiry []*waiter
+
+func (b byExpiry) Len() int               { return len(b) }
+func (b byExpiry) Less(i int, j int) bool { return b[i].at.Before(b[j].at) }
+func (b byExpiry) Swap(i int, j int)      { b[i], b[j] = b[j], b[i] }
@@ -0,0 +1,57 @@
+package edgemaxtest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	c := NewClock(start)
+
+	if want, got := start, c.Now(); !want.Equal(got) {
+		t.Fatalf("unexpected time:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	select {
+	case <-c.After(0):
+	default:
+		t.Fatal("zero duration After did not fire immediately")
+	}
+
+	shortC := c.After(time.Second)
+	longC := c.After(time.Minute)
+
+	if want, got := 2, c.Waiters(); want != got {
+		t.Fatalf("unexpected number of waiters:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	c.Advance(30 * time.Second)
+
+	select {
+	case got := <-shortC:
+		if want := start.Add(time.Second); !want.Equal(got) {
+			t.Fatalf("unexpected expiry time:\n- want: %v\n-  got: %v", want, got)
+		}
+	default:
+		t.Fatal("short After did not fire")
+	}
+
+	select {
+	case <-longC:
+		t.Fatal("long After fired early")
+	default:
+	}
+
+	c.Advance(30 * time.Second)
+
+	select {
+	case <-longC:
+	default:
+		t.Fatal("long After did not fire")
+	}
+
+	if want, got := 0, c.Waiters(); want != got {
+		t.Fatalf("unexpected number of waiters:\n- want: %v\n-  got: %v", want, got)
+	}
+}
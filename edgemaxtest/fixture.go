@@ -0,0 +1,102 @@
+// Package edgemaxtest provides utilities for testing code which uses package
+// edgemax, without access to a real EdgeMAX device.
+package edgemaxtest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// A Fixture contains interactions with an EdgeMAX device, recorded using a
+// Recorder and served back using Replay.
+type Fixture struct {
+	// HTTP contains HTTP requests made to the device, and the device's
+	// responses, in the order they occurred.
+	HTTP []*Interaction `json:"http"`
+
+	// Websocket contains messages sent by the device using the statistics
+	// websocket, in the order they were received.
+	Websocket []string `json:"websocket"`
+}
+
+// An Interaction is a single HTTP request and response.
+type Interaction struct {
+	// Method and Path identify the request.  Path includes the query
+	// string, if any.
+	Method string `json:"method"`
+	Path   string `json:"path"`
+
+	// RequestBody is the body of the request.  Passwords in login
+	// requests are redacted.
+	RequestBody string `json:"request_body,omitempty"`
+
+	// Status, Header, and Body describe the response.  Only headers needed
+	// to replay the response are retained.
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// LoadFixture loads a Fixture from a JSON file at path.
+func LoadFixture(path string) (*Fixture, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := new(Fixture)
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Save saves a Fixture to a JSON file at path.
+func (f *Fixture) Save(path string) error {
+	b, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// replayHeaders are the response headers retained in an Interaction.
+var replayHeaders = []string{
+	"Content-Type",
+	"Location",
+	"Set-Cookie",
+}
+
+// interactionKey returns the key used to match a request to a recorded
+// Interaction.  Cache-busting query parameters, which differ for each
+// request, are ignored.
+func interactionKey(method string, u *url.URL) string {
+	q := u.Query()
+	q.Del("_")
+
+	path := u.Path
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	return method + " " + path
+}
+
+// redactBody removes passwords from the body of a login request.
+func redactBody(contentType string, body string) string {
+	if contentType != "application/x-www-form-urlencoded" {
+		return body
+	}
+
+	v, err := url.ParseQuery(body)
+	if err != nil || v.Get("password") == "" {
+		return body
+	}
+
+	v.Set("password", "REDACTED")
+	return v.Encode()
+}
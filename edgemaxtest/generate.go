@@ -0,0 +1,290 @@
+package edgemaxtest
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// dpiApps are the DPI traffic types and categories assigned to generated
+// hosts.
+var dpiApps = []struct {
+	Type     string
+	Category string
+}{
+	{Type: "YouTube", Category: "Media streaming services"},
+	{Type: "Netflix", Category: "Media streaming services"},
+	{Type: "HTTP", Category: "Web"},
+	{Type: "SSL/TLS", Category: "Web"},
+	{Type: "DNS", Category: "Network protocols"},
+	{Type: "NTP", Category: "Network protocols"},
+	{Type: "Steam", Category: "Games"},
+	{Type: "Skype", Category: "Instant messengers"},
+}
+
+// A Generator produces synthetic statistics which evolve over time in the
+// way a real EdgeMAX device's statistics do: uptime and traffic counters
+// increase, utilization and bandwidth wander, and DPI hosts appear and
+// disappear.  Generators can be used to demo and load test code which
+// consumes statistics, without a device.
+//
+// Generator implements edgemax.StatsClient.  Its methods are safe for
+// concurrent use.
+type Generator struct {
+	// Interval is the interval at which Stats produces statistics.  If
+	// zero, a default of one second is used.
+	Interval time.Duration
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	uptime time.Duration
+	cpu    float64
+	memory float64
+	ifaces []*genInterface
+	hosts  []*genHost
+	nextIP int
+
+	maxHosts int
+}
+
+var _ edgemax.StatsClient = &Generator{}
+
+// A genInterface is the state of a generated network interface.
+type genInterface struct {
+	ifi    edgemax.Interface
+	rxBPS  float64
+	txBPS  float64
+	maxBPS float64
+}
+
+// A genHost is the state of a generated DPI host.
+type genHost struct {
+	ip       net.IP
+	apps     []*edgemax.DPIStat
+	lifetime time.Duration
+}
+
+// NewGenerator creates a Generator with the specified number of network
+// interfaces and maximum number of concurrent DPI hosts.  Generators
+// created using the same seed produce the same statistics.
+func NewGenerator(seed int64, interfaces int, hosts int) *Generator {
+	g := &Generator{
+		rand:   rand.New(rand.NewSource(seed)),
+		cpu:    10,
+		memory: 30,
+
+		maxHosts: hosts,
+	}
+
+	for i := 0; i < interfaces; i++ {
+		g.ifaces = append(g.ifaces, &genInterface{
+			ifi: edgemax.Interface{
+				Name:            fmt.Sprintf("eth%d", i),
+				Up:              true,
+				Autonegotiation: true,
+				Duplex:          "full",
+				Speed:           1000,
+				MAC:             net.HardwareAddr{0x04, 0x18, 0xd6, 0x00, 0x00, byte(i)},
+				MTU:             1500,
+				Addresses:       []net.IP{net.IPv4(192, 168, byte(i), 1)},
+			},
+			maxBPS: 100e6 * (1 + g.rand.Float64()*9),
+		})
+	}
+
+	// Each host has a random lifetime, so that hosts do not all disappear
+	// at once
+	for i := 0; i < hosts; i++ {
+		g.addHost()
+	}
+
+	return g
+}
+
+// Next advances the Generator's simulated time by elapsed, and returns the
+// resulting SystemStats, Interfaces, and DPIStats.
+func (g *Generator) Next(elapsed time.Duration) []edgemax.Stat {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.uptime += elapsed
+
+	// Utilization wanders, with memory changing more slowly than CPU
+	g.cpu = walk(g.rand, g.cpu, 10, 1, 100)
+	g.memory = walk(g.rand, g.memory, 1, 5, 95)
+
+	ifis := make(edgemax.Interfaces, 0, len(g.ifaces))
+	for _, gi := range g.ifaces {
+		ifis = append(ifis, gi.next(g.rand, elapsed))
+	}
+
+	var ds edgemax.DPIStats
+	hosts := g.hosts[:0]
+	for _, h := range g.hosts {
+		h.lifetime -= elapsed
+		if h.lifetime <= 0 {
+			continue
+		}
+
+		hosts = append(hosts, h)
+		ds = append(ds, h.next(g.rand, elapsed)...)
+	}
+
+	// Hosts which disappeared are replaced by new hosts, which appear in
+	// the next set of statistics
+	g.hosts = hosts
+	for len(g.hosts) < g.maxHosts {
+		g.addHost()
+	}
+
+	return []edgemax.Stat{
+		&edgemax.SystemStats{
+			CPU:    int(g.cpu),
+			Uptime: g.uptime,
+			Memory: int(g.memory),
+		},
+		ifis,
+		ds,
+	}
+}
+
+// Stats implements edgemax.StatsClient, producing statistics of the
+// specified types every Interval until done is called.  If no types are
+// specified, all types are produced.
+func (g *Generator) Stats(stats ...edgemax.StatType) (chan edgemax.Stat, func() error, error) {
+	want := make(map[edgemax.StatType]bool, len(stats))
+	for _, s := range stats {
+		want[s] = true
+	}
+
+	interval := g.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	statC := make(chan edgemax.Stat)
+	doneC := make(chan struct{})
+	stoppedC := make(chan struct{})
+
+	go func() {
+		defer close(stoppedC)
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			for _, s := range g.Next(interval) {
+				if len(want) > 0 && !want[s.StatType()] {
+					continue
+				}
+
+				select {
+				case statC <- s:
+				case <-doneC:
+					return
+				}
+			}
+
+			select {
+			case <-t.C:
+			case <-doneC:
+				return
+			}
+		}
+	}()
+
+	done := func() error {
+		close(doneC)
+		<-stoppedC
+		close(statC)
+		return nil
+	}
+
+	return statC, done, nil
+}
+
+// addHost adds a new DPI host with a random set of applications.
+func (g *Generator) addHost() {
+	g.nextIP++
+	ip := net.IPv4(192, 168, 1, byte(10+g.nextIP%240))
+
+	n := 1 + g.rand.Intn(3)
+	apps := make([]*edgemax.DPIStat, 0, n)
+	for _, i := range g.rand.Perm(len(dpiApps))[:n] {
+		apps = append(apps, &edgemax.DPIStat{
+			IP:       ip,
+			Type:     dpiApps[i].Type,
+			Category: dpiApps[i].Category,
+		})
+	}
+
+	g.hosts = append(g.hosts, &genHost{
+		ip:       ip,
+		apps:     apps,
+		lifetime: time.Duration(10+g.rand.Intn(290)) * time.Second,
+	})
+}
+
+// next advances an interface's counters by elapsed, and returns a copy of
+// the interface.
+func (gi *genInterface) next(r *rand.Rand, elapsed time.Duration) *edgemax.Interface {
+	gi.rxBPS = walk(r, gi.rxBPS, gi.maxBPS/10, 0, gi.maxBPS)
+	gi.txBPS = walk(r, gi.txBPS, gi.maxBPS/20, 0, gi.maxBPS/2)
+
+	rxBytes := uint64(gi.rxBPS / 8 * elapsed.Seconds())
+	txBytes := uint64(gi.txBPS / 8 * elapsed.Seconds())
+
+	s := &gi.ifi.Stats
+	s.ReceiveBytes += rxBytes
+	s.TransmitBytes += txBytes
+	s.ReceivePackets += rxBytes / 800
+	s.TransmitPackets += txBytes / 800
+	s.ReceiveBPS = uint64(gi.rxBPS)
+	s.TransmitBPS = uint64(gi.txBPS)
+
+	// Errors and drops are rare
+	if r.Intn(100) == 0 {
+		s.ReceiveErrors++
+	}
+	if r.Intn(50) == 0 {
+		s.ReceiveDropped++
+	}
+
+	ifi := gi.ifi
+	return &ifi
+}
+
+// next advances a host's counters by elapsed, and returns copies of its
+// DPIStats.
+func (h *genHost) next(r *rand.Rand, elapsed time.Duration) edgemax.DPIStats {
+	ds := make(edgemax.DPIStats, 0, len(h.apps))
+	for _, a := range h.apps {
+		a.ReceiveRate = uint64(r.Intn(1 << 20))
+		a.TransmitRate = a.ReceiveRate / uint64(1+r.Intn(10))
+		a.ReceiveBytes += uint64(float64(a.ReceiveRate) * elapsed.Seconds())
+		a.TransmitBytes += uint64(float64(a.TransmitRate) * elapsed.Seconds())
+
+		d := *a
+		ds = append(ds, &d)
+	}
+
+	return ds
+}
+
+// walk moves v by a random amount of at most step in either direction,
+// constrained between min and max.
+func walk(r *rand.Rand, v float64, step float64, min float64, max float64) float64 {
+	v += (r.Float64()*2 - 1) * step
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	default:
+		return v
+	}
+}
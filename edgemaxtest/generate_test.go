@@ -0,0 +1,95 @@
+package edgemaxtest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestGeneratorDeterministic(t *testing.T) {
+	a := NewGenerator(1, 2, 4)
+	b := NewGenerator(1, 2, 4)
+
+	for i := 0; i < 10; i++ {
+		if want, got := a.Next(time.Second), b.Next(time.Second); !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] generators with the same seed produced different statistics", i)
+		}
+	}
+}
+
+func TestGeneratorEvolves(t *testing.T) {
+	const (
+		hosts = 4
+		step  = 10 * time.Second
+	)
+
+	g := NewGenerator(1, 2, hosts)
+
+	var (
+		prevUptime time.Duration
+		prevBytes  = make(map[string]uint64)
+		ips        = make(map[string]struct{})
+	)
+
+	for i := 0; i < 100; i++ {
+		stats := g.Next(step)
+
+		ss := stats[0].(*edgemax.SystemStats)
+		if ss.Uptime <= prevUptime {
+			t.Fatalf("[%02d] uptime did not increase: %v -> %v", i, prevUptime, ss.Uptime)
+		}
+		prevUptime = ss.Uptime
+
+		if ss.CPU < 0 || ss.CPU > 100 || ss.Memory < 0 || ss.Memory > 100 {
+			t.Fatalf("[%02d] utilization out of range: %+v", i, ss)
+		}
+
+		ifis := stats[1].(edgemax.Interfaces)
+		if want, got := 2, len(ifis); want != got {
+			t.Fatalf("[%02d] unexpected number of interfaces:\n- want: %v\n-  got: %v", i, want, got)
+		}
+
+		for _, ifi := range ifis {
+			if ifi.Stats.ReceiveBytes < prevBytes[ifi.Name] {
+				t.Fatalf("[%02d] %s receive bytes decreased", i, ifi.Name)
+			}
+			prevBytes[ifi.Name] = ifi.Stats.ReceiveBytes
+		}
+
+		for _, d := range stats[2].(edgemax.DPIStats) {
+			ips[d.IP.String()] = struct{}{}
+		}
+	}
+
+	// Hosts must have appeared and disappeared over time
+	if len(ips) <= hosts {
+		t.Fatalf("expected more than %d DPI hosts over time, but got %d", hosts, len(ips))
+	}
+}
+
+func TestGeneratorStats(t *testing.T) {
+	g := NewGenerator(1, 1, 1)
+	g.Interval = time.Millisecond
+
+	statC, done, err := g.Stats(edgemax.StatTypeInterfaces)
+	if err != nil {
+		t.Fatalf("failed to start stats: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		s := <-statC
+		if want, got := edgemax.StatTypeInterfaces, s.StatType(); want != got {
+			t.Fatalf("unexpected stat type:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+
+	if err := done(); err != nil {
+		t.Fatalf("failed to stop stats: %v", err)
+	}
+
+	if _, ok := <-statC; ok {
+		t.Fatal("stats channel was not closed")
+	}
+}
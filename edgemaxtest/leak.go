@@ -0,0 +1,89 @@
+package edgemaxtest
+
+import (
+	"bytes"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakTimeout is the amount of time CheckGoroutines waits for goroutines to
+// exit before reporting them as leaked.
+const leakTimeout = 2 * time.Second
+
+// goroutineHeader matches the header of each goroutine in a stack dump.
+var goroutineHeader = regexp.MustCompile(`^goroutine (\d+) \[`)
+
+// CheckGoroutines returns a function which reports a test failure if any
+// goroutine started by package edgemax or its subpackages after the call
+// to CheckGoroutines is still running.  It is typically deferred at the
+// beginning of a test:
+//
+//	defer edgemaxtest.CheckGoroutines(t)()
+//
+// Goroutines are given a short grace period to exit.  Goroutines which
+// only run code from other packages, such as idle HTTP connections, are
+// ignored.
+func CheckGoroutines(t testing.TB) func() {
+	before := goroutines()
+
+	return func() {
+		var leaked []string
+
+		deadline := time.Now().Add(leakTimeout)
+		for {
+			leaked = leaked[:0]
+			for id, stack := range goroutines() {
+				if _, ok := before[id]; ok {
+					continue
+				}
+
+				// Only goroutines running this package's code are of
+				// interest
+				if !strings.Contains(stack, "github.com/mdlayher/edgemax") {
+					continue
+				}
+
+				leaked = append(leaked, stack)
+			}
+
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if len(leaked) > 0 {
+			t.Errorf("%d goroutine(s) leaked:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+		}
+	}
+}
+
+// goroutines returns the stacks of all running goroutines, keyed by ID.
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+
+		buf = make([]byte, 2*len(buf))
+	}
+
+	out := make(map[string]string)
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		m := goroutineHeader.FindSubmatch(stack)
+		if m == nil {
+			continue
+		}
+
+		out[string(m[1])] = string(stack)
+	}
+
+	return out
+}
@@ -0,0 +1,116 @@
+package edgemaxtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestCheckGoroutines(t *testing.T) {
+	ft := &fakeTB{TB: t}
+	check := CheckGoroutines(ft)
+
+	// A running Generator.Stats goroutine which has not been stopped is
+	// reported as a leak
+	g := NewGenerator(1, 1, 1)
+	g.Interval = time.Hour
+	statC, done, _ := g.Stats()
+	<-statC
+
+	check()
+	if !ft.failed {
+		t.Fatal("expected leaked goroutine to be reported")
+	}
+
+	if err := done(); err != nil {
+		t.Fatalf("failed to stop stats: %v", err)
+	}
+
+	ft = &fakeTB{TB: t}
+	CheckGoroutines(ft)()
+	if ft.failed {
+		t.Fatal("unexpected leaked goroutine reported")
+	}
+}
+
+func TestStatsNoLeaks(t *testing.T) {
+	dev := testDevice(t)
+	defer dev.Close()
+
+	defer CheckGoroutines(t)()
+
+	c, err := edgemax.NewClient(dev.URL, edgemax.InsecureHTTPClient(5*time.Second))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.Login("ubnt", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	statC, done, err := c.Stats(edgemax.StatTypeSystemStats)
+	if err != nil {
+		t.Fatalf("failed to start stats: %v", err)
+	}
+	<-statC
+
+	drainedC := make(chan struct{})
+	go func() {
+		defer close(drainedC)
+		for range statC {
+		}
+	}()
+
+	if err := done(); err != nil {
+		t.Fatalf("failed to stop stats: %v", err)
+	}
+	<-drainedC
+}
+
+func TestStatsNoLeaksWithoutReader(t *testing.T) {
+	dev := testDevice(t)
+	defer dev.Close()
+
+	defer CheckGoroutines(t)()
+
+	c, err := edgemax.NewClient(dev.URL, edgemax.InsecureHTTPClient(5*time.Second))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.Login("ubnt", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	_, done, err := c.Stats(edgemax.StatTypeSystemStats)
+	if err != nil {
+		t.Fatalf("failed to start stats: %v", err)
+	}
+
+	// Give the device time to send statistics which are never read
+	time.Sleep(100 * time.Millisecond)
+
+	errC := make(chan error)
+	go func() {
+		errC <- done()
+	}()
+
+	select {
+	case err := <-errC:
+		if err != nil {
+			t.Fatalf("failed to stop stats: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out stopping stats with no reader")
+	}
+}
+
+// A fakeTB is a testing.TB which records failures rather than failing a
+// test.
+type fakeTB struct {
+	testing.TB
+	failed bool
+}
+
+func (t *fakeTB) Errorf(format string, v ...interface{}) { t.failed = true }
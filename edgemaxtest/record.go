@@ -0,0 +1,225 @@
+package edgemaxtest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// A Recorder is a proxy which forwards requests to an EdgeMAX device and
+// records the device's responses in a Fixture.
+//
+// A Client should be created using URL and an HTTP client which accepts
+// the Recorder's self-signed certificate, such as one created using
+// edgemax.InsecureHTTPClient.
+type Recorder struct {
+	// URL is the address which clients should use in place of the
+	// device's address.
+	URL string
+
+	srv    *httptest.Server
+	target *url.URL
+	rt     http.RoundTripper
+	tls    *tls.Config
+
+	mu sync.Mutex
+	f  Fixture
+}
+
+// NewRecorder creates a Recorder which forwards requests to the EdgeMAX
+// device at addr using rt.  If rt is nil, http.DefaultTransport is used.
+// Close must be called to stop the Recorder.
+func NewRecorder(addr string, rt http.RoundTripper) (*Recorder, error) {
+	target, err := url.Parse(strings.TrimRight(addr, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	r := &Recorder{
+		target: target,
+		rt:     rt,
+	}
+
+	// Use the same TLS configuration for websocket connections, so that
+	// insecure transports can also apply to them
+	if tr, ok := rt.(*http.Transport); ok {
+		r.tls = tr.TLSClientConfig
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/ws/stats", websocket.Server{Handler: r.serveWebsocket})
+	mux.HandleFunc("/", r.serveHTTP)
+
+	r.srv = httptest.NewTLSServer(mux)
+	r.URL = r.srv.URL
+
+	return r, nil
+}
+
+// Close stops the Recorder.
+func (r *Recorder) Close() {
+	r.srv.Close()
+}
+
+// Fixture returns a copy of the interactions recorded so far.
+func (r *Recorder) Fixture() *Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := &Fixture{
+		HTTP:      make([]*Interaction, len(r.f.HTTP)),
+		Websocket: make([]string, len(r.f.Websocket)),
+	}
+	copy(f.HTTP, r.f.HTTP)
+	copy(f.Websocket, r.f.Websocket)
+
+	return f
+}
+
+// serveHTTP forwards an HTTP request to the device, and records the result.
+func (r *Recorder) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	out, err := http.NewRequest(req.Method, r.target.ResolveReference(req.URL).String(), bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for k, v := range req.Header {
+		out.Header[k] = v
+	}
+
+	// Devices check the Referer of API requests
+	if req.Header.Get("Referer") != "" {
+		out.Header.Set("Referer", r.target.String())
+	}
+
+	res, err := r.rt.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	ia := &Interaction{
+		Method:      req.Method,
+		Path:        req.URL.RequestURI(),
+		RequestBody: redactBody(req.Header.Get("Content-Type"), string(body)),
+		Status:      res.StatusCode,
+		Header:      make(http.Header),
+		Body:        string(resBody),
+	}
+
+	for _, k := range replayHeaders {
+		for _, v := range res.Header[k] {
+			// Redirects must point back at the Recorder
+			if k == "Location" {
+				v = strings.Replace(v, r.target.String(), r.URL, 1)
+			}
+
+			ia.Header.Add(k, v)
+		}
+	}
+	if len(ia.Header) == 0 {
+		ia.Header = nil
+	}
+
+	r.mu.Lock()
+	r.f.HTTP = append(r.f.HTTP, ia)
+	r.mu.Unlock()
+
+	writeInteraction(w, ia)
+}
+
+// serveWebsocket forwards websocket messages between a client and the
+// device, and records the messages sent by the device.
+func (r *Recorder) serveWebsocket(ws *websocket.Conn) {
+	wsURL := *r.target
+	wsURL.Scheme = "wss"
+	wsURL.Path = "/ws/stats"
+
+	cfg, err := websocket.NewConfig(wsURL.String(), r.target.String())
+	if err != nil {
+		return
+	}
+	cfg.TlsConfig = r.tls
+
+	dev, err := websocket.DialConfig(cfg)
+	if err != nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	// Closing either connection stops forwarding in both directions
+	go func() {
+		defer wg.Done()
+		defer dev.Close()
+		_ = forward(dev, ws, nil)
+	}()
+
+	go func() {
+		defer wg.Done()
+		defer ws.Close()
+		_ = forward(ws, dev, func(msg string) {
+			r.mu.Lock()
+			r.f.Websocket = append(r.f.Websocket, msg)
+			r.mu.Unlock()
+		})
+	}()
+
+	wg.Wait()
+}
+
+// forward sends messages received from src to dst until an error occurs,
+// invoking fn with each message if fn is not nil.
+func forward(dst *websocket.Conn, src *websocket.Conn, fn func(msg string)) error {
+	for {
+		var msg string
+		if err := websocket.Message.Receive(src, &msg); err != nil {
+			return err
+		}
+
+		if fn != nil {
+			fn(msg)
+		}
+
+		if err := websocket.Message.Send(dst, msg); err != nil {
+			return err
+		}
+	}
+}
+
+// writeInteraction writes the response recorded in ia to w.
+func writeInteraction(w http.ResponseWriter, ia *Interaction) {
+	for k, v := range ia.Header {
+		w.Header()[k] = v
+	}
+
+	w.WriteHeader(ia.Status)
+	_, _ = io.WriteString(w, ia.Body)
+}
@@ -0,0 +1,167 @@
+package edgemaxtest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+	"golang.org/x/net/websocket"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dev := testDevice(t)
+	defer dev.Close()
+
+	rec, err := NewRecorder(dev.URL, edgemax.InsecureHTTPClient(0).Transport)
+	if err != nil {
+		t.Fatalf("failed to create recorder: %v", err)
+	}
+
+	want := testSession(t, rec.URL)
+	rec.Close()
+
+	f := rec.Fixture()
+
+	if want, got := 1, len(f.Websocket); want != got {
+		t.Fatalf("unexpected number of websocket messages:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	login := f.HTTP[0]
+	if want, got := "/", login.Path; want != got {
+		t.Fatalf("unexpected first request path:\n- want: %v\n-  got: %v", want, got)
+	}
+	if strings.Contains(login.RequestBody, "secret") {
+		t.Fatalf("password was not redacted from login request: %q", login.RequestBody)
+	}
+
+	// Round trip the fixture through a file before replaying it
+	dir, err := ioutil.TempDir("", "edgemaxtest")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "fixture.json")
+	if err := f.Save(path); err != nil {
+		t.Fatalf("failed to save fixture: %v", err)
+	}
+
+	f, err = LoadFixture(path)
+	if err != nil {
+		t.Fatalf("failed to load fixture: %v", err)
+	}
+
+	srv := Replay(f)
+	defer srv.Close()
+
+	if got := testSession(t, srv.URL); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected replayed session:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestReplayNoInteraction(t *testing.T) {
+	srv := Replay(&Fixture{})
+	defer srv.Close()
+
+	res, err := edgemax.InsecureHTTPClient(0).Get(srv.URL + "/api/edge/data.json?data=sys_images")
+	if err != nil {
+		t.Fatalf("failed to perform request: %v", err)
+	}
+	_ = res.Body.Close()
+
+	if want, got := http.StatusNotFound, res.StatusCode; want != got {
+		t.Fatalf("unexpected status code:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+// testSession logs in to the device at addr, and retrieves SystemImages and
+// a single Stat.
+func testSession(t *testing.T, addr string) []interface{} {
+	c, err := edgemax.NewClient(addr, edgemax.InsecureHTTPClient(5*time.Second))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.Login("ubnt", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	si, err := c.SystemImages()
+	if err != nil {
+		t.Fatalf("failed to retrieve system images: %v", err)
+	}
+
+	statC, done, err := c.Stats(edgemax.StatTypeSystemStats)
+	if err != nil {
+		t.Fatalf("failed to start stats: %v", err)
+	}
+
+	s := <-statC
+
+	go func() {
+		for range statC {
+		}
+	}()
+	if err := done(); err != nil {
+		t.Fatalf("failed to stop stats: %v", err)
+	}
+
+	return []interface{}{si, s}
+}
+
+// testDevice starts a server which imitates an EdgeMAX device.
+func testDevice(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.FormValue("password") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "PHPSESSID", Value: "deadbeef"})
+	})
+
+	mux.HandleFunc("/api/edge/data.json", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("PHPSESSID"); err != nil || c.Value != "deadbeef" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"success":"1","output":{"current":"v1.9.0","default":"v1.9.0","free":"1024"}}`))
+	})
+
+	mux.HandleFunc("/api/edge/heartbeat.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"success":true,"PING":true,"SESSION":true}`))
+	})
+
+	mux.Handle("/ws/stats", websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		var sub string
+		if err := websocket.Message.Receive(ws, &sub); err != nil {
+			return
+		}
+
+		msg := `{"system-stats":{"cpu":"10","uptime":"60","mem":"20"}}`
+		if err := websocket.Message.Send(ws, strconv.Itoa(len(msg))+"\n"+msg); err != nil {
+			return
+		}
+
+		for {
+			if err := websocket.Message.Receive(ws, &sub); err != nil {
+				return
+			}
+		}
+	}})
+
+	return httptest.NewTLSServer(mux)
+}
@@ -0,0 +1,103 @@
+package edgemaxtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// Replay starts a server which serves back the interactions recorded in f.
+// A Client should be created using the server's URL and an HTTP client which
+// accepts the server's self-signed certificate, such as one created using
+// edgemax.InsecureHTTPClient.  The server must be closed when it is no longer
+// needed.
+//
+// Requests are matched to interactions by method and path.  Repeated
+// requests receive the matching interactions in the order they were
+// recorded, and the last matching interaction is repeated once all of them
+// are used, so that periodic requests such as heartbeats can be replayed
+// indefinitely.  Requests with no matching interaction receive a 404.
+//
+// Each statistics websocket connection receives all recorded websocket
+// messages in order, once it has sent its subscription.
+func Replay(f *Fixture) *httptest.Server {
+	rp := &replayer{
+		f:    f,
+		used: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/ws/stats", websocket.Server{Handler: rp.serveWebsocket})
+	mux.HandleFunc("/", rp.serveHTTP)
+
+	return httptest.NewTLSServer(mux)
+}
+
+// A replayer serves the interactions in a Fixture.
+type replayer struct {
+	f *Fixture
+
+	mu   sync.Mutex
+	used map[string]int
+}
+
+// serveHTTP serves the next recorded interaction which matches r.
+func (rp *replayer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	key := interactionKey(r.Method, r.URL)
+
+	rp.mu.Lock()
+	var matches []*Interaction
+	for _, ia := range rp.f.HTTP {
+		if ia.Method != r.Method {
+			continue
+		}
+
+		// Recorded paths are always valid, as they were parsed from a
+		// real request
+		u, err := r.URL.Parse(ia.Path)
+		if err != nil || interactionKey(ia.Method, u) != key {
+			continue
+		}
+
+		matches = append(matches, ia)
+	}
+
+	i := rp.used[key]
+	if i < len(matches)-1 {
+		rp.used[key]++
+	}
+	rp.mu.Unlock()
+
+	if len(matches) == 0 {
+		http.Error(w, "no recorded interaction for "+key, http.StatusNotFound)
+		return
+	}
+
+	writeInteraction(w, matches[i])
+}
+
+// serveWebsocket sends all recorded websocket messages to ws after it sends
+// its subscription, and then waits for ws to be closed.
+func (rp *replayer) serveWebsocket(ws *websocket.Conn) {
+	defer ws.Close()
+
+	var sub string
+	if err := websocket.Message.Receive(ws, &sub); err != nil {
+		return
+	}
+
+	for _, msg := range rp.f.Websocket {
+		if err := websocket.Message.Send(ws, msg); err != nil {
+			return
+		}
+	}
+
+	// Discard further messages, such as unsubscriptions
+	for {
+		if err := websocket.Message.Receive(ws, &sub); err != nil {
+			return
+		}
+	}
+}
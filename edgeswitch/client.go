@@ -0,0 +1,210 @@
+// Package edgeswitch implements a client for Ubiquiti EdgeSwitch devices.
+//
+// EdgeSwitch devices are managed using a different API than EdgeRouters, but
+// are commonly deployed alongside them.  Use edgemax.InsecureHTTPClient to
+// create an HTTP client for devices which do not have a valid TLS
+// certificate.
+package edgeswitch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// userAgent is the default user agent this package will report to the
+	// EdgeSwitch device.
+	userAgent = "github.com/mdlayher/edgemax/edgeswitch"
+
+	// tokenHeader is the HTTP header used to carry a session token.
+	tokenHeader = "x-auth-token"
+)
+
+// ErrNotLoggedIn is returned when a request is made using a Client before
+// Client.Login has been called.
+var ErrNotLoggedIn = errors.New("not logged in to EdgeSwitch device")
+
+// A Client is a client for a Ubiquiti EdgeSwitch device.
+//
+// Client.Login must be called and return a nil error before any additional
+// actions can be performed with a Client.
+type Client struct {
+	UserAgent string
+
+	apiURL *url.URL
+	client *http.Client
+
+	mu    sync.RWMutex
+	token string
+}
+
+// NewClient creates a new Client, using the input EdgeSwitch device address
+// and an optional HTTP client.  If no HTTP client is specified, a default
+// one will be used.
+func NewClient(addr string, client *http.Client) (*Client, error) {
+	// Trim trailing slash to ensure sane path creation in other methods
+	u, err := url.Parse(strings.TrimRight(addr, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if client == nil {
+		client = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	return &Client{
+		UserAgent: userAgent,
+
+		apiURL: u,
+		client: client,
+	}, nil
+}
+
+// Login authenticates against the EdgeSwitch device using the specified
+// username and password.
+func (c *Client) Login(ctx context.Context, username string, password string) error {
+	b, err := json.Marshal(struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/v1.0/user/login", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	res, err := c.do(req, nil)
+	if err != nil {
+		return err
+	}
+
+	token := res.Header.Get(tokenHeader)
+	if token == "" {
+		return errors.New("no session token returned by EdgeSwitch device")
+	}
+
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+
+	return nil
+}
+
+// get performs an HTTP GET request to endpoint, and unmarshals the response
+// onto v.
+func (c *Client) get(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := c.newAuthRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(req, v)
+	return err
+}
+
+// put performs an HTTP PUT request to endpoint with in as its JSON body.
+func (c *Client) put(ctx context.Context, endpoint string, in interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.newAuthRequest(ctx, http.MethodPut, endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(req, nil)
+	return err
+}
+
+// newAuthRequest is like newRequest, but also adds the session token
+// obtained by Login.
+func (c *Client) newAuthRequest(ctx context.Context, method string, endpoint string, body io.Reader) (*http.Request, error) {
+	c.mu.RLock()
+	token := c.token
+	c.mu.RUnlock()
+
+	if token == "" {
+		return nil, ErrNotLoggedIn
+	}
+
+	req, err := c.newRequest(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(tokenHeader, token)
+
+	return req, nil
+}
+
+// newRequest creates a new HTTP request, using the specified HTTP method,
+// API endpoint, and optional JSON request body.
+func (c *Client) newRequest(ctx context.Context, method string, endpoint string, body io.Reader) (*http.Request, error) {
+	rel, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u := c.apiURL.ResolveReference(rel)
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	return req.WithContext(ctx), nil
+}
+
+// do performs an HTTP request using req and unmarshals the result onto v, if
+// v is not nil.  Responses with a non-2xx status are reported as errors.
+func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		// The device may describe the failure in its response body
+		var e struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&e)
+
+		if e.Message != "" {
+			return nil, fmt.Errorf("EdgeSwitch request %s %s failed: %s: %s",
+				req.Method, req.URL.Path, res.Status, e.Message)
+		}
+
+		return nil, fmt.Errorf("EdgeSwitch request %s %s failed: %s",
+			req.Method, req.URL.Path, res.Status)
+	}
+
+	if v == nil {
+		return res, nil
+	}
+
+	return res, json.NewDecoder(res.Body).Decode(v)
+}
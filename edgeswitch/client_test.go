@@ -0,0 +1,107 @@
+package edgeswitch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientLogin(t *testing.T) {
+	h := testHandler(t, http.MethodPost, "/api/v1.0/user/login")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		var v struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Fatalf("failed to decode login request: %v", err)
+		}
+
+		if v.Username != "ubnt" || v.Password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Invalid credentials"}`))
+			return
+		}
+
+		w.Header().Set(tokenHeader, "deadbeef")
+	})
+	defer done()
+
+	// Discard the token provided by testClient
+	c.token = ""
+
+	err := c.Login(context.Background(), "ubnt", "foo")
+	if want, got := "EdgeSwitch request POST /api/v1.0/user/login failed: 401 Unauthorized: Invalid credentials", errString(err); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if err := c.Login(context.Background(), "ubnt", "secret"); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	if want, got := "deadbeef", c.token; want != got {
+		t.Fatalf("unexpected session token:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientNotLoggedIn(t *testing.T) {
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("no request should be made before logging in")
+	})
+	defer done()
+
+	c.token = ""
+
+	if want, got := ErrNotLoggedIn, func() error {
+		_, err := c.Ports(context.Background())
+		return err
+	}(); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+// testClient creates a Client which is logged in to a test server which
+// handles requests using fn.
+func testClient(t *testing.T, fn func(w http.ResponseWriter, r *http.Request)) (*Client, func()) {
+	s := httptest.NewServer(http.HandlerFunc(fn))
+
+	c, err := NewClient(s.URL, nil)
+	if err != nil {
+		t.Fatalf("error creating Client: %v", err)
+	}
+	c.token = "token"
+
+	return c, func() { s.Close() }
+}
+
+func testHandler(t *testing.T, method string, path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if want, got := method, r.Method; want != got {
+			t.Fatalf("unexpected HTTP method:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		if want, got := path, r.URL.Path; want != got {
+			t.Fatalf("unexpected URL path:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		if r.URL.Path == "/api/v1.0/user/login" {
+			return
+		}
+
+		if want, got := "token", r.Header.Get(tokenHeader); want != got {
+			t.Fatalf("unexpected session token:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
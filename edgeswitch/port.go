@@ -0,0 +1,217 @@
+package edgeswitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// A PoEMode is the Power over Ethernet output mode of a switch port.
+type PoEMode string
+
+const (
+	// PoEOff disables PoE output.
+	PoEOff PoEMode = "off"
+
+	// PoEAuto enables 802.3af/at PoE output for compliant devices.
+	PoEAuto PoEMode = "auto"
+
+	// PoEPassive24V enables 24V passive PoE output.
+	PoEPassive24V PoEMode = "24v"
+
+	// PoEPassive24VFourPair enables 24V passive PoE output on all four
+	// pairs.
+	PoEPassive24VFourPair PoEMode = "24v-4pair"
+)
+
+// A Port is a switch port on an EdgeSwitch device.
+type Port struct {
+	// ID is the identifier of the port, such as "0/1".
+	ID   string
+	Name string
+	MAC  net.HardwareAddr
+
+	// Enabled reports whether the port is administratively enabled, and
+	// Up reports whether a link is established.
+	Enabled bool
+	Up      bool
+
+	Speed  int
+	Duplex string
+	MTU    int
+
+	// PoE is the configured PoE output mode of the port, or empty if the
+	// port does not support PoE.
+	PoE PoEMode
+}
+
+// A jsonPort is the JSON representation of a Port used by the EdgeSwitch
+// API.
+type jsonPort struct {
+	Identification struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		MAC  string `json:"mac"`
+		Type string `json:"type"`
+	} `json:"identification"`
+	Status struct {
+		Enabled bool   `json:"enabled"`
+		Plugged bool   `json:"plugged"`
+		Speed   int    `json:"speed"`
+		Duplex  string `json:"duplex"`
+		MTU     int    `json:"mtu"`
+	} `json:"status"`
+	Port struct {
+		PoE string `json:"poe"`
+	} `json:"port"`
+}
+
+// Ports retrieves information about the switch ports of an EdgeSwitch
+// device.  Other interfaces, such as LAGs and VLAN interfaces, are omitted.
+func (c *Client) Ports(ctx context.Context) ([]*Port, error) {
+	var v []jsonPort
+	if err := c.get(ctx, "/api/v1.0/interfaces", &v); err != nil {
+		return nil, err
+	}
+
+	ports := make([]*Port, 0, len(v))
+	for _, p := range v {
+		if p.Identification.Type != "port" {
+			continue
+		}
+
+		var mac net.HardwareAddr
+		if p.Identification.MAC != "" {
+			var err error
+			mac, err = net.ParseMAC(p.Identification.MAC)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ports = append(ports, &Port{
+			ID:      p.Identification.ID,
+			Name:    p.Identification.Name,
+			MAC:     mac,
+			Enabled: p.Status.Enabled,
+			Up:      p.Status.Plugged,
+			Speed:   p.Status.Speed,
+			Duplex:  p.Status.Duplex,
+			MTU:     p.Status.MTU,
+			PoE:     PoEMode(p.Port.PoE),
+		})
+	}
+
+	return ports, nil
+}
+
+// SetPoE sets the PoE output mode of the switch port specified by id, such
+// as "0/1".  Enabling passive PoE on a port connected to a device which does
+// not support it can damage the device.
+func (c *Client) SetPoE(ctx context.Context, id string, mode PoEMode) error {
+	if id == "" {
+		return errors.New("port ID must not be empty")
+	}
+
+	switch mode {
+	case PoEOff, PoEAuto, PoEPassive24V, PoEPassive24VFourPair:
+	default:
+		return fmt.Errorf("unknown PoE mode: %q", mode)
+	}
+
+	// The device only modifies the fields which are specified
+	return c.put(ctx, "/api/v1.0/interfaces", []poeUpdate{{
+		Identification: portID{ID: id},
+		Port:           portPoE{PoE: mode},
+	}})
+}
+
+// A poeUpdate is the request body used to change a port's PoE mode.
+type poeUpdate struct {
+	Identification portID  `json:"identification"`
+	Port           portPoE `json:"port"`
+}
+
+type portID struct {
+	ID string `json:"id"`
+}
+
+type portPoE struct {
+	PoE PoEMode `json:"poe"`
+}
+
+// PortStats contains data transmission and PoE statistics for a switch port.
+type PortStats struct {
+	ID   string
+	Name string
+
+	ReceiveBPS      int
+	TransmitBPS     int
+	ReceiveBytes    int
+	TransmitBytes   int
+	ReceivePackets  int
+	TransmitPackets int
+	ReceiveErrors   int
+	TransmitErrors  int
+
+	// PoEPower is the power drawn by a PoE device on the port, in watts.
+	PoEPower float64
+}
+
+// PortStats retrieves statistics for the switch ports of an EdgeSwitch
+// device, and the time at which they were collected.
+func (c *Client) PortStats(ctx context.Context) ([]*PortStats, time.Time, error) {
+	var v []struct {
+		Timestamp  int64 `json:"timestamp"`
+		Interfaces []struct {
+			ID         string `json:"id"`
+			Name       string `json:"name"`
+			Statistics struct {
+				RXRate    int     `json:"rxRate"`
+				TXRate    int     `json:"txRate"`
+				RXBytes   int     `json:"rxBytes"`
+				TXBytes   int     `json:"txBytes"`
+				RXPackets int     `json:"rxPackets"`
+				TXPackets int     `json:"txPackets"`
+				RXErrors  int     `json:"rxErrors"`
+				TXErrors  int     `json:"txErrors"`
+				PoEPower  float64 `json:"poePower"`
+			} `json:"statistics"`
+		} `json:"interfaces"`
+	}
+	if err := c.get(ctx, "/api/v1.0/statistics", &v); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if len(v) == 0 {
+		return nil, time.Time{}, errors.New("no statistics returned by EdgeSwitch device")
+	}
+
+	// Only the most recent sample is reported
+	s := v[len(v)-1]
+
+	stats := make([]*PortStats, 0, len(s.Interfaces))
+	for _, ifi := range s.Interfaces {
+		st := ifi.Statistics
+		stats = append(stats, &PortStats{
+			ID:              ifi.ID,
+			Name:            ifi.Name,
+			ReceiveBPS:      st.RXRate,
+			TransmitBPS:     st.TXRate,
+			ReceiveBytes:    st.RXBytes,
+			TransmitBytes:   st.TXBytes,
+			ReceivePackets:  st.RXPackets,
+			TransmitPackets: st.TXPackets,
+			ReceiveErrors:   st.RXErrors,
+			TransmitErrors:  st.TXErrors,
+			PoEPower:        st.PoEPower,
+		})
+	}
+
+	// Timestamp is reported in milliseconds
+	t := time.Unix(0, s.Timestamp*int64(time.Millisecond))
+
+	return stats, t, nil
+}
@@ -0,0 +1,173 @@
+package edgeswitch
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientPorts(t *testing.T) {
+	h := testHandler(t, http.MethodGet, "/api/v1.0/interfaces")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`[
+			{
+				"identification": {"id": "0/1", "name": "Uplink", "mac": "04:18:d6:00:00:01", "type": "port"},
+				"status": {"enabled": true, "plugged": true, "speed": 1000, "duplex": "full", "mtu": 1518},
+				"port": {"poe": "auto"}
+			},
+			{
+				"identification": {"id": "0/2", "name": "", "type": "port"},
+				"status": {"enabled": false, "plugged": false, "mtu": 1518},
+				"port": {}
+			},
+			{
+				"identification": {"id": "3/1", "name": "lag1", "type": "lag"}
+			}
+		]`))
+	})
+	defer done()
+
+	ports, err := c.Ports(context.Background())
+	if err != nil {
+		t.Fatalf("failed to retrieve ports: %v", err)
+	}
+
+	want := []*Port{
+		{
+			ID:      "0/1",
+			Name:    "Uplink",
+			MAC:     net.HardwareAddr{0x04, 0x18, 0xd6, 0x00, 0x00, 0x01},
+			Enabled: true,
+			Up:      true,
+			Speed:   1000,
+			Duplex:  "full",
+			MTU:     1518,
+			PoE:     PoEAuto,
+		},
+		{
+			ID:  "0/2",
+			MTU: 1518,
+		},
+	}
+
+	if got := ports; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Ports:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientSetPoE(t *testing.T) {
+	h := testHandler(t, http.MethodPut, "/api/v1.0/interfaces")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read request body: %v", err)
+		}
+
+		want := `[{"identification":{"id":"0/3"},"port":{"poe":"off"}}]`
+		if got := string(b); want != got {
+			t.Fatalf("unexpected request body:\n- want: %v\n-  got: %v", want, got)
+		}
+	})
+	defer done()
+
+	if err := c.SetPoE(context.Background(), "0/3", PoEOff); err != nil {
+		t.Fatalf("failed to set PoE mode: %v", err)
+	}
+}
+
+func TestClientSetPoEInvalid(t *testing.T) {
+	var tests = []struct {
+		desc string
+		id   string
+		mode PoEMode
+	}{
+		{
+			desc: "empty port ID",
+			mode: PoEOff,
+		},
+		{
+			desc: "unknown mode",
+			id:   "0/1",
+			mode: "48v",
+		},
+	}
+
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("no request should be made for invalid arguments")
+	})
+	defer done()
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		if err := c.SetPoE(context.Background(), tt.id, tt.mode); err == nil {
+			t.Fatal("expected an error, but none occurred")
+		}
+	}
+}
+
+func TestClientPortStats(t *testing.T) {
+	h := testHandler(t, http.MethodGet, "/api/v1.0/statistics")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`[
+			{
+				"timestamp": 1500000000000,
+				"interfaces": [{"id": "0/1", "name": "Old", "statistics": {}}]
+			},
+			{
+				"timestamp": 1500000001500,
+				"interfaces": [
+					{
+						"id": "0/1",
+						"name": "Uplink",
+						"statistics": {
+							"rxRate": 1000, "txRate": 2000,
+							"rxBytes": 3000, "txBytes": 4000,
+							"rxPackets": 30, "txPackets": 40,
+							"rxErrors": 1, "txErrors": 2,
+							"poePower": 4.5
+						}
+					}
+				]
+			}
+		]`))
+	})
+	defer done()
+
+	stats, ts, err := c.PortStats(context.Background())
+	if err != nil {
+		t.Fatalf("failed to retrieve port statistics: %v", err)
+	}
+
+	if want, got := time.Unix(1500000001, int64(500*time.Millisecond)), ts; !want.Equal(got) {
+		t.Fatalf("unexpected timestamp:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	want := []*PortStats{{
+		ID:              "0/1",
+		Name:            "Uplink",
+		ReceiveBPS:      1000,
+		TransmitBPS:     2000,
+		ReceiveBytes:    3000,
+		TransmitBytes:   4000,
+		ReceivePackets:  30,
+		TransmitPackets: 40,
+		ReceiveErrors:   1,
+		TransmitErrors:  2,
+		PoEPower:        4.5,
+	}}
+
+	if got := stats; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected PortStats:\n- want: %v\n-  got: %v", want, got)
+	}
+}
@@ -0,0 +1,63 @@
+package edgeswitch
+
+import (
+	"context"
+	"sort"
+)
+
+// A VLAN is a VLAN configured on an EdgeSwitch device.
+type VLAN struct {
+	ID   int
+	Name string
+
+	// Tagged and Untagged are the IDs of switch ports which are members of
+	// the VLAN, and send its traffic with or without a VLAN tag.
+	Tagged   []string
+	Untagged []string
+}
+
+// VLANs retrieves the VLANs configured on an EdgeSwitch device, in order of
+// VLAN ID.
+func (c *Client) VLANs(ctx context.Context) ([]*VLAN, error) {
+	var v []struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Ports []struct {
+			ID   string `json:"id"`
+			Mode string `json:"mode"`
+		} `json:"ports"`
+	}
+	if err := c.get(ctx, "/api/v1.0/vlans", &v); err != nil {
+		return nil, err
+	}
+
+	vlans := make([]*VLAN, 0, len(v))
+	for _, vv := range v {
+		vlan := &VLAN{
+			ID:   vv.ID,
+			Name: vv.Name,
+		}
+
+		// Ports which are excluded from the VLAN are also reported
+		for _, p := range vv.Ports {
+			switch p.Mode {
+			case "tagged":
+				vlan.Tagged = append(vlan.Tagged, p.ID)
+			case "untagged":
+				vlan.Untagged = append(vlan.Untagged, p.ID)
+			}
+		}
+
+		vlans = append(vlans, vlan)
+	}
+
+	sort.Sort(byVLANID(vlans))
+	return vlans, nil
+}
+
+// byVLANID is used to sort VLANs by ID.
+type byVLANID []*VLAN
+
+func (b byVLANID) Len() int               { return len(b) }
+func (b byVLANID) Less(i int, j int) bool { return b[i].ID < b[j].ID }
+func (b byVLANID) Swap(i int, j int)      { b[i], b[j] = b[j], b[i] }
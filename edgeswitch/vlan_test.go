@@ -0,0 +1,56 @@
+package edgeswitch
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClientVLANs(t *testing.T) {
+	h := testHandler(t, http.MethodGet, "/api/v1.0/vlans")
+	c, done := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		h(w, r)
+
+		_, _ = w.Write([]byte(`[
+			{
+				"id": 10,
+				"name": "iot",
+				"ports": [
+					{"id": "0/1", "mode": "tagged"},
+					{"id": "0/2", "mode": "untagged"},
+					{"id": "0/3", "mode": "excluded"}
+				]
+			},
+			{
+				"id": 1,
+				"name": "default",
+				"ports": [{"id": "0/1", "mode": "untagged"}]
+			}
+		]`))
+	})
+	defer done()
+
+	vlans, err := c.VLANs(context.Background())
+	if err != nil {
+		t.Fatalf("failed to retrieve VLANs: %v", err)
+	}
+
+	want := []*VLAN{
+		{
+			ID:       1,
+			Name:     "default",
+			Untagged: []string{"0/1"},
+		},
+		{
+			ID:       10,
+			Name:     "iot",
+			Tagged:   []string{"0/1"},
+			Untagged: []string{"0/2"},
+		},
+	}
+
+	if got := vlans; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected VLANs:\n- want: %v\n-  got: %v", want, got)
+	}
+}
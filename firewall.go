@@ -0,0 +1,373 @@
+package edgemax
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FirewallRulesets is a slice of FirewallRuleset values, sorted by name.
+type FirewallRulesets []*FirewallRuleset
+
+// A FirewallRuleset is a named IPv4 firewall ruleset configured on an
+// EdgeMAX device.  A ruleset has no effect until it is applied to an
+// interface.
+type FirewallRuleset struct {
+	Name        string
+	Description string
+
+	// DefaultAction is the action applied to traffic which matches no
+	// rule: "accept", "drop", or "reject".
+	DefaultAction string
+
+	// Rules are the rules of the ruleset, sorted by number.
+	Rules []*FirewallRule
+}
+
+// A FirewallRule is a single rule within a FirewallRuleset.
+type FirewallRule struct {
+	// Number is the number of the rule, from 1 to 9999, which determines
+	// the order in which rules are evaluated.
+	Number      int
+	Description string
+
+	// Action is the action applied to traffic which matches the rule:
+	// "accept", "drop", or "reject".
+	Action string
+
+	// Protocol is the protocol matched by the rule, such as "tcp", "udp",
+	// "tcp_udp", "icmp", or a protocol number.  If empty, all protocols are
+	// matched.
+	Protocol string
+
+	Source      FirewallMatch
+	Destination FirewallMatch
+
+	// States are the connection tracking states matched by the rule, such
+	// as "established", "related", "new", or "invalid".
+	States []string
+
+	// Log reports whether traffic which matches the rule is logged.
+	Log bool
+
+	// Disabled reports whether the rule is disabled.
+	Disabled bool
+}
+
+// A FirewallMatch matches the source or destination of traffic in a
+// FirewallRule.  Empty fields match all traffic.
+type FirewallMatch struct {
+	// Address is an IP address, CIDR network, or hyphenated range of IP
+	// addresses, optionally prefixed with "!" to match all other addresses.
+	Address string
+
+	// Port is a port, hyphenated range of ports, or comma-separated list of
+	// ports and ranges, such as "22,80,8000-8080".  Ports can only be
+	// matched for the tcp, udp, and tcp_udp protocols.
+	Port string
+}
+
+// Validate reports whether rs and its rules are valid, so that malformed
+// rulesets can be caught before they are sent to a device.
+func (rs *FirewallRuleset) Validate() error {
+	if err := validateConfigName(rs.Name); err != nil {
+		return fmt.Errorf("invalid firewall ruleset name: %v", err)
+	}
+	if err := validateFirewallAction(rs.DefaultAction); err != nil {
+		return fmt.Errorf("firewall ruleset %q: invalid default action: %v", rs.Name, err)
+	}
+
+	seen := make(map[int]bool, len(rs.Rules))
+	for _, r := range rs.Rules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("firewall ruleset %q: %v", rs.Name, err)
+		}
+
+		if seen[r.Number] {
+			return fmt.Errorf("firewall ruleset %q: duplicate rule %d", rs.Name, r.Number)
+		}
+		seen[r.Number] = true
+	}
+
+	return nil
+}
+
+// Validate reports whether r is a valid rule.
+func (r *FirewallRule) Validate() error {
+	if r.Number < 1 || r.Number > 9999 {
+		return fmt.Errorf("firewall rule number %d must be between 1 and 9999", r.Number)
+	}
+
+	if err := validateFirewallAction(r.Action); err != nil {
+		return fmt.Errorf("firewall rule %d: invalid action: %v", r.Number, err)
+	}
+
+	portsOK := true
+	switch r.Protocol {
+	case "", "all", "icmp":
+		portsOK = false
+	case "tcp", "udp", "tcp_udp":
+	default:
+		// Other protocols may be specified by name or number
+		if n, err := strconv.Atoi(r.Protocol); err == nil && (n < 0 || n > 255) {
+			return fmt.Errorf("firewall rule %d: protocol number %d must be between 0 and 255", r.Number, n)
+		}
+		if strings.ContainsAny(r.Protocol, " \t\n") {
+			return fmt.Errorf("firewall rule %d: invalid protocol %q", r.Number, r.Protocol)
+		}
+		portsOK = false
+	}
+
+	matches := []struct {
+		name string
+		m    FirewallMatch
+	}{
+		{name: "source", m: r.Source},
+		{name: "destination", m: r.Destination},
+	}
+
+	for _, m := range matches {
+		if m.m.Address != "" {
+			if err := validateFirewallAddress(m.m.Address); err != nil {
+				return fmt.Errorf("firewall rule %d: invalid %s address: %v", r.Number, m.name, err)
+			}
+		}
+
+		if m.m.Port == "" {
+			continue
+		}
+		if !portsOK {
+			return fmt.Errorf("firewall rule %d: %s port requires protocol tcp, udp, or tcp_udp", r.Number, m.name)
+		}
+		if err := validateFirewallPorts(m.m.Port); err != nil {
+			return fmt.Errorf("firewall rule %d: invalid %s port: %v", r.Number, m.name, err)
+		}
+	}
+
+	for _, s := range r.States {
+		switch s {
+		case "established", "invalid", "new", "related":
+		default:
+			return fmt.Errorf("firewall rule %d: invalid state %q", r.Number, s)
+		}
+	}
+
+	return nil
+}
+
+// validateConfigName reports whether s can be used as the name of a
+// configuration node.
+func validateConfigName(s string) error {
+	if s == "" {
+		return errors.New("name must not be empty")
+	}
+	if strings.ContainsAny(s, " \t\n\"'") {
+		return fmt.Errorf("name %q must not contain whitespace or quotes", s)
+	}
+
+	return nil
+}
+
+// validateFirewallAction reports whether s is a valid firewall action.
+func validateFirewallAction(s string) error {
+	switch s {
+	case "accept", "drop", "reject":
+		return nil
+	}
+
+	return fmt.Errorf("action %q must be accept, drop, or reject", s)
+}
+
+// validateFirewallAddress reports whether s is a valid FirewallMatch
+// address.
+func validateFirewallAddress(s string) error {
+	addr := strings.TrimPrefix(s, "!")
+
+	if strings.Contains(addr, "/") {
+		if _, _, err := net.ParseCIDR(addr); err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	ips := strings.SplitN(addr, "-", 2)
+	for _, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid IP address: %q", ip)
+		}
+	}
+
+	return nil
+}
+
+// validateFirewallPorts reports whether s is a valid FirewallMatch port.
+func validateFirewallPorts(s string) error {
+	for _, p := range strings.Split(s, ",") {
+		ports := strings.SplitN(p, "-", 2)
+
+		var prev int
+		for _, port := range ports {
+			n, err := strconv.Atoi(port)
+			if err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("port %q must be between 1 and 65535", port)
+			}
+			if n < prev {
+				return fmt.Errorf("invalid port range: %q", p)
+			}
+
+			prev = n
+		}
+	}
+
+	return nil
+}
+
+// firewallRulesets retrieves the IPv4 firewall rulesets from the
+// configuration tree t.
+func firewallRulesets(t *ConfigTree) (FirewallRulesets, error) {
+	names := t.Child("firewall", "name")
+
+	rss := make(FirewallRulesets, 0, len(names.Children()))
+	for _, name := range names.Children() {
+		n := names.Child(name)
+
+		rs := &FirewallRuleset{
+			Name: name,
+		}
+		rs.Description, _ = n.Child("description").Value()
+		rs.DefaultAction, _ = n.Child("default-action").Value()
+
+		rules := n.Child("rule")
+		for _, num := range rules.Children() {
+			number, err := strconv.Atoi(num)
+			if err != nil {
+				return nil, fmt.Errorf("firewall ruleset %q: invalid rule number %q", name, num)
+			}
+
+			rs.Rules = append(rs.Rules, parseFirewallRule(number, rules.Child(num)))
+		}
+
+		// Rule numbers are sorted numerically rather than by name
+		sort.Slice(rs.Rules, func(i int, j int) bool {
+			return rs.Rules[i].Number < rs.Rules[j].Number
+		})
+
+		rss = append(rss, rs)
+	}
+
+	return rss, nil
+}
+
+// parseFirewallRule parses the rule with the specified number from the
+// configuration node n.
+func parseFirewallRule(number int, n *ConfigNode) *FirewallRule {
+	r := &FirewallRule{
+		Number:   number,
+		Disabled: n.Child("disable") != nil,
+	}
+
+	r.Description, _ = n.Child("description").Value()
+	r.Action, _ = n.Child("action").Value()
+	r.Protocol, _ = n.Child("protocol").Value()
+	r.Log, _ = n.Child("log").Bool()
+
+	r.Source.Address, _ = n.Child("source", "address").Value()
+	r.Source.Port, _ = n.Child("source", "port").Value()
+	r.Destination.Address, _ = n.Child("destination", "address").Value()
+	r.Destination.Port, _ = n.Child("destination", "port").Value()
+
+	state := n.Child("state")
+	for _, s := range state.Children() {
+		if v, _ := state.Child(s).Bool(); v {
+			r.States = append(r.States, s)
+		}
+	}
+
+	return r
+}
+
+// configTree creates the configuration tree which configures rs, relative
+// to its node within "firewall", "name".
+func (rs *FirewallRuleset) configTree() map[string]interface{} {
+	m := map[string]interface{}{
+		"default-action": rs.DefaultAction,
+	}
+	if rs.Description != "" {
+		m["description"] = rs.Description
+	}
+
+	if len(rs.Rules) > 0 {
+		rules := make(map[string]interface{}, len(rs.Rules))
+		for _, r := range rs.Rules {
+			rules[strconv.Itoa(r.Number)] = r.configTree()
+		}
+
+		m["rule"] = rules
+	}
+
+	return m
+}
+
+// configTree creates the configuration tree which configures r, relative to
+// its node within a ruleset's "rule".
+func (r *FirewallRule) configTree() map[string]interface{} {
+	m := map[string]interface{}{
+		"action": r.Action,
+		"log":    "disable",
+	}
+	if r.Log {
+		m["log"] = "enable"
+	}
+	if r.Disabled {
+		m["disable"] = nil
+	}
+
+	strs := []struct {
+		k string
+		v string
+	}{
+		{k: "description", v: r.Description},
+		{k: "protocol", v: r.Protocol},
+	}
+	for _, s := range strs {
+		if s.v != "" {
+			m[s.k] = s.v
+		}
+	}
+
+	matches := []struct {
+		k string
+		m FirewallMatch
+	}{
+		{k: "source", m: r.Source},
+		{k: "destination", m: r.Destination},
+	}
+	for _, mm := range matches {
+		v := make(map[string]interface{}, 2)
+		if mm.m.Address != "" {
+			v["address"] = mm.m.Address
+		}
+		if mm.m.Port != "" {
+			v["port"] = mm.m.Port
+		}
+
+		if len(v) > 0 {
+			m[mm.k] = v
+		}
+	}
+
+	if len(r.States) > 0 {
+		states := make(map[string]interface{}, len(r.States))
+		for _, s := range r.States {
+			states[s] = "enable"
+		}
+
+		m["state"] = states
+	}
+
+	return m
+}
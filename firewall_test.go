@@ -0,0 +1,210 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFirewallRulesetValidate(t *testing.T) {
+	rule := func(fn func(r *FirewallRule)) *FirewallRuleset {
+		r := &FirewallRule{
+			Number:   10,
+			Action:   "accept",
+			Protocol: "tcp",
+		}
+		if fn != nil {
+			fn(r)
+		}
+
+		return &FirewallRuleset{
+			Name:          "WAN_IN",
+			DefaultAction: "drop",
+			Rules:         []*FirewallRule{r},
+		}
+	}
+
+	var tests = []struct {
+		desc string
+		rs   *FirewallRuleset
+		ok   bool
+	}{
+		{
+			desc: "no name",
+			rs:   &FirewallRuleset{DefaultAction: "drop"},
+		},
+		{
+			desc: "name with whitespace",
+			rs:   &FirewallRuleset{Name: "WAN IN", DefaultAction: "drop"},
+		},
+		{
+			desc: "invalid default action",
+			rs:   &FirewallRuleset{Name: "WAN_IN", DefaultAction: "allow"},
+		},
+		{
+			desc: "invalid rule number",
+			rs:   rule(func(r *FirewallRule) { r.Number = 10000 }),
+		},
+		{
+			desc: "invalid rule action",
+			rs:   rule(func(r *FirewallRule) { r.Action = "" }),
+		},
+		{
+			desc: "duplicate rule",
+			rs: &FirewallRuleset{
+				Name:          "WAN_IN",
+				DefaultAction: "drop",
+				Rules: []*FirewallRule{
+					{Number: 10, Action: "accept"},
+					{Number: 10, Action: "drop"},
+				},
+			},
+		},
+		{
+			desc: "invalid protocol number",
+			rs:   rule(func(r *FirewallRule) { r.Protocol = "256" }),
+		},
+		{
+			desc: "invalid source address",
+			rs:   rule(func(r *FirewallRule) { r.Source.Address = "192.168.1.256" }),
+		},
+		{
+			desc: "invalid destination network",
+			rs:   rule(func(r *FirewallRule) { r.Destination.Address = "192.168.1.0/33" }),
+		},
+		{
+			desc: "invalid port",
+			rs:   rule(func(r *FirewallRule) { r.Destination.Port = "65536" }),
+		},
+		{
+			desc: "invalid port range",
+			rs:   rule(func(r *FirewallRule) { r.Destination.Port = "443-80" }),
+		},
+		{
+			desc: "port without TCP or UDP",
+			rs: rule(func(r *FirewallRule) {
+				r.Protocol = "icmp"
+				r.Destination.Port = "80"
+			}),
+		},
+		{
+			desc: "invalid state",
+			rs:   rule(func(r *FirewallRule) { r.States = []string{"foo"} }),
+		},
+		{
+			desc: "OK no rules",
+			rs:   &FirewallRuleset{Name: "WAN_IN", DefaultAction: "drop"},
+			ok:   true,
+		},
+		{
+			desc: "OK",
+			rs: rule(func(r *FirewallRule) {
+				r.Source.Address = "!192.168.1.0/24"
+				r.Destination.Address = "10.0.0.1-10.0.0.10"
+				r.Destination.Port = "22,80,8000-8080"
+				r.States = []string{"established", "related"}
+			}),
+			ok: true,
+		},
+		{
+			desc: "OK protocol name",
+			rs:   rule(func(r *FirewallRule) { r.Protocol = "gre" }),
+			ok:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		err := tt.rs.Validate()
+		if want, got := tt.ok, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func Test_firewallRulesets(t *testing.T) {
+	var tree ConfigTree
+	b := []byte(`{"firewall":{"name":{
+		"WAN_LOCAL":{"default-action":"drop"},
+		"WAN_IN":{
+			"default-action":"drop",
+			"description":"WAN to internal",
+			"rule":{
+				"20":{"action":"drop","log":"enable","state":{"invalid":"enable","new":"disable"}},
+				"100":{"action":"accept","protocol":"tcp","destination":{"address":"192.168.1.10","port":"443"},"disable":null},
+				"10":{"action":"accept","state":{"established":"enable","related":"enable"}}
+			}
+		}
+	}}}`)
+	if err := json.Unmarshal(b, &tree); err != nil {
+		t.Fatalf("failed to unmarshal ConfigTree: %v", err)
+	}
+
+	rss, err := firewallRulesets(&tree)
+	if err != nil {
+		t.Fatalf("failed to parse firewall rulesets: %v", err)
+	}
+
+	want := FirewallRulesets{
+		{
+			Name:          "WAN_IN",
+			Description:   "WAN to internal",
+			DefaultAction: "drop",
+			Rules: []*FirewallRule{
+				{
+					Number: 10,
+					Action: "accept",
+					States: []string{"established", "related"},
+				},
+				{
+					Number: 20,
+					Action: "drop",
+					States: []string{"invalid"},
+					Log:    true,
+				},
+				{
+					Number:   100,
+					Action:   "accept",
+					Protocol: "tcp",
+					Destination: FirewallMatch{
+						Address: "192.168.1.10",
+						Port:    "443",
+					},
+					Disabled: true,
+				},
+			},
+		},
+		{
+			Name:          "WAN_LOCAL",
+			DefaultAction: "drop",
+		},
+	}
+
+	if got := rss; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected FirewallRulesets:\n- want: %+v\n-  got: %+v", want, got)
+	}
+
+	// Each ruleset should produce a configuration tree which parses to the
+	// same ruleset
+	for _, rs := range want {
+		b, err := json.Marshal(configPath(rs.configTree(), "firewall", "name", rs.Name))
+		if err != nil {
+			t.Fatalf("failed to marshal configuration tree: %v", err)
+		}
+
+		var tree ConfigTree
+		if err := json.Unmarshal(b, &tree); err != nil {
+			t.Fatalf("failed to unmarshal ConfigTree: %v", err)
+		}
+
+		got, err := firewallRulesets(&tree)
+		if err != nil {
+			t.Fatalf("failed to parse firewall rulesets: %v", err)
+		}
+
+		if want := (FirewallRulesets{rs}); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected round-tripped FirewallRulesets:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package edgemax
+
+import (
+	"encoding/json"
+)
+
+// An UpgradeState is the state of a firmware upgrade on an EdgeMAX device.
+type UpgradeState string
+
+const (
+	// UpgradeStateInstalling indicates that a firmware image is being
+	// installed.
+	UpgradeStateInstalling UpgradeState = "installing"
+
+	// UpgradeStateDone indicates that a firmware image was installed
+	// successfully, and will be used on the next boot.
+	UpgradeStateDone UpgradeState = "done"
+
+	// UpgradeStateFailed indicates that a firmware image could not be
+	// installed.
+	UpgradeStateFailed UpgradeState = "failed"
+)
+
+// An UpgradeStatus contains the progress of a firmware upgrade on an
+// EdgeMAX device.
+type UpgradeStatus struct {
+	State    UpgradeState
+	Progress int
+	Message  string
+}
+
+// UnmarshalJSON unmarshals JSON into an UpgradeStatus.
+func (us *UpgradeStatus) UnmarshalJSON(b []byte) error {
+	var v struct {
+		State    string `json:"state"`
+		Progress string `json:"progress"`
+		Message  string `json:"message"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	progress, err := atoiOrZero(v.Progress)
+	if err != nil {
+		return err
+	}
+
+	*us = UpgradeStatus{
+		State:    UpgradeState(v.State),
+		Progress: progress,
+		Message:  v.Message,
+	}
+
+	return nil
+}
+
+// A FirmwareUpdate contains information about the latest firmware version
+// available for an EdgeMAX device, as reported by Ubiquiti.
+type FirmwareUpdate struct {
+	// Current is the version of the currently running firmware.
+	Current string
+
+	// Latest is the version of the latest available firmware.
+	Latest string
+
+	// Available reports whether Latest is newer than Current.
+	Available bool
+
+	// URL is the location from which the device will download Latest.
+	URL string
+}
+
+// UnmarshalJSON unmarshals JSON into a FirmwareUpdate.
+func (fu *FirmwareUpdate) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Current   string  `json:"current"`
+		Latest    string  `json:"latest"`
+		Available apiBool `json:"available"`
+		URL       string  `json:"url"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*fu = FirmwareUpdate{
+		Current:   v.Current,
+		Latest:    v.Latest,
+		Available: bool(v.Available),
+		URL:       v.URL,
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestUpgradeStatusUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		us      *UpgradeStatus
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid progress integer",
+			b:       []byte(`{"progress":"foo"}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK installing",
+			b:    []byte(`{"state":"installing","progress":"40"}`),
+			us: &UpgradeStatus{
+				State:    UpgradeStateInstalling,
+				Progress: 40,
+			},
+		},
+		{
+			desc: "OK failed",
+			b:    []byte(`{"state":"failed","message":"not enough space"}`),
+			us: &UpgradeStatus{
+				State:   UpgradeStateFailed,
+				Message: "not enough space",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		us := new(UpgradeStatus)
+		err := us.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.us, us; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected UpgradeStatus:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+//go:build gofuzz
+// +build gofuzz
+
+package edgemax
+
+import "encoding/json"
+
+// Fuzz targets for use with github.com/dvyukov/go-fuzz.  Select a target
+// using go-fuzz's -func flag, such as:
+//
+//	$ go-fuzz-build github.com/mdlayher/edgemax
+//	$ go-fuzz -bin=edgemax-fuzz.zip -func=FuzzInterfaces
+
+// FuzzSystemStats is a fuzz target for SystemStats.UnmarshalJSON.
+func FuzzSystemStats(data []byte) int {
+	ss := new(SystemStats)
+	return fuzzResult(ss.UnmarshalJSON(data))
+}
+
+// FuzzInterfaces is a fuzz target for Interfaces.UnmarshalJSON.
+func FuzzInterfaces(data []byte) int {
+	var is Interfaces
+	return fuzzResult(is.UnmarshalJSON(data))
+}
+
+// FuzzDPIStats is a fuzz target for DPIStats.UnmarshalJSON.
+func FuzzDPIStats(data []byte) int {
+	var ds DPIStats
+	return fuzzResult(ds.UnmarshalJSON(data))
+}
+
+// FuzzUsers is a fuzz target for Users.UnmarshalJSON.
+func FuzzUsers(data []byte) int {
+	var us Users
+	return fuzzResult(us.UnmarshalJSON(data))
+}
+
+// FuzzDiscoveredDevices is a fuzz target for
+// DiscoveredDevices.UnmarshalJSON.
+func FuzzDiscoveredDevices(data []byte) int {
+	var ds DiscoveredDevices
+	return fuzzResult(ds.UnmarshalJSON(data))
+}
+
+// FuzzFirewallStats is a fuzz target for FirewallStats.UnmarshalJSON.
+func FuzzFirewallStats(data []byte) int {
+	var fs FirewallStats
+	return fuzzResult(fs.UnmarshalJSON(data))
+}
+
+// FuzzWebsocket is a fuzz target for websocket message decoding, as
+// performed when collecting statistics.
+func FuzzWebsocket(data []byte) int {
+	m := make(map[StatType]json.RawMessage)
+	return fuzzResult(wsUnmarshal(data, 0, &m))
+}
+
+// fuzzResult converts the result of a parser into a go-fuzz priority:
+// inputs which parse successfully are more interesting.
+func fuzzResult(err error) int {
+	if err != nil {
+		return 0
+	}
+
+	return 1
+}
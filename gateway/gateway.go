@@ -0,0 +1,220 @@
+// Package gateway implements an HTTP gateway which exposes the operations of
+// an edgemax.Client using JSON-RPC 2.0, so that systems not written in Go
+// can drive an EdgeMAX device through a single process.
+//
+// JSON-RPC requests are sent using HTTP POST to the gateway's RPC endpoint.
+// Supported methods are:
+//
+//	system.info                             -> edgemax.SystemInfo
+//	config.get                              -> configuration tree
+//	config.set    {"ops": [...]}            -> null
+//	op.run        {"args": [...]}           -> edgemax.OpOutput
+//	op.reboot                               -> null
+//	dhcp.renew    {"interface": "eth0"}     -> null
+//	dhcp.release  {"interface": "eth0"}     -> null
+//	dpi.clear                               -> null
+//	interface.set {"name": "eth1", "up": b} -> null
+//
+// Each operation in config.set has the form:
+//
+//	{"action": "set", "path": ["system", "host-name"], "value": "gw"}
+//
+// where action is "set" or "delete".
+//
+// Methods which modify the device's configuration or state, such as
+// config.set, op.run, and op.reboot, are only available when the gateway is
+// configured with a token; see Config.
+//
+// Statistics are not exposed as an RPC method, since they are a stream:
+// they are available from the gateway's stream endpoint using the WebSocket
+// and Server-Sent Events protocols provided by package statserver.
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+	"github.com/mdlayher/edgemax/statserver"
+)
+
+// Default values used when a Config field is unset.
+const (
+	DefaultMaxRequestBytes = 1 << 20
+	DefaultTimeout         = 30 * time.Second
+)
+
+// Paths of the endpoints served by a Gateway.
+const (
+	RPCPath    = "/rpc"
+	StreamPath = "/stream"
+)
+
+// Config specifies configuration for a Gateway.
+type Config struct {
+	// Token, if set, must be presented by clients in an
+	// "Authorization: Bearer" header.  If empty, clients are not
+	// authenticated, and the Gateway is always read-only.
+	Token string
+
+	// ReadOnly disables methods which modify the device's configuration or
+	// state.  ReadOnly is implied if Token is empty.
+	ReadOnly bool
+
+	// MaxRequestBytes limits the size of request bodies.  If zero,
+	// DefaultMaxRequestBytes is used.
+	MaxRequestBytes int64
+
+	// Timeout limits the duration of each method call.  If zero,
+	// DefaultTimeout is used.
+	Timeout time.Duration
+}
+
+// A Gateway is an http.Handler which exposes the operations of a Client.
+type Gateway struct {
+	c       *edgemax.Client
+	cfg     Config
+	stats   *statserver.Server
+	mux     *http.ServeMux
+	methods map[string]method
+}
+
+// New creates a Gateway for the logged in Client c, using the configuration
+// in cfg.  Unless cfg specifies a Token, the Gateway is read-only, so that
+// unauthenticated clients cannot modify the device.
+func New(c *edgemax.Client, cfg Config) *Gateway {
+	if cfg.Token == "" {
+		cfg.ReadOnly = true
+	}
+	if cfg.MaxRequestBytes == 0 {
+		cfg.MaxRequestBytes = DefaultMaxRequestBytes
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	g := &Gateway{
+		c:     c,
+		cfg:   cfg,
+		stats: statserver.New(),
+		mux:   http.NewServeMux(),
+	}
+	g.methods = g.newMethods()
+
+	g.mux.HandleFunc(RPCPath, g.serveRPC)
+	g.mux.Handle(StreamPath, g.stats)
+
+	return g
+}
+
+// Run subscribes to the specified statistics from the device and serves
+// them on the stream endpoint until ctx is canceled.
+func (g *Gateway) Run(ctx context.Context, stats ...edgemax.StatType) error {
+	statC, done, err := g.c.Stats(stats...)
+	if err != nil {
+		return err
+	}
+
+	runErr := g.stats.Run(ctx, statC)
+	if err := done(); err != nil {
+		return err
+	}
+
+	return runErr
+}
+
+// ServeHTTP implements http.Handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !g.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	g.mux.ServeHTTP(w, r)
+}
+
+// authorized reports whether r presents the configured token, if any.
+func (g *Gateway) authorized(r *http.Request) bool {
+	if g.cfg.Token == "" {
+		return true
+	}
+
+	want := []byte("Bearer " + g.cfg.Token)
+	got := []byte(r.Header.Get("Authorization"))
+	return subtle.ConstantTimeCompare(want, got) == 1
+}
+
+// serveRPC serves a single JSON-RPC request.
+func (g *Gateway) serveRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req request
+	dec := json.NewDecoder(io.LimitReader(r.Body, g.cfg.MaxRequestBytes))
+	if err := dec.Decode(&req); err != nil {
+		writeResponse(w, nil, nil, &Error{Code: CodeParseError, Message: err.Error()})
+		return
+	}
+
+	if req.Version != version || req.Method == "" {
+		writeResponse(w, req.ID, nil, &Error{Code: CodeInvalidRequest, Message: "invalid request"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), g.cfg.Timeout)
+	defer cancel()
+
+	result, rerr := g.call(ctx, req.Method, req.Params)
+
+	// Notifications receive no response
+	if req.ID == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeResponse(w, req.ID, result, rerr)
+}
+
+// call invokes the method specified by name with params.
+func (g *Gateway) call(ctx context.Context, name string, params json.RawMessage) (interface{}, *Error) {
+	m, ok := g.methods[name]
+	if !ok || (g.cfg.ReadOnly && m.writes) {
+		return nil, &Error{Code: CodeMethodNotFound, Message: "method not found: " + name}
+	}
+
+	return m.call(ctx, params)
+}
+
+// writeResponse writes a JSON-RPC response to w.  JSON-RPC errors are
+// reported in the response body, so the HTTP status is always OK.
+func writeResponse(w http.ResponseWriter, id json.RawMessage, result interface{}, err *Error) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	res := response{
+		Version: version,
+		ID:      id,
+	}
+	if err != nil {
+		res.Error = err
+	} else {
+		b, merr := json.Marshal(result)
+		if merr != nil {
+			res.Error = &Error{Code: CodeInternalError, Message: merr.Error()}
+		} else {
+			res.Result = b
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
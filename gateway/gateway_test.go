@@ -0,0 +1,145 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestGatewayRPC(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		cfg    Config
+		method string
+		auth   string
+		body   string
+		status int
+		res    string
+	}{
+		{
+			desc:   "unauthorized",
+			cfg:    Config{Token: "secret"},
+			auth:   "Bearer wrong",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "bad HTTP method",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			desc:   "parse error",
+			body:   `{`,
+			status: http.StatusOK,
+			res:    `{"jsonrpc":"2.0","error":{"code":-32700,"message":"unexpected EOF"},"id":null}`,
+		},
+		{
+			desc:   "invalid request",
+			body:   `{"jsonrpc":"1.0","method":"config.get","id":1}`,
+			status: http.StatusOK,
+			res:    `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid request"},"id":1}`,
+		},
+		{
+			desc:   "method not found",
+			body:   `{"jsonrpc":"2.0","method":"foo","id":"a"}`,
+			status: http.StatusOK,
+			res:    `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: foo"},"id":"a"}`,
+		},
+		{
+			desc:   "read-only",
+			cfg:    Config{ReadOnly: true},
+			body:   `{"jsonrpc":"2.0","method":"op.reboot","id":1}`,
+			status: http.StatusOK,
+			res:    `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: op.reboot"},"id":1}`,
+		},
+		{
+			desc:   "read-only without token",
+			cfg:    Config{ReadOnly: false},
+			body:   `{"jsonrpc":"2.0","method":"op.reboot","id":1}`,
+			status: http.StatusOK,
+			res:    `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found: op.reboot"},"id":1}`,
+		},
+		{
+			desc:   "missing params",
+			cfg:    Config{Token: "secret"},
+			auth:   "Bearer secret",
+			body:   `{"jsonrpc":"2.0","method":"config.set","id":1}`,
+			status: http.StatusOK,
+			res:    `{"jsonrpc":"2.0","error":{"code":-32602,"message":"missing parameters"},"id":1}`,
+		},
+		{
+			desc:   "bad config action",
+			cfg:    Config{Token: "secret"},
+			auth:   "Bearer secret",
+			body:   `{"jsonrpc":"2.0","method":"config.set","params":{"ops":[{"action":"foo"}]},"id":1}`,
+			status: http.StatusOK,
+			res:    `{"jsonrpc":"2.0","error":{"code":-32602,"message":"unknown configuration action: foo"},"id":1}`,
+		},
+		{
+			desc:   "notification",
+			body:   `{"jsonrpc":"2.0","method":"config.get"}`,
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "OK",
+			cfg:    Config{Token: "secret"},
+			auth:   "Bearer secret",
+			body:   `{"jsonrpc":"2.0","method":"config.get","id":1}`,
+			status: http.StatusOK,
+			res:    `{"jsonrpc":"2.0","result":{"system":{"host-name":"router"}},"id":1}`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		g, done := testGateway(t, tt.cfg, func(w http.ResponseWriter, r *http.Request) {
+			if want, got := "/api/edge/get.json", r.URL.Path; want != got {
+				t.Fatalf("unexpected URL path:\n- want: %v\n-  got: %v", want, got)
+			}
+
+			_, _ = w.Write([]byte(`{"success":true,"GET":{"system":{"host-name":"router"}}}`))
+		})
+
+		method := tt.method
+		if method == "" {
+			method = http.MethodPost
+		}
+
+		r := httptest.NewRequest(method, RPCPath, strings.NewReader(tt.body))
+		if tt.auth != "" {
+			r.Header.Set("Authorization", tt.auth)
+		}
+
+		w := httptest.NewRecorder()
+		g.ServeHTTP(w, r)
+		done()
+
+		if want, got := tt.status, w.Code; want != got {
+			t.Fatalf("unexpected HTTP status:\n- want: %v\n-  got: %v", want, got)
+		}
+		if tt.res == "" {
+			continue
+		}
+
+		if want, got := tt.res, strings.TrimSpace(w.Body.String()); want != got {
+			t.Fatalf("unexpected response:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+// testGateway creates a Gateway for a Client which communicates with a device
+// using fn.
+func testGateway(t *testing.T, cfg Config, fn http.HandlerFunc) (*Gateway, func()) {
+	s := httptest.NewServer(fn)
+
+	c, err := edgemax.NewClient(s.URL, nil)
+	if err != nil {
+		t.Fatalf("error creating Client: %v", err)
+	}
+
+	return New(c, cfg), func() { s.Close() }
+}
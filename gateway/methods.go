@@ -0,0 +1,180 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// A method is a JSON-RPC method exposed by a Gateway.
+type method struct {
+	// writes indicates that the method modifies the device, and is
+	// disabled in read-only mode.
+	writes bool
+	call   func(ctx context.Context, params json.RawMessage) (interface{}, *Error)
+}
+
+// newMethods creates the methods exposed by g.
+func (g *Gateway) newMethods() map[string]method {
+	c := g.c
+
+	return map[string]method{
+		"system.info": {
+			call: func(ctx context.Context, _ json.RawMessage) (interface{}, *Error) {
+				return result(c.SystemInfo(ctx))
+			},
+		},
+		"config.get": {
+			call: func(ctx context.Context, _ json.RawMessage) (interface{}, *Error) {
+				return result(c.GetConfig(ctx))
+			},
+		},
+		"config.set": {
+			writes: true,
+			call: func(ctx context.Context, params json.RawMessage) (interface{}, *Error) {
+				var p struct {
+					Ops []configOp `json:"ops"`
+				}
+				if err := unmarshalParams(params, &p); err != nil {
+					return nil, err
+				}
+
+				ops := make([]edgemax.ConfigOp, 0, len(p.Ops))
+				for _, op := range p.Ops {
+					cop, err := op.configOp()
+					if err != nil {
+						return nil, err
+					}
+
+					ops = append(ops, cop)
+				}
+
+				return nil, deviceError(c.SetConfig(ctx, ops...))
+			},
+		},
+		"op.run": {
+			writes: true,
+			call: func(ctx context.Context, params json.RawMessage) (interface{}, *Error) {
+				var p struct {
+					Args []string `json:"args"`
+				}
+				if err := unmarshalParams(params, &p); err != nil {
+					return nil, err
+				}
+
+				return result(c.RunOp(ctx, p.Args...))
+			},
+		},
+		"op.reboot": {
+			writes: true,
+			call: func(ctx context.Context, _ json.RawMessage) (interface{}, *Error) {
+				return nil, deviceError(c.Reboot(ctx))
+			},
+		},
+		"dhcp.renew": {
+			writes: true,
+			call:   interfaceMethod(c.RenewDHCP),
+		},
+		"dhcp.release": {
+			writes: true,
+			call:   interfaceMethod(c.ReleaseDHCP),
+		},
+		"dpi.clear": {
+			writes: true,
+			call: func(ctx context.Context, _ json.RawMessage) (interface{}, *Error) {
+				return nil, deviceError(c.ClearDPI(ctx))
+			},
+		},
+		"interface.set": {
+			writes: true,
+			call: func(ctx context.Context, params json.RawMessage) (interface{}, *Error) {
+				var p struct {
+					Name string `json:"name"`
+					Up   bool   `json:"up"`
+				}
+				if err := unmarshalParams(params, &p); err != nil {
+					return nil, err
+				}
+
+				return nil, deviceError(c.SetInterfaceAdminState(ctx, p.Name, p.Up))
+			},
+		},
+	}
+}
+
+// A configOp is the JSON representation of an edgemax.ConfigOp.
+type configOp struct {
+	Action string   `json:"action"`
+	Path   []string `json:"path"`
+	Value  string   `json:"value"`
+}
+
+// configOp converts op into an edgemax.ConfigOp.
+func (op configOp) configOp() (edgemax.ConfigOp, *Error) {
+	cop := edgemax.ConfigOp{
+		Path:  op.Path,
+		Value: op.Value,
+	}
+
+	switch op.Action {
+	case "set":
+		cop.Action = edgemax.ConfigSet
+	case "delete":
+		cop.Action = edgemax.ConfigDelete
+	default:
+		return edgemax.ConfigOp{}, &Error{
+			Code:    CodeInvalidParams,
+			Message: "unknown configuration action: " + op.Action,
+		}
+	}
+
+	return cop, nil
+}
+
+// interfaceMethod creates a method which invokes fn with the interface
+// named in its parameters.
+func interfaceMethod(fn func(ctx context.Context, iface string) error) func(context.Context, json.RawMessage) (interface{}, *Error) {
+	return func(ctx context.Context, params json.RawMessage) (interface{}, *Error) {
+		var p struct {
+			Interface string `json:"interface"`
+		}
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+
+		return nil, deviceError(fn(ctx, p.Interface))
+	}
+}
+
+// unmarshalParams unmarshals method parameters into v.
+func unmarshalParams(params json.RawMessage, v interface{}) *Error {
+	if len(params) == 0 {
+		return &Error{Code: CodeInvalidParams, Message: "missing parameters"}
+	}
+
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	return nil
+}
+
+// result converts the return values of a Client method into a method
+// result.
+func result(v interface{}, err error) (interface{}, *Error) {
+	if err != nil {
+		return nil, deviceError(err)
+	}
+
+	return v, nil
+}
+
+// deviceError converts an error from a Client method into a JSON-RPC error.
+func deviceError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Code: CodeDeviceError, Message: err.Error()}
+}
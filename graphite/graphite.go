@@ -0,0 +1,271 @@
+// Package graphite implements an emitter which periodically pushes
+// interface and system statistics from EdgeMAX devices to Graphite or
+// StatsD.
+//
+// Metrics are named using a configurable prefix, such as:
+//
+//	edgemax.system.cpu
+//	edgemax.interfaces.eth0.rx_bytes
+//
+// and are sent using either the Graphite plaintext protocol over TCP, or as
+// StatsD gauges over UDP.
+package graphite
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// A Protocol is a protocol used to send metrics.
+type Protocol int
+
+// Supported Protocol values.
+const (
+	// Plaintext sends metrics using the Graphite plaintext protocol
+	// over TCP, typically to port 2003.
+	Plaintext Protocol = iota
+
+	// StatsD sends metrics as StatsD gauges over UDP, typically to port
+	// 8125.
+	StatsD
+)
+
+// Default values used when a Config field is unset.
+const (
+	DefaultPrefix        = "edgemax"
+	DefaultFlushInterval = 10 * time.Second
+)
+
+// maxPacketSize is the maximum size of a StatsD packet, chosen to avoid IP
+// fragmentation on typical networks.
+const maxPacketSize = 512
+
+// Config specifies configuration for an Emitter.
+type Config struct {
+	// Protocol specifies the protocol used to send metrics.
+	Protocol Protocol
+
+	// Prefix is prepended to the name of each metric, such as
+	// "routers.gw".  If empty, DefaultPrefix is used.
+	Prefix string
+
+	// FlushInterval is the interval at which Run sends metrics.  If zero,
+	// DefaultFlushInterval is used.
+	FlushInterval time.Duration
+}
+
+// An Emitter records the most recent statistics from a device, and
+// periodically sends them to Graphite or StatsD.
+type Emitter struct {
+	addr string
+	cfg  Config
+
+	mu      sync.Mutex
+	conn    net.Conn
+	metrics map[string]uint64
+	now     func() time.Time
+}
+
+// NewEmitter creates an Emitter which sends metrics to addr, such as
+// "graphite.example.com:2003", using the configuration in cfg.  The
+// connection is established when metrics are first sent, and reestablished
+// if sending fails.
+func NewEmitter(addr string, cfg Config) *Emitter {
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+
+	return &Emitter{
+		addr:    addr,
+		cfg:     cfg,
+		metrics: make(map[string]uint64),
+		now:     time.Now,
+	}
+}
+
+// Close closes the Emitter's connection, if any.
+func (e *Emitter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+
+	err := e.conn.Close()
+	e.conn = nil
+	return err
+}
+
+// Run records each Stat received on statC and sends metrics once per flush
+// interval, until statC is closed or ctx is canceled.  Run can be used with
+// the channel returned by edgemax.Client.Stats.
+func (e *Emitter) Run(ctx context.Context, statC <-chan edgemax.Stat) error {
+	t := time.NewTicker(e.cfg.FlushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s, ok := <-statC:
+			if !ok {
+				return e.Flush(ctx)
+			}
+
+			e.Record(s)
+		case <-t.C:
+			if err := e.Flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Record records the values of interface and system statistics in s, to be
+// sent on the next flush.  Other statistic types are ignored.
+func (e *Emitter) Record(s edgemax.Stat) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch s := s.(type) {
+	case *edgemax.SystemStats:
+		e.metrics["system.cpu"] = uint64(s.CPU)
+		e.metrics["system.memory"] = uint64(s.Memory)
+		e.metrics["system.uptime_seconds"] = uint64(s.Uptime / time.Second)
+	case edgemax.Interfaces:
+		for _, ifi := range s {
+			prefix := "interfaces." + nameReplacer.Replace(ifi.Name) + "."
+
+			var up uint64
+			if ifi.Up {
+				up = 1
+			}
+
+			st := ifi.Stats
+			for _, m := range []struct {
+				name  string
+				value uint64
+			}{
+				{name: "up", value: up},
+				{name: "rx_packets", value: st.ReceivePackets},
+				{name: "tx_packets", value: st.TransmitPackets},
+				{name: "rx_bytes", value: st.ReceiveBytes},
+				{name: "tx_bytes", value: st.TransmitBytes},
+				{name: "rx_errors", value: st.ReceiveErrors},
+				{name: "tx_errors", value: st.TransmitErrors},
+				{name: "rx_dropped", value: st.ReceiveDropped},
+				{name: "tx_dropped", value: st.TransmitDropped},
+				{name: "rx_bps", value: st.ReceiveBPS},
+				{name: "tx_bps", value: st.TransmitBPS},
+			} {
+				e.metrics[prefix+m.name] = m.value
+			}
+		}
+	}
+}
+
+// Flush sends the most recently recorded value of each metric.
+func (e *Emitter) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.metrics) == 0 {
+		return nil
+	}
+
+	packets := e.marshal()
+
+	if e.conn == nil {
+		network := "tcp"
+		if e.cfg.Protocol == StatsD {
+			network = "udp"
+		}
+
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, network, e.addr)
+		if err != nil {
+			return err
+		}
+
+		e.conn = conn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = e.conn.SetWriteDeadline(deadline)
+	} else {
+		_ = e.conn.SetWriteDeadline(time.Now().Add(e.cfg.FlushInterval))
+	}
+
+	for _, p := range packets {
+		if _, err := e.conn.Write(p); err != nil {
+			// Reconnect on the next flush
+			_ = e.conn.Close()
+			e.conn = nil
+			return err
+		}
+	}
+
+	return nil
+}
+
+// marshal marshals the recorded metrics, sorted by name, into one or more
+// packets.  e.mu must be held.
+func (e *Emitter) marshal() [][]byte {
+	names := make([]string, 0, len(e.metrics))
+	for n := range e.metrics {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	ts := strconv.FormatInt(e.now().Unix(), 10)
+
+	var (
+		packets [][]byte
+		buf     bytes.Buffer
+	)
+
+	for _, n := range names {
+		name := e.cfg.Prefix + "." + n
+		v := strconv.FormatUint(e.metrics[n], 10)
+
+		var line string
+		switch e.cfg.Protocol {
+		case StatsD:
+			line = name + ":" + v + "|g\n"
+		default:
+			line = name + " " + v + " " + ts + "\n"
+		}
+
+		// Split StatsD metrics across multiple packets, while plaintext
+		// metrics are written to a stream as a single batch
+		if e.cfg.Protocol == StatsD && buf.Len() > 0 && buf.Len()+len(line) > maxPacketSize {
+			packets = append(packets, append([]byte(nil), buf.Bytes()...))
+			buf.Reset()
+		}
+
+		buf.WriteString(line)
+	}
+
+	return append(packets, buf.Bytes())
+}
+
+// nameReplacer replaces characters in interface names which would be
+// interpreted as metric path separators or are otherwise not permitted, such
+// as the period in VLAN interface names like "eth0.10".
+var nameReplacer = strings.NewReplacer(
+	".", "_",
+	" ", "_",
+	":", "_",
+)
@@ -0,0 +1,173 @@
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestEmitterMarshal(t *testing.T) {
+	ifis := edgemax.Interfaces{{
+		Name: "eth0.10",
+		Up:   true,
+		Stats: edgemax.InterfaceStats{
+			ReceiveBytes: 1,
+		},
+	}}
+
+	var tests = []struct {
+		desc    string
+		cfg     Config
+		s       edgemax.Stat
+		packets []string
+	}{
+		{
+			desc: "plaintext system",
+			s: &edgemax.SystemStats{
+				CPU:    10,
+				Memory: 20,
+				Uptime: time.Minute,
+			},
+			packets: []string{
+				"edgemax.system.cpu 10 1\n" +
+					"edgemax.system.memory 20 1\n" +
+					"edgemax.system.uptime_seconds 60 1\n",
+			},
+		},
+		{
+			desc: "StatsD system",
+			cfg: Config{
+				Protocol: StatsD,
+				Prefix:   "routers.gw",
+			},
+			s: &edgemax.SystemStats{CPU: 10},
+			packets: []string{
+				"routers.gw.system.cpu:10|g\n" +
+					"routers.gw.system.memory:0|g\n" +
+					"routers.gw.system.uptime_seconds:0|g\n",
+			},
+		},
+		{
+			desc: "StatsD interfaces split",
+			cfg: Config{
+				Protocol: StatsD,
+				Prefix:   strings.Repeat("x", 100),
+			},
+			s: ifis,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		e := NewEmitter("", tt.cfg)
+		e.now = func() time.Time { return time.Unix(1, 0) }
+		e.Record(tt.s)
+
+		var packets []string
+		for _, p := range e.marshal() {
+			if len(p) > maxPacketSize {
+				t.Fatalf("packet too large: %d bytes", len(p))
+			}
+
+			packets = append(packets, string(p))
+		}
+
+		if tt.packets == nil {
+			// Each of 11 interface metrics must appear exactly once
+			// across multiple packets
+			all := strings.Join(packets, "")
+			if want, got := 11, strings.Count(all, "\n"); want != got {
+				t.Fatalf("unexpected number of metrics:\n- want: %v\n-  got: %v", want, got)
+			}
+			if len(packets) < 2 {
+				t.Fatalf("expected metrics to be split across packets, got %d", len(packets))
+			}
+			if !strings.Contains(all, ".interfaces.eth0_10.rx_bytes:1|g\n") {
+				t.Fatalf("missing interface metric:\n%s", all)
+			}
+
+			continue
+		}
+
+		if want, got := tt.packets, packets; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected packets:\n- want: %q\n-  got: %q", want, got)
+		}
+	}
+}
+
+func TestEmitterFlushPlaintext(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	lineC := make(chan string, 3)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			panic(err)
+		}
+		defer c.Close()
+
+		s := bufio.NewScanner(c)
+		for s.Scan() {
+			lineC <- s.Text()
+		}
+	}()
+
+	e := NewEmitter(l.Addr().String(), Config{})
+	defer e.Close()
+	e.now = func() time.Time { return time.Unix(1, 0) }
+
+	// Nothing is sent until statistics are recorded
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	e.Record(&edgemax.SystemStats{CPU: 10})
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	if want, got := "edgemax.system.cpu 10 1", <-lineC; want != got {
+		t.Fatalf("unexpected line:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestEmitterRunStatsD(t *testing.T) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	e := NewEmitter(pc.LocalAddr().String(), Config{Protocol: StatsD})
+	defer e.Close()
+
+	statC := make(chan edgemax.Stat, 1)
+	statC <- &edgemax.SystemStats{Memory: 20}
+	close(statC)
+
+	// Closing statC flushes recorded metrics
+	if err := e.Run(context.Background(), statC); err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	b := make([]byte, maxPacketSize)
+	n, _, err := pc.ReadFrom(b)
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	if want, got := "edgemax.system.memory:20|g\n", string(b[:n]); !strings.Contains(got, want) {
+		t.Fatalf("unexpected packet:\n- want: %q\n-  got: %q", want, got)
+	}
+}
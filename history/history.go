@@ -0,0 +1,514 @@
+// Package history implements an embedded time-series store which records
+// statistics from EdgeMAX devices, so that a single program can answer
+// questions such as "what was my WAN usage last night" without an external
+// time-series database.
+//
+// Points are appended to files in a directory using a simple append-only
+// format.  Recent points are retained at full resolution, and older points
+// are periodically downsampled into fixed intervals and eventually
+// discarded.
+//
+// Series are named by the statistic they record, such as:
+//
+//	system.cpu
+//	interfaces.eth0.rx_bytes
+//	dpi.192.168.1.10.tx_bytes
+package history
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// Default values used when a Config field is unset.
+const (
+	DefaultRetention            = 48 * time.Hour
+	DefaultInterval             = 5 * time.Minute
+	DefaultDownsampledRetention = 90 * 24 * time.Hour
+)
+
+// compactInterval is the interval at which Run compacts a Store.
+const compactInterval = time.Hour
+
+// Names of the files in a Store's directory.
+const (
+	rawFile         = "raw.dat"
+	downsampledFile = "downsampled.dat"
+)
+
+// Config specifies configuration for a Store.
+type Config struct {
+	// Retention is the amount of time points are retained at full
+	// resolution before they are downsampled.  If zero, DefaultRetention
+	// is used.
+	Retention time.Duration
+
+	// Interval is the interval into which points are downsampled.  Gauges
+	// are averaged over the interval, while counters retain their last
+	// value.  If zero, DefaultInterval is used.
+	Interval time.Duration
+
+	// DownsampledRetention is the amount of time downsampled points are
+	// retained.  If zero, DefaultDownsampledRetention is used.
+	DownsampledRetention time.Duration
+}
+
+// A Point is a single value of a series at a point in time.
+type Point struct {
+	Time  time.Time
+	Value int64
+}
+
+// A Store records statistics in files in a directory.
+type Store struct {
+	dir string
+	cfg Config
+
+	mu  sync.Mutex
+	raw *os.File
+}
+
+// Open opens or creates a Store in the directory dir, using the
+// configuration in cfg.  If cfg is nil, a default configuration is used.
+// A partially written record at the end of the store, such as after a
+// crash, is discarded.
+func Open(dir string, cfg *Config) (*Store, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	c := *cfg
+	if c.Retention == 0 {
+		c.Retention = DefaultRetention
+	}
+	if c.Interval == 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.DownsampledRetention == 0 {
+		c.DownsampledRetention = DefaultDownsampledRetention
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		dir: dir,
+		cfg: c,
+	}
+
+	for _, name := range []string{rawFile, downsampledFile} {
+		if err := repair(s.path(name)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.openRaw(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Close closes the Store's files.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.raw.Close()
+}
+
+// Run records each Stat received on statC until statC is closed or ctx is
+// canceled, compacting the Store periodically.  Run can be used with the
+// channel returned by edgemax.Client.Stats.
+func (s *Store) Run(ctx context.Context, statC <-chan edgemax.Stat) error {
+	t := time.NewTicker(compactInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case st, ok := <-statC:
+			if !ok {
+				return nil
+			}
+
+			if err := s.Record(st, time.Now()); err != nil {
+				return err
+			}
+		case now := <-t.C:
+			if err := s.Compact(now); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Record records the values in st at time t.  System statistics, interface
+// traffic statistics, and DPI traffic totals for each client are recorded;
+// other types are ignored.
+func (s *Store) Record(st edgemax.Stat, t time.Time) error {
+	rs := statRecords(st, t)
+	if len(rs) == 0 {
+		return nil
+	}
+
+	var b []byte
+	for _, r := range rs {
+		rb, err := r.MarshalBinary()
+		if err != nil {
+			return err
+		}
+
+		b = append(b, rb...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.raw.Write(b)
+	return err
+}
+
+// Query returns the points of series with times in the range [start, end),
+// in chronological order.  Points older than the retention period are
+// returned at downsampled resolution.
+func (s *Store) Query(series string, start time.Time, end time.Time) ([]Point, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The downsampled file is read first, so that raw points which were
+	// already downsampled by an interrupted compaction can be skipped
+	var (
+		ps        []Point
+		watermark time.Time
+	)
+	for _, name := range []string{downsampledFile, rawFile} {
+		raw := name == rawFile
+		err := readFile(s.path(name), func(r *record) {
+			if !raw && r.isWatermark() {
+				if r.Time.After(watermark) {
+					watermark = r.Time
+				}
+				return
+			}
+			if raw && r.Time.Before(watermark) {
+				return
+			}
+			if r.Series != series || r.Time.Before(start) || !r.Time.Before(end) {
+				return
+			}
+
+			ps = append(ps, Point{Time: r.Time, Value: r.Value})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Points are appended in order, but may be recorded out of order if
+	// the caller's clock changes
+	sort.SliceStable(ps, func(i, j int) bool {
+		return ps[i].Time.Before(ps[j].Time)
+	})
+
+	return ps, nil
+}
+
+// Usage returns the increase of the counter series in the range
+// [start, end), such as the number of bytes received by an interface.
+// Counter resets, such as when a device reboots, are accounted for.
+func (s *Store) Usage(series string, start time.Time, end time.Time) (int64, error) {
+	ps, err := s.Query(series, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for i := 1; i < len(ps); i++ {
+		d := ps[i].Value - ps[i-1].Value
+		if d < 0 {
+			// The counter was reset, so the current value is the
+			// increase since the reset
+			d = ps[i].Value
+		}
+
+		total += d
+	}
+
+	return total, nil
+}
+
+// Compact downsamples points older than the retention period, and discards
+// downsampled points older than the downsampled retention period, relative
+// to now.
+//
+// The downsampled file records the cutoff of the most recent compaction, so
+// that if Compact is interrupted before the raw file is replaced, the raw
+// points which were already downsampled are ignored rather than downsampled
+// again.
+func (s *Store) Compact(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Align the cutoff to the interval, so that each interval is
+	// downsampled exactly once
+	cutoff := now.Add(-s.cfg.Retention).Truncate(s.cfg.Interval)
+	expired := now.Add(-s.cfg.DownsampledRetention)
+
+	type key struct {
+		series string
+		start  int64
+	}
+	type bucket struct {
+		counter  bool
+		sum, n   int64
+		last     int64
+		lastTime time.Time
+	}
+
+	var (
+		downsampled []*record
+		watermark   time.Time
+	)
+	err := readFile(s.path(downsampledFile), func(r *record) {
+		if r.isWatermark() {
+			if r.Time.After(watermark) {
+				watermark = r.Time
+			}
+			return
+		}
+
+		if !r.Time.Before(expired) {
+			downsampled = append(downsampled, r)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		keep    []*record
+		buckets = make(map[key]*bucket)
+	)
+
+	err = readFile(s.path(rawFile), func(r *record) {
+		if r.Time.Before(watermark) {
+			// Already downsampled by an interrupted compaction
+			return
+		}
+		if !r.Time.Before(cutoff) {
+			keep = append(keep, r)
+			return
+		}
+
+		k := key{series: r.Series, start: r.Time.Truncate(s.cfg.Interval).UnixNano()}
+		b, ok := buckets[k]
+		if !ok {
+			b = &bucket{counter: r.Counter}
+			buckets[k] = b
+		}
+
+		b.sum += r.Value
+		b.n++
+		if !r.Time.Before(b.lastTime) {
+			b.last = r.Value
+			b.lastTime = r.Time
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := make([]key, 0, len(buckets))
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].start != keys[j].start {
+			return keys[i].start < keys[j].start
+		}
+
+		return keys[i].series < keys[j].series
+	})
+
+	for _, k := range keys {
+		b := buckets[k]
+		r := &record{
+			Series:  k.series,
+			Counter: b.counter,
+			Time:    time.Unix(0, k.start),
+			Value:   b.last,
+		}
+		if !b.counter {
+			r.Value = b.sum / b.n
+		}
+
+		if !r.Time.Before(expired) {
+			downsampled = append(downsampled, r)
+		}
+	}
+
+	if cutoff.After(watermark) {
+		watermark = cutoff
+	}
+	downsampled = append(downsampled, &record{Time: watermark})
+
+	if err := writeFile(s.path(downsampledFile), downsampled); err != nil {
+		return err
+	}
+
+	// Replace the raw file.  It is always reopened for appending, even if
+	// it could not be replaced, so that Record continues to work.
+	cerr := s.raw.Close()
+	werr := writeFile(s.path(rawFile), keep)
+	if err := s.openRaw(); err != nil {
+		return err
+	}
+	if cerr != nil {
+		return cerr
+	}
+
+	return werr
+}
+
+// openRaw opens the raw file for appending.
+func (s *Store) openRaw() error {
+	f, err := os.OpenFile(s.path(rawFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	s.raw = f
+	return nil
+}
+
+// path returns the path of the file name in the Store's directory.
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// readFile invokes fn for each record in the file at path.  A missing file
+// contains no records, and reading stops at a partial or corrupt record.
+func readFile(path string, fn func(r *record)) error {
+	_, err := scanFile(path, fn)
+	return err
+}
+
+// scanFile invokes fn for each record in the file at path, and returns the
+// length of the valid records in the file.
+func scanFile(path string, fn func(r *record)) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+	defer f.Close()
+
+	cr := &countReader{r: f}
+	br := bufio.NewReader(cr)
+
+	var valid int64
+	for {
+		r, err := readRecord(br)
+		if err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF || err == errCorruptRecord {
+				return valid, nil
+			}
+
+			return 0, err
+		}
+
+		valid = cr.n - int64(br.Buffered())
+		if fn != nil {
+			fn(r)
+		}
+	}
+}
+
+// repair truncates the file at path after its last valid record.
+func repair(path string) error {
+	valid, err := scanFile(path, nil)
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	if fi.Size() == valid {
+		return nil
+	}
+
+	return os.Truncate(path, valid)
+}
+
+// writeFile atomically replaces the file at path with one containing rs.  It
+// is a variable so that tests can simulate failures.
+var writeFile = replaceFile
+
+// replaceFile atomically replaces the file at path with one containing rs.
+func replaceFile(path string, rs []*record) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(f)
+	for _, r := range rs {
+		b, err := r.MarshalBinary()
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+
+		if _, err := bw.Write(b); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// A countReader counts the bytes read from an io.Reader.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,406 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestRecordRoundTrip(t *testing.T) {
+	var tests = []struct {
+		desc string
+		r    *record
+	}{
+		{
+			desc: "gauge",
+			r: &record{
+				Series: "system.cpu",
+				Time:   time.Unix(1, 0),
+				Value:  10,
+			},
+		},
+		{
+			desc: "counter",
+			r: &record{
+				Series:  "interfaces.eth0.rx_bytes",
+				Counter: true,
+				Time:    time.Unix(1500000000, 1),
+				Value:   1 << 40,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		b, err := tt.r.MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+
+		r, err := readRecord(bufio.NewReader(bytes.NewReader(b)))
+		if err != nil {
+			t.Fatalf("failed to read record: %v", err)
+		}
+
+		if want, got := tt.r, r; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected record:\n- want: %#v\n-  got: %#v", want, got)
+		}
+	}
+}
+
+func TestReadRecordInvalid(t *testing.T) {
+	b, err := (&record{Series: "system.cpu", Time: time.Unix(1, 0)}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	corrupt := append([]byte(nil), b...)
+	corrupt[len(corrupt)-1]++
+
+	var tests = []struct {
+		desc string
+		b    []byte
+		err  error
+	}{
+		{
+			desc: "empty",
+			err:  io.EOF,
+		},
+		{
+			desc: "truncated",
+			b:    b[:len(b)-1],
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			desc: "bad checksum",
+			b:    corrupt,
+			err:  errCorruptRecord,
+		},
+		{
+			desc: "zero length",
+			b:    []byte{0x00},
+			err:  errCorruptRecord,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		_, err := readRecord(bufio.NewReader(bytes.NewReader(tt.b)))
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestStatRecords(t *testing.T) {
+	now := time.Unix(1, 0)
+
+	var tests = []struct {
+		desc   string
+		s      edgemax.Stat
+		series map[string]int64
+	}{
+		{
+			desc: "system",
+			s: &edgemax.SystemStats{
+				CPU:    10,
+				Memory: 20,
+			},
+			series: map[string]int64{
+				"system.cpu":    10,
+				"system.memory": 20,
+			},
+		},
+		{
+			desc: "interfaces",
+			s: edgemax.Interfaces{{
+				Name: "eth0",
+				Stats: edgemax.InterfaceStats{
+					ReceiveBytes:  1,
+					TransmitBytes: 2,
+					ReceiveBPS:    3,
+					TransmitBPS:   4,
+				},
+			}},
+			series: map[string]int64{
+				"interfaces.eth0.rx_bytes": 1,
+				"interfaces.eth0.tx_bytes": 2,
+				"interfaces.eth0.rx_bps":   3,
+				"interfaces.eth0.tx_bps":   4,
+			},
+		},
+		{
+			desc: "DPI",
+			s: edgemax.DPIStats{
+				{
+					IP:            net.IPv4(192, 168, 1, 10),
+					ReceiveBytes:  1,
+					TransmitBytes: 2,
+				},
+				{
+					IP:            net.IPv4(192, 168, 1, 10),
+					ReceiveBytes:  10,
+					TransmitBytes: 20,
+				},
+			},
+			series: map[string]int64{
+				"dpi.192.168.1.10.rx_bytes": 11,
+				"dpi.192.168.1.10.tx_bytes": 22,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		series := make(map[string]int64)
+		for _, r := range statRecords(tt.s, now) {
+			series[r.Series] = r.Value
+		}
+
+		if want, got := tt.series, series; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected series:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestStoreQueryUsage(t *testing.T) {
+	s, done := testStore(t, nil)
+	defer done()
+
+	// The counter is reset between the second and third points
+	for i, v := range []uint64{100, 150, 20, 50} {
+		recordEth0(t, s, time.Unix(int64(i), 0), v)
+	}
+
+	ps, err := s.Query("interfaces.eth0.rx_bytes", time.Unix(1, 0), time.Unix(3, 0))
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+
+	want := []Point{
+		{Time: time.Unix(1, 0), Value: 150},
+		{Time: time.Unix(2, 0), Value: 20},
+	}
+	if got := ps; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected points:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	u, err := s.Usage("interfaces.eth0.rx_bytes", time.Unix(0, 0), time.Unix(4, 0))
+	if err != nil {
+		t.Fatalf("failed to compute usage: %v", err)
+	}
+
+	if want, got := int64(50+20+30), u; want != got {
+		t.Fatalf("unexpected usage:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestStoreCompact(t *testing.T) {
+	s, done := testStore(t, &Config{
+		Retention:            time.Hour,
+		Interval:             time.Minute,
+		DownsampledRetention: 24 * time.Hour,
+	})
+	defer done()
+
+	base := time.Unix(0, 0).Add(24 * time.Hour)
+
+	// Two points in each of two old intervals, and one recent point
+	for i, v := range []uint64{10, 20, 30, 50} {
+		recordEth0(t, s, base.Add(time.Duration(i)*30*time.Second), v)
+	}
+	recordEth0(t, s, base.Add(2*time.Hour), 100)
+
+	if err := s.Compact(base.Add(2 * time.Hour)); err != nil {
+		t.Fatalf("failed to compact: %v", err)
+	}
+
+	for _, tt := range []struct {
+		series string
+		want   []Point
+	}{
+		{
+			// Counters keep the last value in each interval
+			series: "interfaces.eth0.rx_bytes",
+			want: []Point{
+				{Time: base, Value: 20},
+				{Time: base.Add(time.Minute), Value: 50},
+				{Time: base.Add(2 * time.Hour), Value: 100},
+			},
+		},
+		{
+			// Gauges are averaged over each interval
+			series: "interfaces.eth0.rx_bps",
+			want: []Point{
+				{Time: base, Value: 15},
+				{Time: base.Add(time.Minute), Value: 40},
+				{Time: base.Add(2 * time.Hour), Value: 100},
+			},
+		},
+	} {
+		ps := query(t, s, tt.series)
+		if want, got := tt.want, ps; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected %s points:\n- want: %v\n-  got: %v", tt.series, want, got)
+		}
+	}
+
+	// Compacting again much later discards everything
+	if err := s.Compact(base.Add(48 * time.Hour)); err != nil {
+		t.Fatalf("failed to compact: %v", err)
+	}
+
+	if ps := query(t, s, "interfaces.eth0.rx_bytes"); len(ps) != 0 {
+		t.Fatalf("unexpected points after retention: %v", ps)
+	}
+}
+
+func TestStoreCompactInterrupted(t *testing.T) {
+	s, done := testStore(t, &Config{
+		Retention:            time.Hour,
+		Interval:             time.Minute,
+		DownsampledRetention: 24 * time.Hour,
+	})
+	defer done()
+
+	base := time.Unix(0, 0).Add(24 * time.Hour)
+
+	for i, v := range []uint64{10, 20, 30, 50} {
+		recordEth0(t, s, base.Add(time.Duration(i)*30*time.Second), v)
+	}
+
+	// Simulate a crash after the downsampled file is written, but before
+	// the raw file is replaced
+	writeFile = func(path string, rs []*record) error {
+		if path == s.path(rawFile) {
+			return errors.New("injected failure")
+		}
+
+		return replaceFile(path, rs)
+	}
+	defer func() { writeFile = replaceFile }()
+
+	if err := s.Compact(base.Add(2 * time.Hour)); err == nil {
+		t.Fatal("expected an error from compaction, but none occurred")
+	}
+
+	writeFile = replaceFile
+
+	// The raw file must remain open for recording
+	recordEth0(t, s, base.Add(2*time.Hour), 100)
+
+	want := []Point{
+		{Time: base, Value: 20},
+		{Time: base.Add(time.Minute), Value: 50},
+		{Time: base.Add(2 * time.Hour), Value: 100},
+	}
+
+	// Points which were already downsampled must not be returned or
+	// downsampled again
+	for i := 0; i < 2; i++ {
+		if got := query(t, s, "interfaces.eth0.rx_bytes"); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected points:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		u, err := s.Usage("interfaces.eth0.rx_bytes", base, base.Add(3*time.Hour))
+		if err != nil {
+			t.Fatalf("failed to compute usage: %v", err)
+		}
+		if want, got := int64(80), u; want != got {
+			t.Fatalf("unexpected usage:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		if err := s.Compact(base.Add(2 * time.Hour)); err != nil {
+			t.Fatalf("failed to compact: %v", err)
+		}
+	}
+}
+
+func TestOpenRepairsPartialRecord(t *testing.T) {
+	s, done := testStore(t, nil)
+	defer done()
+
+	recordEth0(t, s, time.Unix(1, 0), 10)
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close store: %v", err)
+	}
+
+	// Simulate a crash while a record was being written
+	f, err := os.OpenFile(s.path(rawFile), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("failed to open raw file: %v", err)
+	}
+	if _, err := f.Write([]byte{0x20, 0x01, 0x02}); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	_ = f.Close()
+
+	s, err = Open(s.dir, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer s.Close()
+
+	recordEth0(t, s, time.Unix(2, 0), 20)
+
+	want := []Point{
+		{Time: time.Unix(1, 0), Value: 10},
+		{Time: time.Unix(2, 0), Value: 20},
+	}
+	if got := query(t, s, "interfaces.eth0.rx_bytes"); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected points:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+// recordEth0 records interface statistics for eth0 with all values set to v.
+func recordEth0(t *testing.T, s *Store, tm time.Time, v uint64) {
+	err := s.Record(edgemax.Interfaces{{
+		Name: "eth0",
+		Stats: edgemax.InterfaceStats{
+			ReceiveBytes: v,
+			ReceiveBPS:   v,
+		},
+	}}, tm)
+	if err != nil {
+		t.Fatalf("failed to record: %v", err)
+	}
+}
+
+// query returns all points for series.
+func query(t *testing.T, s *Store, series string) []Point {
+	ps, err := s.Query(series, time.Unix(0, 0), time.Unix(1<<40, 0))
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+
+	return ps
+}
+
+func testStore(t *testing.T, cfg *Config) (*Store, func()) {
+	dir, err := ioutil.TempDir("", "edgemax-history")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+
+	s, err := Open(filepath.Join(dir, "store"), cfg)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	return s, func() {
+		_ = s.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,114 @@
+package history
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// A record is a single point of a series, as stored in a file.
+type record struct {
+	Series  string
+	Counter bool
+	Time    time.Time
+	Value   int64
+}
+
+// isWatermark reports whether r is the watermark record stored in the
+// downsampled file, which has no series and records the cutoff of the most
+// recent compaction.
+func (r *record) isWatermark() bool {
+	return r.Series == ""
+}
+
+// errCorruptRecord is returned when a record fails its integrity check.
+var errCorruptRecord = errors.New("corrupt history record")
+
+// maxRecordLength is the maximum length of an encoded record, which guards
+// against allocating huge buffers for corrupt lengths.
+const maxRecordLength = 4096
+
+// Record layout: uvarint payload length, payload, and CRC-32 of the payload.
+// The payload contains a flags byte, varint Unix nanosecond time, varint
+// value, and the series name.
+const flagCounter = 0x01
+
+// MarshalBinary marshals a record into binary form.
+func (r *record) MarshalBinary() ([]byte, error) {
+	p := make([]byte, 0, 1+2*binary.MaxVarintLen64+len(r.Series))
+
+	var flags byte
+	if r.Counter {
+		flags |= flagCounter
+	}
+	p = append(p, flags)
+
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], r.Time.UnixNano())
+	p = append(p, buf[:n]...)
+	n = binary.PutVarint(buf[:], r.Value)
+	p = append(p, buf[:n]...)
+	p = append(p, r.Series...)
+
+	if len(p) > maxRecordLength {
+		return nil, errCorruptRecord
+	}
+
+	b := make([]byte, 0, binary.MaxVarintLen64+len(p)+4)
+	n = binary.PutUvarint(buf[:], uint64(len(p)))
+	b = append(b, buf[:n]...)
+	b = append(b, p...)
+
+	var crc [4]byte
+	binary.BigEndian.PutUint32(crc[:], crc32.ChecksumIEEE(p))
+	return append(b, crc[:]...), nil
+}
+
+// readRecord reads a single record from br.  io.EOF is returned at the end
+// of the input, and io.ErrUnexpectedEOF or errCorruptRecord are returned if
+// the input ends with a partial or corrupt record, such as after a crash.
+func readRecord(br *bufio.Reader) (*record, error) {
+	l, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if l == 0 || l > maxRecordLength {
+		return nil, errCorruptRecord
+	}
+
+	b := make([]byte, l+4)
+	if _, err := io.ReadFull(br, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	p := b[:l]
+	if crc32.ChecksumIEEE(p) != binary.BigEndian.Uint32(b[l:]) {
+		return nil, errCorruptRecord
+	}
+
+	r := &record{Counter: p[0]&flagCounter != 0}
+	p = p[1:]
+
+	t, n := binary.Varint(p)
+	if n <= 0 {
+		return nil, errCorruptRecord
+	}
+	p = p[n:]
+
+	v, n := binary.Varint(p)
+	if n <= 0 {
+		return nil, errCorruptRecord
+	}
+
+	r.Time = time.Unix(0, t)
+	r.Value = v
+	r.Series = string(p[n:])
+	return r, nil
+}
@@ -0,0 +1,63 @@
+package history
+
+import (
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// statRecords converts s into records with time t.  Counters are
+// cumulative values, such as bytes transferred, while all other values are
+// gauges, such as CPU usage.
+func statRecords(s edgemax.Stat, t time.Time) []*record {
+	var rs []*record
+	add := func(series string, counter bool, v uint64) {
+		rs = append(rs, &record{
+			Series:  series,
+			Counter: counter,
+			Time:    t,
+			Value:   int64(v),
+		})
+	}
+
+	switch s := s.(type) {
+	case *edgemax.SystemStats:
+		add("system.cpu", false, uint64(s.CPU))
+		add("system.memory", false, uint64(s.Memory))
+	case edgemax.Interfaces:
+		for _, ifi := range s {
+			p := "interfaces." + ifi.Name + "."
+			add(p+"rx_bytes", true, ifi.Stats.ReceiveBytes)
+			add(p+"tx_bytes", true, ifi.Stats.TransmitBytes)
+			add(p+"rx_bps", false, ifi.Stats.ReceiveBPS)
+			add(p+"tx_bps", false, ifi.Stats.TransmitBPS)
+		}
+	case edgemax.DPIStats:
+		// Aggregate by client, as recording each application for each
+		// client would grow the store quickly
+		type total struct{ rx, tx uint64 }
+		var (
+			ips    []string
+			totals = make(map[string]*total)
+		)
+		for _, d := range s {
+			ip := d.IP.String()
+			tt, ok := totals[ip]
+			if !ok {
+				tt = new(total)
+				totals[ip] = tt
+				ips = append(ips, ip)
+			}
+
+			tt.rx += d.ReceiveBytes
+			tt.tx += d.TransmitBytes
+		}
+
+		for _, ip := range ips {
+			add("dpi."+ip+".rx_bytes", true, totals[ip].rx)
+			add("dpi."+ip+".tx_bytes", true, totals[ip].tx)
+		}
+	}
+
+	return rs
+}
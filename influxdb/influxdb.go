@@ -0,0 +1,278 @@
+// Package influxdb implements a writer which batches statistics from EdgeMAX
+// devices and writes them to an InfluxDB v2 bucket.
+//
+// Statistics are written using the InfluxDB line protocol to the following
+// measurements:
+//
+//	edgemax_system:    cpu, memory, uptime
+//	edgemax_interface: interface statistics, tagged by interface
+//	edgemax_dpi:       DPI statistics, tagged by ip, type, and category
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// Default values used when a Config field is unset.
+const (
+	DefaultBatchSize     = 1000
+	DefaultFlushInterval = 10 * time.Second
+	DefaultMaxRetries    = 3
+)
+
+// retryBackoff is the initial amount of time waited before retrying a write
+// rejected by InfluxDB when it does not specify a Retry-After duration.
+const retryBackoff = time.Second
+
+// errIncompleteConfig is returned when a Config is missing required fields.
+var errIncompleteConfig = errors.New("URL, Org, and Bucket must be specified")
+
+// Config specifies configuration for a Writer.
+type Config struct {
+	// URL is the address of the InfluxDB server, such as
+	// "http://localhost:8086".
+	URL string
+
+	// Org, Bucket, and Token specify the organization and bucket which
+	// statistics are written to, and the API token used to authorize
+	// writes.
+	Org    string
+	Bucket string
+	Token  string
+
+	// Tags are added to every point, such as a "host" tag identifying the
+	// device.
+	Tags map[string]string
+
+	// BatchSize is the number of points which are buffered before they
+	// are written.  If zero, DefaultBatchSize is used.
+	BatchSize int
+
+	// FlushInterval is the maximum amount of time points are buffered by
+	// Run before they are written.  If zero, DefaultFlushInterval is used.
+	FlushInterval time.Duration
+
+	// MaxRetries is the number of times a write is retried when InfluxDB
+	// responds with HTTP 429 or 503.  If zero, DefaultMaxRetries is used.
+	MaxRetries int
+
+	// HTTPClient is used to perform writes.  If nil, http.DefaultClient
+	// is used.
+	HTTPClient *http.Client
+}
+
+// A Writer batches statistics and writes them to InfluxDB.
+type Writer struct {
+	cfg      Config
+	writeURL string
+	backoff  time.Duration
+
+	mu     sync.Mutex
+	buf    []byte
+	points int
+}
+
+// NewWriter creates a Writer using the configuration in cfg.
+func NewWriter(cfg Config) (*Writer, error) {
+	if cfg.URL == "" || cfg.Org == "" || cfg.Bucket == "" {
+		return nil, errIncompleteConfig
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v2/write"
+	u.RawQuery = url.Values{
+		"org":       []string{cfg.Org},
+		"bucket":    []string{cfg.Bucket},
+		"precision": []string{"ns"},
+	}.Encode()
+
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	return &Writer{
+		cfg:      cfg,
+		writeURL: u.String(),
+		backoff:  retryBackoff,
+	}, nil
+}
+
+// Run writes each Stat received on statC until statC is closed or ctx is
+// canceled, flushing buffered points at least once per flush interval.
+// Buffered points are flushed before Run returns.  Run can be used with the
+// channel returned by edgemax.Client.Stats.
+//
+// Errors writing points do not stop Run, so that an InfluxDB outage does not
+// stop the writer; the points are discarded, and the errors are reported
+// using errFn, if it is not nil.  Run returns nil when statC is closed, or
+// ctx.Err() when ctx is canceled.
+func (w *Writer) Run(ctx context.Context, statC <-chan edgemax.Stat, errFn func(err error)) error {
+	report := func(err error) {
+		if err != nil && errFn != nil {
+			errFn(err)
+		}
+	}
+
+	t := time.NewTicker(w.cfg.FlushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is already canceled, so use a fresh context to flush
+			// any remaining points
+			fctx, cancel := context.WithTimeout(context.Background(), w.cfg.FlushInterval)
+			report(w.Flush(fctx))
+			cancel()
+
+			return ctx.Err()
+		case s, ok := <-statC:
+			if !ok {
+				report(w.Flush(ctx))
+				return nil
+			}
+
+			report(w.Write(ctx, s, time.Now()))
+		case <-t.C:
+			report(w.Flush(ctx))
+		}
+	}
+}
+
+// Write buffers the points for s, with timestamp t.  If the batch size is
+// reached, the buffered points are written.  System statistics, interface
+// statistics, and DPI statistics are supported; other types are ignored.
+func (w *Writer) Write(ctx context.Context, s edgemax.Stat, t time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(w.buf)
+	w.buf = appendStat(w.buf, s, w.cfg.Tags, t)
+	w.points += bytes.Count(w.buf[n:], []byte("\n"))
+
+	if w.points < w.cfg.BatchSize {
+		return nil
+	}
+
+	return w.flush(ctx)
+}
+
+// Flush writes any buffered points.
+func (w *Writer) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.flush(ctx)
+}
+
+// flush writes any buffered points.  w.mu must be held.  Points which
+// cannot be written are discarded, so that a single bad batch or an
+// unavailable server does not cause unbounded growth of the buffer.
+func (w *Writer) flush(ctx context.Context) error {
+	if w.points == 0 {
+		return nil
+	}
+
+	b := w.buf
+	w.buf = nil
+	w.points = 0
+
+	backoff := w.backoff
+	for i := 0; ; i++ {
+		retry, wait, err := w.write(ctx, b)
+		if err == nil {
+			return nil
+		}
+		if !retry || i == w.cfg.MaxRetries {
+			return err
+		}
+
+		if wait < 0 {
+			wait = backoff
+			backoff *= 2
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// write performs a single write request containing the points in b.  If
+// retry is true, the write may be retried after waiting for the duration
+// wait, or using exponential backoff if wait is negative.
+func (w *Writer) write(ctx context.Context, b []byte) (retry bool, wait time.Duration, err error) {
+	req, err := http.NewRequest(http.MethodPost, w.writeURL, bytes.NewReader(b))
+	if err != nil {
+		return false, 0, err
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+w.cfg.Token)
+	}
+
+	res, err := w.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return false, 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 == 2 {
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		return false, 0, nil
+	}
+
+	err = responseError(res)
+
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		secs, perr := strconv.Atoi(res.Header.Get("Retry-After"))
+		if perr != nil || secs < 0 {
+			return true, -1, err
+		}
+
+		return true, time.Duration(secs) * time.Second, err
+	default:
+		return false, 0, err
+	}
+}
+
+// responseError creates an error from an unsuccessful InfluxDB response,
+// including the error message from its body, if any.
+func responseError(res *http.Response) error {
+	b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	if msg := strings.TrimSpace(string(b)); msg != "" {
+		return fmt.Errorf("InfluxDB write failed: %s: %s", res.Status, msg)
+	}
+
+	return fmt.Errorf("InfluxDB write failed: %s", res.Status)
+}
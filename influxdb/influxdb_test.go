@@ -0,0 +1,234 @@
+package influxdb
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestNewWriterIncompleteConfig(t *testing.T) {
+	_, err := NewWriter(Config{URL: "http://localhost:8086"})
+	if want, got := errIncompleteConfig, err; want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestWriterWriteBatch(t *testing.T) {
+	bodyC := make(chan string, 1)
+	w, done := testWriter(t, Config{BatchSize: 2}, func(w http.ResponseWriter, r *http.Request) {
+		if want, got := "/influx/api/v2/write", r.URL.Path; want != got {
+			t.Fatalf("unexpected path:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		q := r.URL.Query()
+		if want, got := "org/bucket/ns", q.Get("org")+"/"+q.Get("bucket")+"/"+q.Get("precision"); want != got {
+			t.Fatalf("unexpected query:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := "Token token", r.Header.Get("Authorization"); want != got {
+			t.Fatalf("unexpected Authorization:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+
+		bodyC <- string(b)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer done()
+
+	now := time.Unix(1, 0)
+	ctx := context.Background()
+
+	// The first point is buffered, and the second fills the batch
+	if err := w.Write(ctx, &edgemax.SystemStats{}, now); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	select {
+	case <-bodyC:
+		t.Fatal("points written before batch was full")
+	default:
+	}
+
+	if err := w.Write(ctx, &edgemax.SystemStats{CPU: 1}, now); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	want := "edgemax_system cpu=0i,memory=0i,uptime=0i 1000000000\n" +
+		"edgemax_system cpu=1i,memory=0i,uptime=0i 1000000000\n"
+
+	if got := <-bodyC; want != got {
+		t.Fatalf("unexpected body:\n- want: %q\n-  got: %q", want, got)
+	}
+
+	// Nothing remains to be flushed
+	if err := w.Flush(ctx); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+}
+
+func TestWriterFlushRetry(t *testing.T) {
+	var tests = []struct {
+		desc     string
+		statuses []int
+		requests int
+		err      string
+	}{
+		{
+			desc:     "retry on 429",
+			statuses: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusNoContent},
+			requests: 3,
+		},
+		{
+			desc:     "too many retries",
+			statuses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+			requests: 3,
+			err:      "InfluxDB write failed: 429 Too Many Requests: slow down",
+		},
+		{
+			desc:     "no retry on 400",
+			statuses: []int{http.StatusBadRequest},
+			requests: 1,
+			err:      "InfluxDB write failed: 400 Bad Request: slow down",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var requests int
+		w, done := testWriter(t, Config{MaxRetries: 2}, func(w http.ResponseWriter, r *http.Request) {
+			status := tt.statuses[requests]
+			requests++
+
+			if status == http.StatusNoContent {
+				w.WriteHeader(status)
+				return
+			}
+
+			// Exercise both Retry-After and exponential backoff
+			if requests == 1 {
+				w.Header().Set("Retry-After", "0")
+			}
+			http.Error(w, "slow down", status)
+		})
+		w.backoff = time.Millisecond
+
+		if err := w.Write(context.Background(), &edgemax.SystemStats{}, time.Unix(1, 0)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+
+		err := w.Flush(context.Background())
+		if want, got := tt.err, errString(err); want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		if want, got := tt.requests, requests; want != got {
+			t.Fatalf("unexpected number of requests:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		done()
+	}
+}
+
+func TestWriterRun(t *testing.T) {
+	bodyC := make(chan string, 1)
+	w, done := testWriter(t, Config{}, func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+
+		bodyC <- string(b)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer done()
+
+	statC := make(chan edgemax.Stat, 1)
+	statC <- edgemax.Interfaces{{Name: "eth0"}}
+	close(statC)
+
+	// Closing statC flushes buffered points
+	if err := w.Run(context.Background(), statC, nil); err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	if len(<-bodyC) == 0 {
+		t.Fatal("no points written")
+	}
+}
+
+func TestWriterRunContinuesAfterError(t *testing.T) {
+	var writes int
+	bodyC := make(chan string, 1)
+	w, done := testWriter(t, Config{BatchSize: 1}, func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+
+		// Reject the first batch outright
+		writes++
+		if writes == 1 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		bodyC <- string(b)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	defer done()
+
+	statC := make(chan edgemax.Stat, 2)
+	statC <- edgemax.Interfaces{{Name: "eth0"}}
+	statC <- edgemax.Interfaces{{Name: "eth1"}}
+	close(statC)
+
+	var errs []error
+	if err := w.Run(context.Background(), statC, func(err error) {
+		errs = append(errs, err)
+	}); err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	if want, got := 1, len(errs); want != got {
+		t.Fatalf("unexpected number of errors:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if body := <-bodyC; !strings.Contains(body, "eth1") {
+		t.Fatalf("second batch was not written: %q", body)
+	}
+}
+
+// testWriter creates a Writer which writes to an InfluxDB server using fn.
+func testWriter(t *testing.T, cfg Config, fn http.HandlerFunc) (*Writer, func()) {
+	s := httptest.NewServer(fn)
+
+	cfg.URL = s.URL + "/influx/"
+	cfg.Org = "org"
+	cfg.Bucket = "bucket"
+	cfg.Token = "token"
+
+	w, err := NewWriter(cfg)
+	if err != nil {
+		t.Fatalf("failed to create writer: %v", err)
+	}
+
+	return w, func() { s.Close() }
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
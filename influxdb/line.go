@@ -0,0 +1,160 @@
+package influxdb
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// Measurement names used for each type of statistic.
+const (
+	measurementSystem    = "edgemax_system"
+	measurementInterface = "edgemax_interface"
+	measurementDPI       = "edgemax_dpi"
+)
+
+// A field is a single field of a point.
+type field struct {
+	key   string
+	value string
+}
+
+// intField creates an integer field.
+func intField(key string, v int) field {
+	return field{key: key, value: strconv.Itoa(v) + "i"}
+}
+
+// uintField creates an integer field from a 64-bit counter.  Counters are
+// written as signed integers, rather than InfluxDB's unsigned type, so that
+// their type matches existing series, and values which cannot be represented
+// are clamped.
+func uintField(key string, v uint64) field {
+	if v > math.MaxInt64 {
+		v = math.MaxInt64
+	}
+
+	return field{key: key, value: strconv.FormatUint(v, 10) + "i"}
+}
+
+// boolField creates a boolean field.
+func boolField(key string, v bool) field {
+	return field{key: key, value: strconv.FormatBool(v)}
+}
+
+// appendStat appends the line protocol representation of s to b, using the
+// additional tags in tags and timestamp t.  Unsupported statistic types are
+// ignored.
+func appendStat(b []byte, s edgemax.Stat, tags map[string]string, t time.Time) []byte {
+	switch s := s.(type) {
+	case *edgemax.SystemStats:
+		b = appendPoint(b, measurementSystem, tags, nil, []field{
+			intField("cpu", s.CPU),
+			intField("memory", s.Memory),
+			intField("uptime", int(s.Uptime/time.Second)),
+		}, t)
+	case edgemax.Interfaces:
+		for _, ifi := range s {
+			st := ifi.Stats
+			b = appendPoint(b, measurementInterface, tags, map[string]string{
+				"interface": ifi.Name,
+			}, []field{
+				boolField("up", ifi.Up),
+				uintField("rx_packets", st.ReceivePackets),
+				uintField("tx_packets", st.TransmitPackets),
+				uintField("rx_bytes", st.ReceiveBytes),
+				uintField("tx_bytes", st.TransmitBytes),
+				uintField("rx_errors", st.ReceiveErrors),
+				uintField("tx_errors", st.TransmitErrors),
+				uintField("rx_dropped", st.ReceiveDropped),
+				uintField("tx_dropped", st.TransmitDropped),
+				uintField("multicast", st.Multicast),
+				uintField("rx_bps", st.ReceiveBPS),
+				uintField("tx_bps", st.TransmitBPS),
+			}, t)
+		}
+	case edgemax.DPIStats:
+		for _, d := range s {
+			b = appendPoint(b, measurementDPI, tags, map[string]string{
+				"ip":       d.IP.String(),
+				"type":     d.Type,
+				"category": d.Category,
+			}, []field{
+				uintField("rx_bytes", d.ReceiveBytes),
+				uintField("rx_rate", d.ReceiveRate),
+				uintField("tx_bytes", d.TransmitBytes),
+				uintField("tx_rate", d.TransmitRate),
+			}, t)
+		}
+	}
+
+	return b
+}
+
+// appendPoint appends a single line protocol point to b.  Tags from both
+// common and tags are sorted by key, as recommended by InfluxDB.
+func appendPoint(b []byte, measurement string, common map[string]string, tags map[string]string, fields []field, t time.Time) []byte {
+	b = append(b, measurementEscaper.Replace(measurement)...)
+
+	all := make(map[string]string, len(common)+len(tags))
+	for k, v := range common {
+		all[k] = v
+	}
+	for k, v := range tags {
+		all[k] = v
+	}
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		// Empty tag values are not permitted
+		v := all[k]
+		if v == "" {
+			continue
+		}
+
+		b = append(b, ',')
+		b = append(b, tagEscaper.Replace(k)...)
+		b = append(b, '=')
+		b = append(b, tagEscaper.Replace(v)...)
+	}
+
+	for i, f := range fields {
+		if i == 0 {
+			b = append(b, ' ')
+		} else {
+			b = append(b, ',')
+		}
+
+		b = append(b, tagEscaper.Replace(f.key)...)
+		b = append(b, '=')
+		b = append(b, f.value...)
+	}
+
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, t.UnixNano(), 10)
+	return append(b, '\n')
+}
+
+var (
+	// measurementEscaper escapes special characters in measurement names.
+	measurementEscaper = strings.NewReplacer(
+		",", `\,`,
+		" ", `\ `,
+	)
+
+	// tagEscaper escapes special characters in tag keys, tag values, and
+	// field keys.
+	tagEscaper = strings.NewReplacer(
+		",", `\,`,
+		"=", `\=`,
+		" ", `\ `,
+	)
+)
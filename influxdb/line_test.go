@@ -0,0 +1,71 @@
+package influxdb
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func Test_appendStat(t *testing.T) {
+	now := time.Unix(1, 0)
+
+	var tests = []struct {
+		desc string
+		s    edgemax.Stat
+		tags map[string]string
+		b    string
+	}{
+		{
+			desc: "unsupported",
+		},
+		{
+			desc: "system",
+			s: &edgemax.SystemStats{
+				CPU:    10,
+				Memory: 20,
+				Uptime: time.Minute,
+			},
+			tags: map[string]string{"host": "gw"},
+			b:    "edgemax_system,host=gw cpu=10i,memory=20i,uptime=60i 1000000000\n",
+		},
+		{
+			desc: "interfaces",
+			s: edgemax.Interfaces{{
+				Name: "eth0",
+				Up:   true,
+				Stats: edgemax.InterfaceStats{
+					ReceiveBytes: 1,
+				},
+			}},
+			tags: map[string]string{"site": "home office"},
+			b:    "edgemax_interface,interface=eth0,site=home\\ office up=true,rx_packets=0i,tx_packets=0i,rx_bytes=1i,tx_bytes=0i,rx_errors=0i,tx_errors=0i,rx_dropped=0i,tx_dropped=0i,multicast=0i,rx_bps=0i,tx_bps=0i 1000000000\n",
+		},
+		{
+			desc: "DPI",
+			s: edgemax.DPIStats{
+				{
+					IP:       net.IPv4(192, 168, 1, 10),
+					Type:     "Web=HTTP, HTTPS",
+					Category: "Web",
+				},
+				{
+					IP:          net.IPv4(192, 168, 1, 11),
+					Category:    "Web",
+					ReceiveRate: 1,
+				},
+			},
+			b: "edgemax_dpi,category=Web,ip=192.168.1.10,type=Web\\=HTTP\\,\\ HTTPS rx_bytes=0i,rx_rate=0i,tx_bytes=0i,tx_rate=0i 1000000000\n" +
+				"edgemax_dpi,category=Web,ip=192.168.1.11 rx_bytes=0i,rx_rate=1i,tx_bytes=0i,tx_rate=0i 1000000000\n",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		if want, got := tt.b, string(appendStat(nil, tt.s, tt.tags, now)); want != got {
+			t.Fatalf("unexpected line protocol:\n- want: %q\n-  got: %q", want, got)
+		}
+	}
+}
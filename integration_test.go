@@ -0,0 +1,263 @@
+//go:build integration
+// +build integration
+
+package edgemax_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// Integration tests run against a real EdgeMAX device, and are only built
+// with the integration build tag.  The device is specified using environment
+// variables:
+//
+//	EDGEMAX_ADDR:     device address, such as https://192.168.1.1 (required)
+//	EDGEMAX_USERNAME: username (default ubnt)
+//	EDGEMAX_PASSWORD: password
+//	EDGEMAX_INSECURE: if set to 1, the device's certificate is not verified
+//	EDGEMAX_WRITE:    if set to 1, tests which modify the device are run
+//
+// For example:
+//
+//	$ EDGEMAX_ADDR=https://192.168.1.1 EDGEMAX_PASSWORD=ubnt go test -tags integration -run Integration
+//
+// Unless EDGEMAX_WRITE is set, every request other than logging in is
+// rejected before it is sent if it could modify the device.
+
+// testIntegrationTimeout is the timeout for each integration test.
+const testIntegrationTimeout = 2 * time.Minute
+
+func TestIntegrationLogin(t *testing.T) {
+	c := testIntegrationClient(t)
+
+	if _, err := c.SystemImages(); err != nil {
+		t.Fatalf("failed to retrieve system images after logging in: %v", err)
+	}
+}
+
+func TestIntegrationSystem(t *testing.T) {
+	c := testIntegrationClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testIntegrationTimeout)
+	defer cancel()
+
+	si, err := c.SystemInfo(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve system info: %v", err)
+	}
+	if si.Version == "" {
+		t.Fatal("device reported no firmware version")
+	}
+
+	imgs, err := c.SystemImages()
+	if err != nil {
+		t.Fatalf("failed to retrieve system images: %v", err)
+	}
+	if imgs.Current == "" {
+		t.Fatal("device reported no current system image")
+	}
+
+	mi, err := c.MemoryInfo()
+	if err != nil {
+		t.Fatalf("failed to retrieve memory info: %v", err)
+	}
+	if mi.Total == 0 {
+		t.Fatal("device reported no memory")
+	}
+
+	cores, err := c.CPUCores()
+	if err != nil {
+		t.Fatalf("failed to retrieve CPU cores: %v", err)
+	}
+	if len(cores) == 0 {
+		t.Fatal("device reported no CPU cores")
+	}
+}
+
+func TestIntegrationStats(t *testing.T) {
+	types := []edgemax.StatType{
+		edgemax.StatTypeSystemStats,
+		edgemax.StatTypeInterfaces,
+		edgemax.StatTypeDPIStats,
+	}
+
+	for _, st := range types {
+		t.Logf("stat type %q", st)
+
+		c := testIntegrationClient(t)
+
+		statC, done, err := c.Stats(st)
+		if err != nil {
+			t.Fatalf("failed to start stats: %v", err)
+		}
+
+		// DPI statistics are only sent if traffic analysis is enabled,
+		// so only wait a limited time for them
+		timeout := 30 * time.Second
+		var s edgemax.Stat
+		select {
+		case s = <-statC:
+		case <-time.After(timeout):
+		}
+
+		go func() {
+			for range statC {
+			}
+		}()
+		if err := done(); err != nil {
+			t.Fatalf("failed to stop stats: %v", err)
+		}
+
+		switch {
+		case s == nil && st == edgemax.StatTypeDPIStats:
+			t.Logf("no DPI statistics received; traffic analysis may be disabled")
+		case s == nil:
+			t.Fatalf("no %q statistics received within %s", st, timeout)
+		case s.StatType() != st:
+			t.Fatalf("unexpected stat type:\n- want: %v\n-  got: %v", st, s.StatType())
+		}
+	}
+}
+
+func TestIntegrationConfig(t *testing.T) {
+	c := testIntegrationClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testIntegrationTimeout)
+	defer cancel()
+
+	tree, err := c.GetConfig(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve configuration: %v", err)
+	}
+
+	if _, ok := tree.Get("system"); !ok {
+		t.Fatal("configuration has no system section")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := c.BackupConfig(ctx, buf); err != nil {
+		t.Fatalf("failed to back up configuration: %v", err)
+	}
+
+	// Backups are gzip-compressed
+	if b := buf.Bytes(); len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		t.Fatal("configuration backup is not gzip-compressed")
+	}
+}
+
+func TestIntegrationConfigWrite(t *testing.T) {
+	if os.Getenv("EDGEMAX_WRITE") != "1" {
+		t.Skip("skipping test which modifies device; set EDGEMAX_WRITE=1 to enable")
+	}
+
+	c := testIntegrationClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), testIntegrationTimeout)
+	defer cancel()
+
+	// The loopback interface description is harmless to modify
+	path := []string{"interfaces", "loopback", "lo", "description"}
+	desc := fmt.Sprintf("edgemax integration test %d", time.Now().Unix())
+
+	tree, err := c.GetConfig(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve configuration: %v", err)
+	}
+	v, hadPrev := tree.Get(path...)
+	prev, _ := v.(string)
+
+	defer func() {
+		restore := edgemax.ConfigOp{Action: edgemax.ConfigDelete, Path: path}
+		if hadPrev {
+			restore = edgemax.ConfigOp{Action: edgemax.ConfigSet, Path: path, Value: prev}
+		}
+
+		if err := c.SetConfig(context.Background(), restore); err != nil {
+			t.Errorf("failed to restore configuration: %v", err)
+		}
+	}()
+
+	if err := c.SetConfig(ctx, edgemax.ConfigOp{
+		Action: edgemax.ConfigSet,
+		Path:   path,
+		Value:  desc,
+	}); err != nil {
+		t.Fatalf("failed to set configuration: %v", err)
+	}
+
+	tree, err = c.GetConfig(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve configuration: %v", err)
+	}
+
+	if got, _ := tree.Get(path...); got != desc {
+		t.Fatalf("unexpected description:\n- want: %v\n-  got: %v", desc, got)
+	}
+}
+
+// testIntegrationClient creates a Client which is logged in to the device
+// specified by environment variables, or skips the test if none is
+// specified.
+func testIntegrationClient(t *testing.T) *edgemax.Client {
+	addr := os.Getenv("EDGEMAX_ADDR")
+	if addr == "" {
+		t.Skip("skipping integration test; set EDGEMAX_ADDR to enable")
+	}
+
+	username := os.Getenv("EDGEMAX_USERNAME")
+	if username == "" {
+		username = "ubnt"
+	}
+
+	const timeout = 30 * time.Second
+	hc := &http.Client{Timeout: timeout}
+	if os.Getenv("EDGEMAX_INSECURE") == "1" {
+		hc = edgemax.InsecureHTTPClient(timeout)
+	}
+
+	rt := hc.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	hc.Transport = &readOnlyTransport{
+		rt:    rt,
+		write: os.Getenv("EDGEMAX_WRITE") == "1",
+	}
+
+	c, err := edgemax.NewClient(addr, hc)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if err := c.Login(username, os.Getenv("EDGEMAX_PASSWORD")); err != nil {
+		t.Fatalf("failed to log in: %v", err)
+	}
+
+	return c
+}
+
+// A readOnlyTransport is an http.RoundTripper which rejects requests which
+// could modify a device, unless write is true.
+type readOnlyTransport struct {
+	rt    http.RoundTripper
+	write bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *readOnlyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// Logging in is the only request with side effects which is always
+	// permitted
+	readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead ||
+		(r.Method == http.MethodPost && (r.URL.Path == "" || r.URL.Path == "/"))
+
+	if !readOnly && !t.write {
+		return nil, fmt.Errorf("refusing to perform %s %s without EDGEMAX_WRITE=1", r.Method, r.URL.Path)
+	}
+
+	return t.rt.RoundTrip(r)
+}
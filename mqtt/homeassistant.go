@@ -0,0 +1,166 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// DefaultDiscoveryPrefix is the MQTT discovery prefix used by Home Assistant
+// when HomeAssistant.Prefix is empty.
+const DefaultDiscoveryPrefix = "homeassistant"
+
+// HomeAssistant specifies configuration for Home Assistant MQTT discovery.
+//
+// When enabled, a Bridge publishes a retained discovery message the first
+// time it publishes a statistic which corresponds to a sensor, so that the
+// sensor appears in Home Assistant automatically.  Sensors are created for
+// CPU and memory usage, WAN throughput, and DPI usage of each client.
+type HomeAssistant struct {
+	// Prefix is the discovery prefix configured in Home Assistant.  If
+	// empty, DefaultDiscoveryPrefix is used.
+	Prefix string
+
+	// NodeID uniquely identifies the device, such as "gw".  It is used in
+	// discovery topics and in the unique ID of each sensor.
+	NodeID string
+
+	// Name is the name of the device shown in Home Assistant.  If empty,
+	// NodeID is used.
+	Name string
+
+	// WANInterface is the name of the device's WAN interface, such as
+	// "eth0".  If empty, WAN throughput sensors are not created.
+	WANInterface string
+}
+
+// A haSensor is the discovery message for a Home Assistant sensor.
+type haSensor struct {
+	Name        string   `json:"name"`
+	UniqueID    string   `json:"unique_id"`
+	StateTopic  string   `json:"state_topic"`
+	Unit        string   `json:"unit_of_measurement,omitempty"`
+	DeviceClass string   `json:"device_class,omitempty"`
+	StateClass  string   `json:"state_class,omitempty"`
+	Device      haDevice `json:"device"`
+}
+
+// A haDevice is the device which a haSensor belongs to.
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+}
+
+// sensor returns the sensor which corresponds to the statistic identified
+// by path, and its object ID.  If the statistic has no corresponding
+// sensor, ok is false.
+func (ha *HomeAssistant) sensor(path []string) (s *haSensor, objectID string, ok bool) {
+	s = new(haSensor)
+
+	switch {
+	case len(path) == 2 && path[0] == "system":
+		switch path[1] {
+		case "cpu":
+			s.Name = "CPU"
+		case "memory":
+			s.Name = "Memory"
+		default:
+			return nil, "", false
+		}
+
+		s.Unit = "%"
+		s.StateClass = "measurement"
+	case len(path) == 3 && path[0] == "interfaces":
+		if ha.WANInterface == "" || path[1] != ha.WANInterface {
+			return nil, "", false
+		}
+
+		switch path[2] {
+		case "rx_bps":
+			s.Name = "WAN download"
+		case "tx_bps":
+			s.Name = "WAN upload"
+		default:
+			return nil, "", false
+		}
+
+		s.Unit = "bit/s"
+		s.DeviceClass = "data_rate"
+		s.StateClass = "measurement"
+	case len(path) == 4 && path[0] == "dpi" && path[1] == "clients":
+		switch path[3] {
+		case "rx_bytes":
+			s.Name = path[2] + " download"
+			s.Unit = "B"
+			s.DeviceClass = "data_size"
+			s.StateClass = "total_increasing"
+		case "tx_bytes":
+			s.Name = path[2] + " upload"
+			s.Unit = "B"
+			s.DeviceClass = "data_size"
+			s.StateClass = "total_increasing"
+		case "rx_rate":
+			s.Name = path[2] + " download rate"
+			s.Unit = "B/s"
+			s.DeviceClass = "data_rate"
+			s.StateClass = "measurement"
+		case "tx_rate":
+			s.Name = path[2] + " upload rate"
+			s.Unit = "B/s"
+			s.DeviceClass = "data_rate"
+			s.StateClass = "measurement"
+		default:
+			return nil, "", false
+		}
+	default:
+		return nil, "", false
+	}
+
+	name := ha.Name
+	if name == "" {
+		name = ha.NodeID
+	}
+
+	objectID = objectIDReplacer.Replace(strings.Join(path, "_"))
+	s.UniqueID = ha.NodeID + "_" + objectID
+	s.Device = haDevice{
+		Identifiers:  []string{ha.NodeID},
+		Name:         name,
+		Manufacturer: "Ubiquiti",
+	}
+
+	return s, objectID, true
+}
+
+// discovery returns the discovery topic and message for the statistic
+// identified by path and published on stateTopic.  If the statistic has no
+// corresponding sensor, ok is false.
+func (ha *HomeAssistant) discovery(path []string, stateTopic string) (topic string, payload []byte, ok bool, err error) {
+	s, objectID, ok := ha.sensor(path)
+	if !ok {
+		return "", nil, false, nil
+	}
+	s.StateTopic = stateTopic
+
+	payload, err = json.Marshal(s)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	prefix := ha.Prefix
+	if prefix == "" {
+		prefix = DefaultDiscoveryPrefix
+	}
+
+	topic = strings.Join([]string{prefix, "sensor", ha.NodeID, objectID, "config"}, "/")
+	return topic, payload, true, nil
+}
+
+// objectIDReplacer replaces characters which are not permitted in Home
+// Assistant object IDs, such as those in IPv4 and IPv6 addresses.
+var objectIDReplacer = strings.NewReplacer(
+	".", "_",
+	":", "_",
+	"/", "_",
+	" ", "_",
+)
@@ -0,0 +1,82 @@
+package mqtt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHomeAssistantDiscovery(t *testing.T) {
+	ha := &HomeAssistant{
+		NodeID:       "gw",
+		WANInterface: "eth0",
+	}
+
+	var tests = []struct {
+		desc    string
+		path    []string
+		ok      bool
+		topic   string
+		payload string
+	}{
+		{
+			desc: "system uptime",
+			path: []string{"system", "uptime_seconds"},
+		},
+		{
+			desc: "LAN interface",
+			path: []string{"interfaces", "eth1", "rx_bps"},
+		},
+		{
+			desc: "WAN interface bytes",
+			path: []string{"interfaces", "eth0", "rx_bytes"},
+		},
+		{
+			desc: "DPI category",
+			path: []string{"dpi", "categories", "Web", "rx_bytes"},
+		},
+		{
+			desc:    "CPU",
+			path:    []string{"system", "cpu"},
+			ok:      true,
+			topic:   "homeassistant/sensor/gw/system_cpu/config",
+			payload: `{"name":"CPU","unique_id":"gw_system_cpu","state_topic":"state","unit_of_measurement":"%","state_class":"measurement","device":{"identifiers":["gw"],"name":"gw","manufacturer":"Ubiquiti"}}`,
+		},
+		{
+			desc:    "WAN download",
+			path:    []string{"interfaces", "eth0", "rx_bps"},
+			ok:      true,
+			topic:   "homeassistant/sensor/gw/interfaces_eth0_rx_bps/config",
+			payload: `{"name":"WAN download","unique_id":"gw_interfaces_eth0_rx_bps","state_topic":"state","unit_of_measurement":"bit/s","device_class":"data_rate","state_class":"measurement","device":{"identifiers":["gw"],"name":"gw","manufacturer":"Ubiquiti"}}`,
+		},
+		{
+			desc:    "DPI client",
+			path:    []string{"dpi", "clients", "192.168.1.10", "tx_bytes"},
+			ok:      true,
+			topic:   "homeassistant/sensor/gw/dpi_clients_192_168_1_10_tx_bytes/config",
+			payload: `{"name":"192.168.1.10 upload","unique_id":"gw_dpi_clients_192_168_1_10_tx_bytes","state_topic":"state","unit_of_measurement":"B","device_class":"data_size","state_class":"total_increasing","device":{"identifiers":["gw"],"name":"gw","manufacturer":"Ubiquiti"}}`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		topic, payload, ok, err := ha.discovery(tt.path, "state")
+		if err != nil {
+			t.Fatalf("failed to create discovery message: %v", err)
+		}
+
+		if want, got := tt.ok, ok; want != got {
+			t.Fatalf("unexpected sensor OK:\n- want: %v\n-  got: %v", want, got)
+		}
+		if !ok {
+			continue
+		}
+
+		if want, got := tt.topic, topic; want != got {
+			t.Fatalf("unexpected topic:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := []byte(tt.payload), payload; !bytes.Equal(want, got) {
+			t.Fatalf("unexpected payload:\n- want: %s\n-  got: %s", want, got)
+		}
+	}
+}
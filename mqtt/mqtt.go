@@ -0,0 +1,353 @@
+// Package mqtt implements a bridge which publishes statistics from EdgeMAX
+// devices to an MQTT broker.
+//
+// Each statistic is published as a separate message with a plain text
+// payload, so that it can be consumed directly by home automation systems.
+// By default, topics are structured as follows:
+//
+//	edgemax/system/cpu
+//	edgemax/interfaces/eth0/rx_bytes
+//	edgemax/dpi/categories/Web/rx_bytes
+//	edgemax/dpi/clients/192.168.1.10/rx_bytes
+//
+// The topic scheme can be changed using Config.Topic.  Sensors can also be
+// created automatically in Home Assistant using Config.HomeAssistant.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// Port is the TCP port used by MQTT brokers.
+const Port = 1883
+
+// DefaultTimeout is the amount of time a Bridge waits for each operation
+// when its context has no deadline.
+const DefaultTimeout = 10 * time.Second
+
+// DefaultPrefix is the topic prefix used when Config.Topic is nil.
+const DefaultPrefix = "edgemax"
+
+// A QoS is an MQTT quality of service level.
+type QoS byte
+
+// Supported QoS levels.  Exactly once delivery is not supported, as it is
+// rarely useful for periodic statistics.
+const (
+	AtMostOnce  QoS = 0
+	AtLeastOnce QoS = 1
+)
+
+// errUnsupportedQoS is returned when a Config specifies an unsupported QoS.
+var errUnsupportedQoS = errors.New("unsupported QoS level")
+
+// Config specifies configuration for a Bridge.
+type Config struct {
+	// ClientID identifies the Bridge to the broker.  If empty, a random
+	// client ID is assigned by the broker.
+	ClientID string
+
+	// Username and Password are used to authenticate with the broker, if
+	// set.
+	Username string
+	Password string
+
+	// Topic returns the topic used to publish the statistic identified by
+	// path, such as "interfaces", "eth0", "rx_bytes".  If nil,
+	// PrefixTopic(DefaultPrefix) is used.
+	Topic func(path ...string) string
+
+	// QoS specifies the QoS level of published messages.
+	QoS QoS
+
+	// Retain specifies if the broker retains published messages, so that
+	// new subscribers receive the most recent value of each statistic.
+	Retain bool
+
+	// HomeAssistant enables Home Assistant MQTT discovery, if set.
+	HomeAssistant *HomeAssistant
+}
+
+// PrefixTopic returns a function for use with Config.Topic which joins
+// prefix and each element of a statistic's path with slashes.
+func PrefixTopic(prefix string) func(path ...string) string {
+	return func(path ...string) string {
+		return strings.Join(append([]string{prefix}, path...), "/")
+	}
+}
+
+// A Bridge publishes statistics to an MQTT broker.
+type Bridge struct {
+	cfg Config
+
+	mu   sync.Mutex
+	conn net.Conn
+	br   *bufio.Reader
+	id   uint16
+
+	// discovered tracks the topics of statistics which have been announced
+	// using Home Assistant discovery.
+	discovered map[string]bool
+}
+
+// Dial connects to the MQTT broker at addr and creates a Bridge using the
+// configuration in cfg.  If addr does not specify a port, Port is used.
+// If cfg is nil, a default configuration is used.
+func Dial(ctx context.Context, addr string, cfg *Config) (*Bridge, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if cfg.QoS > AtLeastOnce {
+		return nil, errUnsupportedQoS
+	}
+
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(Port))
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	b := newBridge(conn, *cfg)
+	if err := b.connect(ctx); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// newBridge creates a Bridge using conn and cfg.
+func newBridge(conn net.Conn, cfg Config) *Bridge {
+	if cfg.Topic == nil {
+		cfg.Topic = PrefixTopic(DefaultPrefix)
+	}
+
+	return &Bridge{
+		cfg:        cfg,
+		conn:       conn,
+		br:         bufio.NewReader(conn),
+		discovered: make(map[string]bool),
+	}
+}
+
+// Close disconnects from the broker and closes the Bridge's connection.
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Disconnect on a best-effort basis; the connection is closed anyway
+	_ = b.conn.SetDeadline(time.Now().Add(DefaultTimeout))
+	_ = b.write(&packet{Type: packetDisconnect})
+
+	return b.conn.Close()
+}
+
+// Run publishes each Stat received on statC until statC is closed or ctx
+// is canceled.  Run can be used with the channel returned by
+// edgemax.Client.Stats.
+func (b *Bridge) Run(ctx context.Context, statC <-chan edgemax.Stat) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s, ok := <-statC:
+			if !ok {
+				return nil
+			}
+
+			if err := b.Publish(ctx, s); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Publish publishes each of the statistics in s.  System statistics,
+// interface statistics, and DPI statistics aggregated by category and by
+// client are supported; other types are ignored.
+func (b *Bridge) Publish(ctx context.Context, s edgemax.Stat) error {
+	values := statValues(s)
+	if len(values) == 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.do(ctx, func() error {
+		for _, v := range values {
+			topic := b.cfg.Topic(v.path...)
+			if err := b.discover(v.path, topic); err != nil {
+				return err
+			}
+
+			if err := b.publish(topic, []byte(v.value), b.cfg.Retain); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// discover publishes a Home Assistant discovery message for the statistic
+// identified by path and published on topic, if discovery is enabled and the
+// statistic has not already been announced.
+func (b *Bridge) discover(path []string, topic string) error {
+	ha := b.cfg.HomeAssistant
+	if ha == nil || b.discovered[topic] {
+		return nil
+	}
+
+	dtopic, payload, ok, err := ha.discovery(path, topic)
+	if err != nil || !ok {
+		return err
+	}
+
+	// Discovery messages are always retained, so that sensors are restored
+	// when Home Assistant restarts
+	if err := b.publish(dtopic, payload, true); err != nil {
+		return err
+	}
+
+	b.discovered[topic] = true
+	return nil
+}
+
+// connect performs the MQTT connection handshake.
+func (b *Bridge) connect(ctx context.Context) error {
+	return b.do(ctx, func() error {
+		if err := b.write(connectPacket(b.cfg.ClientID, b.cfg.Username, b.cfg.Password)); err != nil {
+			return err
+		}
+
+		p, err := readPacket(b.br)
+		if err != nil {
+			return err
+		}
+		if p.Type != packetConnAck || len(p.Body) != 2 {
+			return errInvalidPacket
+		}
+
+		if code := p.Body[1]; code != 0 {
+			return fmt.Errorf("broker refused connection: %s", connAckReason(code))
+		}
+
+		return nil
+	})
+}
+
+// publish publishes a single message, waiting for the broker to acknowledge
+// it if required by the configured QoS level.
+func (b *Bridge) publish(topic string, payload []byte, retain bool) error {
+	// Packet identifiers must be non-zero
+	b.id++
+	if b.id == 0 {
+		b.id++
+	}
+
+	if err := b.write(publishPacket(topic, payload, b.cfg.QoS, retain, b.id)); err != nil {
+		return err
+	}
+
+	if b.cfg.QoS == AtMostOnce {
+		return nil
+	}
+
+	for {
+		p, err := readPacket(b.br)
+		if err != nil {
+			return err
+		}
+
+		// Ignore any other packets, such as late acknowledgements for
+		// earlier messages
+		if p.Type != packetPubAck {
+			continue
+		}
+
+		id, err := p.packetID()
+		if err != nil {
+			return err
+		}
+		if id == b.id {
+			return nil
+		}
+	}
+}
+
+// write writes p to the Bridge's connection.
+func (b *Bridge) write(p *packet) error {
+	pb, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	_, err = b.conn.Write(pb)
+	return err
+}
+
+// do invokes fn with a connection deadline derived from ctx.  b.mu must be
+// held, or the Bridge must not yet be in use.
+func (b *Bridge) do(ctx context.Context, fn func() error) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	if err := b.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	// Unblock reads and writes early if ctx is canceled before its deadline
+	doneC := make(chan struct{})
+	defer close(doneC)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = b.conn.SetDeadline(time.Unix(1, 0))
+		case <-doneC:
+		}
+	}()
+
+	if err := fn(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// connAckReason returns a description of a CONNACK return code.
+func connAckReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	default:
+		return fmt.Sprintf("unknown return code %d", code)
+	}
+}
@@ -0,0 +1,204 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestDialRefused(t *testing.T) {
+	addr := testBroker(t, 4, nil)
+
+	_, err := Dial(context.Background(), addr, nil)
+	if want, got := "broker refused connection: bad user name or password", errString(err); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestDialUnsupportedQoS(t *testing.T) {
+	_, err := Dial(context.Background(), "127.0.0.1", &Config{QoS: 2})
+	if want, got := errUnsupportedQoS, err; want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestBridgeRun(t *testing.T) {
+	pubC := make(chan *packet, 16)
+	addr := testBroker(t, 0, pubC)
+
+	b, err := Dial(context.Background(), addr, &Config{
+		ClientID: "gw",
+		Topic:    PrefixTopic("home/router"),
+		QoS:      AtLeastOnce,
+		Retain:   true,
+	})
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer b.Close()
+
+	statC := make(chan edgemax.Stat, 2)
+	statC <- &edgemax.SystemStats{CPU: 10}
+	// Unsupported statistics are ignored
+	statC <- edgemax.Stat(nil)
+	close(statC)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := b.Run(ctx, statC); err != nil {
+		t.Fatalf("failed to run bridge: %v", err)
+	}
+
+	var topics []string
+	for i := 0; i < 3; i++ {
+		p := <-pubC
+		if want, got := byte(0x03), p.Flags; want != got {
+			t.Fatalf("unexpected PUBLISH flags:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		topics = append(topics, packetTopic(p))
+	}
+
+	want := []string{
+		"home/router/system/cpu",
+		"home/router/system/memory",
+		"home/router/system/uptime_seconds",
+	}
+
+	if got := topics; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected topics:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestBridgePublishHomeAssistant(t *testing.T) {
+	pubC := make(chan *packet, 16)
+	addr := testBroker(t, 0, pubC)
+
+	b, err := Dial(context.Background(), addr, &Config{
+		HomeAssistant: &HomeAssistant{NodeID: "gw"},
+	})
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer b.Close()
+
+	// Sensors are only announced the first time they are published
+	for i := 0; i < 2; i++ {
+		if err := b.Publish(context.Background(), &edgemax.SystemStats{}); err != nil {
+			t.Fatalf("failed to publish: %v", err)
+		}
+	}
+
+	var (
+		topics   []string
+		retained []string
+	)
+	for i := 0; i < 8; i++ {
+		p := <-pubC
+		topics = append(topics, packetTopic(p))
+		if p.Flags&0x01 != 0 {
+			retained = append(retained, packetTopic(p))
+		}
+	}
+
+	want := []string{
+		"homeassistant/sensor/gw/system_cpu/config",
+		"edgemax/system/cpu",
+		"homeassistant/sensor/gw/system_memory/config",
+		"edgemax/system/memory",
+		"edgemax/system/uptime_seconds",
+		"edgemax/system/cpu",
+		"edgemax/system/memory",
+		"edgemax/system/uptime_seconds",
+	}
+
+	if got := topics; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected topics:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	wantRetained := []string{
+		"homeassistant/sensor/gw/system_cpu/config",
+		"homeassistant/sensor/gw/system_memory/config",
+	}
+
+	if got := retained; !reflect.DeepEqual(wantRetained, got) {
+		t.Fatalf("unexpected retained topics:\n- want: %v\n-  got: %v", wantRetained, got)
+	}
+}
+
+// packetTopic returns the topic of a PUBLISH packet.
+func packetTopic(p *packet) string {
+	n := int(p.Body[0])<<8 | int(p.Body[1])
+	return string(p.Body[2 : 2+n])
+}
+
+// testBroker starts a broker which accepts a single connection, replies to
+// CONNECT with the specified return code, and sends each PUBLISH packet it
+// receives on pubC, acknowledging it if required.
+func testBroker(t *testing.T, code byte, pubC chan<- *packet) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	go func() {
+		defer l.Close()
+
+		c, err := l.Accept()
+		if err != nil {
+			panic(err)
+		}
+		defer c.Close()
+
+		br := bufio.NewReader(c)
+		for {
+			p, err := readPacket(br)
+			if err != nil {
+				return
+			}
+
+			var reply *packet
+			switch p.Type {
+			case packetConnect:
+				reply = &packet{Type: packetConnAck, Body: []byte{0x00, code}}
+			case packetPublish:
+				pubC <- p
+
+				if p.Flags&0x06 != 0 {
+					n := int(p.Body[0])<<8 | int(p.Body[1])
+					reply = &packet{Type: packetPubAck, Body: p.Body[2+n : 4+n]}
+				}
+			case packetDisconnect:
+				return
+			}
+
+			if reply == nil {
+				continue
+			}
+
+			b, err := reply.MarshalBinary()
+			if err != nil {
+				panic(err)
+			}
+			if _, err := c.Write(b); err != nil {
+				return
+			}
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
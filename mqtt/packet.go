@@ -0,0 +1,172 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types.
+const (
+	packetConnect    = 1
+	packetConnAck    = 2
+	packetPublish    = 3
+	packetPubAck     = 4
+	packetDisconnect = 14
+)
+
+const (
+	// protocolLevel is the protocol level of MQTT 3.1.1.
+	protocolLevel = 4
+
+	// maxRemainingLength is the maximum length of a packet following its
+	// fixed header.
+	maxRemainingLength = 268435455
+)
+
+// CONNECT flags.
+const (
+	connectCleanSession = 0x02
+	connectPassword     = 0x40
+	connectUsername     = 0x80
+)
+
+// errInvalidPacket is returned when a packet from a broker is malformed.
+var errInvalidPacket = errors.New("invalid MQTT packet")
+
+// A packet is an MQTT control packet.
+type packet struct {
+	Type  byte
+	Flags byte
+	Body  []byte
+}
+
+// MarshalBinary marshals a packet into binary form.
+func (p *packet) MarshalBinary() ([]byte, error) {
+	if len(p.Body) > maxRemainingLength {
+		return nil, errInvalidPacket
+	}
+
+	b := make([]byte, 0, 5+len(p.Body))
+	b = append(b, p.Type<<4|p.Flags&0x0f)
+
+	// The remaining length is encoded seven bits at a time, with the high
+	// bit indicating that more bytes follow
+	n := len(p.Body)
+	for {
+		c := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			c |= 0x80
+		}
+
+		b = append(b, c)
+		if n == 0 {
+			break
+		}
+	}
+
+	return append(b, p.Body...), nil
+}
+
+// readPacket reads a single packet from r.
+func readPacket(r *bufio.Reader) (*packet, error) {
+	h, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	var n, shift int
+	for i := 0; ; i++ {
+		if i == 4 {
+			return nil, errInvalidPacket
+		}
+
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		n |= int(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			break
+		}
+	}
+
+	p := &packet{
+		Type:  h >> 4,
+		Flags: h & 0x0f,
+		Body:  make([]byte, n),
+	}
+
+	if _, err := io.ReadFull(r, p.Body); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// connectPacket creates a CONNECT packet.
+func connectPacket(clientID string, username string, password string) *packet {
+	var flags byte = connectCleanSession
+	if username != "" {
+		flags |= connectUsername
+	}
+	if password != "" {
+		flags |= connectPassword
+	}
+
+	b := appendString(nil, "MQTT")
+	// Keep alive is disabled: the bridge only writes to the broker, and
+	// write errors are used to detect a broken connection
+	b = append(b, protocolLevel, flags, 0x00, 0x00)
+	b = appendString(b, clientID)
+	if username != "" {
+		b = appendString(b, username)
+	}
+	if password != "" {
+		b = appendString(b, password)
+	}
+
+	return &packet{
+		Type: packetConnect,
+		Body: b,
+	}
+}
+
+// publishPacket creates a PUBLISH packet.  id is only used when qos is
+// greater than zero.
+func publishPacket(topic string, payload []byte, qos QoS, retain bool, id uint16) *packet {
+	flags := byte(qos) << 1
+	if retain {
+		flags |= 0x01
+	}
+
+	b := appendString(nil, topic)
+	if qos > AtMostOnce {
+		b = append(b, byte(id>>8), byte(id))
+	}
+
+	return &packet{
+		Type:  packetPublish,
+		Flags: flags,
+		Body:  append(b, payload...),
+	}
+}
+
+// packetID returns the packet identifier from the body of a PUBACK packet.
+func (p *packet) packetID() (uint16, error) {
+	if len(p.Body) != 2 {
+		return 0, errInvalidPacket
+	}
+
+	return binary.BigEndian.Uint16(p.Body), nil
+}
+
+// appendString appends the length-prefixed UTF-8 string s to b.
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
@@ -0,0 +1,123 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestPacketMarshalBinary(t *testing.T) {
+	var tests = []struct {
+		desc string
+		p    *packet
+		b    []byte
+	}{
+		{
+			desc: "no body",
+			p:    &packet{Type: packetDisconnect},
+			b:    []byte{0xe0, 0x00},
+		},
+		{
+			desc: "flags",
+			p: &packet{
+				Type:  packetPublish,
+				Flags: 0x03,
+				Body:  []byte{0xff},
+			},
+			b: []byte{0x33, 0x01, 0xff},
+		},
+		{
+			desc: "two byte length",
+			p: &packet{
+				Type: packetPublish,
+				Body: make([]byte, 321),
+			},
+			b: append([]byte{0x30, 0xc1, 0x02}, make([]byte, 321)...),
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		b, err := tt.p.MarshalBinary()
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+
+		if want, got := tt.b, b; !bytes.Equal(want, got) {
+			t.Fatalf("unexpected bytes:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		p, err := readPacket(bufio.NewReader(bytes.NewReader(b)))
+		if err != nil {
+			t.Fatalf("failed to read packet: %v", err)
+		}
+
+		if tt.p.Body == nil {
+			tt.p.Body = []byte{}
+		}
+
+		if want, got := tt.p, p; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected packet:\n- want: %#v\n-  got: %#v", want, got)
+		}
+	}
+}
+
+func Test_readPacketInvalid(t *testing.T) {
+	var tests = []struct {
+		desc string
+		b    []byte
+		err  error
+	}{
+		{
+			desc: "empty",
+			err:  io.EOF,
+		},
+		{
+			desc: "no length",
+			b:    []byte{0x20},
+			err:  io.EOF,
+		},
+		{
+			desc: "length too long",
+			b:    []byte{0x20, 0xff, 0xff, 0xff, 0xff, 0x01},
+			err:  errInvalidPacket,
+		},
+		{
+			desc: "short body",
+			b:    []byte{0x20, 0x02, 0x00},
+			err:  io.ErrUnexpectedEOF,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		_, err := readPacket(bufio.NewReader(bytes.NewReader(tt.b)))
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func Test_connectPacket(t *testing.T) {
+	want := []byte{
+		0x10, 0x17,
+		0x00, 0x04, 'M', 'Q', 'T', 'T',
+		protocolLevel, 0xc2, 0x00, 0x00,
+		0x00, 0x02, 'g', 'w',
+		0x00, 0x03, 'b', 'o', 'b',
+		0x00, 0x02, 'p', 'w',
+	}
+
+	got, err := connectPacket("gw", "bob", "pw").MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	if !bytes.Equal(want, got) {
+		t.Fatalf("unexpected CONNECT:\n- want: %v\n-  got: %v", want, got)
+	}
+}
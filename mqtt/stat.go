@@ -0,0 +1,145 @@
+package mqtt
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// A statValue is a single statistic and the path which identifies it.
+type statValue struct {
+	path  []string
+	value string
+}
+
+// statValues flattens s into individual statistics.
+func statValues(s edgemax.Stat) []statValue {
+	switch s := s.(type) {
+	case *edgemax.SystemStats:
+		return systemValues(s)
+	case edgemax.Interfaces:
+		return interfaceValues(s)
+	case edgemax.DPIStats:
+		return dpiValues(s)
+	default:
+		return nil
+	}
+}
+
+// systemValues flattens system statistics.
+func systemValues(ss *edgemax.SystemStats) []statValue {
+	return []statValue{
+		{path: []string{"system", "cpu"}, value: strconv.Itoa(ss.CPU)},
+		{path: []string{"system", "memory"}, value: strconv.Itoa(ss.Memory)},
+		{path: []string{"system", "uptime_seconds"}, value: strconv.Itoa(int(ss.Uptime / time.Second))},
+	}
+}
+
+// interfaceValues flattens the statistics of each interface.
+func interfaceValues(ifis edgemax.Interfaces) []statValue {
+	var vs []statValue
+	for _, ifi := range ifis {
+		add := func(name string, v uint64) {
+			vs = append(vs, statValue{
+				path:  []string{"interfaces", ifi.Name, name},
+				value: strconv.FormatUint(v, 10),
+			})
+		}
+
+		var up uint64
+		if ifi.Up {
+			up = 1
+		}
+		add("up", up)
+
+		s := ifi.Stats
+		add("rx_packets", s.ReceivePackets)
+		add("tx_packets", s.TransmitPackets)
+		add("rx_bytes", s.ReceiveBytes)
+		add("tx_bytes", s.TransmitBytes)
+		add("rx_errors", s.ReceiveErrors)
+		add("tx_errors", s.TransmitErrors)
+		add("rx_dropped", s.ReceiveDropped)
+		add("tx_dropped", s.TransmitDropped)
+		add("rx_bps", s.ReceiveBPS)
+		add("tx_bps", s.TransmitBPS)
+	}
+
+	return vs
+}
+
+// A dpiTotal is an aggregate of DPI statistics.
+type dpiTotal struct {
+	rxBytes, txBytes uint64
+	rxRate, txRate   uint64
+}
+
+// add adds the statistics in s to t.
+func (t *dpiTotal) add(s *edgemax.DPIStat) {
+	t.rxBytes += s.ReceiveBytes
+	t.txBytes += s.TransmitBytes
+	t.rxRate += s.ReceiveRate
+	t.txRate += s.TransmitRate
+}
+
+// dpiValues flattens DPI statistics aggregated by category and by client.
+// Individual DPI statistics are not published, as the number of topics
+// would grow with every application used by every client.
+func dpiValues(ds edgemax.DPIStats) []statValue {
+	var (
+		categories = make(map[string]*dpiTotal)
+		clients    = make(map[string]*dpiTotal)
+	)
+
+	total := func(m map[string]*dpiTotal, key string) *dpiTotal {
+		t, ok := m[key]
+		if !ok {
+			t = new(dpiTotal)
+			m[key] = t
+		}
+
+		return t
+	}
+
+	for _, d := range ds {
+		total(categories, d.Category).add(d)
+		total(clients, d.IP.String()).add(d)
+	}
+
+	var vs []statValue
+	for _, g := range []struct {
+		name   string
+		totals map[string]*dpiTotal
+	}{
+		{name: "categories", totals: categories},
+		{name: "clients", totals: clients},
+	} {
+		keys := make([]string, 0, len(g.totals))
+		for k := range g.totals {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			t := g.totals[k]
+			for _, v := range []struct {
+				name  string
+				value uint64
+			}{
+				{name: "rx_bytes", value: t.rxBytes},
+				{name: "tx_bytes", value: t.txBytes},
+				{name: "rx_rate", value: t.rxRate},
+				{name: "tx_rate", value: t.txRate},
+			} {
+				vs = append(vs, statValue{
+					path:  []string{"dpi", g.name, k, v.name},
+					value: strconv.FormatUint(v.value, 10),
+				})
+			}
+		}
+	}
+
+	return vs
+}
@@ -0,0 +1,103 @@
+package mqtt
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func Test_statValues(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    edgemax.Stat
+		vs   []statValue
+	}{
+		{
+			desc: "system",
+			s: &edgemax.SystemStats{
+				CPU:    10,
+				Memory: 20,
+				Uptime: time.Minute,
+			},
+			vs: []statValue{
+				{path: []string{"system", "cpu"}, value: "10"},
+				{path: []string{"system", "memory"}, value: "20"},
+				{path: []string{"system", "uptime_seconds"}, value: "60"},
+			},
+		},
+		{
+			desc: "interfaces",
+			s: edgemax.Interfaces{{
+				Name: "eth0",
+				Up:   true,
+				Stats: edgemax.InterfaceStats{
+					ReceiveBytes: 1,
+					TransmitBPS:  2,
+				},
+			}},
+			vs: []statValue{
+				{path: []string{"interfaces", "eth0", "up"}, value: "1"},
+				{path: []string{"interfaces", "eth0", "rx_packets"}, value: "0"},
+				{path: []string{"interfaces", "eth0", "tx_packets"}, value: "0"},
+				{path: []string{"interfaces", "eth0", "rx_bytes"}, value: "1"},
+				{path: []string{"interfaces", "eth0", "tx_bytes"}, value: "0"},
+				{path: []string{"interfaces", "eth0", "rx_errors"}, value: "0"},
+				{path: []string{"interfaces", "eth0", "tx_errors"}, value: "0"},
+				{path: []string{"interfaces", "eth0", "rx_dropped"}, value: "0"},
+				{path: []string{"interfaces", "eth0", "tx_dropped"}, value: "0"},
+				{path: []string{"interfaces", "eth0", "rx_bps"}, value: "0"},
+				{path: []string{"interfaces", "eth0", "tx_bps"}, value: "2"},
+			},
+		},
+		{
+			desc: "DPI",
+			s: edgemax.DPIStats{
+				{
+					IP:           net.IPv4(192, 168, 1, 10),
+					Category:     "Web",
+					ReceiveBytes: 1,
+				},
+				{
+					IP:            net.IPv4(192, 168, 1, 10),
+					Category:      "Streaming",
+					TransmitBytes: 2,
+				},
+				{
+					IP:           net.IPv4(192, 168, 1, 11),
+					Category:     "Web",
+					ReceiveBytes: 3,
+					ReceiveRate:  4,
+				},
+			},
+			vs: []statValue{
+				{path: []string{"dpi", "categories", "Streaming", "rx_bytes"}, value: "0"},
+				{path: []string{"dpi", "categories", "Streaming", "tx_bytes"}, value: "2"},
+				{path: []string{"dpi", "categories", "Streaming", "rx_rate"}, value: "0"},
+				{path: []string{"dpi", "categories", "Streaming", "tx_rate"}, value: "0"},
+				{path: []string{"dpi", "categories", "Web", "rx_bytes"}, value: "4"},
+				{path: []string{"dpi", "categories", "Web", "tx_bytes"}, value: "0"},
+				{path: []string{"dpi", "categories", "Web", "rx_rate"}, value: "4"},
+				{path: []string{"dpi", "categories", "Web", "tx_rate"}, value: "0"},
+				{path: []string{"dpi", "clients", "192.168.1.10", "rx_bytes"}, value: "1"},
+				{path: []string{"dpi", "clients", "192.168.1.10", "tx_bytes"}, value: "2"},
+				{path: []string{"dpi", "clients", "192.168.1.10", "rx_rate"}, value: "0"},
+				{path: []string{"dpi", "clients", "192.168.1.10", "tx_rate"}, value: "0"},
+				{path: []string{"dpi", "clients", "192.168.1.11", "rx_bytes"}, value: "3"},
+				{path: []string{"dpi", "clients", "192.168.1.11", "tx_bytes"}, value: "0"},
+				{path: []string{"dpi", "clients", "192.168.1.11", "rx_rate"}, value: "4"},
+				{path: []string{"dpi", "clients", "192.168.1.11", "tx_rate"}, value: "0"},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		if want, got := tt.vs, statValues(tt.s); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected values:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
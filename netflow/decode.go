@@ -0,0 +1,377 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+)
+
+// Protocol versions.
+const (
+	versionNine  = 9
+	versionIPFIX = 10
+)
+
+// Set IDs which carry templates rather than data.  Set IDs of
+// minDataSetID and above carry data records.
+const (
+	setTemplateV9    = 0
+	setTemplateIPFIX = 2
+	minDataSetID     = 256
+)
+
+// Lengths of packet structures.
+const (
+	headerLengthV9       = 20
+	headerLengthIPFIX    = 16
+	setHeaderLength      = 4
+	templateHeaderLength = 4
+	variableLength       = 65535
+	enterpriseBit        = 0x8000
+)
+
+// Information elements decoded into a Flow.  NetFlow v9 field types and
+// IPFIX information element IDs share the same numbering.
+const (
+	fieldBytes           = 1
+	fieldPackets         = 2
+	fieldProtocol        = 4
+	fieldTCPFlags        = 6
+	fieldSourcePort      = 7
+	fieldSourceIPv4      = 8
+	fieldInputInterface  = 10
+	fieldDestinationPort = 11
+	fieldDestinationIPv4 = 12
+	fieldOutputInterface = 14
+	fieldLastSwitched    = 21
+	fieldFirstSwitched   = 22
+	fieldSourceIPv6      = 27
+	fieldDestinationIPv6 = 28
+	fieldStartSeconds    = 150
+	fieldEndSeconds      = 151
+	fieldStartMillis     = 152
+	fieldEndMillis       = 153
+)
+
+// errInvalidPacket is returned when a NetFlow or IPFIX packet is malformed.
+var errInvalidPacket = errors.New("invalid NetFlow or IPFIX packet")
+
+// A field is a field specifier within a template.
+type field struct {
+	Type   uint16
+	Length uint16
+
+	// Enterprise-specific fields are skipped when decoding.
+	Enterprise bool
+}
+
+// A templateKey uniquely identifies a template received from an exporter.
+type templateKey struct {
+	exporter string
+	domain   uint32
+	id       uint16
+}
+
+// A decoder decodes NetFlow v9 and IPFIX packets, and retains the templates
+// needed to decode data records.
+type decoder struct {
+	templates map[templateKey][]field
+}
+
+// newDecoder creates a decoder with no templates.
+func newDecoder() *decoder {
+	return &decoder{
+		templates: make(map[templateKey][]field),
+	}
+}
+
+// A header contains the information from a packet header needed to decode
+// its records.
+type header struct {
+	version uint16
+	domain  uint32
+
+	// export is the time the packet was exported, and uptime is the device
+	// uptime at export, used to resolve NetFlow v9 timestamps.
+	export time.Time
+	uptime time.Duration
+}
+
+// decode decodes a packet sent by exporter.  Templates are stored for use
+// by later packets, and any flows which can be decoded using known templates
+// are returned.
+func (d *decoder) decode(exporter net.IP, b []byte) ([]*Flow, error) {
+	if len(b) < 2 {
+		return nil, errInvalidPacket
+	}
+
+	var h header
+	h.version = binary.BigEndian.Uint16(b[0:2])
+
+	switch h.version {
+	case versionNine:
+		if len(b) < headerLengthV9 {
+			return nil, errInvalidPacket
+		}
+
+		h.uptime = time.Duration(binary.BigEndian.Uint32(b[4:8])) * time.Millisecond
+		h.export = time.Unix(int64(binary.BigEndian.Uint32(b[8:12])), 0)
+		h.domain = binary.BigEndian.Uint32(b[16:20])
+		b = b[headerLengthV9:]
+	case versionIPFIX:
+		if len(b) < headerLengthIPFIX {
+			return nil, errInvalidPacket
+		}
+
+		// IPFIX packets specify their total length
+		l := int(binary.BigEndian.Uint16(b[2:4]))
+		if l < headerLengthIPFIX || l > len(b) {
+			return nil, errInvalidPacket
+		}
+
+		h.export = time.Unix(int64(binary.BigEndian.Uint32(b[4:8])), 0)
+		h.domain = binary.BigEndian.Uint32(b[12:16])
+		b = b[headerLengthIPFIX:l]
+	default:
+		return nil, errInvalidPacket
+	}
+
+	var flows []*Flow
+	for len(b) > 0 {
+		if len(b) < setHeaderLength {
+			return nil, errInvalidPacket
+		}
+
+		id := binary.BigEndian.Uint16(b[0:2])
+		l := int(binary.BigEndian.Uint16(b[2:4]))
+		if l < setHeaderLength || l > len(b) {
+			return nil, errInvalidPacket
+		}
+
+		set := b[setHeaderLength:l]
+		b = b[l:]
+
+		switch {
+		case (h.version == versionNine && id == setTemplateV9) ||
+			(h.version == versionIPFIX && id == setTemplateIPFIX):
+			if err := d.parseTemplates(exporter, h, set); err != nil {
+				return nil, err
+			}
+		case id >= minDataSetID:
+			fs, err := d.parseData(exporter, h, id, set)
+			if err != nil {
+				return nil, err
+			}
+
+			flows = append(flows, fs...)
+		}
+
+		// Options templates and their data are not needed to decode flows,
+		// and are skipped
+	}
+
+	return flows, nil
+}
+
+// parseTemplates parses a template set, and stores its templates.
+func (d *decoder) parseTemplates(exporter net.IP, h header, b []byte) error {
+	for len(b) >= templateHeaderLength {
+		id := binary.BigEndian.Uint16(b[0:2])
+		n := int(binary.BigEndian.Uint16(b[2:4]))
+		b = b[templateHeaderLength:]
+
+		// A zero ID is padding at the end of the set
+		if id == 0 {
+			return nil
+		}
+		if id < minDataSetID {
+			return errInvalidPacket
+		}
+
+		fields := make([]field, 0, n)
+		for i := 0; i < n; i++ {
+			if len(b) < 4 {
+				return errInvalidPacket
+			}
+
+			f := field{
+				Type:   binary.BigEndian.Uint16(b[0:2]),
+				Length: binary.BigEndian.Uint16(b[2:4]),
+			}
+			b = b[4:]
+
+			// Only IPFIX has enterprise-specific fields, which are followed
+			// by an enterprise number
+			if h.version == versionIPFIX && f.Type&enterpriseBit != 0 {
+				if len(b) < 4 {
+					return errInvalidPacket
+				}
+
+				f.Type &^= enterpriseBit
+				f.Enterprise = true
+				b = b[4:]
+			}
+
+			if f.Length == variableLength && h.version != versionIPFIX {
+				return errInvalidPacket
+			}
+
+			fields = append(fields, f)
+		}
+
+		d.templates[templateKey{
+			exporter: exporter.String(),
+			domain:   h.domain,
+			id:       id,
+		}] = fields
+	}
+
+	return nil
+}
+
+// parseData parses a data set using the template specified by id.  Data for
+// unknown templates is skipped, since templates are sent periodically and
+// may not have been received yet.
+func (d *decoder) parseData(exporter net.IP, h header, id uint16, b []byte) ([]*Flow, error) {
+	fields, ok := d.templates[templateKey{
+		exporter: exporter.String(),
+		domain:   h.domain,
+		id:       id,
+	}]
+	if !ok {
+		return nil, nil
+	}
+
+	// The minimum length of a record, used to detect padding at the end of
+	// the set
+	var min int
+	for _, f := range fields {
+		if f.Length == variableLength {
+			min++
+			continue
+		}
+
+		min += int(f.Length)
+	}
+	if min == 0 {
+		return nil, errInvalidPacket
+	}
+
+	var flows []*Flow
+	for len(b) >= min {
+		f := &Flow{Exporter: exporter}
+
+		// NetFlow v9 timestamps are device uptimes in milliseconds
+		var first, last time.Duration
+		var hasUptimes bool
+
+		for _, fd := range fields {
+			l := int(fd.Length)
+			if fd.Length == variableLength {
+				if len(b) < 1 {
+					return nil, errInvalidPacket
+				}
+
+				l = int(b[0])
+				b = b[1:]
+
+				if l == 255 {
+					if len(b) < 2 {
+						return nil, errInvalidPacket
+					}
+
+					l = int(binary.BigEndian.Uint16(b[0:2]))
+					b = b[2:]
+				}
+			}
+
+			if len(b) < l {
+				return nil, errInvalidPacket
+			}
+			v := b[:l]
+			b = b[l:]
+
+			if fd.Enterprise {
+				continue
+			}
+
+			switch fd.Type {
+			case fieldSourceIPv4, fieldSourceIPv6:
+				f.Source = ip(v)
+			case fieldDestinationIPv4, fieldDestinationIPv6:
+				f.Destination = ip(v)
+			case fieldSourcePort:
+				f.SourcePort = int(decodeUint(v))
+			case fieldDestinationPort:
+				f.DestinationPort = int(decodeUint(v))
+			case fieldProtocol:
+				f.Protocol = int(decodeUint(v))
+			case fieldTCPFlags:
+				f.TCPFlags = int(decodeUint(v))
+			case fieldBytes:
+				f.Bytes = decodeUint(v)
+			case fieldPackets:
+				f.Packets = decodeUint(v)
+			case fieldInputInterface:
+				f.InputInterface = int(decodeUint(v))
+			case fieldOutputInterface:
+				f.OutputInterface = int(decodeUint(v))
+			case fieldFirstSwitched:
+				first = time.Duration(decodeUint(v)) * time.Millisecond
+				hasUptimes = true
+			case fieldLastSwitched:
+				last = time.Duration(decodeUint(v)) * time.Millisecond
+				hasUptimes = true
+			case fieldStartSeconds:
+				f.Start = time.Unix(int64(decodeUint(v)), 0)
+			case fieldEndSeconds:
+				f.End = time.Unix(int64(decodeUint(v)), 0)
+			case fieldStartMillis:
+				f.Start = millis(decodeUint(v))
+			case fieldEndMillis:
+				f.End = millis(decodeUint(v))
+			}
+		}
+
+		if hasUptimes && h.version == versionNine {
+			f.Start = h.export.Add(first - h.uptime)
+			f.End = h.export.Add(last - h.uptime)
+		}
+
+		flows = append(flows, f)
+	}
+
+	return flows, nil
+}
+
+// decodeUint decodes a big endian unsigned integer of up to 8 bytes.  Exporters
+// may use reduced-size encoding, so any length is accepted.
+func decodeUint(b []byte) uint64 {
+	if len(b) > 8 {
+		b = b[len(b)-8:]
+	}
+
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u
+}
+
+// ip decodes an IPv4 or IPv6 address.
+func ip(b []byte) net.IP {
+	if len(b) != net.IPv4len && len(b) != net.IPv6len {
+		return nil
+	}
+
+	out := make(net.IP, len(b))
+	copy(out, b)
+	return out
+}
+
+// millis converts milliseconds since the Unix epoch to a time.Time.
+func millis(ms uint64) time.Time {
+	return time.Unix(int64(ms/1000), int64(ms%1000)*int64(time.Millisecond))
+}
@@ -0,0 +1,239 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testExporter = net.IPv4(192, 0, 2, 1)
+
+func Test_decoderDecodeV9(t *testing.T) {
+	d := newDecoder()
+
+	// Uptime of 100 seconds at export time
+	export := time.Unix(1500000000, 0)
+	h := v9Header(100000, export)
+
+	template := set(setTemplateV9,
+		u16(256), u16(9),
+		u16(fieldSourceIPv4), u16(4),
+		u16(fieldDestinationIPv4), u16(4),
+		u16(fieldSourcePort), u16(2),
+		u16(fieldDestinationPort), u16(2),
+		u16(fieldProtocol), u16(1),
+		u16(fieldBytes), u16(4),
+		u16(fieldPackets), u16(4),
+		u16(fieldFirstSwitched), u16(4),
+		u16(fieldLastSwitched), u16(4),
+	)
+
+	record := func(srcPort uint16) []byte {
+		return cat(
+			[]byte{192, 168, 1, 10},
+			[]byte{203, 0, 113, 1},
+			u16(srcPort), u16(443),
+			[]byte{6},
+			u32(1500), u32(10),
+			u32(90000), u32(99000),
+		)
+	}
+
+	// Data received before its template cannot be decoded
+	data := set(256, record(50000), record(50001), []byte{0, 0, 0})
+
+	flows, err := d.decode(testExporter, cat(h, data))
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if len(flows) != 0 {
+		t.Fatalf("unexpected flows before template: %v", flows)
+	}
+
+	flows, err = d.decode(testExporter, cat(h, template, data))
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	flow := func(srcPort int) *Flow {
+		return &Flow{
+			Exporter:        testExporter,
+			Source:          net.IP{192, 168, 1, 10},
+			Destination:     net.IP{203, 0, 113, 1},
+			SourcePort:      srcPort,
+			DestinationPort: 443,
+			Protocol:        6,
+			Bytes:           1500,
+			Packets:         10,
+			Start:           export.Add(-10 * time.Second),
+			End:             export.Add(-1 * time.Second),
+		}
+	}
+
+	if want, got := []*Flow{flow(50000), flow(50001)}, flows; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected flows:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// Templates are not shared between exporters
+	flows, err = d.decode(net.IPv4(192, 0, 2, 2), cat(h, data))
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if len(flows) != 0 {
+		t.Fatalf("unexpected flows from other exporter: %v", flows)
+	}
+}
+
+func Test_decoderDecodeIPFIX(t *testing.T) {
+	d := newDecoder()
+
+	template := set(setTemplateIPFIX,
+		u16(300), u16(6),
+		u16(fieldSourceIPv6), u16(16),
+		u16(fieldDestinationIPv6), u16(16),
+		// Enterprise-specific, variable length
+		u16(enterpriseBit|1), u16(variableLength), u32(4242),
+		u16(fieldBytes), u16(8),
+		u16(fieldStartMillis), u16(8),
+		u16(fieldEndSeconds), u16(4),
+	)
+
+	src := net.ParseIP("2001:db8::1")
+	dst := net.ParseIP("2001:db8::2")
+
+	data := set(300, cat(
+		src, dst,
+		[]byte{3, 'f', 'o', 'o'},
+		u64(1<<40),
+		u64(1500000000250),
+		u32(1500000001),
+	))
+
+	b := cat(template, data)
+	p := cat(u16(versionIPFIX), u16(uint16(headerLengthIPFIX+len(b))), u32(1500000002), u32(1), u32(0), b)
+
+	flows, err := d.decode(testExporter, p)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+
+	want := []*Flow{{
+		Exporter:    testExporter,
+		Source:      src,
+		Destination: dst,
+		Bytes:       1 << 40,
+		Start:       time.Unix(1500000000, int64(250*time.Millisecond)),
+		End:         time.Unix(1500000001, 0),
+	}}
+
+	if got := flows; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected flows:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func Test_decoderDecodeInvalid(t *testing.T) {
+	h := v9Header(0, time.Unix(0, 0))
+
+	var tests = []struct {
+		desc string
+		b    []byte
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "unknown version",
+			b:    cat(u16(5), make([]byte, 22)),
+		},
+		{
+			desc: "short v9 header",
+			b:    h[:10],
+		},
+		{
+			desc: "IPFIX length too long",
+			b:    cat(u16(versionIPFIX), u16(100), make([]byte, 12)),
+		},
+		{
+			desc: "short set header",
+			b:    cat(h, []byte{0x01, 0x00}),
+		},
+		{
+			desc: "set length too long",
+			b:    cat(h, u16(256), u16(100)),
+		},
+		{
+			desc: "short template field",
+			b:    cat(h, set(setTemplateV9, u16(256), u16(2), u16(1), u16(4))),
+		},
+		{
+			desc: "reserved template ID",
+			b:    cat(h, set(setTemplateV9, u16(1), u16(1), u16(1), u16(4))),
+		},
+		{
+			desc: "variable length field in v9",
+			b:    cat(h, set(setTemplateV9, u16(256), u16(1), u16(1), u16(variableLength))),
+		},
+		{
+			desc: "zero length template",
+			b: cat(h,
+				set(setTemplateV9, u16(256), u16(1), u16(1), u16(0)),
+				set(256, []byte{0}),
+			),
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		if want, got := errInvalidPacket, func() error {
+			_, err := newDecoder().decode(testExporter, tt.b)
+			return err
+		}(); want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+// v9Header creates a NetFlow v9 packet header.
+func v9Header(uptimeMillis uint32, export time.Time) []byte {
+	return cat(
+		u16(versionNine), u16(0),
+		u32(uptimeMillis), u32(uint32(export.Unix())),
+		u32(1), u32(0),
+	)
+}
+
+// set creates a set with the specified ID and contents.
+func set(id uint16, bs ...[]byte) []byte {
+	b := cat(bs...)
+	return cat(u16(id), u16(uint16(setHeaderLength+len(b))), b)
+}
+
+func cat(bs ...[]byte) []byte {
+	var out []byte
+	for _, b := range bs {
+		out = append(out, b...)
+	}
+
+	return out
+}
+
+func u16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func u64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
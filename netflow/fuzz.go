@@ -0,0 +1,23 @@
+//go:build gofuzz
+// +build gofuzz
+
+package netflow
+
+import "net"
+
+// Fuzz is a fuzz target for use with github.com/dvyukov/go-fuzz, which
+// tests decoding of NetFlow v9 and IPFIX packets.  Each input is decoded
+// twice, so that templates in the input are used to decode its data.
+func Fuzz(data []byte) int {
+	d := newDecoder()
+	exporter := net.IPv4(192, 0, 2, 1)
+
+	if _, err := d.decode(exporter, data); err != nil {
+		return 0
+	}
+	if _, err := d.decode(exporter, data); err != nil {
+		return 0
+	}
+
+	return 1
+}
@@ -0,0 +1,125 @@
+// Package netflow implements a collector for NetFlow v9 and IPFIX flow
+// records exported by EdgeMAX devices.
+//
+// Flow records describe individual connections through a device,
+// complementing the aggregate statistics provided by edgemax.DPIStats.
+// EdgeOS exports flow records when configured using the "system
+// flow-accounting netflow" configuration, such as:
+//
+//	set system flow-accounting netflow server 192.0.2.10 port 2055
+//	set system flow-accounting netflow version 9
+package netflow
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultPort is the UDP port commonly used by NetFlow collectors.
+const DefaultPort = 2055
+
+// A Flow is a flow record exported by a device.
+type Flow struct {
+	// Exporter is the address of the device which exported the flow.
+	Exporter net.IP
+
+	Source          net.IP
+	Destination     net.IP
+	SourcePort      int
+	DestinationPort int
+
+	// Protocol is the IP protocol number of the flow, such as 6 for TCP.
+	Protocol int
+
+	// TCPFlags is the union of all TCP flags observed in the flow.
+	TCPFlags int
+
+	Bytes   uint64
+	Packets uint64
+
+	// InputInterface and OutputInterface are the SNMP interface indices of
+	// the interfaces which the flow passed through.
+	InputInterface  int
+	OutputInterface int
+
+	Start time.Time
+	End   time.Time
+}
+
+// Collect listens for NetFlow v9 and IPFIX packets on the UDP address addr,
+// such as ":2055", and sends the flows they contain on flowC.  The done
+// closure must be invoked to stop collecting flows and clean up resources
+// from Collect; flowC is closed once done returns.
+//
+// Data records cannot be decoded until their template has been received,
+// so flows may not be sent until the exporter sends its templates, which
+// occurs periodically.  Malformed packets are ignored.
+func Collect(addr string) (flowC <-chan *Flow, done func() error, err error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	flowC, done = collect(conn)
+	return flowC, done, nil
+}
+
+// collect collects flows from packets received on conn.
+func collect(conn net.PacketConn) (<-chan *Flow, func() error) {
+	var (
+		flowC = make(chan *Flow)
+		doneC = make(chan struct{})
+		wg    sync.WaitGroup
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		d := newDecoder()
+		b := make([]byte, 65536)
+		for {
+			n, addr, err := conn.ReadFrom(b)
+			if err != nil {
+				// Closing conn stops collection; any other error is
+				// assumed to be transient
+				select {
+				case <-doneC:
+					return
+				default:
+					continue
+				}
+			}
+
+			var exporter net.IP
+			if uaddr, ok := addr.(*net.UDPAddr); ok {
+				exporter = uaddr.IP
+			}
+
+			flows, err := d.decode(exporter, b[:n])
+			if err != nil {
+				continue
+			}
+
+			for _, f := range flows {
+				select {
+				case flowC <- f:
+				case <-doneC:
+					return
+				}
+			}
+		}
+	}()
+
+	done := func() error {
+		close(doneC)
+		err := conn.Close()
+		wg.Wait()
+
+		close(flowC)
+		return err
+	}
+
+	return flowC, done
+}
@@ -0,0 +1,59 @@
+package netflow
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_collect(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	flowC, done := collect(conn)
+
+	exp, err := net.Dial("udp4", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer exp.Close()
+
+	template := set(setTemplateV9,
+		u16(256), u16(2),
+		u16(fieldSourceIPv4), u16(4),
+		u16(fieldPackets), u16(4),
+	)
+	data := set(256,
+		[]byte{192, 168, 1, 10}, u32(1),
+		[]byte{192, 168, 1, 11}, u32(2),
+	)
+
+	for _, b := range [][]byte{
+		// Malformed packets are ignored
+		{0xff},
+		append(v9Header(0, time.Unix(0, 0)), cat(template, data)...),
+	} {
+		if _, err := exp.Write(b); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+
+	f := <-flowC
+	if want, got := (net.IP{192, 168, 1, 10}), f.Source; !want.Equal(got) {
+		t.Fatalf("unexpected source:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := net.IPv4(127, 0, 0, 1), f.Exporter; !want.Equal(got) {
+		t.Fatalf("unexpected exporter:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// The second flow is never read, but done must not block
+	if err := done(); err != nil {
+		t.Fatalf("failed to stop collecting: %v", err)
+	}
+
+	if _, ok := <-flowC; ok {
+		t.Fatal("flow channel should be closed")
+	}
+}
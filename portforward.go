@@ -0,0 +1,132 @@
+package edgemax
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+)
+
+// A PortForward is a port forwarding rule configured on an EdgeMAX device,
+// which forwards traffic received on the device's WAN interface to a host on
+// its LAN.
+type PortForward struct {
+	// Number is the number of the rule, which must be greater than zero.
+	Number      int
+	Description string
+
+	// Protocol is the protocol forwarded by the rule: "tcp", "udp", or
+	// "tcp_udp".
+	Protocol string
+
+	// OriginalPort is the port, hyphenated range of ports, or
+	// comma-separated list of ports and ranges on which traffic is
+	// received.
+	OriginalPort string
+
+	// ForwardAddress is the IPv4 address of the host which receives the
+	// forwarded traffic.
+	ForwardAddress net.IP
+
+	// ForwardPort is the port on the host which receives the forwarded
+	// traffic.  If empty, traffic is forwarded to OriginalPort.
+	ForwardPort string
+}
+
+// Validate reports whether pf is a valid port forwarding rule, so that
+// malformed rules can be caught before they are sent to a device.
+func (pf *PortForward) Validate() error {
+	if pf.Number < 1 {
+		return fmt.Errorf("port forward rule number %d must be greater than zero", pf.Number)
+	}
+
+	switch pf.Protocol {
+	case "tcp", "udp", "tcp_udp":
+	default:
+		return fmt.Errorf("port forward rule %d: protocol %q must be tcp, udp, or tcp_udp", pf.Number, pf.Protocol)
+	}
+
+	if pf.OriginalPort == "" {
+		return fmt.Errorf("port forward rule %d: original port must not be empty", pf.Number)
+	}
+	if err := validateFirewallPorts(pf.OriginalPort); err != nil {
+		return fmt.Errorf("port forward rule %d: invalid original port: %v", pf.Number, err)
+	}
+
+	if pf.ForwardAddress.To4() == nil {
+		return fmt.Errorf("port forward rule %d: forward address %v must be an IPv4 address", pf.Number, pf.ForwardAddress)
+	}
+	if pf.ForwardPort != "" {
+		if err := validateFirewallPorts(pf.ForwardPort); err != nil {
+			return fmt.Errorf("port forward rule %d: invalid forward port: %v", pf.Number, err)
+		}
+	}
+
+	return nil
+}
+
+// portForwards retrieves the port forwarding rules from the configuration
+// tree t, sorted by number.
+func portForwards(t *ConfigTree) ([]*PortForward, error) {
+	rules := t.Child("port-forward", "rule")
+
+	pfs := make([]*PortForward, 0, len(rules.Children()))
+	for _, num := range rules.Children() {
+		number, err := strconv.Atoi(num)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port forward rule number %q", num)
+		}
+
+		n := rules.Child(num)
+		pf := &PortForward{
+			Number: number,
+		}
+
+		pf.Description, _ = n.Child("description").Value()
+		pf.Protocol, _ = n.Child("protocol").Value()
+		pf.OriginalPort, _ = n.Child("original-port").Value()
+		pf.ForwardPort, _ = n.Child("forward-to", "port").Value()
+
+		if addr, ok := n.Child("forward-to", "address").Value(); ok {
+			pf.ForwardAddress = net.ParseIP(addr)
+			if pf.ForwardAddress == nil {
+				return nil, fmt.Errorf("port forward rule %d: invalid forward address %q", number, addr)
+			}
+		}
+
+		pfs = append(pfs, pf)
+	}
+
+	sort.Slice(pfs, func(i int, j int) bool {
+		return pfs[i].Number < pfs[j].Number
+	})
+
+	return pfs, nil
+}
+
+// configPath returns the configuration path of pf.
+func (pf *PortForward) configPath() []string {
+	return []string{"port-forward", "rule", strconv.Itoa(pf.Number)}
+}
+
+// configTree creates the configuration tree which configures pf, relative
+// to its node within "port-forward", "rule".
+func (pf *PortForward) configTree() map[string]interface{} {
+	forward := map[string]interface{}{
+		"address": pf.ForwardAddress.String(),
+	}
+	if pf.ForwardPort != "" {
+		forward["port"] = pf.ForwardPort
+	}
+
+	m := map[string]interface{}{
+		"protocol":      pf.Protocol,
+		"original-port": pf.OriginalPort,
+		"forward-to":    forward,
+	}
+	if pf.Description != "" {
+		m["description"] = pf.Description
+	}
+
+	return m
+}
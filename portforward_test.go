@@ -0,0 +1,144 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestPortForwardValidate(t *testing.T) {
+	rule := func(fn func(pf *PortForward)) *PortForward {
+		pf := &PortForward{
+			Number:         1,
+			Protocol:       "tcp",
+			OriginalPort:   "2222",
+			ForwardAddress: net.IPv4(192, 168, 1, 10),
+			ForwardPort:    "22",
+		}
+		if fn != nil {
+			fn(pf)
+		}
+
+		return pf
+	}
+
+	var tests = []struct {
+		desc string
+		pf   *PortForward
+		ok   bool
+	}{
+		{
+			desc: "invalid number",
+			pf:   rule(func(pf *PortForward) { pf.Number = 0 }),
+		},
+		{
+			desc: "invalid protocol",
+			pf:   rule(func(pf *PortForward) { pf.Protocol = "icmp" }),
+		},
+		{
+			desc: "no original port",
+			pf:   rule(func(pf *PortForward) { pf.OriginalPort = "" }),
+		},
+		{
+			desc: "invalid original port",
+			pf:   rule(func(pf *PortForward) { pf.OriginalPort = "foo" }),
+		},
+		{
+			desc: "no forward address",
+			pf:   rule(func(pf *PortForward) { pf.ForwardAddress = nil }),
+		},
+		{
+			desc: "IPv6 forward address",
+			pf:   rule(func(pf *PortForward) { pf.ForwardAddress = net.ParseIP("fd00::10") }),
+		},
+		{
+			desc: "invalid forward port",
+			pf:   rule(func(pf *PortForward) { pf.ForwardPort = "0" }),
+		},
+		{
+			desc: "OK",
+			pf:   rule(nil),
+			ok:   true,
+		},
+		{
+			desc: "OK same port range",
+			pf: rule(func(pf *PortForward) {
+				pf.Protocol = "tcp_udp"
+				pf.OriginalPort = "27015-27030"
+				pf.ForwardPort = ""
+			}),
+			ok: true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		err := tt.pf.Validate()
+		if want, got := tt.ok, err == nil; want != got {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func Test_portForwards(t *testing.T) {
+	var tree ConfigTree
+	b := []byte(`{"port-forward":{"wan-interface":"eth0","lan-interface":"eth1","rule":{
+		"10":{"description":"games","protocol":"udp","original-port":"27015-27030","forward-to":{"address":"192.168.1.20"}},
+		"2":{"description":"ssh","protocol":"tcp","original-port":"2222","forward-to":{"address":"192.168.1.10","port":"22"}}
+	}}}`)
+	if err := json.Unmarshal(b, &tree); err != nil {
+		t.Fatalf("failed to unmarshal ConfigTree: %v", err)
+	}
+
+	pfs, err := portForwards(&tree)
+	if err != nil {
+		t.Fatalf("failed to parse port forwards: %v", err)
+	}
+
+	want := []*PortForward{
+		{
+			Number:         2,
+			Description:    "ssh",
+			Protocol:       "tcp",
+			OriginalPort:   "2222",
+			ForwardAddress: net.IPv4(192, 168, 1, 10),
+			ForwardPort:    "22",
+		},
+		{
+			Number:         10,
+			Description:    "games",
+			Protocol:       "udp",
+			OriginalPort:   "27015-27030",
+			ForwardAddress: net.IPv4(192, 168, 1, 20),
+		},
+	}
+
+	if got := pfs; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected port forwards:\n- want: %+v\n-  got: %+v", want, got)
+	}
+
+	// Each rule should produce a configuration tree which parses to the
+	// same rule
+	for _, pf := range want {
+		b, err := json.Marshal(configPath(pf.configTree(), pf.configPath()...))
+		if err != nil {
+			t.Fatalf("failed to marshal configuration tree: %v", err)
+		}
+
+		var tree ConfigTree
+		if err := json.Unmarshal(b, &tree); err != nil {
+			t.Fatalf("failed to unmarshal ConfigTree: %v", err)
+		}
+
+		got, err := portForwards(&tree)
+		if err != nil {
+			t.Fatalf("failed to parse port forwards: %v", err)
+		}
+
+		if want := []*PortForward{pf}; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected round-tripped port forwards:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package edgemax
+
+import (
+	"net"
+)
+
+// A Route is an entry in the routing table of an EdgeMAX device.
+type Route struct {
+	Destination *net.IPNet
+
+	// Gateway is the next hop for the route, or nil if the destination
+	// is directly connected.
+	Gateway net.IP
+
+	Interface string
+
+	// Protocol is the source of the route, such as "connected", "static",
+	// "kernel", "ospf", "rip", or "bgp".
+	Protocol string
+
+	Distance int
+	Metric   int
+
+	// Selected reports whether the route is the best route to its
+	// destination, and is installed in the forwarding table.
+	Selected bool
+}
@@ -0,0 +1,64 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"net"
+	"sort"
+	"time"
+)
+
+// Sessions is a slice of Session values, which contains the web interface
+// sessions active on an EdgeMAX device.
+type Sessions []*Session
+
+// A Session is a web interface session active on an EdgeMAX device.
+type Session struct {
+	ID         string
+	Username   string
+	IP         net.IP
+	LastActive time.Time
+}
+
+// UnmarshalJSON unmarshals JSON into a Sessions.
+func (s *Sessions) UnmarshalJSON(b []byte) error {
+	var v map[string]struct {
+		Username   string `json:"username"`
+		IP         string `json:"ip"`
+		LastActive string `json:"last_active"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	ss := make(Sessions, 0, len(v))
+	for id, vv := range v {
+		last, err := atoiOrZero(vv.LastActive)
+		if err != nil {
+			return err
+		}
+
+		var lastActive time.Time
+		if last != 0 {
+			lastActive = time.Unix(int64(last), 0)
+		}
+
+		ss = append(ss, &Session{
+			ID:         id,
+			Username:   vv.Username,
+			IP:         net.ParseIP(vv.IP),
+			LastActive: lastActive,
+		})
+	}
+
+	sort.Sort(bySessionID(ss))
+	*s = ss
+	return nil
+}
+
+// bySessionID is used to sort Sessions by session ID.
+type bySessionID []*Session
+
+func (b bySessionID) Len() int               { return len(b) }
+func (b bySessionID) Less(i int, j int) bool { return b[i].ID < b[j].ID }
+func (b bySessionID) Swap(i int, j int)      { b[i], b[j] = b[j], b[i] }
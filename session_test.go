@@ -0,0 +1,65 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSessionsUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		ss      Sessions
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid last active integer",
+			b:       []byte(`{"abc":{"last_active":"foo"}}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK two sessions",
+			b:    []byte(`{"def":{"username":"bar","ip":"192.168.1.20"},"abc":{"username":"foo","ip":"192.168.1.10","last_active":"1451606400"}}`),
+			ss: Sessions{
+				{
+					ID:         "abc",
+					Username:   "foo",
+					IP:         net.ParseIP("192.168.1.10"),
+					LastActive: time.Unix(1451606400, 0),
+				},
+				{
+					ID:       "def",
+					Username: "bar",
+					IP:       net.ParseIP("192.168.1.20"),
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var ss Sessions
+		err := ss.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.ss, ss; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected Sessions:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
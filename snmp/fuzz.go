@@ -0,0 +1,19 @@
+//go:build gofuzz
+// +build gofuzz
+
+package snmp
+
+// Fuzz is a fuzz target for use with github.com/dvyukov/go-fuzz, which
+// tests message.UnmarshalBinary.
+func Fuzz(data []byte) int {
+	var m message
+	if err := m.UnmarshalBinary(data); err != nil {
+		return 0
+	}
+
+	if _, err := m.MarshalBinary(); err != nil {
+		panic(err)
+	}
+
+	return 1
+}
@@ -0,0 +1,430 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BER tags used by SNMPv2c messages.
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+
+	tagIPAddress = 0x40
+	tagCounter32 = 0x41
+	tagGauge32   = 0x42
+	tagTimeTicks = 0x43
+	tagCounter64 = 0x46
+
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMIBView   = 0x82
+)
+
+// PDU types.
+const (
+	pduGetRequest = 0xa0
+	pduResponse   = 0xa2
+	pduGetBulk    = 0xa5
+)
+
+// versionTwoC is the version number used for SNMPv2c messages.
+const versionTwoC = 1
+
+// errInvalidMessage is returned when an SNMP message is malformed.
+var errInvalidMessage = errors.New("invalid SNMP message")
+
+// A message is an SNMPv2c message.
+type message struct {
+	Version   int
+	Community string
+	PDU       pdu
+}
+
+// A pdu is an SNMP protocol data unit.  For GetBulk requests, ErrorStatus
+// and ErrorIndex carry the non-repeaters and max-repetitions values.
+type pdu struct {
+	Type        byte
+	RequestID   int32
+	ErrorStatus int
+	ErrorIndex  int
+	Varbinds    []varbind
+}
+
+// A varbind is an OID and its value.
+type varbind struct {
+	OID   oid
+	Value value
+}
+
+// A value is a BER-encoded value with its tag.
+type value struct {
+	Tag   byte
+	Bytes []byte
+}
+
+// uint returns the value of an integer, counter, gauge, or time ticks value.
+func (v value) uint() (uint64, error) {
+	switch v.Tag {
+	case tagInteger, tagCounter32, tagGauge32, tagTimeTicks, tagCounter64:
+	default:
+		return 0, fmt.Errorf("unexpected SNMP value type: %#x", v.Tag)
+	}
+
+	// Unsigned values may be padded with a leading zero byte so that they
+	// are not interpreted as negative
+	b := v.Bytes
+	if len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	if len(b) == 0 || len(b) > 8 {
+		return 0, errInvalidMessage
+	}
+
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+
+	return u, nil
+}
+
+// MarshalBinary marshals a message into binary form.
+func (m *message) MarshalBinary() ([]byte, error) {
+	vbs := make([][]byte, 0, len(m.PDU.Varbinds))
+	for _, vb := range m.PDU.Varbinds {
+		o, err := vb.OID.marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		vbs = append(vbs, tlv(tagSequence,
+			tlv(tagOID, o),
+			tlv(vb.Value.Tag, vb.Value.Bytes),
+		))
+	}
+
+	return tlv(tagSequence,
+		tlv(tagInteger, marshalInt(int64(m.Version))),
+		tlv(tagOctetString, []byte(m.Community)),
+		tlv(m.PDU.Type,
+			tlv(tagInteger, marshalInt(int64(m.PDU.RequestID))),
+			tlv(tagInteger, marshalInt(int64(m.PDU.ErrorStatus))),
+			tlv(tagInteger, marshalInt(int64(m.PDU.ErrorIndex))),
+			tlv(tagSequence, vbs...),
+		),
+	), nil
+}
+
+// UnmarshalBinary unmarshals a message from binary form.
+func (m *message) UnmarshalBinary(b []byte) error {
+	tag, b, rest, err := parseTLV(b)
+	if err != nil {
+		return err
+	}
+	if tag != tagSequence || len(rest) != 0 {
+		return errInvalidMessage
+	}
+
+	var mm message
+
+	version, b, err := parseInt(b)
+	if err != nil {
+		return err
+	}
+	mm.Version = int(version)
+
+	tag, community, b, err := parseTLV(b)
+	if err != nil {
+		return err
+	}
+	if tag != tagOctetString {
+		return errInvalidMessage
+	}
+	mm.Community = string(community)
+
+	tag, b, _, err = parseTLV(b)
+	if err != nil {
+		return err
+	}
+	if tag&0xe0 != 0xa0 {
+		return errInvalidMessage
+	}
+	mm.PDU.Type = tag
+
+	ints := make([]int64, 3)
+	for i := range ints {
+		ints[i], b, err = parseInt(b)
+		if err != nil {
+			return err
+		}
+	}
+	mm.PDU.RequestID = int32(ints[0])
+	mm.PDU.ErrorStatus = int(ints[1])
+	mm.PDU.ErrorIndex = int(ints[2])
+
+	tag, b, _, err = parseTLV(b)
+	if err != nil {
+		return err
+	}
+	if tag != tagSequence {
+		return errInvalidMessage
+	}
+
+	for len(b) > 0 {
+		var vb []byte
+		tag, vb, b, err = parseTLV(b)
+		if err != nil {
+			return err
+		}
+		if tag != tagSequence {
+			return errInvalidMessage
+		}
+
+		tag, o, vb, err := parseTLV(vb)
+		if err != nil {
+			return err
+		}
+		if tag != tagOID {
+			return errInvalidMessage
+		}
+
+		oid, err := parseOID(o)
+		if err != nil {
+			return err
+		}
+
+		tag, v, _, err := parseTLV(vb)
+		if err != nil {
+			return err
+		}
+
+		mm.PDU.Varbinds = append(mm.PDU.Varbinds, varbind{
+			OID:   oid,
+			Value: value{Tag: tag, Bytes: v},
+		})
+	}
+
+	*m = mm
+	return nil
+}
+
+// tlv encodes a BER tag, length, and the concatenation of values.
+func tlv(tag byte, values ...[]byte) []byte {
+	var n int
+	for _, v := range values {
+		n += len(v)
+	}
+
+	b := []byte{tag}
+	if n < 0x80 {
+		b = append(b, byte(n))
+	} else {
+		// Long form: number of length bytes, then the length
+		var l []byte
+		for nn := n; nn > 0; nn >>= 8 {
+			l = append([]byte{byte(nn)}, l...)
+		}
+		b = append(b, 0x80|byte(len(l)))
+		b = append(b, l...)
+	}
+
+	for _, v := range values {
+		b = append(b, v...)
+	}
+
+	return b
+}
+
+// parseTLV parses a BER tag and length, and returns the value and any bytes
+// following it.
+func parseTLV(b []byte) (tag byte, v []byte, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errInvalidMessage
+	}
+
+	tag = b[0]
+	n := int(b[1])
+	b = b[2:]
+
+	if n&0x80 != 0 {
+		ll := n & 0x7f
+		if ll == 0 || ll > 4 || len(b) < ll {
+			return 0, nil, nil, errInvalidMessage
+		}
+
+		n = 0
+		for _, c := range b[:ll] {
+			n = n<<8 | int(c)
+		}
+		b = b[ll:]
+	}
+
+	if n < 0 || len(b) < n {
+		return 0, nil, nil, errInvalidMessage
+	}
+
+	return tag, b[:n], b[n:], nil
+}
+
+// marshalInt encodes i as a minimal two's complement BER integer.
+func marshalInt(i int64) []byte {
+	b := make([]byte, 8)
+	for j := 0; j < 8; j++ {
+		b[7-j] = byte(i >> uint(8*j))
+	}
+
+	// Trim redundant leading bytes while preserving the sign bit
+	for len(b) > 1 && ((b[0] == 0 && b[1]&0x80 == 0) || (b[0] == 0xff && b[1]&0x80 != 0)) {
+		b = b[1:]
+	}
+
+	return b
+}
+
+// parseInt parses a BER integer, and returns it and any bytes following it.
+func parseInt(b []byte) (int64, []byte, error) {
+	tag, v, rest, err := parseTLV(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if tag != tagInteger || len(v) == 0 || len(v) > 8 {
+		return 0, nil, errInvalidMessage
+	}
+
+	// Sign extend from the first byte
+	i := int64(int8(v[0]))
+	for _, c := range v[1:] {
+		i = i<<8 | int64(c)
+	}
+
+	return i, rest, nil
+}
+
+// An oid is an SNMP object identifier.
+type oid []uint32
+
+// mustOID parses an OID in dotted form, and panics if it is invalid.
+func mustOID(s string) oid {
+	ss := strings.Split(s, ".")
+	o := make(oid, 0, len(ss))
+	for _, s := range ss {
+		v, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			panic(fmt.Sprintf("invalid OID %q: %v", s, err))
+		}
+
+		o = append(o, uint32(v))
+	}
+
+	return o
+}
+
+// String returns the dotted form of an OID.
+func (o oid) String() string {
+	ss := make([]string, 0, len(o))
+	for _, v := range o {
+		ss = append(ss, strconv.FormatUint(uint64(v), 10))
+	}
+
+	return strings.Join(ss, ".")
+}
+
+// hasPrefix reports whether o begins with prefix.
+func (o oid) hasPrefix(prefix oid) bool {
+	if len(o) < len(prefix) {
+		return false
+	}
+
+	for i := range prefix {
+		if o[i] != prefix[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// less reports whether o sorts before p in lexicographic order.
+func (o oid) less(p oid) bool {
+	for i := 0; i < len(o) && i < len(p); i++ {
+		if o[i] != p[i] {
+			return o[i] < p[i]
+		}
+	}
+
+	return len(o) < len(p)
+}
+
+// marshal encodes an OID's value in BER form.
+func (o oid) marshal() ([]byte, error) {
+	if len(o) < 2 || o[0] > 2 || (o[0] < 2 && o[1] > 39) || o[1] > ^uint32(0)-80 {
+		return nil, fmt.Errorf("invalid OID: %s", o)
+	}
+
+	// The first two arcs are combined into a single value
+	vs := append([]uint32{o[0]*40 + o[1]}, o[2:]...)
+
+	var b []byte
+	for _, v := range vs {
+		// Base 128, with the high bit set on all but the final byte
+		enc := []byte{byte(v & 0x7f)}
+		for v >>= 7; v > 0; v >>= 7 {
+			enc = append([]byte{byte(v&0x7f) | 0x80}, enc...)
+		}
+
+		b = append(b, enc...)
+	}
+
+	return b, nil
+}
+
+// parseOID parses a BER-encoded OID value.
+func parseOID(b []byte) (oid, error) {
+	if len(b) == 0 {
+		return nil, errInvalidMessage
+	}
+
+	var (
+		o oid
+		v uint32
+	)
+
+	for i, c := range b {
+		// Reject values which would overflow 32 bits
+		if v > 1<<25-1 {
+			return nil, errInvalidMessage
+		}
+
+		v = v<<7 | uint32(c&0x7f)
+		if c&0x80 != 0 {
+			if i == len(b)-1 {
+				return nil, errInvalidMessage
+			}
+			continue
+		}
+
+		if len(o) == 0 {
+			// Split the first value into the first two arcs
+			switch {
+			case v < 40:
+				o = append(o, 0, v)
+			case v < 80:
+				o = append(o, 1, v-40)
+			default:
+				o = append(o, 2, v-80)
+			}
+		} else {
+			o = append(o, v)
+		}
+
+		v = 0
+	}
+
+	return o, nil
+}
@@ -0,0 +1,257 @@
+package snmp
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMessageMarshalBinary(t *testing.T) {
+	m := &message{
+		Version:   versionTwoC,
+		Community: "public",
+		PDU: pdu{
+			Type:      pduGetRequest,
+			RequestID: 1,
+			Varbinds: []varbind{{
+				OID:   mustOID("1.3.6.1.2.1.1.1.0"),
+				Value: value{Tag: tagNull, Bytes: []byte{}},
+			}},
+		},
+	}
+
+	want := []byte{
+		0x30, 0x26,
+		0x02, 0x01, 0x01,
+		0x04, 0x06, 'p', 'u', 'b', 'l', 'i', 'c',
+		0xa0, 0x19,
+		0x02, 0x01, 0x01,
+		0x02, 0x01, 0x00,
+		0x02, 0x01, 0x00,
+		0x30, 0x0e,
+		0x30, 0x0c,
+		0x06, 0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00,
+		0x05, 0x00,
+	}
+
+	got, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	if !bytes.Equal(want, got) {
+		t.Fatalf("unexpected bytes:\n- want: [%# x]\n-  got: [%# x]", want, got)
+	}
+
+	var mm message
+	if err := mm.UnmarshalBinary(got); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if want, got := m, &mm; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected message:\n- want: %#v\n-  got: %#v", want, got)
+	}
+}
+
+func TestMessageUnmarshalBinary(t *testing.T) {
+	var tests = []struct {
+		desc string
+		b    []byte
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "not a sequence",
+			b:    []byte{0x02, 0x01, 0x01},
+		},
+		{
+			desc: "trailing bytes",
+			b:    []byte{0x30, 0x00, 0x00},
+		},
+		{
+			desc: "length too long",
+			b:    []byte{0x30, 0x10, 0x02, 0x01},
+		},
+		{
+			desc: "long form length too long",
+			b:    []byte{0x30, 0x85, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			desc: "no community",
+			b:    []byte{0x30, 0x03, 0x02, 0x01, 0x01},
+		},
+		{
+			desc: "not a PDU",
+			b: []byte{
+				0x30, 0x08,
+				0x02, 0x01, 0x01,
+				0x04, 0x00,
+				0x02, 0x01, 0x00,
+			},
+		},
+		{
+			desc: "bad OID",
+			b: []byte{
+				0x30, 0x17,
+				0x02, 0x01, 0x01,
+				0x04, 0x00,
+				0xa2, 0x10,
+				0x02, 0x01, 0x01,
+				0x02, 0x01, 0x00,
+				0x02, 0x01, 0x00,
+				0x30, 0x05,
+				0x30, 0x03,
+				0x06, 0x01, 0x81,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var m message
+		if want, got := errInvalidMessage, m.UnmarshalBinary(tt.b); want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func Test_marshalInt(t *testing.T) {
+	var tests = []struct {
+		i int64
+		b []byte
+	}{
+		{i: 0, b: []byte{0x00}},
+		{i: 127, b: []byte{0x7f}},
+		{i: 128, b: []byte{0x00, 0x80}},
+		{i: 256, b: []byte{0x01, 0x00}},
+		{i: -1, b: []byte{0xff}},
+		{i: -128, b: []byte{0x80}},
+		{i: -129, b: []byte{0xff, 0x7f}},
+	}
+
+	for _, tt := range tests {
+		b := marshalInt(tt.i)
+		if want, got := tt.b, b; !bytes.Equal(want, got) {
+			t.Fatalf("unexpected bytes for %d:\n- want: [%# x]\n-  got: [%# x]", tt.i, want, got)
+		}
+
+		i, _, err := parseInt(tlv(tagInteger, b))
+		if err != nil {
+			t.Fatalf("failed to parse integer: %v", err)
+		}
+
+		if want, got := tt.i, i; want != got {
+			t.Fatalf("unexpected integer:\n- want: %d\n-  got: %d", want, got)
+		}
+	}
+}
+
+func Test_parseOID(t *testing.T) {
+	var tests = []struct {
+		desc string
+		b    []byte
+		o    oid
+		ok   bool
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "truncated",
+			b:    []byte{0x2b, 0x86},
+		},
+		{
+			desc: "overflow",
+			b:    []byte{0x2b, 0xff, 0xff, 0xff, 0xff, 0x7f},
+		},
+		{
+			desc: "OK",
+			b:    []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0x82, 0x37, 0x01},
+			o:    mustOID("1.3.6.1.4.1.311.1"),
+			ok:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		o, err := parseOID(tt.b)
+		if err != nil && tt.ok {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !tt.ok {
+			if err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			continue
+		}
+
+		if want, got := tt.o, o; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected OID:\n- want: %v\n-  got: %v", want, got)
+		}
+
+		b, err := o.marshal()
+		if err != nil {
+			t.Fatalf("failed to marshal OID: %v", err)
+		}
+
+		if want, got := tt.b, b; !bytes.Equal(want, got) {
+			t.Fatalf("unexpected bytes:\n- want: [%# x]\n-  got: [%# x]", want, got)
+		}
+	}
+}
+
+func Test_valueUint(t *testing.T) {
+	var tests = []struct {
+		desc string
+		v    value
+		u    uint64
+		ok   bool
+	}{
+		{
+			desc: "wrong type",
+			v:    value{Tag: tagOctetString, Bytes: []byte{0x01}},
+		},
+		{
+			desc: "empty",
+			v:    value{Tag: tagCounter32},
+		},
+		{
+			desc: "too long",
+			v:    value{Tag: tagCounter64, Bytes: make([]byte, 10)},
+		},
+		{
+			desc: "OK Counter64 padded",
+			v:    value{Tag: tagCounter64, Bytes: []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+			u:    1<<64 - 1,
+			ok:   true,
+		},
+		{
+			desc: "OK Gauge32",
+			v:    value{Tag: tagGauge32, Bytes: []byte{0x03, 0xe8}},
+			u:    1000,
+			ok:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		u, err := tt.v.uint()
+		if err != nil && tt.ok {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !tt.ok {
+			if err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			continue
+		}
+
+		if want, got := tt.u, u; want != got {
+			t.Fatalf("unexpected value:\n- want: %d\n-  got: %d", want, got)
+		}
+	}
+}
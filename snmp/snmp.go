@@ -0,0 +1,491 @@
+// Package snmp implements a client which polls EdgeMAX devices for
+// statistics using SNMPv2c.
+//
+// The standard IF-MIB and HOST-RESOURCES-MIB objects exposed by EdgeOS are
+// mapped into the same types used by package edgemax, as a lightweight
+// alternative when the web interface's websocket is not accessible.  SNMP
+// must be enabled on the device using the "service snmp" configuration.
+package snmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// Port is the UDP port used by SNMP agents.
+const Port = 161
+
+// DefaultTimeout is the amount of time a Client waits for each reply when
+// its context has no deadline.
+const DefaultTimeout = 5 * time.Second
+
+// maxRepetitions is the number of values requested by each GetBulk request.
+const maxRepetitions = 25
+
+// OIDs of objects polled by a Client.
+var (
+	oidIfTable        = mustOID("1.3.6.1.2.1.2.2.1")
+	oidIfXTable       = mustOID("1.3.6.1.2.1.31.1.1.1")
+	oidIPAdEntIfIndex = mustOID("1.3.6.1.2.1.4.20.1.2")
+
+	oidHRSystemUptime  = mustOID("1.3.6.1.2.1.25.1.1.0")
+	oidHRStorageTable  = mustOID("1.3.6.1.2.1.25.2.3.1")
+	oidHRStorageRAM    = mustOID("1.3.6.1.2.1.25.2.1.2")
+	oidHRProcessorLoad = mustOID("1.3.6.1.2.1.25.3.3.1.2")
+)
+
+// A Client is an SNMPv2c client for a Ubiquiti EdgeMAX device.
+type Client struct {
+	community string
+
+	mu    sync.Mutex
+	conn  net.Conn
+	reqID int32
+}
+
+// Dial creates a Client which polls the EdgeMAX device at addr, using the
+// specified SNMP community.  If addr does not specify a port, Port is used.
+func Dial(addr string, community string) (*Client, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(Port))
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		community: community,
+		conn:      conn,
+	}, nil
+}
+
+// Close closes the Client's connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Interfaces retrieves information about the network interfaces of an
+// EdgeMAX device from IF-MIB.  Duplex, autonegotiation, and rate information
+// is not available using SNMP, and is left unset.
+func (c *Client) Interfaces(ctx context.Context) (edgemax.Interfaces, error) {
+	ifis := make(map[uint32]*edgemax.Interface)
+	get := func(idx uint32) *edgemax.Interface {
+		ifi, ok := ifis[idx]
+		if !ok {
+			ifi = new(edgemax.Interface)
+			ifis[idx] = ifi
+		}
+
+		return ifi
+	}
+
+	// Prefer ifName from ifXTable, which matches EdgeOS interface names;
+	// ifDescr is used as a fallback
+	descrs := make(map[uint32]string)
+
+	err := c.walk(ctx, oidIfTable, func(sub oid, v value) error {
+		column, index := sub[0], sub[1:]
+		if len(index) != 1 {
+			return nil
+		}
+		ifi := get(index[0])
+
+		var err error
+		switch column {
+		case 2: // ifDescr
+			descrs[index[0]] = string(v.Bytes)
+		case 4: // ifMtu
+			err = setInt(&ifi.MTU, v)
+		case 6: // ifPhysAddress
+			if len(v.Bytes) > 0 {
+				ifi.MAC = net.HardwareAddr(copyBytes(v.Bytes))
+			}
+		case 8: // ifOperStatus
+			var status int
+			err = setInt(&status, v)
+			ifi.Up = status == 1
+		case 13: // ifInDiscards
+			err = setCounter(&ifi.Stats.ReceiveDropped, v)
+		case 14: // ifInErrors
+			err = setCounter(&ifi.Stats.ReceiveErrors, v)
+		case 19: // ifOutDiscards
+			err = setCounter(&ifi.Stats.TransmitDropped, v)
+		case 20: // ifOutErrors
+			err = setCounter(&ifi.Stats.TransmitErrors, v)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.walk(ctx, oidIfXTable, func(sub oid, v value) error {
+		column, index := sub[0], sub[1:]
+		if len(index) != 1 {
+			return nil
+		}
+		ifi := get(index[0])
+
+		var err error
+		switch column {
+		case 1: // ifName
+			ifi.Name = string(v.Bytes)
+		case 6: // ifHCInOctets
+			err = setCounter(&ifi.Stats.ReceiveBytes, v)
+		case 7, 9: // ifHCInUcastPkts, ifHCInBroadcastPkts
+			err = addCounter(&ifi.Stats.ReceivePackets, v)
+		case 8: // ifHCInMulticastPkts
+			if err = addCounter(&ifi.Stats.ReceivePackets, v); err == nil {
+				err = setCounter(&ifi.Stats.Multicast, v)
+			}
+		case 10: // ifHCOutOctets
+			err = setCounter(&ifi.Stats.TransmitBytes, v)
+		case 11, 12, 13: // ifHCOutUcastPkts, ifHCOutMulticastPkts, ifHCOutBroadcastPkts
+			err = addCounter(&ifi.Stats.TransmitPackets, v)
+		case 15: // ifHighSpeed
+			err = setInt(&ifi.Speed, v)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ipAdEntIfIndex is indexed by IPv4 address, and its value is the
+	// index of the interface which has the address
+	err = c.walk(ctx, oidIPAdEntIfIndex, func(index oid, v value) error {
+		if len(index) != 4 {
+			return nil
+		}
+
+		var idx int
+		if err := setInt(&idx, v); err != nil {
+			return err
+		}
+
+		ifi, ok := ifis[uint32(idx)]
+		if !ok {
+			return nil
+		}
+
+		ifi.Addresses = append(ifi.Addresses,
+			net.IPv4(byte(index[0]), byte(index[1]), byte(index[2]), byte(index[3])))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(edgemax.Interfaces, 0, len(ifis))
+	for idx, ifi := range ifis {
+		if ifi.Name == "" {
+			ifi.Name = descrs[idx]
+		}
+
+		out = append(out, ifi)
+	}
+
+	sort.Slice(out, func(i int, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	return out, nil
+}
+
+// SystemStats retrieves system statistics for an EdgeMAX device from
+// HOST-RESOURCES-MIB.  CPU is the average load of all processors.  Memory
+// usage includes memory used for buffers and caches, so it may be higher
+// than reported by the web interface.
+func (c *Client) SystemStats(ctx context.Context) (*edgemax.SystemStats, error) {
+	vbs, err := c.get(ctx, oidHRSystemUptime)
+	if err != nil {
+		return nil, err
+	}
+
+	ticks, err := vbs[0].Value.uint()
+	if err != nil {
+		return nil, err
+	}
+
+	// Time ticks are hundredths of a second
+	ss := &edgemax.SystemStats{
+		Uptime: time.Duration(ticks) * 10 * time.Millisecond,
+	}
+
+	var load, cpus int
+	err = c.walk(ctx, oidHRProcessorLoad, func(_ oid, v value) error {
+		cpus++
+		return addInt(&load, v)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cpus > 0 {
+		ss.CPU = load / cpus
+	}
+
+	type storage struct {
+		ram        bool
+		size, used int
+	}
+	storages := make(map[uint32]*storage)
+
+	err = c.walk(ctx, oidHRStorageTable, func(sub oid, v value) error {
+		column, index := sub[0], sub[1:]
+		if len(index) != 1 {
+			return nil
+		}
+
+		s, ok := storages[index[0]]
+		if !ok {
+			s = new(storage)
+			storages[index[0]] = s
+		}
+
+		switch column {
+		case 2: // hrStorageType
+			if v.Tag != tagOID {
+				return fmt.Errorf("unexpected SNMP value type: %#x", v.Tag)
+			}
+
+			o, err := parseOID(v.Bytes)
+			if err != nil {
+				return err
+			}
+			s.ram = len(o) == len(oidHRStorageRAM) && o.hasPrefix(oidHRStorageRAM)
+		case 5: // hrStorageSize
+			return setInt(&s.size, v)
+		case 6: // hrStorageUsed
+			return setInt(&s.used, v)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range storages {
+		if s.ram && s.size > 0 {
+			ss.Memory = s.used * 100 / s.size
+			break
+		}
+	}
+
+	return ss, nil
+}
+
+// get retrieves the values of oids.
+func (c *Client) get(ctx context.Context, oids ...oid) ([]varbind, error) {
+	vbs := make([]varbind, 0, len(oids))
+	for _, o := range oids {
+		vbs = append(vbs, varbind{
+			OID:   o,
+			Value: value{Tag: tagNull},
+		})
+	}
+
+	res, err := c.request(ctx, pdu{
+		Type:     pduGetRequest,
+		Varbinds: vbs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Varbinds) != len(oids) {
+		return nil, errInvalidMessage
+	}
+
+	for _, vb := range res.Varbinds {
+		switch vb.Value.Tag {
+		case tagNoSuchObject, tagNoSuchInstance, tagEndOfMIBView:
+			return nil, fmt.Errorf("no such SNMP object: %s", vb.OID)
+		}
+	}
+
+	return res.Varbinds, nil
+}
+
+// walk retrieves all values in the subtree rooted at root, and invokes fn
+// with each value and the arcs of its OID which follow root.  For a table,
+// the first arc is the column, and the remaining arcs are the index.
+func (c *Client) walk(ctx context.Context, root oid, fn func(sub oid, v value) error) error {
+	next := root
+	for {
+		res, err := c.request(ctx, pdu{
+			Type: pduGetBulk,
+			// Non-repeaters and max-repetitions
+			ErrorStatus: 0,
+			ErrorIndex:  maxRepetitions,
+			Varbinds: []varbind{{
+				OID:   next,
+				Value: value{Tag: tagNull},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(res.Varbinds) == 0 {
+			return nil
+		}
+
+		for _, vb := range res.Varbinds {
+			if vb.Value.Tag == tagEndOfMIBView || !vb.OID.hasPrefix(root) || len(vb.OID) == len(root) {
+				return nil
+			}
+
+			// Guard against agents which do not return values in order,
+			// which would otherwise cause an infinite loop
+			if !next.less(vb.OID) {
+				return fmt.Errorf("SNMP agent returned OID %s out of order", vb.OID)
+			}
+			next = vb.OID
+
+			if err := fn(vb.OID[len(root):], vb.Value); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// request sends an SNMP request containing p, and waits for its response.
+func (c *Client) request(ctx context.Context, p pdu) (*pdu, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reqID++
+	p.RequestID = c.reqID
+
+	b, err := (&message{
+		Version:   versionTwoC,
+		Community: c.community,
+		PDU:       p,
+	}).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	// Unblock reads early if ctx is canceled before its deadline
+	doneC := make(chan struct{})
+	defer close(doneC)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = c.conn.SetReadDeadline(time.Unix(1, 0))
+		case <-doneC:
+		}
+	}()
+
+	if _, err := c.conn.Write(b); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 65536)
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			return nil, err
+		}
+
+		var m message
+		if err := m.UnmarshalBinary(buf[:n]); err != nil {
+			return nil, err
+		}
+
+		// Ignore late replies to earlier requests
+		if m.PDU.Type != pduResponse || m.PDU.RequestID != p.RequestID {
+			continue
+		}
+
+		if m.PDU.ErrorStatus != 0 {
+			return nil, fmt.Errorf("SNMP error status %d at index %d", m.PDU.ErrorStatus, m.PDU.ErrorIndex)
+		}
+
+		return &m.PDU, nil
+	}
+}
+
+// errOverflow is returned when an SNMP value does not fit in an int.
+var errOverflow = errors.New("SNMP value overflows int")
+
+// setInt sets the integer value of v into i.
+func setInt(i *int, v value) error {
+	u, err := v.uint()
+	if err != nil {
+		return err
+	}
+
+	if u > uint64(^uint(0)>>1) {
+		return errOverflow
+	}
+
+	*i = int(u)
+	return nil
+}
+
+// addInt adds the integer value of v to i.
+func addInt(i *int, v value) error {
+	var n int
+	if err := setInt(&n, v); err != nil {
+		return err
+	}
+
+	*i += n
+	return nil
+}
+
+// setCounter sets c to the integer value of v.
+func setCounter(c *uint64, v value) error {
+	u, err := v.uint()
+	if err != nil {
+		return err
+	}
+
+	*c = u
+	return nil
+}
+
+// addCounter adds the integer value of v to c.
+func addCounter(c *uint64, v value) error {
+	var n uint64
+	if err := setCounter(&n, v); err != nil {
+		return err
+	}
+
+	*c += n
+	return nil
+}
+
+// copyBytes returns a copy of b, so that values do not retain references
+// to a packet buffer.
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
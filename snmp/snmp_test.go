@@ -0,0 +1,277 @@
+package snmp
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestClientInterfaces(t *testing.T) {
+	c, done := testClient(t, map[string]value{
+		// ifTable
+		"1.3.6.1.2.1.2.2.1.2.1":  octets("lo"),
+		"1.3.6.1.2.1.2.2.1.2.2":  octets("eth0 description"),
+		"1.3.6.1.2.1.2.2.1.4.1":  integer(65536),
+		"1.3.6.1.2.1.2.2.1.4.2":  integer(1500),
+		"1.3.6.1.2.1.2.2.1.6.1":  octets(""),
+		"1.3.6.1.2.1.2.2.1.6.2":  octets("\x04\x18\xd6\x00\x00\x01"),
+		"1.3.6.1.2.1.2.2.1.8.1":  integer(1),
+		"1.3.6.1.2.1.2.2.1.8.2":  integer(2),
+		"1.3.6.1.2.1.2.2.1.13.2": counter(tagCounter32, 1),
+		"1.3.6.1.2.1.2.2.1.14.2": counter(tagCounter32, 2),
+		"1.3.6.1.2.1.2.2.1.19.2": counter(tagCounter32, 3),
+		"1.3.6.1.2.1.2.2.1.20.2": counter(tagCounter32, 4),
+
+		// ifXTable; lo has no ifName, so ifDescr is used
+		"1.3.6.1.2.1.31.1.1.1.1.2":  octets("eth0"),
+		"1.3.6.1.2.1.31.1.1.1.6.2":  counter(tagCounter64, 1000),
+		"1.3.6.1.2.1.31.1.1.1.7.2":  counter(tagCounter64, 10),
+		"1.3.6.1.2.1.31.1.1.1.8.2":  counter(tagCounter64, 5),
+		"1.3.6.1.2.1.31.1.1.1.9.2":  counter(tagCounter64, 1),
+		"1.3.6.1.2.1.31.1.1.1.10.2": counter(tagCounter64, 2000),
+		"1.3.6.1.2.1.31.1.1.1.11.2": counter(tagCounter64, 20),
+		"1.3.6.1.2.1.31.1.1.1.12.2": counter(tagCounter64, 2),
+		"1.3.6.1.2.1.31.1.1.1.13.2": counter(tagCounter64, 1),
+		"1.3.6.1.2.1.31.1.1.1.15.2": counter(tagGauge32, 1000),
+
+		// ipAdEntIfIndex
+		"1.3.6.1.2.1.4.20.1.2.127.0.0.1":   integer(1),
+		"1.3.6.1.2.1.4.20.1.2.192.168.1.1": integer(2),
+		"1.3.6.1.2.1.4.20.1.2.192.168.2.1": integer(2),
+	})
+	defer done()
+
+	ifis, err := c.Interfaces(context.Background())
+	if err != nil {
+		t.Fatalf("failed to retrieve interfaces: %v", err)
+	}
+
+	want := edgemax.Interfaces{
+		{
+			Name:  "eth0",
+			Speed: 1000,
+			MAC:   net.HardwareAddr{0x04, 0x18, 0xd6, 0x00, 0x00, 0x01},
+			MTU:   1500,
+			Addresses: []net.IP{
+				net.IPv4(192, 168, 1, 1),
+				net.IPv4(192, 168, 2, 1),
+			},
+			Stats: edgemax.InterfaceStats{
+				ReceivePackets:  16,
+				TransmitPackets: 23,
+				ReceiveBytes:    1000,
+				TransmitBytes:   2000,
+				ReceiveErrors:   2,
+				TransmitErrors:  4,
+				ReceiveDropped:  1,
+				TransmitDropped: 3,
+				Multicast:       5,
+			},
+		},
+		{
+			Name:      "lo",
+			Up:        true,
+			MTU:       65536,
+			Addresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		},
+	}
+
+	if got := ifis; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Interfaces:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientSystemStats(t *testing.T) {
+	c, done := testClient(t, map[string]value{
+		"1.3.6.1.2.1.25.1.1.0": counter(tagTimeTicks, 360000),
+
+		"1.3.6.1.2.1.25.2.3.1.2.1": objectID("1.3.6.1.2.1.25.2.1.2"),
+		"1.3.6.1.2.1.25.2.3.1.2.2": objectID("1.3.6.1.2.1.25.2.1.4"),
+		"1.3.6.1.2.1.25.2.3.1.4.1": integer(1024),
+		"1.3.6.1.2.1.25.2.3.1.4.2": integer(4096),
+		"1.3.6.1.2.1.25.2.3.1.5.1": integer(1000),
+		"1.3.6.1.2.1.25.2.3.1.5.2": integer(2000),
+		"1.3.6.1.2.1.25.2.3.1.6.1": integer(250),
+		"1.3.6.1.2.1.25.2.3.1.6.2": integer(1900),
+
+		"1.3.6.1.2.1.25.3.3.1.2.196608": integer(10),
+		"1.3.6.1.2.1.25.3.3.1.2.196609": integer(30),
+	})
+	defer done()
+
+	ss, err := c.SystemStats(context.Background())
+	if err != nil {
+		t.Fatalf("failed to retrieve system stats: %v", err)
+	}
+
+	want := &edgemax.SystemStats{
+		CPU:    20,
+		Uptime: 1 * time.Hour,
+		Memory: 25,
+	}
+
+	if got := ss; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected SystemStats:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientSystemStatsNoSuchObject(t *testing.T) {
+	c, done := testClient(t, nil)
+	defer done()
+
+	if _, err := c.SystemStats(context.Background()); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+}
+
+func TestClientContextCanceled(t *testing.T) {
+	// Listen, but never reply
+	pc, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer pc.Close()
+
+	c, err := Dial(pc.LocalAddr().String(), "public")
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	if want, got := context.Canceled, func() error {
+		_, err := c.SystemStats(ctx)
+		return err
+	}(); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+// testClient starts an SNMP agent which serves objects, and returns a Client
+// which polls it.
+func testClient(t *testing.T, objects map[string]value) (*Client, func()) {
+	pc, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	vbs := make([]varbind, 0, len(objects))
+	for o, v := range objects {
+		vbs = append(vbs, varbind{OID: mustOID(o), Value: v})
+	}
+	sort.Slice(vbs, func(i int, j int) bool {
+		return vbs[i].OID.less(vbs[j].OID)
+	})
+
+	go func() {
+		b := make([]byte, 65536)
+		for {
+			n, addr, err := pc.ReadFromUDP(b)
+			if err != nil {
+				return
+			}
+
+			var req message
+			if err := req.UnmarshalBinary(b[:n]); err != nil {
+				panic(err)
+			}
+
+			res := message{
+				Version:   req.Version,
+				Community: req.Community,
+				PDU: pdu{
+					Type:      pduResponse,
+					RequestID: req.PDU.RequestID,
+				},
+			}
+
+			if req.Community != "public" {
+				res.PDU.ErrorStatus = 16
+			}
+
+			switch req.PDU.Type {
+			case pduGetRequest:
+				for _, rvb := range req.PDU.Varbinds {
+					vb := varbind{OID: rvb.OID, Value: value{Tag: tagNoSuchObject}}
+					for _, v := range vbs {
+						if v.OID.String() == rvb.OID.String() {
+							vb = v
+							break
+						}
+					}
+
+					res.PDU.Varbinds = append(res.PDU.Varbinds, vb)
+				}
+			case pduGetBulk:
+				start := req.PDU.Varbinds[0].OID
+				for _, v := range vbs {
+					if len(res.PDU.Varbinds) == req.PDU.ErrorIndex {
+						break
+					}
+					if start.less(v.OID) {
+						res.PDU.Varbinds = append(res.PDU.Varbinds, v)
+					}
+				}
+
+				if len(res.PDU.Varbinds) == 0 {
+					res.PDU.Varbinds = []varbind{{OID: start, Value: value{Tag: tagEndOfMIBView}}}
+				}
+			}
+
+			out, err := res.MarshalBinary()
+			if err != nil {
+				panic(err)
+			}
+
+			if _, err := pc.WriteToUDP(out, addr); err != nil {
+				return
+			}
+		}
+	}()
+
+	c, err := Dial(pc.LocalAddr().String(), "public")
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+
+	return c, func() {
+		_ = c.Close()
+		_ = pc.Close()
+	}
+}
+
+func octets(s string) value {
+	return value{Tag: tagOctetString, Bytes: []byte(s)}
+}
+
+func integer(i int64) value {
+	return value{Tag: tagInteger, Bytes: marshalInt(i)}
+}
+
+func counter(tag byte, u uint64) value {
+	b := []byte{0x00}
+	for i := 7; i >= 0; i-- {
+		b = append(b, byte(u>>uint(8*i)))
+	}
+
+	return value{Tag: tag, Bytes: b}
+}
+
+func objectID(s string) value {
+	b, err := mustOID(s).marshal()
+	if err != nil {
+		panic(err)
+	}
+
+	return value{Tag: tagOID, Bytes: b}
+}
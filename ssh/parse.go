@@ -0,0 +1,293 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// routeProtocols maps route codes to protocol names.
+var routeProtocols = map[string]string{
+	"K": "kernel",
+	"C": "connected",
+	"S": "static",
+	"R": "rip",
+	"B": "bgp",
+	"O": "ospf",
+}
+
+// parseRoutes parses the output of "show ip route".
+func parseRoutes(s string) ([]*edgemax.Route, error) {
+	var (
+		routes []*edgemax.Route
+		prev   *edgemax.Route
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Additional next hops for a route appear on their own lines
+		if fields[0] == "via" {
+			if prev == nil {
+				return nil, fmt.Errorf("invalid route: %q", scanner.Text())
+			}
+
+			r := *prev
+			if err := parseNextHop(&r, fields); err != nil {
+				return nil, err
+			}
+
+			routes = append(routes, &r)
+			continue
+		}
+
+		// Route lines contain a destination prefix; any other lines, such
+		// as the legend which precedes the table, are skipped
+		i := -1
+		var dst *net.IPNet
+		for j, f := range fields {
+			if _, ipn, err := net.ParseCIDR(f); err == nil {
+				i, dst = j, ipn
+				break
+			}
+		}
+		if i < 1 {
+			continue
+		}
+
+		proto, ok := routeProtocols[fields[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown route code: %q", fields[0])
+		}
+
+		r := &edgemax.Route{
+			Destination: dst,
+			Protocol:    proto,
+			Selected:    strings.Contains(strings.Join(fields[1:i], ""), ">"),
+		}
+
+		rest := fields[i+1:]
+		if len(rest) > 0 && strings.HasPrefix(rest[0], "[") {
+			if _, err := fmt.Sscanf(rest[0], "[%d/%d]", &r.Distance, &r.Metric); err != nil {
+				return nil, fmt.Errorf("invalid route distance and metric: %q", rest[0])
+			}
+			rest = rest[1:]
+		}
+
+		if err := parseNextHop(r, rest); err != nil {
+			return nil, err
+		}
+
+		routes = append(routes, r)
+		prev = r
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// parseNextHop parses the gateway and interface of a route from fields, such
+// as "via 192.0.2.1, eth0" or "is directly connected, eth0".
+func parseNextHop(r *edgemax.Route, fields []string) error {
+	s := strings.Join(fields, " ")
+
+	switch {
+	case strings.HasPrefix(s, "is directly connected, "):
+		r.Gateway = nil
+		r.Interface = strings.Split(strings.TrimPrefix(s, "is directly connected, "), ",")[0]
+	case strings.HasPrefix(s, "via "):
+		ss := strings.Split(strings.TrimPrefix(s, "via "), ", ")
+
+		gw := net.ParseIP(ss[0])
+		if gw == nil {
+			return fmt.Errorf("invalid route gateway: %q", ss[0])
+		}
+		r.Gateway = gw
+
+		r.Interface = ""
+		if len(ss) > 1 {
+			r.Interface = ss[1]
+		}
+	default:
+		return fmt.Errorf("invalid route next hop: %q", s)
+	}
+
+	return nil
+}
+
+// leaseTimeFormat is the format of DHCP lease expiration times.
+const leaseTimeFormat = "2006/01/02 15:04:05"
+
+// parseDHCPLeases parses the output of "show dhcp leases".
+func parseDHCPLeases(s string) ([]*edgemax.DHCPLease, error) {
+	var leases []*edgemax.DHCPLease
+
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		// Lease lines begin with an IP address; the header lines are skipped
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			continue
+		}
+
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("invalid DHCP lease: %q", scanner.Text())
+		}
+
+		mac, err := net.ParseMAC(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		expires, err := time.Parse(leaseTimeFormat, fields[2]+" "+fields[3])
+		if err != nil {
+			return nil, err
+		}
+
+		leases = append(leases, &edgemax.DHCPLease{
+			IP:       ip,
+			MAC:      mac,
+			Hostname: strings.Join(fields[5:], " "),
+			Pool:     fields[4],
+			Expires:  expires,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// interfaceHeader matches the first line of each interface's information.
+var interfaceHeader = regexp.MustCompile(`^(\S+): <([^>]*)> mtu (\d+)`)
+
+// parseInterfaces parses the output of "show interfaces detail".
+func parseInterfaces(s string) (edgemax.Interfaces, error) {
+	var (
+		ifis edgemax.Interfaces
+		ifi  *edgemax.Interface
+
+		// counters is the set of counters to be parsed from the next line,
+		// after an RX or TX heading
+		counters []*uint64
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := interfaceHeader.FindStringSubmatch(line); m != nil {
+			mtu, err := strconv.Atoi(m[3])
+			if err != nil {
+				return nil, err
+			}
+
+			// VLAN and other virtual interfaces are named with their parent,
+			// as in "eth0.10@eth0"
+			ifi = &edgemax.Interface{
+				Name: strings.Split(m[1], "@")[0],
+				MTU:  mtu,
+			}
+			for _, f := range strings.Split(m[2], ",") {
+				if f == "UP" {
+					ifi.Up = true
+				}
+			}
+
+			ifis = append(ifis, ifi)
+			counters = nil
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if ifi == nil || len(fields) == 0 {
+			continue
+		}
+
+		if counters != nil {
+			if len(fields) < len(counters) {
+				return nil, fmt.Errorf("invalid interface counters: %q", line)
+			}
+
+			for i, c := range counters {
+				v, err := strconv.ParseUint(fields[i], 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				*c = v
+			}
+
+			counters = nil
+			continue
+		}
+
+		st := &ifi.Stats
+		switch fields[0] {
+		case "link/ether":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("invalid interface hardware address: %q", line)
+			}
+
+			mac, err := net.ParseMAC(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			ifi.MAC = mac
+		case "inet", "inet6":
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("invalid interface address: %q", line)
+			}
+
+			ip, _, err := net.ParseCIDR(fields[1])
+			if err != nil {
+				return nil, err
+			}
+			ifi.Addresses = append(ifi.Addresses, ip)
+		case "RX:":
+			// bytes, packets, errors, dropped, overrun, mcast
+			var overrun uint64
+			counters = []*uint64{
+				&st.ReceiveBytes, &st.ReceivePackets, &st.ReceiveErrors,
+				&st.ReceiveDropped, &overrun, &st.Multicast,
+			}
+		case "TX:":
+			// bytes, packets, errors, dropped, carrier, collisions
+			var carrier, collisions uint64
+			counters = []*uint64{
+				&st.TransmitBytes, &st.TransmitPackets, &st.TransmitErrors,
+				&st.TransmitDropped, &carrier, &collisions,
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ifis, func(i int, j int) bool {
+		return ifis[i].Name < ifis[j].Name
+	})
+
+	return ifis, nil
+}
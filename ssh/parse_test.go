@@ -0,0 +1,312 @@
+package ssh
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func Test_parseRoutes(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		s      string
+		ok     bool
+		routes []*edgemax.Route
+	}{
+		{
+			desc: "unknown route code",
+			s:    "X    *> 10.0.0.0/8 is directly connected, eth0\n",
+		},
+		{
+			desc: "invalid distance and metric",
+			s:    "S    *> 0.0.0.0/0 [a/b] via 192.0.2.1, eth0\n",
+		},
+		{
+			desc: "invalid gateway",
+			s:    "S    *> 0.0.0.0/0 [1/0] via foo, eth0\n",
+		},
+		{
+			desc: "invalid next hop",
+			s:    "S    *> 0.0.0.0/0 [1/0] blackhole\n",
+		},
+		{
+			desc: "next hop without route",
+			s:    "                         via 192.0.2.1, eth0\n",
+		},
+		{
+			desc: "OK",
+			s: `Codes: K - kernel, C - connected, S - static, R - RIP, B - BGP
+       O - OSPF, IA - OSPF inter area
+       > - selected route, * - FIB route, p - stale info
+
+IP Route Table for VRF "default"
+S    *> 0.0.0.0/0 [1/0] via 203.0.113.1, eth0
+C    *> 127.0.0.0/8 is directly connected, lo
+O IA *> 10.1.0.0/16 [110/30] via 10.0.0.2, eth2, 00:10:12
+                         via 10.0.0.3, eth3, 00:10:12
+O       192.168.1.0/24 [110/20] via 10.0.0.2, eth2, 00:10:12
+`,
+			ok: true,
+			routes: []*edgemax.Route{
+				{
+					Destination: mustCIDR("0.0.0.0/0"),
+					Gateway:     net.ParseIP("203.0.113.1"),
+					Interface:   "eth0",
+					Protocol:    "static",
+					Distance:    1,
+					Selected:    true,
+				},
+				{
+					Destination: mustCIDR("127.0.0.0/8"),
+					Interface:   "lo",
+					Protocol:    "connected",
+					Selected:    true,
+				},
+				{
+					Destination: mustCIDR("10.1.0.0/16"),
+					Gateway:     net.ParseIP("10.0.0.2"),
+					Interface:   "eth2",
+					Protocol:    "ospf",
+					Distance:    110,
+					Metric:      30,
+					Selected:    true,
+				},
+				{
+					Destination: mustCIDR("10.1.0.0/16"),
+					Gateway:     net.ParseIP("10.0.0.3"),
+					Interface:   "eth3",
+					Protocol:    "ospf",
+					Distance:    110,
+					Metric:      30,
+					Selected:    true,
+				},
+				{
+					Destination: mustCIDR("192.168.1.0/24"),
+					Gateway:     net.ParseIP("10.0.0.2"),
+					Interface:   "eth2",
+					Protocol:    "ospf",
+					Distance:    110,
+					Metric:      20,
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		routes, err := parseRoutes(tt.s)
+		if err != nil && tt.ok {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !tt.ok {
+			if err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			continue
+		}
+
+		if want, got := tt.routes, routes; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected Routes:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func Test_parseDHCPLeases(t *testing.T) {
+	var tests = []struct {
+		desc   string
+		s      string
+		ok     bool
+		leases []*edgemax.DHCPLease
+	}{
+		{
+			desc: "too few fields",
+			s:    "192.168.1.10    00:11:22:33:44:55  2017/03/04\n",
+		},
+		{
+			desc: "invalid MAC",
+			s:    "192.168.1.10    foo  2017/03/04 12:34:56  LAN\n",
+		},
+		{
+			desc: "invalid expiration",
+			s:    "192.168.1.10    00:11:22:33:44:55  foo bar  LAN\n",
+		},
+		{
+			desc: "OK",
+			s: `IP address      Hardware Address   Lease expiration     Pool       Client Name
+----------      ----------------   ----------------     ----       -----------
+192.168.1.10    00:11:22:33:44:55  2017/03/04 12:34:56  LAN        laptop
+192.168.1.11    00:11:22:33:44:66  2017/03/05 01:02:03  LAN
+`,
+			ok: true,
+			leases: []*edgemax.DHCPLease{
+				{
+					IP:       net.ParseIP("192.168.1.10"),
+					MAC:      net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55},
+					Hostname: "laptop",
+					Pool:     "LAN",
+					Expires:  time.Date(2017, time.March, 4, 12, 34, 56, 0, time.UTC),
+				},
+				{
+					IP:      net.ParseIP("192.168.1.11"),
+					MAC:     net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x66},
+					Pool:    "LAN",
+					Expires: time.Date(2017, time.March, 5, 1, 2, 3, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		leases, err := parseDHCPLeases(tt.s)
+		if err != nil && tt.ok {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !tt.ok {
+			if err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			continue
+		}
+
+		if want, got := tt.leases, leases; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected DHCPLeases:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func Test_parseInterfaces(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    string
+		ok   bool
+		ifis edgemax.Interfaces
+	}{
+		{
+			desc: "invalid MAC",
+			s: `eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc pfifo_fast state UP
+    link/ether foo brd ff:ff:ff:ff:ff:ff
+`,
+		},
+		{
+			desc: "invalid address",
+			s: `eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc pfifo_fast state UP
+    inet foo brd 192.168.1.255 scope global eth0
+`,
+		},
+		{
+			desc: "too few counters",
+			s: `eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc pfifo_fast state UP
+    RX:  bytes    packets     errors    dropped    overrun      mcast
+          1          2
+`,
+		},
+		{
+			desc: "invalid counter",
+			s: `eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc pfifo_fast state UP
+    TX:  bytes    packets     errors    dropped    carrier collisions
+          1          2          3          4          5        foo
+`,
+		},
+		{
+			desc: "OK",
+			s: `lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN group default
+    link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00
+    inet 127.0.0.1/8 scope host lo
+       valid_lft forever preferred_lft forever
+
+    RX:  bytes    packets     errors    dropped    overrun      mcast
+          100         10          0          0          0          0
+    TX:  bytes    packets     errors    dropped    carrier collisions
+          100         10          0          0          0          0
+
+eth0.10@eth0: <BROADCAST,MULTICAST> mtu 1496 qdisc noqueue state DOWN group default
+    link/ether 04:18:d6:00:00:01 brd ff:ff:ff:ff:ff:ff
+
+eth0: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc pfifo_fast state UP group default qlen 1000
+    link/ether 04:18:d6:00:00:01 brd ff:ff:ff:ff:ff:ff
+    inet 192.168.1.1/24 brd 192.168.1.255 scope global eth0
+       valid_lft forever preferred_lft forever
+    inet6 fe80::618:d6ff:fe00:1/64 scope link
+       valid_lft forever preferred_lft forever
+    Description: LAN
+
+    RX:  bytes    packets     errors    dropped    overrun      mcast
+       123456        789          1          2          0          3
+    TX:  bytes    packets     errors    dropped    carrier collisions
+       654321        987          4          5          0          0
+`,
+			ok: true,
+			ifis: edgemax.Interfaces{
+				{
+					Name:      "eth0",
+					Up:        true,
+					MAC:       net.HardwareAddr{0x04, 0x18, 0xd6, 0x00, 0x00, 0x01},
+					MTU:       1500,
+					Addresses: []net.IP{net.ParseIP("192.168.1.1"), net.ParseIP("fe80::618:d6ff:fe00:1")},
+					Stats: edgemax.InterfaceStats{
+						ReceiveBytes:    123456,
+						ReceivePackets:  789,
+						ReceiveErrors:   1,
+						ReceiveDropped:  2,
+						Multicast:       3,
+						TransmitBytes:   654321,
+						TransmitPackets: 987,
+						TransmitErrors:  4,
+						TransmitDropped: 5,
+					},
+				},
+				{
+					Name: "eth0.10",
+					MAC:  net.HardwareAddr{0x04, 0x18, 0xd6, 0x00, 0x00, 0x01},
+					MTU:  1496,
+				},
+				{
+					Name:      "lo",
+					Up:        true,
+					MTU:       65536,
+					Addresses: []net.IP{net.ParseIP("127.0.0.1")},
+					Stats: edgemax.InterfaceStats{
+						ReceiveBytes:    100,
+						ReceivePackets:  10,
+						TransmitBytes:   100,
+						TransmitPackets: 10,
+					},
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		ifis, err := parseInterfaces(tt.s)
+		if err != nil && tt.ok {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !tt.ok {
+			if err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+			continue
+		}
+
+		if want, got := tt.ifis, ifis; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected Interfaces:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, ipn, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return ipn
+}
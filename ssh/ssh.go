@@ -0,0 +1,244 @@
+// Package ssh implements a client which retrieves information from and
+// configures EdgeMAX devices using SSH, for devices where the web interface
+// and its API are disabled.
+//
+// Op-mode and configuration commands are run using the EdgeOS command
+// wrappers, and their output is parsed into the same types used by package
+// edgemax.
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/mdlayher/edgemax"
+	xssh "golang.org/x/crypto/ssh"
+)
+
+const (
+	// opWrapper runs op-mode commands in a non-interactive shell.
+	opWrapper = "/opt/vyatta/bin/vyatta-op-cmd-wrapper"
+
+	// cfgWrapper runs configuration commands in a non-interactive shell.
+	cfgWrapper = "/opt/vyatta/sbin/vyatta-cfg-cmd-wrapper"
+)
+
+// A Client is a client for a Ubiquiti EdgeMAX device which uses SSH.
+type Client struct {
+	c *xssh.Client
+
+	// run runs a command on the device; swapped out in tests.
+	run func(ctx context.Context, cmd string) (*edgemax.OpOutput, error)
+}
+
+// Dial connects to the EdgeMAX device at addr using SSH, and creates a
+// Client.  If addr does not specify a port, port 22 is used.
+func Dial(addr string, config *xssh.ClientConfig) (*Client, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+
+	c, err := xssh.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(c), nil
+}
+
+// New creates a Client using an existing SSH client connection.
+func New(c *xssh.Client) *Client {
+	client := &Client{c: c}
+	client.run = client.runSession
+	return client
+}
+
+// Close closes the Client's SSH connection.
+func (c *Client) Close() error {
+	return c.c.Close()
+}
+
+// RunOp runs the op-mode command specified by args on an EdgeMAX device, such
+// as "show", "ip", "route", and returns its output.  As with
+// edgemax.Client.RunOp, a command which exits with a non-zero status does not
+// cause RunOp to return an error; callers should inspect OpOutput.ExitStatus.
+func (c *Client) RunOp(ctx context.Context, args ...string) (*edgemax.OpOutput, error) {
+	if len(args) == 0 {
+		return nil, errors.New("op-mode command must not be empty")
+	}
+
+	return c.run(ctx, opWrapper+" "+quote(args))
+}
+
+// Routes retrieves the routing table of an EdgeMAX device.
+func (c *Client) Routes(ctx context.Context) ([]*edgemax.Route, error) {
+	out, err := c.op(ctx, "show", "ip", "route")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseRoutes(out)
+}
+
+// DHCPLeases retrieves the leases handed out by the DHCP server on an
+// EdgeMAX device.  Lease expiration times are reported by the device without
+// a time zone, and are assumed to be UTC.
+func (c *Client) DHCPLeases(ctx context.Context) ([]*edgemax.DHCPLease, error) {
+	out, err := c.op(ctx, "show", "dhcp", "leases")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDHCPLeases(out)
+}
+
+// Interfaces retrieves information about the network interfaces of an
+// EdgeMAX device.  Speed, duplex, and rate information is not available
+// using SSH, and is left unset.
+func (c *Client) Interfaces(ctx context.Context) (edgemax.Interfaces, error) {
+	out, err := c.op(ctx, "show", "interfaces", "detail")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseInterfaces(out)
+}
+
+// SetConfig applies ops to the configuration of an EdgeMAX device in a single
+// configuration session, and commits and saves the result.  If any operation
+// fails, none of the operations are committed.
+func (c *Client) SetConfig(ctx context.Context, ops ...edgemax.ConfigOp) error {
+	if len(ops) == 0 {
+		return errors.New("no configuration operations specified")
+	}
+
+	// The configuration session must always be ended, even if an operation
+	// fails, so the script exits early on errors and ends the session on exit
+	script := []string{
+		"set -e",
+		fmt.Sprintf("trap '%s end' EXIT", cfgWrapper),
+		cfgWrapper + " begin",
+	}
+
+	for _, op := range ops {
+		if len(op.Path) == 0 {
+			return errors.New("configuration operation path must not be empty")
+		}
+
+		args := make([]string, 0, len(op.Path)+2)
+		switch op.Action {
+		case edgemax.ConfigSet:
+			args = append(args, "set")
+			args = append(args, op.Path...)
+			if op.Value != "" {
+				args = append(args, op.Value)
+			}
+		case edgemax.ConfigDelete:
+			args = append(args, "delete")
+			args = append(args, op.Path...)
+		default:
+			return fmt.Errorf("unknown configuration action: %d", op.Action)
+		}
+
+		script = append(script, cfgWrapper+" "+quote(args))
+	}
+
+	script = append(script,
+		cfgWrapper+" commit",
+		cfgWrapper+" save",
+	)
+
+	out, err := c.run(ctx, strings.Join(script, "\n"))
+	if err != nil {
+		return err
+	}
+
+	if out.ExitStatus != 0 {
+		return fmt.Errorf("failed to apply configuration: %s", output(out))
+	}
+
+	return nil
+}
+
+// op runs the op-mode command specified by args, and returns its standard
+// output.  A non-zero exit status is reported as an error.
+func (c *Client) op(ctx context.Context, args ...string) (string, error) {
+	out, err := c.RunOp(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+
+	if out.ExitStatus != 0 {
+		return "", fmt.Errorf("command %q exited with status %d: %s",
+			strings.Join(args, " "), out.ExitStatus, output(out))
+	}
+
+	return out.Stdout, nil
+}
+
+// runSession runs cmd in a new SSH session, and stops the session if ctx is
+// canceled before cmd completes.
+func (c *Client) runSession(ctx context.Context, cmd string) (*edgemax.OpOutput, error) {
+	s, err := c.c.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	var stdout, stderr bytes.Buffer
+	s.Stdout = &stdout
+	s.Stderr = &stderr
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- s.Run(cmd)
+	}()
+
+	select {
+	case err = <-errC:
+	case <-ctx.Done():
+		_ = s.Close()
+		<-errC
+		return nil, ctx.Err()
+	}
+
+	var status int
+	if err != nil {
+		eerr, ok := err.(*xssh.ExitError)
+		if !ok {
+			return nil, err
+		}
+
+		status = eerr.ExitStatus()
+	}
+
+	return &edgemax.OpOutput{
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		ExitStatus: status,
+	}, nil
+}
+
+// output returns the error output of a failed command, which EdgeOS may
+// write to either standard output or standard error.
+func output(out *edgemax.OpOutput) string {
+	if s := strings.TrimSpace(out.Stderr); s != "" {
+		return s
+	}
+
+	return strings.TrimSpace(out.Stdout)
+}
+
+// quote quotes each of args for a POSIX shell, and joins them with spaces.
+func quote(args []string) string {
+	ss := make([]string, 0, len(args))
+	for _, a := range args {
+		ss = append(ss, "'"+strings.Replace(a, "'", `'\''`, -1)+"'")
+	}
+
+	return strings.Join(ss, " ")
+}
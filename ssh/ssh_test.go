@@ -0,0 +1,157 @@
+package ssh
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestClientRunOp(t *testing.T) {
+	c := testClient(t, func(cmd string) *edgemax.OpOutput {
+		want := opWrapper + ` 'show' 'interfaces' 'ethernet' 'eth0'\''s'`
+		if got := cmd; want != got {
+			t.Fatalf("unexpected command:\n- want: %q\n-  got: %q", want, got)
+		}
+
+		return &edgemax.OpOutput{Stdout: "ok"}
+	})
+
+	out, err := c.RunOp(context.Background(), "show", "interfaces", "ethernet", "eth0's")
+	if err != nil {
+		t.Fatalf("failed to run op-mode command: %v", err)
+	}
+
+	if want, got := "ok", out.Stdout; want != got {
+		t.Fatalf("unexpected output:\n- want: %q\n-  got: %q", want, got)
+	}
+}
+
+func TestClientRoutesExitStatus(t *testing.T) {
+	c := testClient(t, func(cmd string) *edgemax.OpOutput {
+		return &edgemax.OpOutput{
+			Stderr:     "Invalid command\n",
+			ExitStatus: 1,
+		}
+	})
+
+	_, err := c.Routes(context.Background())
+	if err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+
+	if want, got := "Invalid command", err.Error(); !strings.HasSuffix(got, want) {
+		t.Fatalf("unexpected error:\n- want suffix: %q\n-           got: %q", want, got)
+	}
+}
+
+func TestClientSetConfig(t *testing.T) {
+	c := testClient(t, func(cmd string) *edgemax.OpOutput {
+		want := strings.Join([]string{
+			"set -e",
+			"trap '" + cfgWrapper + " end' EXIT",
+			cfgWrapper + " begin",
+			cfgWrapper + ` 'set' 'interfaces' 'ethernet' 'eth0' 'description' 'WAN'`,
+			cfgWrapper + ` 'set' 'service' 'ssh'`,
+			cfgWrapper + ` 'delete' 'service' 'gui'`,
+			cfgWrapper + " commit",
+			cfgWrapper + " save",
+		}, "\n")
+
+		if got := cmd; want != got {
+			t.Fatalf("unexpected script:\n- want: %q\n-  got: %q", want, got)
+		}
+
+		return &edgemax.OpOutput{}
+	})
+
+	err := c.SetConfig(context.Background(),
+		edgemax.ConfigOp{
+			Action: edgemax.ConfigSet,
+			Path:   []string{"interfaces", "ethernet", "eth0", "description"},
+			Value:  "WAN",
+		},
+		edgemax.ConfigOp{
+			Action: edgemax.ConfigSet,
+			Path:   []string{"service", "ssh"},
+		},
+		edgemax.ConfigOp{
+			Action: edgemax.ConfigDelete,
+			Path:   []string{"service", "gui"},
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to set configuration: %v", err)
+	}
+}
+
+func TestClientSetConfigFailure(t *testing.T) {
+	c := testClient(t, func(cmd string) *edgemax.OpOutput {
+		return &edgemax.OpOutput{
+			Stdout:     "Commit failed\n",
+			ExitStatus: 1,
+		}
+	})
+
+	err := c.SetConfig(context.Background(), edgemax.ConfigOp{
+		Action: edgemax.ConfigDelete,
+		Path:   []string{"service", "gui"},
+	})
+
+	if want, got := "failed to apply configuration: Commit failed", errString(err); want != got {
+		t.Fatalf("unexpected error:\n- want: %q\n-  got: %q", want, got)
+	}
+}
+
+func TestClientSetConfigInvalid(t *testing.T) {
+	var tests = []struct {
+		desc string
+		ops  []edgemax.ConfigOp
+	}{
+		{
+			desc: "no operations",
+		},
+		{
+			desc: "empty path",
+			ops:  []edgemax.ConfigOp{{Action: edgemax.ConfigSet}},
+		},
+		{
+			desc: "unknown action",
+			ops: []edgemax.ConfigOp{{
+				Action: edgemax.ConfigAction(-1),
+				Path:   []string{"service", "ssh"},
+			}},
+		},
+	}
+
+	c := testClient(t, func(cmd string) *edgemax.OpOutput {
+		t.Fatal("no command should be run for invalid configuration operations")
+		return nil
+	})
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		if err := c.SetConfig(context.Background(), tt.ops...); err == nil {
+			t.Fatal("expected an error, but none occurred")
+		}
+	}
+}
+
+// testClient creates a Client which runs commands using fn.
+func testClient(t *testing.T, fn func(cmd string) *edgemax.OpOutput) *Client {
+	return &Client{
+		run: func(_ context.Context, cmd string) (*edgemax.OpOutput, error) {
+			return fn(cmd), nil
+		},
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
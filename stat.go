@@ -1,10 +1,10 @@
 package edgemax
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net"
-	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -32,6 +32,34 @@ const (
 
 	// StatTypeInterfaces retrieves EdgeMAX network interface statistics.
 	StatTypeInterfaces StatType = "interfaces"
+
+	// StatTypeRouteCounts retrieves the number of routes in the routing
+	// table.
+	StatTypeRouteCounts StatType = "num-routes"
+
+	// StatTypeConfigChange retrieves notifications of configuration
+	// commits.
+	StatTypeConfigChange StatType = "config-change"
+
+	// StatTypeUsers retrieves the users logged in to the device.
+	StatTypeUsers StatType = "users"
+
+	// StatTypeDiscoveredDevices retrieves Ubiquiti devices discovered on
+	// the device's networks.
+	StatTypeDiscoveredDevices StatType = "discover"
+
+	// StatTypePONStats retrieves passive optical network port statistics
+	// from optical line terminals.
+	StatTypePONStats StatType = "pon-stats"
+
+	// StatTypeLTEStats retrieves LTE modem statistics.
+	StatTypeLTEStats StatType = "lte-stats"
+
+	// StatTypeNATStats retrieves NAT rule counters.
+	StatTypeNATStats StatType = "nat-stats"
+
+	// StatTypeFirewallStats retrieves firewall rule counters.
+	StatTypeFirewallStats StatType = "fw-stats"
 )
 
 // SystemStats is a Stat which contains system statistics for an EdgeMAX
@@ -52,26 +80,27 @@ func (ss *SystemStats) StatType() StatType {
 // UnmarshalJSON unmarshals JSON into a SystemStats.
 func (ss *SystemStats) UnmarshalJSON(b []byte) error {
 	var v struct {
-		CPU    string `json:"cpu"`
-		Uptime string `json:"uptime"`
-		Mem    string `json:"mem"`
+		CPU    apiNumber `json:"cpu"`
+		Uptime apiNumber `json:"uptime"`
+		Mem    apiNumber `json:"mem"`
 	}
 
 	if err := json.Unmarshal(b, &v); err != nil {
 		return err
 	}
 
-	cpu, err := strconv.Atoi(v.CPU)
+	// Unknown values are reported as zero
+	cpu, _, err := v.CPU.int()
 	if err != nil {
 		return err
 	}
 
-	uptime, err := strconv.Atoi(v.Uptime)
+	uptime, _, err := v.Uptime.uint64()
 	if err != nil {
 		return err
 	}
 
-	memory, err := strconv.Atoi(v.Mem)
+	memory, _, err := v.Mem.int()
 	if err != nil {
 		return err
 	}
@@ -107,21 +136,30 @@ type Interface struct {
 	MTU             int
 	Addresses       []net.IP
 	Stats           InterfaceStats
+
+	// DuplexUnknown and SpeedUnknown report whether the device reported
+	// the interface's duplex or speed as unknown, such as for a virtual
+	// interface or an interface which is down.  If unknown, Duplex is
+	// empty and Speed is zero.
+	DuplexUnknown bool
+	SpeedUnknown  bool
 }
 
 // InterfaceStats contains network interface data transmission statistics.
+// Counters are 64-bit so that they cannot overflow on devices with long
+// uptimes, even when decoded on 32-bit platforms.
 type InterfaceStats struct {
-	ReceivePackets  int
-	TransmitPackets int
-	ReceiveBytes    int
-	TransmitBytes   int
-	ReceiveErrors   int
-	TransmitErrors  int
-	ReceiveDropped  int
-	TransmitDropped int
-	Multicast       int
-	ReceiveBPS      int
-	TransmitBPS     int
+	ReceivePackets  uint64
+	TransmitPackets uint64
+	ReceiveBytes    uint64
+	TransmitBytes   uint64
+	ReceiveErrors   uint64
+	TransmitErrors  uint64
+	ReceiveDropped  uint64
+	TransmitDropped uint64
+	Multicast       uint64
+	ReceiveBPS      uint64
+	TransmitBPS     uint64
 }
 
 // UnmarshalJSON unmarshals JSON into an Interfaces.
@@ -130,22 +168,22 @@ func (i *Interfaces) UnmarshalJSON(b []byte) error {
 		Up        string      `json:"up"`
 		Autoneg   string      `json:"autoneg"`
 		Duplex    string      `json:"duplex"`
-		Speed     string      `json:"speed"`
+		Speed     apiNumber   `json:"speed"`
 		MAC       string      `json:"mac"`
-		MTU       string      `json:"mtu"`
+		MTU       apiNumber   `json:"mtu"`
 		Addresses interface{} `json:"addresses"`
 		Stats     struct {
-			RXPackets string `json:"rx_packets"`
-			TXPackets string `json:"tx_packets"`
-			RXBytes   string `json:"rx_bytes"`
-			TXBytes   string `json:"tx_bytes"`
-			RXErrors  string `json:"rx_errors"`
-			TXErrors  string `json:"tx_errors"`
-			RXDropped string `json:"rx_dropped"`
-			TXDropped string `json:"tx_dropped"`
-			Multicast string `json:"multicast"`
-			RXBPS     string `json:"rx_bps"`
-			TXBPS     string `json:"tx_bps"`
+			RXPackets apiNumber `json:"rx_packets"`
+			TXPackets apiNumber `json:"tx_packets"`
+			RXBytes   apiNumber `json:"rx_bytes"`
+			TXBytes   apiNumber `json:"tx_bytes"`
+			RXErrors  apiNumber `json:"rx_errors"`
+			TXErrors  apiNumber `json:"tx_errors"`
+			RXDropped apiNumber `json:"rx_dropped"`
+			TXDropped apiNumber `json:"tx_dropped"`
+			Multicast apiNumber `json:"multicast"`
+			RXBPS     apiNumber `json:"rx_bps"`
+			TXBPS     apiNumber `json:"tx_bps"`
 		} `json:"stats"`
 	}
 
@@ -155,91 +193,84 @@ func (i *Interfaces) UnmarshalJSON(b []byte) error {
 
 	is := make(Interfaces, 0, len(v))
 	for k, vv := range v {
-		ss := []string{
-			vv.Speed,
-			vv.MTU,
-			vv.Stats.RXPackets,
-			vv.Stats.TXPackets,
-			vv.Stats.RXBytes,
-			vv.Stats.TXBytes,
-			vv.Stats.RXErrors,
-			vv.Stats.TXErrors,
-			vv.Stats.RXDropped,
-			vv.Stats.TXDropped,
-			vv.Stats.Multicast,
-			vv.Stats.RXBPS,
-			vv.Stats.TXBPS,
+		ifi := &Interface{
+			Name:            k,
+			Up:              vv.Up == "true",
+			Autonegotiation: vv.Autoneg == "true",
+			Duplex:          vv.Duplex,
 		}
 
-		ints := make([]int, 0, len(ss))
-		for _, str := range ss {
-			if str == "" {
-				ints = append(ints, 0)
-				continue
-			}
+		// Pair each numeric field with its destination, so that fields
+		// can be added without relying on their positions
+		ints := []struct {
+			n   apiNumber
+			dst *int
+		}{
+			{n: vv.Speed, dst: &ifi.Speed},
+			{n: vv.MTU, dst: &ifi.MTU},
+		}
 
-			v, err := strconv.Atoi(str)
+		for _, f := range ints {
+			// Unknown values are reported as zero
+			n, _, err := f.n.int()
 			if err != nil {
 				return err
 			}
 
-			ints = append(ints, v)
+			*f.dst = n
 		}
 
-		var mac net.HardwareAddr
-		if vv.MAC != "" {
-			var err error
-			mac, err = net.ParseMAC(vv.MAC)
+		counters := []struct {
+			n   apiNumber
+			dst *uint64
+		}{
+			{n: vv.Stats.RXPackets, dst: &ifi.Stats.ReceivePackets},
+			{n: vv.Stats.TXPackets, dst: &ifi.Stats.TransmitPackets},
+			{n: vv.Stats.RXBytes, dst: &ifi.Stats.ReceiveBytes},
+			{n: vv.Stats.TXBytes, dst: &ifi.Stats.TransmitBytes},
+			{n: vv.Stats.RXErrors, dst: &ifi.Stats.ReceiveErrors},
+			{n: vv.Stats.TXErrors, dst: &ifi.Stats.TransmitErrors},
+			{n: vv.Stats.RXDropped, dst: &ifi.Stats.ReceiveDropped},
+			{n: vv.Stats.TXDropped, dst: &ifi.Stats.TransmitDropped},
+			{n: vv.Stats.Multicast, dst: &ifi.Stats.Multicast},
+			{n: vv.Stats.RXBPS, dst: &ifi.Stats.ReceiveBPS},
+			{n: vv.Stats.TXBPS, dst: &ifi.Stats.TransmitBPS},
+		}
+
+		for _, f := range counters {
+			n, _, err := f.n.uint64()
 			if err != nil {
 				return err
 			}
+
+			*f.dst = n
 		}
 
-		ips := make([]net.IP, 0)
+		// Speed and duplex are unknown for some interfaces, such as those
+		// which are down or virtual
+		_, speedKnown, _ := vv.Speed.int()
+		ifi.SpeedUnknown = !speedKnown
+		if isPlaceholder(ifi.Duplex) {
+			ifi.Duplex = ""
+		}
+		ifi.DuplexUnknown = ifi.Duplex == ""
 
-		switch reflect.ValueOf(vv.Addresses).Kind() {
-		case reflect.Slice:
-			for _, ip := range vv.Addresses.([]interface{}) {
-				ip, _, err := net.ParseCIDR(ip.(string))
-				if err != nil {
-					return err
-				}
-				ips = append(ips, ip)
-			}
-		case reflect.String:
-			v := vv.Addresses.(string)
-			if v != "" {
-				ip, _, err := net.ParseCIDR(v)
-				if err != nil {
-					return err
-				}
-				ips = append(ips, ip)
+		if vv.MAC != "" {
+			mac, err := net.ParseMAC(vv.MAC)
+			if err != nil {
+				return err
 			}
+
+			ifi.MAC = mac
 		}
 
-		is = append(is, &Interface{
-			Name:            k,
-			Up:              vv.Up == "true",
-			Autonegotiation: vv.Autoneg == "true",
-			Duplex:          vv.Duplex,
-			Speed:           ints[0],
-			MAC:             mac,
-			MTU:             ints[1],
-			Addresses:       ips,
-			Stats: InterfaceStats{
-				ReceivePackets:  ints[2],
-				TransmitPackets: ints[3],
-				ReceiveBytes:    ints[4],
-				TransmitBytes:   ints[5],
-				ReceiveErrors:   ints[6],
-				TransmitErrors:  ints[7],
-				ReceiveDropped:  ints[8],
-				TransmitDropped: ints[9],
-				Multicast:       ints[10],
-				ReceiveBPS:      ints[11],
-				TransmitBPS:     ints[12],
-			},
-		})
+		ips, err := parseInterfaceAddresses(vv.Addresses)
+		if err != nil {
+			return err
+		}
+		ifi.Addresses = ips
+
+		is = append(is, ifi)
 	}
 
 	sort.Sort(byInterfaceName(is))
@@ -247,6 +278,38 @@ func (i *Interfaces) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// parseInterfaceAddresses parses the addresses of an interface, which are
+// represented as either a list of CIDR strings, or a single CIDR string.
+func parseInterfaceAddresses(v interface{}) ([]net.IP, error) {
+	ips := make([]net.IP, 0)
+
+	switch v := v.(type) {
+	case []interface{}:
+		for _, a := range v {
+			s, ok := a.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid interface address: %v", a)
+			}
+
+			ip, _, err := net.ParseCIDR(s)
+			if err != nil {
+				return nil, err
+			}
+			ips = append(ips, ip)
+		}
+	case string:
+		if v != "" {
+			ip, _, err := net.ParseCIDR(v)
+			if err != nil {
+				return nil, err
+			}
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips, nil
+}
+
 // byInterfaceName is used to sort Interfaces by network interface name.
 type byInterfaceName []*Interface
 
@@ -264,10 +327,10 @@ type DPIStat struct {
 	IP            net.IP
 	Type          string
 	Category      string
-	ReceiveBytes  int
-	ReceiveRate   int
-	TransmitBytes int
-	TransmitRate  int
+	ReceiveBytes  uint64
+	ReceiveRate   uint64
+	TransmitBytes uint64
+	TransmitRate  uint64
 }
 
 // StatType implements the Stats interface.
@@ -277,109 +340,151 @@ func (d DPIStats) StatType() StatType {
 
 // UnmarshalJSON unmarshals JSON into a DPIStats.
 func (d *DPIStats) UnmarshalJSON(b []byte) error {
+	// DPI statistics are by far the largest statistics on busy networks, so
+	// values are decoded directly into integers, and all DPIStat values are
+	// allocated at once
 	var v map[string]map[string]struct {
-		RXBytes string `json:"rx_bytes"`
-		RXRate  string `json:"rx_rate"`
-		TXBytes string `json:"tx_bytes"`
-		TXRate  string `json:"tx_rate"`
+		RXBytes dpiCounter `json:"rx_bytes"`
+		RXRate  dpiCounter `json:"rx_rate"`
+		TXBytes dpiCounter `json:"tx_bytes"`
+		TXRate  dpiCounter `json:"tx_rate"`
 	}
 
 	if err := json.Unmarshal(b, &v); err != nil {
 		return err
 	}
 
-	var out DPIStats
-	for statIP := range v {
+	var n int
+	for _, types := range v {
+		n += len(types)
+	}
+
+	var (
+		stats = make([]DPIStat, 0, n)
+		out   = make(DPIStats, 0, n)
+	)
+
+	for statIP, types := range v {
 		ip := net.ParseIP(statIP)
 		if ip == nil {
 			continue
 		}
 
-		for statType, stats := range v[statIP] {
-			nameCat := strings.SplitN(statType, "|", 2)
-			if len(nameCat) != 2 {
+		for statType, st := range types {
+			i := strings.IndexByte(statType, '|')
+			if i == -1 {
 				return fmt.Errorf("invalid stat type: %q", statType)
 			}
 
-			rxBytes, err := strconv.Atoi(stats.RXBytes)
-			if err != nil {
-				return err
-			}
+			stats = append(stats, DPIStat{
+				IP:            ip,
+				Type:          statType[:i],
+				Category:      statType[i+1:],
+				ReceiveBytes:  uint64(st.RXBytes),
+				ReceiveRate:   uint64(st.RXRate),
+				TransmitBytes: uint64(st.TXBytes),
+				TransmitRate:  uint64(st.TXRate),
+			})
+			out = append(out, &stats[len(stats)-1])
+		}
+	}
 
-			rxRate, err := strconv.Atoi(stats.RXRate)
-			if err != nil {
-				return err
-			}
+	sort.Sort(byIPAndType(out))
+	*d = out
+	return nil
+}
 
-			txBytes, err := strconv.Atoi(stats.TXBytes)
-			if err != nil {
-				return err
-			}
+// A dpiCounter is a DPI statistic value.  Unlike apiNumber, a dpiCounter is
+// parsed without allocating.  Placeholder values are decoded as zero.
+type dpiCounter uint64
 
-			txRate, err := strconv.Atoi(stats.TXRate)
-			if err != nil {
-				return err
+// UnmarshalJSON unmarshals JSON into a dpiCounter.
+func (c *dpiCounter) UnmarshalJSON(b []byte) error {
+	if len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"' {
+		b = b[1 : len(b)-1]
+	}
+
+	// Fast path: a decimal integer which cannot overflow
+	if len(b) > 0 && len(b) < 20 {
+		var v uint64
+		for _, d := range b {
+			if d < '0' || d > '9' {
+				return c.parseSlow(b)
 			}
 
-			out = append(out, &DPIStat{
-				IP:            ip,
-				Type:          nameCat[0],
-				Category:      nameCat[1],
-				ReceiveBytes:  rxBytes,
-				ReceiveRate:   rxRate,
-				TransmitBytes: txBytes,
-				TransmitRate:  txRate,
-			})
+			v = v*10 + uint64(d-'0')
 		}
+
+		*c = dpiCounter(v)
+		return nil
 	}
 
-	sort.Sort(byIPAndType(out))
-	*d = out
+	return c.parseSlow(b)
+}
+
+// parseSlow parses b, which may be a placeholder, an invalid value, or a
+// very large value.
+func (c *dpiCounter) parseSlow(b []byte) error {
+	s := string(b)
+	if isPlaceholder(s) {
+		*c = 0
+		return nil
+	}
+
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*c = dpiCounter(v)
 	return nil
 }
 
-// byIPAndType is used to sort Interfaces by network interface name.
+// byIPAndType is used to sort DPIStats by IP address and traffic type.
 type byIPAndType []*DPIStat
 
 func (b byIPAndType) Len() int { return len(b) }
 func (b byIPAndType) Less(i int, j int) bool {
-	less := ipLess(b[i].IP, b[j].IP)
-	if less {
-		return true
-	}
-
-	if b[i].Type < b[j].Type {
-		return true
+	if c := ipCompare(b[i].IP, b[j].IP); c != 0 {
+		return c < 0
 	}
 
-	return false
+	return b[i].Type < b[j].Type
 }
 func (b byIPAndType) Swap(i int, j int) { b[i], b[j] = b[j], b[i] }
 
+// ipLess reports whether IP address a sorts before b.  Invalid addresses
+// sort before IPv4 addresses, which sort before IPv6 addresses.
 func ipLess(a net.IP, b net.IP) bool {
-	// Need 4-byte IPv4 representation where possible
-	if ip4 := a.To4(); ip4 != nil {
-		a = ip4
-	}
-	if ip4 := b.To4(); ip4 != nil {
-		b = ip4
-	}
+	return ipCompare(a, b) < 0
+}
 
-	switch {
-	// IPv4 addresses should appear before IPv6 addresses
-	case len(a) == net.IPv4len && len(b) == net.IPv6len:
-		return true
-	case len(a) == net.IPv6len && len(b) == net.IPv4len:
-		return false
-	case a == nil && b == nil:
-		return false
-	}
+// ipCompare compares IP addresses a and b, returning -1, 0, or 1 in the
+// order described by ipLess.
+func ipCompare(a net.IP, b net.IP) int {
+	a, b = normalizeIP(a), normalizeIP(b)
 
-	for i := range a {
-		if a[i] < b[i] {
-			return true
+	// Compare families first: invalid, then IPv4, then IPv6
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
 		}
+
+		return 1
 	}
 
-	return false
+	return bytes.Compare(a, b)
+}
+
+// normalizeIP returns the 4-byte form of an IPv4 address, the 16-byte form
+// of an IPv6 address, or nil for an invalid address.
+func normalizeIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	if len(ip) == net.IPv6len {
+		return ip
+	}
+
+	return nil
 }
@@ -0,0 +1,568 @@
+package edgemax
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// RouteCounts is a Stat which contains the number of routes in an EdgeMAX
+// device's routing table.
+type RouteCounts struct {
+	// Total is the total number of routes.
+	Total int
+
+	// Protocols maps the source of routes, such as "connected", "static",
+	// or "ospf", to the number of routes from that source.
+	Protocols map[string]int
+}
+
+var _ Stat = &RouteCounts{}
+
+// StatType implements the Stats interface.
+func (rc *RouteCounts) StatType() StatType {
+	return StatTypeRouteCounts
+}
+
+// UnmarshalJSON unmarshals JSON into a RouteCounts.
+func (rc *RouteCounts) UnmarshalJSON(b []byte) error {
+	var v map[string]apiNumber
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	out := RouteCounts{
+		Protocols: make(map[string]int, len(v)),
+	}
+
+	var (
+		sum        int
+		totalKnown bool
+	)
+
+	for k, vv := range v {
+		// Unknown values are reported as zero
+		n, known, err := vv.int()
+		if err != nil {
+			return err
+		}
+
+		if k == "total" {
+			out.Total, totalKnown = n, known
+			continue
+		}
+
+		out.Protocols[k] = n
+		sum += n
+	}
+
+	// Older firmware does not report a total
+	if !totalKnown {
+		out.Total = sum
+	}
+
+	*rc = out
+	return nil
+}
+
+// ConfigChange is a Stat which is sent when an EdgeMAX device's
+// configuration is committed.
+type ConfigChange struct {
+	// Commit is the state of the commit, such as "started" or "ended".
+	Commit string
+}
+
+var _ Stat = &ConfigChange{}
+
+// StatType implements the Stats interface.
+func (cc *ConfigChange) StatType() StatType {
+	return StatTypeConfigChange
+}
+
+// UnmarshalJSON unmarshals JSON into a ConfigChange.
+func (cc *ConfigChange) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Commit string `json:"commit"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*cc = ConfigChange{
+		Commit: v.Commit,
+	}
+
+	return nil
+}
+
+// Users is a Stat which contains the users logged in to an EdgeMAX device,
+// sorted by name and terminal.
+type Users []*User
+
+var _ Stat = &Users{}
+
+// StatType implements the Stats interface.
+func (u Users) StatType() StatType {
+	return StatTypeUsers
+}
+
+// A User is a single login session of a user on an EdgeMAX device.
+type User struct {
+	Name     string
+	Terminal string
+	Host     string
+	Idle     time.Duration
+}
+
+// UnmarshalJSON unmarshals JSON into a Users.
+func (u *Users) UnmarshalJSON(b []byte) error {
+	var v map[string][]struct {
+		TTY  string    `json:"tty"`
+		Host string    `json:"host"`
+		Idle apiNumber `json:"idle"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	us := make(Users, 0, len(v))
+	for name, sessions := range v {
+		for _, s := range sessions {
+			idle, _, err := s.Idle.uint64()
+			if err != nil {
+				return err
+			}
+
+			us = append(us, &User{
+				Name:     name,
+				Terminal: s.TTY,
+				Host:     s.Host,
+				Idle:     time.Duration(idle) * time.Second,
+			})
+		}
+	}
+
+	sort.Slice(us, func(i int, j int) bool {
+		if us[i].Name != us[j].Name {
+			return us[i].Name < us[j].Name
+		}
+
+		return us[i].Terminal < us[j].Terminal
+	})
+
+	*u = us
+	return nil
+}
+
+// DiscoveredDevices is a Stat which contains Ubiquiti devices discovered on
+// an EdgeMAX device's networks, sorted by MAC address.
+type DiscoveredDevices []*DiscoveredDevice
+
+var _ Stat = &DiscoveredDevices{}
+
+// StatType implements the Stats interface.
+func (d DiscoveredDevices) StatType() StatType {
+	return StatTypeDiscoveredDevices
+}
+
+// A DiscoveredDevice is a Ubiquiti device discovered by an EdgeMAX device.
+type DiscoveredDevice struct {
+	MAC       net.HardwareAddr
+	Addresses []net.IP
+	Hostname  string
+	Product   string
+	Firmware  string
+	Uptime    time.Duration
+}
+
+// UnmarshalJSON unmarshals JSON into a DiscoveredDevices.
+func (d *DiscoveredDevices) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Devices []struct {
+			HWAddr    string `json:"hwaddr"`
+			Addresses []struct {
+				IPv4 string `json:"ipv4"`
+			} `json:"addresses"`
+			Hostname string    `json:"hostname"`
+			Product  string    `json:"product"`
+			Firmware string    `json:"fwversion"`
+			Uptime   apiNumber `json:"uptime"`
+		} `json:"devices"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	ds := make(DiscoveredDevices, 0, len(v.Devices))
+	for _, vv := range v.Devices {
+		mac, err := net.ParseMAC(vv.HWAddr)
+		if err != nil {
+			return err
+		}
+
+		ips := make([]net.IP, 0, len(vv.Addresses))
+		for _, a := range vv.Addresses {
+			ip := net.ParseIP(a.IPv4)
+			if ip == nil {
+				return fmt.Errorf("invalid discovered device IP address: %q", a.IPv4)
+			}
+
+			ips = append(ips, normalizeIP(ip))
+		}
+
+		uptime, _, err := vv.Uptime.uint64()
+		if err != nil {
+			return err
+		}
+
+		ds = append(ds, &DiscoveredDevice{
+			MAC:       mac,
+			Addresses: ips,
+			Hostname:  vv.Hostname,
+			Product:   vv.Product,
+			Firmware:  vv.Firmware,
+			Uptime:    time.Duration(uptime) * time.Second,
+		})
+	}
+
+	sort.Slice(ds, func(i int, j int) bool {
+		return bytes.Compare(ds[i].MAC, ds[j].MAC) < 0
+	})
+
+	*d = ds
+	return nil
+}
+
+// PONStats is a Stat which contains statistics for the passive optical
+// network ports of an EdgeMAX optical line terminal, sorted by name.
+type PONStats []*PONStat
+
+var _ Stat = &PONStats{}
+
+// StatType implements the Stats interface.
+func (p PONStats) StatType() StatType {
+	return StatTypePONStats
+}
+
+// A PONStat contains statistics for a single passive optical network port.
+type PONStat struct {
+	Name string
+
+	// ONUs is the number of optical network units connected to the port.
+	ONUs int
+
+	ReceivePackets  uint64
+	TransmitPackets uint64
+	ReceiveBytes    uint64
+	TransmitBytes   uint64
+	ReceiveBPS      uint64
+	TransmitBPS     uint64
+}
+
+// UnmarshalJSON unmarshals JSON into a PONStats.
+func (p *PONStats) UnmarshalJSON(b []byte) error {
+	var v map[string]struct {
+		ONUs      apiNumber `json:"onus"`
+		RXPackets apiNumber `json:"rx_packets"`
+		TXPackets apiNumber `json:"tx_packets"`
+		RXBytes   apiNumber `json:"rx_bytes"`
+		TXBytes   apiNumber `json:"tx_bytes"`
+		RXBPS     apiNumber `json:"rx_bps"`
+		TXBPS     apiNumber `json:"tx_bps"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	ps := make(PONStats, 0, len(v))
+	for k, vv := range v {
+		onus, _, err := vv.ONUs.int()
+		if err != nil {
+			return err
+		}
+
+		s := &PONStat{
+			Name: k,
+			ONUs: onus,
+		}
+
+		counters := []struct {
+			n   apiNumber
+			dst *uint64
+		}{
+			{n: vv.RXPackets, dst: &s.ReceivePackets},
+			{n: vv.TXPackets, dst: &s.TransmitPackets},
+			{n: vv.RXBytes, dst: &s.ReceiveBytes},
+			{n: vv.TXBytes, dst: &s.TransmitBytes},
+			{n: vv.RXBPS, dst: &s.ReceiveBPS},
+			{n: vv.TXBPS, dst: &s.TransmitBPS},
+		}
+
+		for _, f := range counters {
+			n, _, err := f.n.uint64()
+			if err != nil {
+				return err
+			}
+
+			*f.dst = n
+		}
+
+		ps = append(ps, s)
+	}
+
+	sort.Slice(ps, func(i int, j int) bool {
+		return ps[i].Name < ps[j].Name
+	})
+
+	*p = ps
+	return nil
+}
+
+// LTEStats is a Stat which contains statistics for the LTE modems of an
+// EdgeMAX device, sorted by name.
+type LTEStats []*LTEStat
+
+var _ Stat = &LTEStats{}
+
+// StatType implements the Stats interface.
+func (l LTEStats) StatType() StatType {
+	return StatTypeLTEStats
+}
+
+// An LTEStat contains statistics for a single LTE modem.
+type LTEStat struct {
+	Name       string
+	Connected  bool
+	Operator   string
+	Technology string
+
+	// Signal quality measurements, in dBm for RSSI and RSRP, and in dB for
+	// RSRQ and SINR.  Unknown measurements are zero.
+	RSSI int
+	RSRP int
+	RSRQ int
+	SINR int
+}
+
+// UnmarshalJSON unmarshals JSON into an LTEStats.
+func (l *LTEStats) UnmarshalJSON(b []byte) error {
+	var v map[string]struct {
+		Connected  apiBool   `json:"connected"`
+		Operator   string    `json:"operator"`
+		Technology string    `json:"technology"`
+		RSSI       apiNumber `json:"rssi"`
+		RSRP       apiNumber `json:"rsrp"`
+		RSRQ       apiNumber `json:"rsrq"`
+		SINR       apiNumber `json:"sinr"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	ls := make(LTEStats, 0, len(v))
+	for k, vv := range v {
+		s := &LTEStat{
+			Name:       k,
+			Connected:  bool(vv.Connected),
+			Operator:   vv.Operator,
+			Technology: vv.Technology,
+		}
+
+		signals := []struct {
+			n   apiNumber
+			dst *int
+		}{
+			{n: vv.RSSI, dst: &s.RSSI},
+			{n: vv.RSRP, dst: &s.RSRP},
+			{n: vv.RSRQ, dst: &s.RSRQ},
+			{n: vv.SINR, dst: &s.SINR},
+		}
+
+		for _, f := range signals {
+			n, _, err := f.n.signed()
+			if err != nil {
+				return err
+			}
+
+			*f.dst = n
+		}
+
+		ls = append(ls, s)
+	}
+
+	sort.Slice(ls, func(i int, j int) bool {
+		return ls[i].Name < ls[j].Name
+	})
+
+	*l = ls
+	return nil
+}
+
+// NATStats is a Stat which contains counters for the NAT rules of an
+// EdgeMAX device, sorted by type and rule number.
+type NATStats []*NATStat
+
+var _ Stat = &NATStats{}
+
+// StatType implements the Stats interface.
+func (n NATStats) StatType() StatType {
+	return StatTypeNATStats
+}
+
+// A NATStat contains counters for a single NAT rule.
+type NATStat struct {
+	// Type is the type of the rule, such as "source" or "destination".
+	Type    string
+	Rule    int
+	Packets uint64
+	Bytes   uint64
+}
+
+// UnmarshalJSON unmarshals JSON into a NATStats.
+func (n *NATStats) UnmarshalJSON(b []byte) error {
+	var v map[string][]struct {
+		Rule apiNumber `json:"rule"`
+		ruleCounters
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	ns := make(NATStats, 0, len(v))
+	for typ, rules := range v {
+		for _, r := range rules {
+			rule, _, err := r.Rule.int()
+			if err != nil {
+				return err
+			}
+
+			packets, byteCount, err := r.parse()
+			if err != nil {
+				return err
+			}
+
+			ns = append(ns, &NATStat{
+				Type:    typ,
+				Rule:    rule,
+				Packets: packets,
+				Bytes:   byteCount,
+			})
+		}
+	}
+
+	sort.Slice(ns, func(i int, j int) bool {
+		if ns[i].Type != ns[j].Type {
+			return ns[i].Type < ns[j].Type
+		}
+
+		return ns[i].Rule < ns[j].Rule
+	})
+
+	*n = ns
+	return nil
+}
+
+// FirewallStats is a Stat which contains counters for the firewall rules
+// of an EdgeMAX device, sorted by ruleset and rule number, with each
+// ruleset's default action last.
+type FirewallStats []*FirewallStat
+
+var _ Stat = &FirewallStats{}
+
+// StatType implements the Stats interface.
+func (f FirewallStats) StatType() StatType {
+	return StatTypeFirewallStats
+}
+
+// A FirewallStat contains counters for a single firewall rule.
+type FirewallStat struct {
+	Ruleset string
+
+	// Rule is the rule number, or zero if Default is true.
+	Rule int
+
+	// Default reports whether the counters are for the ruleset's default
+	// action, which applies to traffic matched by no rule.
+	Default bool
+
+	Packets uint64
+	Bytes   uint64
+}
+
+// UnmarshalJSON unmarshals JSON into a FirewallStats.
+func (f *FirewallStats) UnmarshalJSON(b []byte) error {
+	var v map[string]map[string]ruleCounters
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	fs := make(FirewallStats, 0, len(v))
+	for ruleset, rules := range v {
+		for k, r := range rules {
+			s := &FirewallStat{
+				Ruleset: ruleset,
+				Default: k == "default",
+			}
+
+			if !s.Default {
+				rule, err := strconv.Atoi(k)
+				if err != nil {
+					return err
+				}
+
+				s.Rule = rule
+			}
+
+			packets, byteCount, err := r.parse()
+			if err != nil {
+				return err
+			}
+
+			s.Packets, s.Bytes = packets, byteCount
+			fs = append(fs, s)
+		}
+	}
+
+	sort.Slice(fs, func(i int, j int) bool {
+		switch {
+		case fs[i].Ruleset != fs[j].Ruleset:
+			return fs[i].Ruleset < fs[j].Ruleset
+		case fs[i].Default != fs[j].Default:
+			return !fs[i].Default
+		}
+
+		return fs[i].Rule < fs[j].Rule
+	})
+
+	*f = fs
+	return nil
+}
+
+// ruleCounters contains the packet and byte counters of a NAT or firewall
+// rule.
+type ruleCounters struct {
+	Packets apiNumber `json:"packets"`
+	Bytes   apiNumber `json:"bytes"`
+}
+
+// parse parses the counters, reporting unknown values as zero.
+func (rc ruleCounters) parse() (packets uint64, byteCount uint64, err error) {
+	if packets, _, err = rc.Packets.uint64(); err != nil {
+		return 0, 0, err
+	}
+	if byteCount, _, err = rc.Bytes.uint64(); err != nil {
+		return 0, 0, err
+	}
+
+	return packets, byteCount, nil
+}
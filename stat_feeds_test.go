@@ -0,0 +1,511 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRouteCountsUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		rc      *RouteCounts
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid count",
+			b:       []byte(`{"static":"foo"}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK",
+			b:    []byte(`{"connected":"3","static":2,"ospf":"-","total":"6"}`),
+			rc: &RouteCounts{
+				Total: 6,
+				Protocols: map[string]int{
+					"connected": 3,
+					"static":    2,
+					"ospf":      0,
+				},
+			},
+		},
+		{
+			desc: "OK no total",
+			b:    []byte(`{"connected":"3","static":"2"}`),
+			rc: &RouteCounts{
+				Total: 5,
+				Protocols: map[string]int{
+					"connected": 3,
+					"static":    2,
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		rc := new(RouteCounts)
+		err := rc.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.rc, rc; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected RouteCounts:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestConfigChangeUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		cc      *ConfigChange
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc: "OK",
+			b:    []byte(`{"commit":"ended"}`),
+			cc: &ConfigChange{
+				Commit: "ended",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		cc := new(ConfigChange)
+		err := cc.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.cc, cc; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected ConfigChange:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestUsersUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		u       Users
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid idle time",
+			b:       []byte(`{"ubnt":[{"tty":"pts/0","idle":"foo"}]}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK",
+			b: []byte(`{
+				"ubnt":[
+					{"tty":"pts/1","host":"192.168.1.3","idle":"-"},
+					{"tty":"pts/0","host":"192.168.1.2","idle":"30"}
+				],
+				"admin":[{"tty":"ttyS0","host":"","idle":5}]
+			}`),
+			u: Users{
+				{
+					Name:     "admin",
+					Terminal: "ttyS0",
+					Idle:     5 * time.Second,
+				},
+				{
+					Name:     "ubnt",
+					Terminal: "pts/0",
+					Host:     "192.168.1.2",
+					Idle:     30 * time.Second,
+				},
+				{
+					Name:     "ubnt",
+					Terminal: "pts/1",
+					Host:     "192.168.1.3",
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var u Users
+		err := u.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.u, u; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected Users:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestDiscoveredDevicesUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		d       DiscoveredDevices
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid MAC",
+			b:       []byte(`{"devices":[{"hwaddr":"foo"}]}`),
+			errType: reflect.TypeOf(&net.AddrError{}),
+		},
+		{
+			desc:    "invalid uptime",
+			b:       []byte(`{"devices":[{"hwaddr":"de:ad:be:ef:de:ad","uptime":"foo"}]}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK",
+			b: []byte(`{"devices":[
+				{
+					"hwaddr":"f0:9f:c2:00:00:02",
+					"addresses":[{"ipv4":"192.168.1.20"}],
+					"hostname":"ap-office",
+					"product":"UAP-AC-Pro",
+					"fwversion":"4.0.10",
+					"uptime":"3600"
+				},
+				{
+					"hwaddr":"f0:9f:c2:00:00:01",
+					"addresses":[],
+					"hostname":"switch",
+					"product":"ES-8-150W",
+					"fwversion":"1.7.4",
+					"uptime":"-"
+				}
+			]}`),
+			d: DiscoveredDevices{
+				{
+					MAC:       net.HardwareAddr{0xf0, 0x9f, 0xc2, 0x00, 0x00, 0x01},
+					Addresses: []net.IP{},
+					Hostname:  "switch",
+					Product:   "ES-8-150W",
+					Firmware:  "1.7.4",
+				},
+				{
+					MAC:       net.HardwareAddr{0xf0, 0x9f, 0xc2, 0x00, 0x00, 0x02},
+					Addresses: []net.IP{net.IPv4(192, 168, 1, 20).To4()},
+					Hostname:  "ap-office",
+					Product:   "UAP-AC-Pro",
+					Firmware:  "4.0.10",
+					Uptime:    1 * time.Hour,
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var d DiscoveredDevices
+		err := d.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.d, d; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected DiscoveredDevices:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestPONStatsUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		p       PONStats
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid counter",
+			b:       []byte(`{"pon1":{"rx_bytes":"foo"}}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK",
+			b: []byte(`{
+				"pon2":{"onus":"0","rx_packets":"-","tx_packets":"-"},
+				"pon1":{
+					"onus":"4",
+					"rx_packets":"1",
+					"tx_packets":"2",
+					"rx_bytes":"18446744073709551615",
+					"tx_bytes":"4",
+					"rx_bps":"5",
+					"tx_bps":6
+				}
+			}`),
+			p: PONStats{
+				{
+					Name:            "pon1",
+					ONUs:            4,
+					ReceivePackets:  1,
+					TransmitPackets: 2,
+					ReceiveBytes:    18446744073709551615,
+					TransmitBytes:   4,
+					ReceiveBPS:      5,
+					TransmitBPS:     6,
+				},
+				{
+					Name: "pon2",
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var p PONStats
+		err := p.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.p, p; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected PONStats:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestLTEStatsUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		l       LTEStats
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid signal",
+			b:       []byte(`{"lte0":{"rssi":"foo"}}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK",
+			b: []byte(`{"lte0":{
+				"connected":"true",
+				"operator":"Carrier",
+				"technology":"LTE",
+				"rssi":"-67",
+				"rsrp":-95,
+				"rsrq":"-11",
+				"sinr":"12"
+			}}`),
+			l: LTEStats{{
+				Name:       "lte0",
+				Connected:  true,
+				Operator:   "Carrier",
+				Technology: "LTE",
+				RSSI:       -67,
+				RSRP:       -95,
+				RSRQ:       -11,
+				SINR:       12,
+			}},
+		},
+		{
+			desc: "OK disconnected",
+			b:    []byte(`{"lte0":{"connected":false,"rssi":"n/a"}}`),
+			l: LTEStats{{
+				Name: "lte0",
+			}},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var l LTEStats
+		err := l.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.l, l; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected LTEStats:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestNATStatsUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		n       NATStats
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid rule",
+			b:       []byte(`{"source":[{"rule":"foo"}]}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc:    "invalid packets",
+			b:       []byte(`{"source":[{"rule":"5000","packets":"foo"}]}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK",
+			b: []byte(`{
+				"source":[
+					{"rule":"5010","packets":"3","bytes":"4"},
+					{"rule":"5000","packets":"1","bytes":"2"}
+				],
+				"destination":[{"rule":1,"packets":5,"bytes":6}]
+			}`),
+			n: NATStats{
+				{Type: "destination", Rule: 1, Packets: 5, Bytes: 6},
+				{Type: "source", Rule: 5000, Packets: 1, Bytes: 2},
+				{Type: "source", Rule: 5010, Packets: 3, Bytes: 4},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var n NATStats
+		err := n.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.n, n; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected NATStats:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestFirewallStatsUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		f       FirewallStats
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid rule",
+			b:       []byte(`{"WAN_IN":{"foo":{}}}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc:    "invalid bytes",
+			b:       []byte(`{"WAN_IN":{"10":{"packets":"1","bytes":"foo"}}}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK",
+			b: []byte(`{
+				"WAN_LOCAL":{"default":{"packets":"7","bytes":"8"}},
+				"WAN_IN":{
+					"default":{"packets":"5","bytes":"6"},
+					"20":{"packets":"3","bytes":"4"},
+					"10":{"packets":"1","bytes":"2"}
+				}
+			}`),
+			f: FirewallStats{
+				{Ruleset: "WAN_IN", Rule: 10, Packets: 1, Bytes: 2},
+				{Ruleset: "WAN_IN", Rule: 20, Packets: 3, Bytes: 4},
+				{Ruleset: "WAN_IN", Default: true, Packets: 5, Bytes: 6},
+				{Ruleset: "WAN_LOCAL", Default: true, Packets: 7, Bytes: 8},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var f FirewallStats
+		err := f.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.f, f; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected FirewallStats:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
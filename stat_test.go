@@ -1,8 +1,10 @@
 package edgemax
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"reflect"
 	"strconv"
@@ -46,6 +48,25 @@ func TestSystemStatsUnmarshalJSON(t *testing.T) {
 				Memory: 30,
 			},
 		},
+		{
+			desc: "OK numbers",
+			b:    []byte(`{"cpu":10,"uptime":20,"mem":30}`),
+			s: &SystemStats{
+				CPU:    10,
+				Uptime: 20 * time.Second,
+				Memory: 30,
+			},
+		},
+		{
+			desc: "OK placeholder values",
+			b:    []byte(`{"cpu":"-","uptime":null,"mem":"n/a"}`),
+			s:    &SystemStats{},
+		},
+		{
+			desc:    "invalid CPU boolean",
+			b:       []byte(`{"cpu":true}`),
+			errType: reflect.TypeOf(&json.UnmarshalTypeError{}),
+		},
 	}
 
 	for i, tt := range tests {
@@ -154,6 +175,11 @@ func TestInterfacesUnmarshalJSON(t *testing.T) {
 			b:       []byte(`{"eth0":{"addresses":["foo"]}}`),
 			errType: reflect.TypeOf(&net.ParseError{}),
 		},
+		{
+			desc:    "invalid address type",
+			b:       []byte(`{"eth0":{"addresses":[1]}}`),
+			errType: reflect.TypeOf(errors.New("")),
+		},
 		{
 			desc: "OK one interface",
 			b:    []byte(`{"eth0":{"up":"true","autoneg":"true","duplex":"full","speed":"10","mac":"de:ad:be:ef:de:ad","mtu":"1500","addresses":["192.168.1.1/24"],"stats":{"rx_packets":"1","tx_packets":"2","rx_bytes":"3","tx_bytes":"4","rx_errors":"5","tx_errors":"6","rx_dropped":"7","tx_dropped":"8","multicast":"9","rx_bps":"10","tx_bps":"11"}}}`),
@@ -181,22 +207,80 @@ func TestInterfacesUnmarshalJSON(t *testing.T) {
 				},
 			}},
 		},
+		{
+			desc: "OK one interface with 64-bit counters",
+			b:    []byte(`{"eth0":{"stats":{"rx_bytes":"5497558138880","tx_bytes":18446744073709551615}}}`),
+			ifis: Interfaces{{
+				Name:          "eth0",
+				Addresses:     []net.IP{},
+				DuplexUnknown: true,
+				SpeedUnknown:  true,
+				Stats: InterfaceStats{
+					ReceiveBytes:  5497558138880,
+					TransmitBytes: 18446744073709551615,
+				},
+			}},
+		},
+		{
+			desc:    "counter overflows 64 bits",
+			b:       []byte(`{"eth0":{"stats":{"rx_bytes":"18446744073709551616"}}}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK one interface with numbers",
+			b:    []byte(`{"eth0":{"speed":10,"mtu":1500,"stats":{"rx_packets":1,"tx_packets":2,"rx_bytes":"3","tx_bytes":4,"rx_errors":5,"tx_errors":6,"rx_dropped":7,"tx_dropped":8,"multicast":9,"rx_bps":10,"tx_bps":11}}}`),
+			ifis: Interfaces{{
+				Name:          "eth0",
+				Speed:         10,
+				MTU:           1500,
+				Addresses:     []net.IP{},
+				DuplexUnknown: true,
+				Stats: InterfaceStats{
+					ReceivePackets:  1,
+					TransmitPackets: 2,
+					ReceiveBytes:    3,
+					TransmitBytes:   4,
+					ReceiveErrors:   5,
+					TransmitErrors:  6,
+					ReceiveDropped:  7,
+					TransmitDropped: 8,
+					Multicast:       9,
+					ReceiveBPS:      10,
+					TransmitBPS:     11,
+				},
+			}},
+		},
 		{
 			desc: "OK two interfaces",
 			b:    []byte(`{"eth1":{"mac":"ab:ad:1d:ea:ab:ad","addresses":["192.168.1.2/24"]},"eth0":{"mac":"de:ad:be:ef:de:ad","addresses":["192.168.1.1/24"]}}`),
 			ifis: Interfaces{
 				{
-					Name:      "eth0",
-					MAC:       net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
-					Addresses: []net.IP{net.IPv4(192, 168, 1, 1)},
+					Name:          "eth0",
+					MAC:           net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+					Addresses:     []net.IP{net.IPv4(192, 168, 1, 1)},
+					DuplexUnknown: true,
+					SpeedUnknown:  true,
 				},
 				{
-					Name:      "eth1",
-					MAC:       net.HardwareAddr{0xab, 0xad, 0x1d, 0xea, 0xab, 0xad},
-					Addresses: []net.IP{net.IPv4(192, 168, 1, 2)},
+					Name:          "eth1",
+					MAC:           net.HardwareAddr{0xab, 0xad, 0x1d, 0xea, 0xab, 0xad},
+					Addresses:     []net.IP{net.IPv4(192, 168, 1, 2)},
+					DuplexUnknown: true,
+					SpeedUnknown:  true,
 				},
 			},
 		},
+		{
+			desc: "OK placeholder values",
+			b:    []byte(`{"eth0":{"up":"false","duplex":"-","speed":"n/a","mtu":"1500","stats":{"rx_packets":null,"tx_packets":"-"}}}`),
+			ifis: Interfaces{{
+				Name:          "eth0",
+				MTU:           1500,
+				Addresses:     []net.IP{},
+				DuplexUnknown: true,
+				SpeedUnknown:  true,
+			}},
+		},
 	}
 
 	for i, tt := range tests {
@@ -218,6 +302,56 @@ func TestInterfacesUnmarshalJSON(t *testing.T) {
 	}
 }
 
+func TestInterfacesUnmarshalJSONFields(t *testing.T) {
+	// Each numeric field must be decoded into exactly one Interface field
+	var tests = []struct {
+		key   string
+		stats bool
+		set   func(ifi *Interface)
+	}{
+		{key: "speed", set: func(ifi *Interface) { ifi.Speed = 42 }},
+		{key: "mtu", set: func(ifi *Interface) { ifi.MTU = 42 }},
+		{key: "rx_packets", stats: true, set: func(ifi *Interface) { ifi.Stats.ReceivePackets = 42 }},
+		{key: "tx_packets", stats: true, set: func(ifi *Interface) { ifi.Stats.TransmitPackets = 42 }},
+		{key: "rx_bytes", stats: true, set: func(ifi *Interface) { ifi.Stats.ReceiveBytes = 42 }},
+		{key: "tx_bytes", stats: true, set: func(ifi *Interface) { ifi.Stats.TransmitBytes = 42 }},
+		{key: "rx_errors", stats: true, set: func(ifi *Interface) { ifi.Stats.ReceiveErrors = 42 }},
+		{key: "tx_errors", stats: true, set: func(ifi *Interface) { ifi.Stats.TransmitErrors = 42 }},
+		{key: "rx_dropped", stats: true, set: func(ifi *Interface) { ifi.Stats.ReceiveDropped = 42 }},
+		{key: "tx_dropped", stats: true, set: func(ifi *Interface) { ifi.Stats.TransmitDropped = 42 }},
+		{key: "multicast", stats: true, set: func(ifi *Interface) { ifi.Stats.Multicast = 42 }},
+		{key: "rx_bps", stats: true, set: func(ifi *Interface) { ifi.Stats.ReceiveBPS = 42 }},
+		{key: "tx_bps", stats: true, set: func(ifi *Interface) { ifi.Stats.TransmitBPS = 42 }},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.key)
+
+		b := fmt.Sprintf(`{"eth0":{%q:"42"}}`, tt.key)
+		if tt.stats {
+			b = fmt.Sprintf(`{"eth0":{"stats":{%q:"42"}}}`, tt.key)
+		}
+
+		var ifis Interfaces
+		if err := ifis.UnmarshalJSON([]byte(b)); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+
+		// Only the field under test should be set
+		want := &Interface{
+			Name:          "eth0",
+			Addresses:     []net.IP{},
+			DuplexUnknown: true,
+			SpeedUnknown:  tt.key != "speed",
+		}
+		tt.set(want)
+
+		if got := ifis[0]; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected Interface:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
+
 func TestDPIStatsUnmarshalJSON(t *testing.T) {
 	var tests = []struct {
 		desc    string
@@ -269,6 +403,40 @@ func TestDPIStatsUnmarshalJSON(t *testing.T) {
 				TransmitRate:  4,
 			}},
 		},
+		{
+			desc: "one IP, one DPI stat with numbers",
+			b:    []byte(`{"192.168.1.1":{"Web|Web - Other":{"rx_bytes":1,"rx_rate":2,"tx_bytes":3,"tx_rate":4}}}`),
+			d: DPIStats{{
+				IP:            net.ParseIP("192.168.1.1"),
+				Type:          "Web",
+				Category:      "Web - Other",
+				ReceiveBytes:  1,
+				ReceiveRate:   2,
+				TransmitBytes: 3,
+				TransmitRate:  4,
+			}},
+		},
+		{
+			desc: "one IP, one DPI stat with 64-bit counters",
+			b:    []byte(`{"192.168.1.1":{"Web|Web - Other":{"rx_bytes":"5497558138880","rx_rate":"0","tx_bytes":"18446744073709551615","tx_rate":"0"}}}`),
+			d: DPIStats{{
+				IP:            net.ParseIP("192.168.1.1"),
+				Type:          "Web",
+				Category:      "Web - Other",
+				ReceiveBytes:  5497558138880,
+				TransmitBytes: 18446744073709551615,
+			}},
+		},
+		{
+			desc: "one IP, one DPI stat with placeholders",
+			b:    []byte(`{"192.168.1.1":{"Web|Web - Other":{"rx_bytes":"-","rx_rate":null,"tx_bytes":"n/a","tx_rate":"4"}}}`),
+			d: DPIStats{{
+				IP:           net.ParseIP("192.168.1.1"),
+				Type:         "Web",
+				Category:     "Web - Other",
+				TransmitRate: 4,
+			}},
+		},
 		{
 			desc: "one IP, two DPI stats",
 			b:    []byte(`{"192.168.1.1":{"Web|Web - Other":{"rx_bytes":"1","rx_rate":"2","tx_bytes":"3","tx_rate":"4"},"P2P|BitTorrent series":{"rx_bytes":"5","rx_rate":"6","tx_bytes":"7","tx_rate":"8"}}}`),
@@ -398,6 +566,36 @@ func Test_ipLess(t *testing.T) {
 			b:    net.ParseIP("2001:db8::1"),
 			less: false,
 		},
+		{
+			a:    net.ParseIP("10.0.1.0"),
+			b:    net.ParseIP("10.0.0.1"),
+			less: false,
+		},
+		{
+			a:    net.ParseIP("192.168.1.1"),
+			b:    net.IPv4(192, 168, 1, 1).To4(),
+			less: false,
+		},
+		{
+			a:    nil,
+			b:    net.ParseIP("10.0.0.1"),
+			less: true,
+		},
+		{
+			a:    net.ParseIP("10.0.0.1"),
+			b:    nil,
+			less: false,
+		},
+		{
+			a:    net.IP{10, 0, 0},
+			b:    nil,
+			less: false,
+		},
+		{
+			a:    net.IP{10, 0, 0},
+			b:    net.ParseIP("2001:db8::1"),
+			less: true,
+		},
 	}
 
 	for i, tt := range tests {
@@ -410,3 +608,44 @@ func Test_ipLess(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkDPIStatsUnmarshalJSON(b *testing.B) {
+	// Simulate a large LAN with many clients using many applications
+	const (
+		clients = 250
+		types   = 20
+	)
+
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i := 0; i < clients; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		fmt.Fprintf(&buf, `"192.168.%d.%d":{`, i/256, i%256)
+		for j := 0; j < types; j++ {
+			if j > 0 {
+				buf.WriteString(",")
+			}
+
+			fmt.Fprintf(&buf, `"Type %d|Category %d":{"rx_bytes":"%d","rx_rate":"%d","tx_bytes":"%d","tx_rate":"%d"}`,
+				j, j, i*j*1000, j*10, i*j*100, j)
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString("}")
+
+	in := buf.Bytes()
+
+	b.SetBytes(int64(len(in)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var d DPIStats
+		if err := d.UnmarshalJSON(in); err != nil {
+			b.Fatalf("failed to unmarshal: %v", err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package statserver
+
+import (
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// A jsonStat is the JSON representation of a Stat sent to clients.  The
+// schema matches the output of "edgemaxctl -json stats".
+type jsonStat struct {
+	Time time.Time        `json:"time"`
+	Type edgemax.StatType `json:"type"`
+	Data interface{}      `json:"data"`
+}
+
+// newJSONStat creates a jsonStat from s, received at time t.  ok is false
+// if s is not a supported statistic type.
+func newJSONStat(s edgemax.Stat, t time.Time) (js jsonStat, ok bool) {
+	var data interface{}
+	switch s := s.(type) {
+	case *edgemax.SystemStats:
+		data = newJSONSystemStats(s)
+	case edgemax.Interfaces:
+		data = newJSONInterfaces(s)
+	case edgemax.DPIStats:
+		data = newJSONDPIStats(s)
+	default:
+		return jsonStat{}, false
+	}
+
+	return jsonStat{
+		Time: t.UTC(),
+		Type: s.StatType(),
+		Data: data,
+	}, true
+}
+
+// A jsonSystemStats is the JSON representation of SystemStats.
+type jsonSystemStats struct {
+	Uptime int `json:"uptime_seconds"`
+	CPU    int `json:"cpu_percent"`
+	Memory int `json:"memory_percent"`
+}
+
+// newJSONSystemStats creates a jsonSystemStats from ss.
+func newJSONSystemStats(ss *edgemax.SystemStats) jsonSystemStats {
+	return jsonSystemStats{
+		Uptime: int(ss.Uptime / time.Second),
+		CPU:    ss.CPU,
+		Memory: ss.Memory,
+	}
+}
+
+// A jsonInterface is the JSON representation of an Interface.
+type jsonInterface struct {
+	Name            string   `json:"name"`
+	Up              bool     `json:"up"`
+	Autonegotiation bool     `json:"autonegotiation"`
+	Duplex          string   `json:"duplex"`
+	Speed           int      `json:"speed"`
+	MAC             string   `json:"mac"`
+	MTU             int      `json:"mtu"`
+	Addresses       []string `json:"addresses"`
+
+	ReceivePackets  uint64 `json:"rx_packets"`
+	TransmitPackets uint64 `json:"tx_packets"`
+	ReceiveBytes    uint64 `json:"rx_bytes"`
+	TransmitBytes   uint64 `json:"tx_bytes"`
+	ReceiveErrors   uint64 `json:"rx_errors"`
+	TransmitErrors  uint64 `json:"tx_errors"`
+	ReceiveDropped  uint64 `json:"rx_dropped"`
+	TransmitDropped uint64 `json:"tx_dropped"`
+	Multicast       uint64 `json:"multicast"`
+	ReceiveBPS      uint64 `json:"rx_bps"`
+	TransmitBPS     uint64 `json:"tx_bps"`
+}
+
+// newJSONInterfaces creates a slice of jsonInterface values from ifis.
+func newJSONInterfaces(ifis edgemax.Interfaces) []jsonInterface {
+	out := make([]jsonInterface, 0, len(ifis))
+	for _, ifi := range ifis {
+		addrs := make([]string, 0, len(ifi.Addresses))
+		for _, a := range ifi.Addresses {
+			addrs = append(addrs, a.String())
+		}
+
+		out = append(out, jsonInterface{
+			Name:            ifi.Name,
+			Up:              ifi.Up,
+			Autonegotiation: ifi.Autonegotiation,
+			Duplex:          ifi.Duplex,
+			Speed:           ifi.Speed,
+			MAC:             ifi.MAC.String(),
+			MTU:             ifi.MTU,
+			Addresses:       addrs,
+
+			ReceivePackets:  ifi.Stats.ReceivePackets,
+			TransmitPackets: ifi.Stats.TransmitPackets,
+			ReceiveBytes:    ifi.Stats.ReceiveBytes,
+			TransmitBytes:   ifi.Stats.TransmitBytes,
+			ReceiveErrors:   ifi.Stats.ReceiveErrors,
+			TransmitErrors:  ifi.Stats.TransmitErrors,
+			ReceiveDropped:  ifi.Stats.ReceiveDropped,
+			TransmitDropped: ifi.Stats.TransmitDropped,
+			Multicast:       ifi.Stats.Multicast,
+			ReceiveBPS:      ifi.Stats.ReceiveBPS,
+			TransmitBPS:     ifi.Stats.TransmitBPS,
+		})
+	}
+
+	return out
+}
+
+// A jsonDPIStat is the JSON representation of a DPIStat.
+type jsonDPIStat struct {
+	IP            string `json:"ip"`
+	Type          string `json:"type"`
+	Category      string `json:"category"`
+	ReceiveBytes  uint64 `json:"rx_bytes"`
+	ReceiveRate   uint64 `json:"rx_rate"`
+	TransmitBytes uint64 `json:"tx_bytes"`
+	TransmitRate  uint64 `json:"tx_rate"`
+}
+
+// newJSONDPIStats creates a slice of jsonDPIStat values from ds.
+func newJSONDPIStats(ds edgemax.DPIStats) []jsonDPIStat {
+	out := make([]jsonDPIStat, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, jsonDPIStat{
+			IP:            d.IP.String(),
+			Type:          d.Type,
+			Category:      d.Category,
+			ReceiveBytes:  d.ReceiveBytes,
+			ReceiveRate:   d.ReceiveRate,
+			TransmitBytes: d.TransmitBytes,
+			TransmitRate:  d.TransmitRate,
+		})
+	}
+
+	return out
+}
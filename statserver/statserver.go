@@ -0,0 +1,242 @@
+// Package statserver implements an HTTP server which re-broadcasts
+// statistics from an EdgeMAX device to browser clients using WebSocket or
+// Server-Sent Events.
+//
+// Browser dashboards can consume a Server's stream without talking to the
+// device and its self-signed certificate directly.  Each statistic is sent
+// as a JSON object with "time", "type", and "data" fields, using the same
+// schema as the output of "edgemaxctl -json stats".
+package statserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+	"golang.org/x/net/websocket"
+)
+
+// bufferSize is the number of messages buffered for each client.  Clients
+// which fall further behind miss messages until they catch up.
+const bufferSize = 16
+
+// errClosed is returned when a Server is used after it is closed.
+var errClosed = errors.New("server closed")
+
+// A Server is an http.Handler which re-broadcasts statistics to each
+// connected client.
+//
+// Requests which attempt a WebSocket upgrade receive each statistic as a
+// text message.  All other requests receive a Server-Sent Events stream,
+// suitable for use with a browser's EventSource.  Newly connected clients
+// immediately receive the most recent statistic of each type.
+type Server struct {
+	ws http.Handler
+
+	mu     sync.Mutex
+	closed bool
+	subs   map[chan []byte]struct{}
+	latest map[edgemax.StatType][]byte
+}
+
+// New creates a Server.
+func New() *Server {
+	s := &Server{
+		subs:   make(map[chan []byte]struct{}),
+		latest: make(map[edgemax.StatType][]byte),
+	}
+
+	// The default websocket.Handler rejects cross-origin requests, but
+	// dashboards are typically served from a different origin
+	s.ws = websocket.Server{Handler: s.serveWebSocket}
+	return s
+}
+
+// Run broadcasts each Stat received on statC until statC is closed or ctx
+// is canceled.  Run can be used with the channel returned by
+// edgemax.Client.Stats.
+func (s *Server) Run(ctx context.Context, statC <-chan edgemax.Stat) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case st, ok := <-statC:
+			if !ok {
+				return nil
+			}
+
+			if err := s.Broadcast(st); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Broadcast sends st to each connected client.  System statistics,
+// interface statistics, and DPI statistics are supported; other types are
+// ignored.  Broadcast does not block on slow clients.
+func (s *Server) Broadcast(st edgemax.Stat) error {
+	js, ok := newJSONStat(st, time.Now())
+	if !ok {
+		return nil
+	}
+
+	b, err := json.Marshal(js)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errClosed
+	}
+
+	s.latest[js.Type] = b
+	for c := range s.subs {
+		select {
+		case c <- b:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Close disconnects all clients.  Further requests are rejected.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errClosed
+	}
+	s.closed = true
+
+	for c := range s.subs {
+		close(c)
+		delete(s.subs, c)
+	}
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		s.ws.ServeHTTP(w, r)
+		return
+	}
+
+	s.serveEvents(w, r)
+}
+
+// serveEvents sends statistics to a client as Server-Sent Events.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	c, ok := s.subscribe()
+	if !ok {
+		http.Error(w, errClosed.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.unsubscribe(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case b, ok := <-c:
+			if !ok {
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+				return
+			}
+			f.Flush()
+		}
+	}
+}
+
+// serveWebSocket sends statistics to a client as WebSocket messages.
+func (s *Server) serveWebSocket(ws *websocket.Conn) {
+	c, ok := s.subscribe()
+	if !ok {
+		return
+	}
+	defer s.unsubscribe(c)
+
+	// Messages from the client are discarded, but reading detects when the
+	// client disconnects
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+
+		var discard string
+		for {
+			if err := websocket.Message.Receive(ws, &discard); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-doneC:
+			return
+		case b, ok := <-c:
+			if !ok {
+				return
+			}
+
+			if err := websocket.Message.Send(ws, string(b)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// subscribe registers a new client, which immediately receives the most
+// recent statistic of each type.  ok is false if the Server is closed.
+func (s *Server) subscribe() (c chan []byte, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, false
+	}
+
+	c = make(chan []byte, bufferSize)
+	for _, b := range s.latest {
+		c <- b
+	}
+
+	s.subs[c] = struct{}{}
+	return c, true
+}
+
+// unsubscribe removes a client registered by subscribe.
+func (s *Server) unsubscribe(c chan []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subs, c)
+}
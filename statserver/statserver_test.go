@@ -0,0 +1,178 @@
+package statserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+	"golang.org/x/net/websocket"
+)
+
+func Test_newJSONStat(t *testing.T) {
+	now := time.Unix(1, 0)
+
+	var tests = []struct {
+		desc string
+		s    edgemax.Stat
+		ok   bool
+		b    string
+	}{
+		{
+			desc: "unsupported",
+		},
+		{
+			desc: "system",
+			s: &edgemax.SystemStats{
+				CPU:    10,
+				Memory: 20,
+				Uptime: time.Minute,
+			},
+			ok: true,
+			b:  `{"time":"1970-01-01T00:00:01Z","type":"system-stats","data":{"uptime_seconds":60,"cpu_percent":10,"memory_percent":20}}`,
+		},
+		{
+			desc: "DPI",
+			s: edgemax.DPIStats{{
+				IP:           net.IPv4(192, 168, 1, 10),
+				Type:         "HTTP",
+				Category:     "Web",
+				ReceiveBytes: 1,
+			}},
+			ok: true,
+			b:  `{"time":"1970-01-01T00:00:01Z","type":"export","data":[{"ip":"192.168.1.10","type":"HTTP","category":"Web","rx_bytes":1,"rx_rate":0,"tx_bytes":0,"tx_rate":0}]}`,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		js, ok := newJSONStat(tt.s, now)
+		if want, got := tt.ok, ok; want != got {
+			t.Fatalf("unexpected OK:\n- want: %v\n-  got: %v", want, got)
+		}
+		if !ok {
+			continue
+		}
+
+		b, err := json.Marshal(js)
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+
+		if want, got := tt.b, string(b); want != got {
+			t.Fatalf("unexpected JSON:\n- want: %s\n-  got: %s", want, got)
+		}
+	}
+}
+
+func TestServerEvents(t *testing.T) {
+	s := New()
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	// A statistic broadcast before the client connects is sent immediately
+	if err := s.Broadcast(&edgemax.SystemStats{CPU: 10}); err != nil {
+		t.Fatalf("failed to broadcast: %v", err)
+	}
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to get events: %v", err)
+	}
+	defer res.Body.Close()
+
+	if want, got := "text/event-stream", res.Header.Get("Content-Type"); want != got {
+		t.Fatalf("unexpected Content-Type:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	br := bufio.NewReader(res.Body)
+	if want, got := edgemax.StatTypeSystemStats, readEvent(t, br); want != got {
+		t.Fatalf("unexpected statistic type:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if err := s.Broadcast(edgemax.Interfaces{}); err != nil {
+		t.Fatalf("failed to broadcast: %v", err)
+	}
+
+	if want, got := edgemax.StatTypeInterfaces, readEvent(t, br); want != got {
+		t.Fatalf("unexpected statistic type:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// Closing the Server ends the stream
+	if err := s.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if _, err := br.ReadString('\n'); err == nil {
+		t.Fatal("expected end of stream after close")
+	}
+}
+
+func TestServerWebSocket(t *testing.T) {
+	s := New()
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	u := "ws" + strings.TrimPrefix(srv.URL, "http")
+	ws, err := websocket.Dial(u, "", "http://dashboard.example.com")
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer ws.Close()
+
+	// Broadcast until the client is subscribed, since the handshake may
+	// complete before the subscription is registered
+	doneC := make(chan struct{})
+	defer close(doneC)
+	go func() {
+		for {
+			select {
+			case <-doneC:
+				return
+			case <-time.After(10 * time.Millisecond):
+				_ = s.Broadcast(edgemax.DPIStats{})
+			}
+		}
+	}()
+
+	var js struct {
+		Type edgemax.StatType `json:"type"`
+		Data []jsonDPIStat    `json:"data"`
+	}
+	if err := websocket.JSON.Receive(ws, &js); err != nil {
+		t.Fatalf("failed to receive: %v", err)
+	}
+
+	if want, got := edgemax.StatTypeDPIStats, js.Type; want != got {
+		t.Fatalf("unexpected statistic type:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := []jsonDPIStat{}, js.Data; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected data:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+// readEvent reads a single Server-Sent Event from br and returns the type
+// of the statistic it contains.
+func readEvent(t *testing.T, br *bufio.Reader) edgemax.StatType {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read event: %v", err)
+	}
+	if _, err := br.ReadString('\n'); err != nil {
+		t.Fatalf("failed to read event terminator: %v", err)
+	}
+
+	var js jsonStat
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &js); err != nil {
+		t.Fatalf("failed to unmarshal event: %v", err)
+	}
+
+	return js.Type
+}
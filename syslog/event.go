@@ -0,0 +1,242 @@
+package syslog
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// An Event is an event parsed from a syslog message.  Event is one of
+// *Message, *FirewallEvent, or *DHCPEvent.  Messages which are not
+// recognized as a more specific event are delivered as *Message.
+type Event interface {
+	isEvent()
+}
+
+func (*Message) isEvent()       {}
+func (*FirewallEvent) isEvent() {}
+func (*DHCPEvent) isEvent()     {}
+
+// A FirewallAction is the action taken by a firewall rule.
+type FirewallAction string
+
+// List of possible FirewallAction values.
+const (
+	FirewallAccept FirewallAction = "accept"
+	FirewallDrop   FirewallAction = "drop"
+	FirewallReject FirewallAction = "reject"
+)
+
+// A FirewallEvent is a packet logged by an EdgeOS firewall rule with
+// logging enabled.
+type FirewallEvent struct {
+	// Message is the syslog message which contained the event.
+	Message *Message
+
+	// Ruleset and Rule identify the rule which logged the packet, such as
+	// "WAN_IN" and "10".  Rule is "default" when the packet was handled by
+	// the ruleset's default action.
+	Ruleset string
+	Rule    string
+	Action  FirewallAction
+
+	// InputInterface and OutputInterface are the names of the interfaces
+	// the packet arrived on and would be sent on, if any.
+	InputInterface  string
+	OutputInterface string
+
+	Source          net.IP
+	Destination     net.IP
+	SourcePort      int
+	DestinationPort int
+
+	// Protocol is the protocol of the packet, such as "TCP" or "ICMP".
+	Protocol string
+
+	// Length is the length of the packet in bytes.
+	Length int
+}
+
+// A DHCPEvent is a DHCP message processed by the EdgeOS DHCP server.
+type DHCPEvent struct {
+	// Message is the syslog message which contained the event.
+	Message *Message
+
+	// Type is the type of DHCP message, such as "DHCPDISCOVER" or
+	// "DHCPACK".
+	Type string
+
+	// IP is the address offered to, requested by, or assigned to the
+	// client, if any.
+	IP net.IP
+
+	// MAC and Hostname identify the client, if available.
+	MAC      net.HardwareAddr
+	Hostname string
+
+	// Interface is the name of the interface the message was processed on.
+	Interface string
+}
+
+// parseEvent parses a more specific event from m, if possible.
+func parseEvent(m *Message) Event {
+	switch m.App {
+	case "kernel":
+		if e, ok := parseFirewallEvent(m); ok {
+			return e
+		}
+	case "dhcpd":
+		if e, ok := parseDHCPEvent(m); ok {
+			return e
+		}
+	}
+
+	return m
+}
+
+// parseFirewallEvent parses a kernel log message produced by a firewall
+// rule, such as:
+//
+//	[WAN_IN-default-D]IN=eth0 OUT=eth1 SRC=192.0.2.1 DST=192.168.1.10 LEN=60 PROTO=TCP SPT=443 DPT=51000
+func parseFirewallEvent(m *Message) (*FirewallEvent, bool) {
+	// The kernel may prepend its own timestamp, such as "[ 1234.567890] "
+	s := m.Text
+	i := strings.Index(s, "]IN=")
+	if i == -1 {
+		return nil, false
+	}
+
+	start := strings.LastIndexByte(s[:i], '[')
+	if start == -1 {
+		return nil, false
+	}
+
+	// The prefix is the ruleset name, the rule number, and a single
+	// character action, separated by hyphens; the ruleset name may also
+	// contain hyphens
+	prefix := s[start+1 : i]
+	ai := strings.LastIndexByte(prefix, '-')
+	if ai == -1 {
+		return nil, false
+	}
+	ri := strings.LastIndexByte(prefix[:ai], '-')
+	if ri == -1 {
+		return nil, false
+	}
+
+	e := &FirewallEvent{
+		Message: m,
+		Ruleset: prefix[:ri],
+		Rule:    prefix[ri+1 : ai],
+	}
+
+	switch prefix[ai+1:] {
+	case "A":
+		e.Action = FirewallAccept
+	case "D":
+		e.Action = FirewallDrop
+	case "R":
+		e.Action = FirewallReject
+	default:
+		return nil, false
+	}
+
+	for _, kv := range strings.Fields(s[i+1:]) {
+		// ICMP errors contain the packet which caused the error in
+		// brackets, which is not part of the logged packet
+		if strings.HasPrefix(kv, "[") {
+			break
+		}
+
+		ss := strings.SplitN(kv, "=", 2)
+		if len(ss) != 2 {
+			// Flags such as "DF" and "SYN" have no value
+			continue
+		}
+
+		k, v := ss[0], ss[1]
+		switch k {
+		case "IN":
+			e.InputInterface = v
+		case "OUT":
+			e.OutputInterface = v
+		case "SRC":
+			e.Source = net.ParseIP(v)
+		case "DST":
+			e.Destination = net.ParseIP(v)
+		case "SPT":
+			e.SourcePort = atoiOrZero(v)
+		case "DPT":
+			e.DestinationPort = atoiOrZero(v)
+		case "PROTO":
+			e.Protocol = v
+		case "LEN":
+			e.Length = atoiOrZero(v)
+		}
+	}
+
+	return e, true
+}
+
+// parseDHCPEvent parses a log message produced by the DHCP server, such as:
+//
+//	DHCPACK on 192.168.1.10 to de:ad:be:ef:de:ad (laptop) via eth1
+func parseDHCPEvent(m *Message) (*DHCPEvent, bool) {
+	fields := strings.Fields(m.Text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "DHCP") {
+		return nil, false
+	}
+
+	e := &DHCPEvent{
+		Message: m,
+		Type:    fields[0],
+	}
+
+	for i := 1; i < len(fields)-1; i++ {
+		v := fields[i+1]
+
+		switch fields[i] {
+		case "on", "for", "of":
+			e.IP = net.ParseIP(v)
+		case "to", "from":
+			// The client is identified by MAC address, or by IP address
+			// for DHCPINFORM
+			if mac, err := net.ParseMAC(v); err == nil {
+				e.MAC = mac
+
+				// The client's hostname follows its MAC address
+				if i+2 < len(fields) && isParenthesized(fields[i+2]) {
+					e.Hostname = strings.Trim(fields[i+2], "()")
+				}
+				break
+			}
+
+			if e.IP == nil {
+				e.IP = net.ParseIP(v)
+			}
+		case "via":
+			e.Interface = v
+		default:
+			continue
+		}
+
+		i++
+	}
+
+	return e, true
+}
+
+// isParenthesized reports whether s is enclosed in parentheses.
+func isParenthesized(s string) bool {
+	return len(s) > 2 && s[0] == '(' && s[len(s)-1] == ')'
+}
+
+// atoiOrZero returns the integer value of s, or zero if s is not an integer.
+func atoiOrZero(s string) int {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+
+	return v
+}
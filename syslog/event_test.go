@@ -0,0 +1,156 @@
+package syslog
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func Test_parseEvent(t *testing.T) {
+	var tests = []struct {
+		desc string
+		m    *Message
+		e    func(m *Message) Event
+	}{
+		{
+			desc: "other program",
+			m:    &Message{App: "sshd", Text: "DHCPACK on 192.168.1.10"},
+		},
+		{
+			desc: "kernel not firewall",
+			m:    &Message{App: "kernel", Text: "eth0: link up"},
+		},
+		{
+			desc: "firewall bad action",
+			m:    &Message{App: "kernel", Text: "[WAN_IN-10-X]IN=eth0 OUT="},
+		},
+		{
+			desc: "firewall no rule",
+			m:    &Message{App: "kernel", Text: "[WAN_IN]IN=eth0 OUT="},
+		},
+		{
+			desc: "dhcpd not DHCP message",
+			m:    &Message{App: "dhcpd", Text: "Wrote 3 leases to leases file."},
+		},
+		{
+			desc: "firewall drop",
+			m: &Message{
+				App:  "kernel",
+				Text: "[ 1234.567890] [WAN-IN-default-D]IN=eth0 OUT= MAC=de:ad:be:ef:de:ad:00:11:22:33:44:55:08:00 SRC=192.0.2.1 DST=198.51.100.1 LEN=60 TOS=0x00 PREC=0x00 TTL=52 ID=0 DF PROTO=TCP SPT=443 DPT=51000 WINDOW=29200 RES=0x00 SYN URGP=0",
+			},
+			e: func(m *Message) Event {
+				return &FirewallEvent{
+					Message:         m,
+					Ruleset:         "WAN-IN",
+					Rule:            "default",
+					Action:          FirewallDrop,
+					InputInterface:  "eth0",
+					Source:          net.ParseIP("192.0.2.1"),
+					Destination:     net.ParseIP("198.51.100.1"),
+					SourcePort:      443,
+					DestinationPort: 51000,
+					Protocol:        "TCP",
+					Length:          60,
+				}
+			},
+		},
+		{
+			desc: "firewall accept ICMP",
+			m: &Message{
+				App:  "kernel",
+				Text: "[LAN_OUT-20-A]IN=eth0 OUT=eth1 SRC=192.0.2.1 DST=192.168.1.10 LEN=84 PROTO=ICMP TYPE=3 CODE=3 [SRC=192.168.1.10 DST=192.0.2.1 LEN=56 PROTO=UDP SPT=53 DPT=5353 LEN=36 ]",
+			},
+			e: func(m *Message) Event {
+				return &FirewallEvent{
+					Message:         m,
+					Ruleset:         "LAN_OUT",
+					Rule:            "20",
+					Action:          FirewallAccept,
+					InputInterface:  "eth0",
+					OutputInterface: "eth1",
+					Source:          net.ParseIP("192.0.2.1"),
+					Destination:     net.ParseIP("192.168.1.10"),
+					Protocol:        "ICMP",
+					Length:          84,
+				}
+			},
+		},
+		{
+			desc: "DHCPDISCOVER",
+			m: &Message{
+				App:  "dhcpd",
+				Text: "DHCPDISCOVER from de:ad:be:ef:de:ad via eth1",
+			},
+			e: func(m *Message) Event {
+				return &DHCPEvent{
+					Message:   m,
+					Type:      "DHCPDISCOVER",
+					MAC:       net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+					Interface: "eth1",
+				}
+			},
+		},
+		{
+			desc: "DHCPREQUEST",
+			m: &Message{
+				App:  "dhcpd",
+				Text: "DHCPREQUEST for 192.168.1.10 (192.168.1.1) from de:ad:be:ef:de:ad (laptop) via eth1",
+			},
+			e: func(m *Message) Event {
+				return &DHCPEvent{
+					Message:   m,
+					Type:      "DHCPREQUEST",
+					IP:        net.ParseIP("192.168.1.10"),
+					MAC:       net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+					Hostname:  "laptop",
+					Interface: "eth1",
+				}
+			},
+		},
+		{
+			desc: "DHCPACK",
+			m: &Message{
+				App:  "dhcpd",
+				Text: "DHCPACK on 192.168.1.10 to de:ad:be:ef:de:ad (laptop) via eth1",
+			},
+			e: func(m *Message) Event {
+				return &DHCPEvent{
+					Message:   m,
+					Type:      "DHCPACK",
+					IP:        net.ParseIP("192.168.1.10"),
+					MAC:       net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+					Hostname:  "laptop",
+					Interface: "eth1",
+				}
+			},
+		},
+		{
+			desc: "DHCPINFORM",
+			m: &Message{
+				App:  "dhcpd",
+				Text: "DHCPINFORM from 192.168.1.10 via eth1",
+			},
+			e: func(m *Message) Event {
+				return &DHCPEvent{
+					Message:   m,
+					Type:      "DHCPINFORM",
+					IP:        net.ParseIP("192.168.1.10"),
+					Interface: "eth1",
+				}
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var want Event = tt.m
+		if tt.e != nil {
+			want = tt.e(tt.m)
+		}
+
+		if got := parseEvent(tt.m); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected Event:\n- want: %#v\n-  got: %#v", want, got)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build gofuzz
+// +build gofuzz
+
+package syslog
+
+import "time"
+
+// Fuzz is a fuzz target for use with github.com/dvyukov/go-fuzz, which
+// tests parsing of syslog messages and the events they contain.
+func Fuzz(data []byte) int {
+	m, err := parseMessage(data, time.Now())
+	if err != nil {
+		return 0
+	}
+
+	if _, ok := parseEvent(m).(*Message); ok {
+		return 0
+	}
+
+	return 1
+}
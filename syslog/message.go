@@ -0,0 +1,227 @@
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxPriority is the maximum valid PRI value: facility 23, severity 7.
+	maxPriority = 191
+
+	// nilValue indicates an absent RFC 5424 header field.
+	nilValue = "-"
+
+	// rfc3164Time is the timestamp format used by RFC 3164 messages, which
+	// do not specify a year or time zone.
+	rfc3164Time = "Jan _2 15:04:05"
+)
+
+var (
+	// errInvalidMessage is returned when a syslog message is malformed.
+	errInvalidMessage = errors.New("invalid syslog message")
+
+	// bom is the UTF-8 byte order mark which may precede an RFC 5424
+	// message.
+	bom = []byte{0xef, 0xbb, 0xbf}
+)
+
+// A Message is a syslog message sent by a device.
+type Message struct {
+	// Sender is the address of the device which sent the message.
+	Sender net.IP
+
+	// Facility and Severity are decoded from the message's priority value.
+	// Lower severity values indicate more severe messages.
+	Facility int
+	Severity int
+
+	// Time is the time the message was generated.  RFC 3164 messages do
+	// not specify a year or time zone, so their time is interpreted in the
+	// receiver's local time zone within the past year.
+	Time time.Time
+
+	// Hostname is the hostname of the device which generated the message.
+	Hostname string
+
+	// App is the name of the program which generated the message, such as
+	// "kernel" or "dhcpd".
+	App string
+
+	// ProcessID is the process ID of the program, if available.
+	ProcessID string
+
+	// MessageID is the RFC 5424 message ID, if available.
+	MessageID string
+
+	// Text is the free-form text of the message.
+	Text string
+}
+
+// parseMessage parses an RFC 3164 or RFC 5424 syslog message from b.  now is
+// used to infer the year and time zone of RFC 3164 timestamps.
+func parseMessage(b []byte, now time.Time) (*Message, error) {
+	if len(b) < 3 || b[0] != '<' {
+		return nil, errInvalidMessage
+	}
+
+	end := bytes.IndexByte(b, '>')
+	if end < 2 || end > 4 {
+		return nil, errInvalidMessage
+	}
+
+	pri, err := strconv.Atoi(string(b[1:end]))
+	if err != nil || pri < 0 || pri > maxPriority {
+		return nil, errInvalidMessage
+	}
+
+	m := &Message{
+		Facility: pri / 8,
+		Severity: pri % 8,
+	}
+
+	b = bytes.TrimRight(b[end+1:], "\r\n\x00")
+
+	// RFC 5424 messages carry a version immediately after the priority
+	if bytes.HasPrefix(b, []byte("1 ")) {
+		if err := m.parse5424(b[2:]); err != nil {
+			return nil, err
+		}
+
+		return m, nil
+	}
+
+	m.parse3164(b, now)
+	return m, nil
+}
+
+// parse5424 parses the header and message of an RFC 5424 message.
+func (m *Message) parse5424(b []byte) error {
+	// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID
+	fields := make([]string, 5)
+	for i := range fields {
+		var f []byte
+		f, b = nextField(b)
+		if len(f) == 0 {
+			return errInvalidMessage
+		}
+
+		if s := string(f); s != nilValue {
+			fields[i] = s
+		}
+	}
+
+	if fields[0] != "" {
+		t, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return errInvalidMessage
+		}
+
+		m.Time = t
+	}
+
+	m.Hostname = fields[1]
+	m.App = fields[2]
+	m.ProcessID = fields[3]
+	m.MessageID = fields[4]
+
+	rest, err := skipStructuredData(b)
+	if err != nil {
+		return err
+	}
+
+	rest = bytes.TrimPrefix(rest, []byte(" "))
+	m.Text = string(bytes.TrimPrefix(rest, bom))
+	return nil
+}
+
+// skipStructuredData skips the RFC 5424 structured data at the beginning
+// of b, returning the remainder of the message.
+func skipStructuredData(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, errInvalidMessage
+	}
+
+	if b[0] == '-' {
+		return b[1:], nil
+	}
+
+	for len(b) > 0 && b[0] == '[' {
+		i := 1
+		for ; i < len(b); i++ {
+			// Parameter values may contain escaped closing brackets
+			if b[i] == '\\' {
+				i++
+				continue
+			}
+			if b[i] == ']' {
+				break
+			}
+		}
+		if i >= len(b) {
+			return nil, errInvalidMessage
+		}
+
+		b = b[i+1:]
+	}
+
+	return b, nil
+}
+
+// parse3164 parses the header and message of an RFC 3164 message.  Messages
+// which do not conform to RFC 3164 are kept in their entirety as text.
+func (m *Message) parse3164(b []byte, now time.Time) {
+	m.Text = string(b)
+
+	if len(b) < len(rfc3164Time) {
+		return
+	}
+
+	t, err := time.ParseInLocation(rfc3164Time, string(b[:len(rfc3164Time)]), now.Location())
+	if err != nil {
+		return
+	}
+
+	// Assume the message was generated within the past year, allowing a
+	// small margin for clock skew between the device and receiver
+	year := now.Year()
+	if time.Date(year, t.Month(), t.Day(), 0, 0, 0, 0, now.Location()).After(now.Add(24 * time.Hour)) {
+		year--
+	}
+	t = time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+	m.Time = t
+
+	host, rest := nextField(bytes.TrimPrefix(b[len(rfc3164Time):], []byte(" ")))
+	m.Hostname = string(host)
+	m.Text = string(rest)
+
+	// The tag is terminated by a colon, and optionally contains a process
+	// ID in brackets, such as "dhcpd[1234]:"
+	tag, text := nextField(rest)
+	if len(tag) < 2 || tag[len(tag)-1] != ':' {
+		return
+	}
+	tag = tag[:len(tag)-1]
+
+	if i := bytes.IndexByte(tag, '['); i > 0 && tag[len(tag)-1] == ']' {
+		m.ProcessID = string(tag[i+1 : len(tag)-1])
+		tag = tag[:i]
+	}
+
+	m.App = string(tag)
+	m.Text = string(text)
+}
+
+// nextField returns the space-delimited field at the beginning of b, and
+// the remainder of b following the space.
+func nextField(b []byte) (field []byte, rest []byte) {
+	i := bytes.IndexByte(b, ' ')
+	if i == -1 {
+		return b, nil
+	}
+
+	return b[:i], b[i+1:]
+}
@@ -0,0 +1,136 @@
+package syslog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parseMessage(t *testing.T) {
+	now := time.Date(2017, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		desc string
+		b    string
+		m    *Message
+		err  error
+	}{
+		{
+			desc: "empty",
+			err:  errInvalidMessage,
+		},
+		{
+			desc: "no priority",
+			b:    "hello world",
+			err:  errInvalidMessage,
+		},
+		{
+			desc: "unterminated priority",
+			b:    "<13 hello",
+			err:  errInvalidMessage,
+		},
+		{
+			desc: "bad priority",
+			b:    "<192>hello",
+			err:  errInvalidMessage,
+		},
+		{
+			desc: "RFC 5424 short header",
+			b:    "<13>1 2017-01-15T12:00:00Z gw",
+			err:  errInvalidMessage,
+		},
+		{
+			desc: "RFC 5424 bad timestamp",
+			b:    "<13>1 yesterday gw app - - - hello",
+			err:  errInvalidMessage,
+		},
+		{
+			desc: "RFC 5424 unterminated structured data",
+			b:    "<13>1 - gw app - - [id a=\"b\"",
+			err:  errInvalidMessage,
+		},
+		{
+			desc: "RFC 3164 no header",
+			b:    "<13>hello world",
+			m: &Message{
+				Facility: 1,
+				Severity: 5,
+				Text:     "hello world",
+			},
+		},
+		{
+			desc: "RFC 3164 no tag",
+			b:    "<13>Jan 15 11:59:00 gw hello world",
+			m: &Message{
+				Facility: 1,
+				Severity: 5,
+				Time:     time.Date(2017, time.January, 15, 11, 59, 0, 0, time.UTC),
+				Hostname: "gw",
+				Text:     "hello world",
+			},
+		},
+		{
+			desc: "RFC 3164 previous year",
+			b:    "<30>Dec 31 23:59:59 gw dhcpd: DHCPACK\n",
+			m: &Message{
+				Facility: 3,
+				Severity: 6,
+				Time:     time.Date(2016, time.December, 31, 23, 59, 59, 0, time.UTC),
+				Hostname: "gw",
+				App:      "dhcpd",
+				Text:     "DHCPACK",
+			},
+		},
+		{
+			desc: "RFC 3164 process ID",
+			b:    "<4>Jan  5 01:02:03 gw kernel[123]: [WAN_IN-default-D]IN=eth0",
+			m: &Message{
+				Facility:  0,
+				Severity:  4,
+				Time:      time.Date(2017, time.January, 5, 1, 2, 3, 0, time.UTC),
+				Hostname:  "gw",
+				App:       "kernel",
+				ProcessID: "123",
+				Text:      "[WAN_IN-default-D]IN=eth0",
+			},
+		},
+		{
+			desc: "RFC 5424 nil values",
+			b:    "<13>1 - - - - - -",
+			m: &Message{
+				Facility: 1,
+				Severity: 5,
+			},
+		},
+		{
+			desc: "RFC 5424 OK",
+			b:    "<165>1 2017-01-15T12:00:00.5Z gw app 123 ID47 [a b=\"c\\]\"][d] \xef\xbb\xbfhello world",
+			m: &Message{
+				Facility:  20,
+				Severity:  5,
+				Time:      time.Date(2017, time.January, 15, 12, 0, 0, 500e6, time.UTC),
+				Hostname:  "gw",
+				App:       "app",
+				ProcessID: "123",
+				MessageID: "ID47",
+				Text:      "hello world",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		m, err := parseMessage([]byte(tt.b), now)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.m, m; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected Message:\n- want: %#v\n-  got: %#v", want, got)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package syslog implements a receiver for RFC 3164 and RFC 5424 syslog
+// messages sent by EdgeMAX devices.
+//
+// Messages produced by EdgeOS firewall rules with logging enabled and by
+// the EdgeOS DHCP server are parsed into FirewallEvent and DHCPEvent
+// values.  A device can be configured to send its logs to a receiver using
+// ConfigOps and edgemax.Client.SetConfig.
+package syslog
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// DefaultPort is the UDP port used by syslog.
+const DefaultPort = 514
+
+// ConfigOps returns the configuration operations which configure a device
+// to send its logs to the syslog receiver at host, such as "192.0.2.10" or
+// "192.0.2.10:5514".  All messages with informational severity or higher
+// are sent, which includes firewall and DHCP server messages.
+func ConfigOps(host string) []edgemax.ConfigOp {
+	return []edgemax.ConfigOp{{
+		Action: edgemax.ConfigSet,
+		Path:   []string{"system", "syslog", "host", host, "facility", "all", "level"},
+		Value:  "info",
+	}}
+}
+
+// Receive listens for syslog messages on the UDP address addr, such as
+// ":514", and sends the events they contain on eventC.  The done closure
+// must be invoked to stop receiving events and clean up resources from
+// Receive; eventC is closed once done returns.
+//
+// Malformed messages are ignored.
+func Receive(addr string) (eventC <-chan Event, done func() error, err error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eventC, done = receive(conn, time.Now)
+	return eventC, done, nil
+}
+
+// receive receives events from messages received on conn.  now is used to
+// infer the year and time zone of RFC 3164 timestamps.
+func receive(conn net.PacketConn, now func() time.Time) (<-chan Event, func() error) {
+	var (
+		eventC = make(chan Event)
+		doneC  = make(chan struct{})
+		wg     sync.WaitGroup
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		b := make([]byte, 65536)
+		for {
+			n, addr, err := conn.ReadFrom(b)
+			if err != nil {
+				// Closing conn stops receiving; any other error is
+				// assumed to be transient
+				select {
+				case <-doneC:
+					return
+				default:
+					continue
+				}
+			}
+
+			m, err := parseMessage(b[:n], now())
+			if err != nil {
+				continue
+			}
+
+			if uaddr, ok := addr.(*net.UDPAddr); ok {
+				m.Sender = uaddr.IP
+			}
+
+			select {
+			case eventC <- parseEvent(m):
+			case <-doneC:
+				return
+			}
+		}
+	}()
+
+	done := func() error {
+		close(doneC)
+		err := conn.Close()
+		wg.Wait()
+
+		close(eventC)
+		return err
+	}
+
+	return eventC, done
+}
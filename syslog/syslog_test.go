@@ -0,0 +1,72 @@
+package syslog
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestConfigOps(t *testing.T) {
+	want := []edgemax.ConfigOp{{
+		Action: edgemax.ConfigSet,
+		Path:   []string{"system", "syslog", "host", "192.0.2.10:5514", "facility", "all", "level"},
+		Value:  "info",
+	}}
+
+	if got := ConfigOps("192.0.2.10:5514"); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected ConfigOps:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func Test_receive(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	now := func() time.Time {
+		return time.Date(2017, time.January, 15, 12, 0, 0, 0, time.UTC)
+	}
+	eventC, done := receive(conn, now)
+
+	dev, err := net.Dial("udp4", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer dev.Close()
+
+	for _, s := range []string{
+		// Malformed messages are ignored
+		"hello",
+		"<30>Jan 15 11:59:00 gw dhcpd: DHCPDISCOVER from de:ad:be:ef:de:ad via eth1",
+		"<13>Jan 15 11:59:01 gw root: hello",
+	} {
+		if _, err := dev.Write([]byte(s)); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+	}
+
+	e, ok := (<-eventC).(*DHCPEvent)
+	if !ok {
+		t.Fatalf("unexpected event type: %T", e)
+	}
+
+	if want, got := "DHCPDISCOVER", e.Type; want != got {
+		t.Fatalf("unexpected DHCP message type:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := net.IPv4(127, 0, 0, 1), e.Message.Sender; !want.Equal(got) {
+		t.Fatalf("unexpected sender:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// The second event is never read, but done must not block
+	if err := done(); err != nil {
+		t.Fatalf("failed to stop receiving: %v", err)
+	}
+
+	if _, ok := <-eventC; ok {
+		t.Fatal("event channel should be closed")
+	}
+}
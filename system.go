@@ -0,0 +1,234 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// SystemInfo contains information about the hardware and running firmware of
+// an EdgeMAX device.
+type SystemInfo struct {
+	// Model is the model of the device, such as "ER-X".
+	Model string
+
+	// Version is the full version string of the running firmware, such as
+	// "EdgeRouter.ER-e50.v1.9.7+hotfix.4.5024279.171006.0255".  The
+	// version of the running system image, as reported by SystemImages,
+	// is a suffix of Version.
+	Version string
+}
+
+// UnmarshalJSON unmarshals JSON into a SystemInfo.
+func (si *SystemInfo) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Model   string `json:"model"`
+		Version string `json:"sw_ver"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*si = SystemInfo{
+		Model:   v.Model,
+		Version: v.Version,
+	}
+
+	return nil
+}
+
+// SystemImages contains information about the system images installed on
+// an EdgeMAX device, and the space available to install new images.
+type SystemImages struct {
+	// Current is the version of the currently running system image.
+	Current string
+
+	// Previous is the version of the alternate system image retained on
+	// the device, if one is installed.
+	Previous string
+
+	// Default is the version of the image which will be used on the next
+	// boot of the device.
+	Default string
+
+	// Free is the number of bytes available on the image partition.
+	Free int
+}
+
+// UnmarshalJSON unmarshals JSON into a SystemImages.
+func (si *SystemImages) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Current  string `json:"current"`
+		Previous string `json:"previous"`
+		Default  string `json:"default"`
+		Free     string `json:"free"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	free, err := atoiOrZero(v.Free)
+	if err != nil {
+		return err
+	}
+
+	*si = SystemImages{
+		Current:  v.Current,
+		Previous: v.Previous,
+		Default:  v.Default,
+		Free:     free,
+	}
+
+	return nil
+}
+
+// MemoryInfo contains a detailed breakdown of memory utilization for an
+// EdgeMAX device.  All values are in bytes.
+type MemoryInfo struct {
+	Total   int
+	Free    int
+	Buffers int
+	Cached  int
+	Zones   []*MemoryZone
+}
+
+// A MemoryZone contains memory information for an individual kernel memory
+// zone, such as "DMA" or "Normal".  All values are in bytes.
+type MemoryZone struct {
+	Name string
+	Free int
+	Min  int
+	Low  int
+	High int
+}
+
+// UnmarshalJSON unmarshals JSON into a MemoryInfo.
+func (mi *MemoryInfo) UnmarshalJSON(b []byte) error {
+	var v struct {
+		Total   string `json:"total"`
+		Free    string `json:"free"`
+		Buffers string `json:"buffers"`
+		Cached  string `json:"cached"`
+		Zones   []struct {
+			Name string `json:"name"`
+			Free string `json:"free"`
+			Min  string `json:"min"`
+			Low  string `json:"low"`
+			High string `json:"high"`
+		} `json:"zones"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	ints, err := atoisOrZero(v.Total, v.Free, v.Buffers, v.Cached)
+	if err != nil {
+		return err
+	}
+
+	zones := make([]*MemoryZone, 0, len(v.Zones))
+	for _, z := range v.Zones {
+		zints, err := atoisOrZero(z.Free, z.Min, z.Low, z.High)
+		if err != nil {
+			return err
+		}
+
+		zones = append(zones, &MemoryZone{
+			Name: z.Name,
+			Free: zints[0],
+			Min:  zints[1],
+			Low:  zints[2],
+			High: zints[3],
+		})
+	}
+
+	*mi = MemoryInfo{
+		Total:   ints[0],
+		Free:    ints[1],
+		Buffers: ints[2],
+		Cached:  ints[3],
+		Zones:   zones,
+	}
+
+	return nil
+}
+
+// CPUCores is a slice of CPUCore values, which contains per-core CPU
+// utilization for an EdgeMAX device.
+type CPUCores []*CPUCore
+
+// A CPUCore contains utilization information for an individual CPU core.
+// All values are percentages.
+type CPUCore struct {
+	ID      int
+	Usage   int
+	IRQ     int
+	SoftIRQ int
+}
+
+// UnmarshalJSON unmarshals JSON into a CPUCores.
+func (cc *CPUCores) UnmarshalJSON(b []byte) error {
+	var v []struct {
+		ID      string `json:"id"`
+		Usage   string `json:"usage"`
+		IRQ     string `json:"irq"`
+		SoftIRQ string `json:"softirq"`
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	cores := make(CPUCores, 0, len(v))
+	for _, c := range v {
+		ints, err := atoisOrZero(c.ID, c.Usage, c.IRQ, c.SoftIRQ)
+		if err != nil {
+			return err
+		}
+
+		cores = append(cores, &CPUCore{
+			ID:      ints[0],
+			Usage:   ints[1],
+			IRQ:     ints[2],
+			SoftIRQ: ints[3],
+		})
+	}
+
+	sort.Sort(byCPUCoreID(cores))
+	*cc = cores
+	return nil
+}
+
+// byCPUCoreID is used to sort CPUCores by core ID.
+type byCPUCoreID []*CPUCore
+
+func (b byCPUCoreID) Len() int               { return len(b) }
+func (b byCPUCoreID) Less(i int, j int) bool { return b[i].ID < b[j].ID }
+func (b byCPUCoreID) Swap(i int, j int)      { b[i], b[j] = b[j], b[i] }
+
+// atoiOrZero parses an integer from s, returning zero if s is empty.
+func atoiOrZero(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(s)
+}
+
+// atoisOrZero parses integers from each string in ss using atoiOrZero.
+func atoisOrZero(ss ...string) ([]int, error) {
+	ints := make([]int, 0, len(ss))
+	for _, s := range ss {
+		v, err := atoiOrZero(s)
+		if err != nil {
+			return nil, err
+		}
+
+		ints = append(ints, v)
+	}
+
+	return ints, nil
+}
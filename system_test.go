@@ -0,0 +1,190 @@
+package edgemax
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestSystemImagesUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		si      *SystemImages
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid free space integer",
+			b:       []byte(`{"free":"foo"}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK no previous image",
+			b:    []byte(`{"current":"v1.9.0","default":"v1.9.0","free":"1024"}`),
+			si: &SystemImages{
+				Current: "v1.9.0",
+				Default: "v1.9.0",
+				Free:    1024,
+			},
+		},
+		{
+			desc: "OK",
+			b:    []byte(`{"current":"v1.9.0","previous":"v1.8.5","default":"v1.9.0","free":"2048"}`),
+			si: &SystemImages{
+				Current:  "v1.9.0",
+				Previous: "v1.8.5",
+				Default:  "v1.9.0",
+				Free:     2048,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		si := new(SystemImages)
+		err := si.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.si, si; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected SystemImages:\n- want: %v\n-  got: %v", want, got)
+		}
+	}
+}
+
+func TestMemoryInfoUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		mi      *MemoryInfo
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid total integer",
+			b:       []byte(`{"total":"foo"}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc:    "invalid zone free integer",
+			b:       []byte(`{"zones":[{"name":"Normal","free":"foo"}]}`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK",
+			b:    []byte(`{"total":"100","free":"20","buffers":"10","cached":"30","zones":[{"name":"DMA","free":"1","min":"2","low":"3","high":"4"},{"name":"Normal","free":"5","min":"6","low":"7","high":"8"}]}`),
+			mi: &MemoryInfo{
+				Total:   100,
+				Free:    20,
+				Buffers: 10,
+				Cached:  30,
+				Zones: []*MemoryZone{
+					{
+						Name: "DMA",
+						Free: 1,
+						Min:  2,
+						Low:  3,
+						High: 4,
+					},
+					{
+						Name: "Normal",
+						Free: 5,
+						Min:  6,
+						Low:  7,
+						High: 8,
+					},
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		mi := new(MemoryInfo)
+		err := mi.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.mi, mi; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected MemoryInfo:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
+
+func TestCPUCoresUnmarshalJSON(t *testing.T) {
+	var tests = []struct {
+		desc    string
+		b       []byte
+		errType reflect.Type
+		cc      CPUCores
+	}{
+		{
+			desc:    "invalid JSON",
+			b:       []byte(`foo`),
+			errType: reflect.TypeOf(&json.SyntaxError{}),
+		},
+		{
+			desc:    "invalid usage integer",
+			b:       []byte(`[{"id":"0","usage":"foo"}]`),
+			errType: reflect.TypeOf(&strconv.NumError{}),
+		},
+		{
+			desc: "OK two cores",
+			b:    []byte(`[{"id":"1","usage":"90","irq":"2","softirq":"40"},{"id":"0","usage":"10","irq":"1","softirq":"3"}]`),
+			cc: CPUCores{
+				{
+					ID:      0,
+					Usage:   10,
+					IRQ:     1,
+					SoftIRQ: 3,
+				},
+				{
+					ID:      1,
+					Usage:   90,
+					IRQ:     2,
+					SoftIRQ: 40,
+				},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Logf("[%02d] test %q", i, tt.desc)
+
+		var cc CPUCores
+		err := cc.UnmarshalJSON(tt.b)
+
+		if want, got := tt.errType, reflect.TypeOf(err); !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected error type:\n- want: %v\n-  got: %v", want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.cc, cc; !reflect.DeepEqual(want, got) {
+			t.Fatalf("unexpected CPUCores:\n- want: %+v\n-  got: %+v", want, got)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package webhook implements a forwarder which sends significant events from
+// EdgeMAX devices to a webhook URL as JSON, for use with ChatOps and incident
+// management tools.
+//
+// Each event is sent using an HTTP POST request.  If a secret is configured,
+// requests are signed using HMAC-SHA256 of the request body, and the
+// signature is sent in the SignatureHeader header as "sha256=" followed by
+// the hexadecimal signature.  Receivers should compute the same signature
+// and compare the two using a constant time comparison.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+// HTTP headers sent with each event.
+const (
+	EventHeader     = "X-EdgeMAX-Event"
+	SignatureHeader = "X-EdgeMAX-Signature"
+)
+
+// An EventType is the type of an Event.
+type EventType string
+
+// Possible EventType values.
+const (
+	EventInterfaceUp       EventType = "interface_up"
+	EventInterfaceDown     EventType = "interface_down"
+	EventConfigChanged     EventType = "config_changed"
+	EventReconnected       EventType = "reconnected"
+	EventFirmwareAvailable EventType = "firmware_available"
+)
+
+// An Event is a significant event which occurred on a device.
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Device identifies the device, as specified by Config.Device.
+	Device string `json:"device,omitempty"`
+
+	// Interface is the name of the interface for interface events.
+	Interface string `json:"interface,omitempty"`
+
+	// Firmware is the newly available firmware version for firmware
+	// events.
+	Firmware string `json:"firmware,omitempty"`
+
+	// Message is a human-readable description of the event.
+	Message string `json:"message"`
+}
+
+// Config specifies configuration for a Forwarder.
+type Config struct {
+	// Secret, if set, is used to sign each request.
+	Secret string
+
+	// Device identifies the device in each Event, such as its hostname.
+	Device string
+
+	// HTTPClient is used to send events.  If nil, a client with a 10
+	// second timeout is used.
+	HTTPClient *http.Client
+}
+
+// A Forwarder sends events to a webhook URL.
+type Forwarder struct {
+	url string
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	up       map[string]bool
+	config   *edgemax.ConfigTree
+	firmware string
+}
+
+// NewForwarder creates a Forwarder which sends events to url, using the
+// configuration in cfg.
+func NewForwarder(url string, cfg Config) *Forwarder {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	return &Forwarder{
+		url: url,
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// Run sends interface up and down events derived from the interface
+// statistics received on statC, until statC is closed or ctx is canceled.
+// Other statistic types are ignored.  Run can be used with the channel
+// returned by edgemax.Client.Stats.
+//
+// Errors sending events do not stop Run, so that a webhook outage does not
+// stop monitoring; they are reported using errFn, if it is not nil.
+func (f *Forwarder) Run(ctx context.Context, statC <-chan edgemax.Stat, errFn func(err error)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s, ok := <-statC:
+			if !ok {
+				return nil
+			}
+
+			ifis, ok := s.(edgemax.Interfaces)
+			if !ok {
+				continue
+			}
+
+			for _, e := range f.interfaceEvents(ifis) {
+				if err := f.Send(ctx, e); err != nil && errFn != nil {
+					errFn(err)
+				}
+			}
+		}
+	}
+}
+
+// interfaceEvents returns events for interfaces in ifis whose state has
+// changed since the previous call.  The first state observed for each
+// interface produces no event.
+func (f *Forwarder) interfaceEvents(ifis edgemax.Interfaces) []*Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.up == nil {
+		f.up = make(map[string]bool, len(ifis))
+	}
+
+	var events []*Event
+	for _, ifi := range ifis {
+		prev, ok := f.up[ifi.Name]
+		f.up[ifi.Name] = ifi.Up
+		if !ok || prev == ifi.Up {
+			continue
+		}
+
+		e := &Event{
+			Type:      EventInterfaceDown,
+			Interface: ifi.Name,
+			Message:   fmt.Sprintf("interface %s is down", ifi.Name),
+		}
+		if ifi.Up {
+			e.Type = EventInterfaceUp
+			e.Message = fmt.Sprintf("interface %s is up", ifi.Name)
+		}
+
+		events = append(events, e)
+	}
+
+	return events
+}
+
+// Config sends a config changed event if tree differs from the tree passed
+// to the previous call.  The first call records tree and sends no event.
+// Config is typically called periodically with the result of
+// edgemax.Client.GetConfig.
+func (f *Forwarder) Config(ctx context.Context, tree *edgemax.ConfigTree) error {
+	f.mu.Lock()
+	prev := f.config
+	f.config = tree
+	f.mu.Unlock()
+
+	if prev == nil || prev.Equal(tree) {
+		return nil
+	}
+
+	return f.Send(ctx, &Event{
+		Type:    EventConfigChanged,
+		Message: "configuration changed",
+	})
+}
+
+// Firmware sends a firmware available event if fu reports that newer
+// firmware is available.  An event is sent only once for each version.
+// Firmware is typically called periodically with the result of
+// edgemax.Client.CheckFirmwareUpdate.
+func (f *Forwarder) Firmware(ctx context.Context, fu *edgemax.FirmwareUpdate) error {
+	if !fu.Available {
+		return nil
+	}
+
+	f.mu.Lock()
+	seen := f.firmware == fu.Latest
+	f.firmware = fu.Latest
+	f.mu.Unlock()
+
+	if seen {
+		return nil
+	}
+
+	return f.Send(ctx, &Event{
+		Type:     EventFirmwareAvailable,
+		Firmware: fu.Latest,
+		Message:  fmt.Sprintf("firmware %s is available (running %s)", fu.Latest, fu.Current),
+	})
+}
+
+// Reconnected sends a reconnected event, and should be called when a
+// connection to the device is reestablished, such as when a statistics
+// stream is restarted after an error.  cause is the error which caused the
+// connection to be lost, if known.
+func (f *Forwarder) Reconnected(ctx context.Context, cause error) error {
+	msg := "reconnected to device"
+	if cause != nil {
+		msg = fmt.Sprintf("reconnected to device after error: %v", cause)
+	}
+
+	return f.Send(ctx, &Event{
+		Type:    EventReconnected,
+		Message: msg,
+	})
+}
+
+// Send sends e to the webhook URL.  If e's time or device are unset, they
+// are set before e is sent.
+func (f *Forwarder) Send(ctx context.Context, e *Event) error {
+	if e.Time.IsZero() {
+		e.Time = f.now().UTC()
+	}
+	if e.Device == "" {
+		e.Device = f.cfg.Device
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, f.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(e.Type))
+	if f.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(f.cfg.Secret, b))
+	}
+
+	res, err := f.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook request failed: %s", res.Status)
+	}
+
+	return nil
+}
+
+// Sign computes the signature of a request body using secret, in the format
+// sent in the SignatureHeader header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,187 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/edgemax"
+)
+
+func TestForwarderSend(t *testing.T) {
+	const secret = "secret"
+
+	f, eventC, done := testForwarder(t, Config{
+		Secret: secret,
+		Device: "gw",
+	}, func(w http.ResponseWriter, r *http.Request, body []byte) {
+		if want, got := Sign(secret, body), r.Header.Get(SignatureHeader); want != got {
+			t.Fatalf("unexpected signature:\n- want: %v\n-  got: %v", want, got)
+		}
+		if want, got := string(EventReconnected), r.Header.Get(EventHeader); want != got {
+			t.Fatalf("unexpected event header:\n- want: %v\n-  got: %v", want, got)
+		}
+	})
+	defer done()
+
+	if err := f.Reconnected(context.Background(), errors.New("EOF")); err != nil {
+		t.Fatalf("failed to send event: %v", err)
+	}
+
+	want := &Event{
+		Type:    EventReconnected,
+		Time:    time.Unix(1, 0).UTC(),
+		Device:  "gw",
+		Message: "reconnected to device after error: EOF",
+	}
+
+	if got := <-eventC; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Event:\n- want: %#v\n-  got: %#v", want, got)
+	}
+}
+
+func TestForwarderSendError(t *testing.T) {
+	f, _, done := testForwarder(t, Config{}, func(w http.ResponseWriter, _ *http.Request, _ []byte) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	defer done()
+
+	err := f.Send(context.Background(), &Event{Type: EventReconnected})
+	if want, got := "webhook request failed: 500 Internal Server Error", errString(err); want != got {
+		t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestForwarderRun(t *testing.T) {
+	f, eventC, done := testForwarder(t, Config{}, nil)
+	defer done()
+
+	statC := make(chan edgemax.Stat, 4)
+	// The initial state produces no events, and other statistics are
+	// ignored
+	statC <- edgemax.Interfaces{{Name: "eth0", Up: true}, {Name: "eth1"}}
+	statC <- &edgemax.SystemStats{}
+	statC <- edgemax.Interfaces{{Name: "eth0"}, {Name: "eth1", Up: true}}
+	statC <- edgemax.Interfaces{{Name: "eth0"}, {Name: "eth1", Up: true}}
+	close(statC)
+
+	if err := f.Run(context.Background(), statC, func(err error) {
+		t.Fatalf("failed to send event: %v", err)
+	}); err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	for _, want := range []struct {
+		typ   EventType
+		iface string
+	}{
+		{typ: EventInterfaceDown, iface: "eth0"},
+		{typ: EventInterfaceUp, iface: "eth1"},
+	} {
+		e := <-eventC
+		if e.Type != want.typ || e.Interface != want.iface {
+			t.Fatalf("unexpected event: %s %s", e.Type, e.Interface)
+		}
+	}
+
+	select {
+	case e := <-eventC:
+		t.Fatalf("unexpected extra event: %#v", e)
+	default:
+	}
+}
+
+func TestForwarderConfigAndFirmware(t *testing.T) {
+	f, eventC, done := testForwarder(t, Config{}, nil)
+	defer done()
+
+	ctx := context.Background()
+	tree := func(hostname string) *edgemax.ConfigTree {
+		var t edgemax.ConfigTree
+		if err := t.UnmarshalJSON([]byte(`{"system":{"host-name":"` + hostname + `"}}`)); err != nil {
+			panic(err)
+		}
+
+		return &t
+	}
+
+	fu := &edgemax.FirmwareUpdate{
+		Current:   "v1.10.0",
+		Latest:    "v1.10.1",
+		Available: true,
+	}
+
+	for _, fn := range []func() error{
+		// Only changes and new versions produce events
+		func() error { return f.Config(ctx, tree("gw")) },
+		func() error { return f.Config(ctx, tree("gw")) },
+		func() error { return f.Config(ctx, tree("router")) },
+		func() error { return f.Firmware(ctx, &edgemax.FirmwareUpdate{}) },
+		func() error { return f.Firmware(ctx, fu) },
+		func() error { return f.Firmware(ctx, fu) },
+	} {
+		if err := fn(); err != nil {
+			t.Fatalf("failed to send event: %v", err)
+		}
+	}
+
+	if want, got := EventConfigChanged, (<-eventC).Type; want != got {
+		t.Fatalf("unexpected event type:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	e := <-eventC
+	if want, got := EventFirmwareAvailable, e.Type; want != got {
+		t.Fatalf("unexpected event type:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := "v1.10.1", e.Firmware; want != got {
+		t.Fatalf("unexpected firmware:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	select {
+	case e := <-eventC:
+		t.Fatalf("unexpected extra event: %#v", e)
+	default:
+	}
+}
+
+// testForwarder creates a Forwarder which sends events to a server which
+// invokes fn, if not nil, and then sends each decoded event on eventC.
+func testForwarder(t *testing.T, cfg Config, fn func(w http.ResponseWriter, r *http.Request, body []byte)) (*Forwarder, <-chan *Event, func()) {
+	eventC := make(chan *Event, 8)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body: %v", err)
+		}
+
+		if fn != nil {
+			fn(w, r, body)
+		}
+
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Fatalf("failed to unmarshal event: %v", err)
+		}
+
+		eventC <- &e
+	}))
+
+	f := NewForwarder(s.URL, cfg)
+	f.now = func() time.Time { return time.Unix(1, 0) }
+
+	return f, eventC, func() { s.Close() }
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
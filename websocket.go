@@ -3,35 +3,230 @@ package edgemax
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"strconv"
+	"sync"
+
+	"golang.org/x/net/websocket"
 )
 
+// A wsBuffer is a scratch buffer for a single websocket message, with a
+// JSON encoder which writes to it.
+type wsBuffer struct {
+	bytes.Buffer
+	enc *json.Encoder
+}
+
+// wsBufferPool provides scratch buffers for encoding and receiving websocket
+// messages, so that many concurrent streams do not each allocate buffers
+// and encoders per message.
+var wsBufferPool = sync.Pool{
+	New: func() interface{} {
+		b := new(wsBuffer)
+		b.enc = json.NewEncoder(&b.Buffer)
+		return b
+	},
+}
+
+// maxPooledBuffer is the capacity above which a wsBuffer is not returned to
+// wsBufferPool, so that one unusually large message does not pin memory.
+const maxPooledBuffer = 1 << 20
+
+func getWSBuffer() *wsBuffer {
+	b := wsBufferPool.Get().(*wsBuffer)
+	b.Reset()
+	return b
+}
+
+func putWSBuffer(b *wsBuffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+
+	wsBufferPool.Put(b)
+}
+
+// wsMarshal encodes v as a length prefixed websocket message.  The message
+// is owned by websocket.Codec once returned, so it is allocated once with
+// its exact length; the encoder and its scratch buffer are reused.
 func wsMarshal(v interface{}) ([]byte, byte, error) {
-	b, err := json.Marshal(v)
-	if err != nil {
+	buf := getWSBuffer()
+	defer putWSBuffer(buf)
+
+	if err := buf.enc.Encode(v); err != nil {
 		return nil, 0, err
 	}
 
-	blen := []byte(strconv.Itoa(len(b)) + "\n")
-	return append(blen, b...), 0, nil
+	// Encode adds a trailing newline which json.Marshal would not
+	b := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+
+	var lbuf [20]byte
+	blen := strconv.AppendInt(lbuf[:0], int64(len(b)), 10)
+
+	out := make([]byte, 0, len(blen)+1+len(b))
+	out = append(out, blen...)
+	out = append(out, '\n')
+	return append(out, b...), 0, nil
+}
+
+// maxFrameLength is the maximum length of a stats websocket frame: a
+// message of maxMessageLength, and a length prefix of up to 10 digits.
+const maxFrameLength = maxMessageLength + 11
+
+// receiveStats receives a single stats websocket message from wsc, and
+// unmarshals it into v using unmarshalStats.
+//
+// Unlike websocket.Codec.Receive, which allocates a new buffer for every
+// message, the message is read into a pooled buffer.  The buffer is reused
+// once v is unmarshaled, so v must not retain the message's bytes;
+// json.RawMessage values are copied by json.Unmarshal.
+//
+// receiveStats does not hold the websocket.Conn's read lock, so only one
+// goroutine may receive from wsc.
+func receiveStats(wsc *websocket.Conn, v interface{}) error {
+	var (
+		frame io.Reader
+		typ   byte
+		l     int
+	)
+
+	for frame == nil {
+		fr, err := wsc.NewFrameReader()
+		if err != nil {
+			return err
+		}
+
+		// Control frames are handled by wsc, and yield no message
+		fr, err = wsc.HandleFrame(fr)
+		if err != nil {
+			return err
+		}
+		if fr != nil {
+			frame, typ, l = fr, fr.PayloadType(), fr.Len()
+		}
+	}
+
+	if l > maxFrameLength {
+		// Discard the oversized frame so the next can be received
+		if _, err := io.Copy(ioutil.Discard, frame); err != nil {
+			return err
+		}
+
+		return &decodeError{
+			err: fmt.Errorf("websocket frame length %d exceeds maximum of %d",
+				l, maxFrameLength),
+		}
+	}
+
+	buf := getWSBuffer()
+	defer putWSBuffer(buf)
+
+	if _, err := buf.ReadFrom(frame); err != nil {
+		return err
+	}
+
+	return unmarshalStats(buf.Bytes(), typ, v)
 }
 
-func wsUnmarshal(data []byte, _ byte, v interface{}) error {
+// unmarshalStats unmarshals a stats websocket message using wsUnmarshal,
+// and marks any error as a decodeError.
+func unmarshalStats(data []byte, typ byte, v interface{}) error {
+	if err := wsUnmarshal(data, typ, v); err != nil {
+		return &decodeError{err: err}
+	}
+
+	return nil
+}
+
+// A decodeError is an error decoding a single websocket message.  The
+// connection remains usable after a decodeError.
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string { return e.err.Error() }
+
+// Unwrap returns the underlying error, such as a *LengthError.
+func (e *decodeError) Unwrap() error { return e.err }
+
+// isDecodeError reports whether err is a decodeError.
+func isDecodeError(err error) bool {
+	_, ok := err.(*decodeError)
+	return ok
+}
+
+// maxMessageLength is the maximum declared length of a websocket message,
+// which guards against a corrupt prefix causing a huge allocation.
+const maxMessageLength = 16 << 20
+
+// A LengthError is returned when a stats websocket message's payload does
+// not have the length declared in its prefix, or the declared length is
+// too large.
+type LengthError struct {
+	// Declared is the length declared by the message's prefix.
+	Declared int
+
+	// Actual is the length of the message's payload.
+	Actual int
+}
+
+func (e *LengthError) Error() string {
+	if e.Declared > maxMessageLength {
+		return fmt.Sprintf("websocket message length %d exceeds maximum of %d",
+			e.Declared, maxMessageLength)
+	}
+
+	return fmt.Sprintf("websocket message has length %d, but declared length %d",
+		e.Actual, e.Declared)
+}
+
+func wsUnmarshal(data []byte, payloadType byte, v interface{}) error {
+	if payloadType == websocket.BinaryFrame {
+		return errors.New("unexpected binary websocket message")
+	}
+
+	if len(data) == 0 {
+		return errors.New("empty websocket message")
+	}
+
 	if data[0] == '{' {
 		return json.Unmarshal(data, v)
 	}
 
-	bb := bytes.SplitN(data, []byte("\n"), 2)
-	if l := len(bb); l != 2 {
-		return fmt.Errorf("incorrect number of elements in websocket message: %d", l)
+	i := bytes.IndexByte(data, '\n')
+	if i == -1 {
+		return errors.New("websocket message has no length prefix")
+	}
+
+	prefix := data[:i]
+	if len(prefix) == 0 || len(prefix) > 10 {
+		return fmt.Errorf("invalid websocket message length prefix: %q", prefix)
+	}
+	for _, c := range prefix {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("invalid websocket message length prefix: %q", prefix)
+		}
+	}
+
+	// The prefix is at most 10 digits, so it cannot overflow
+	l, _ := strconv.Atoi(string(prefix))
+
+	b := data[i+1:]
+	if l > maxMessageLength || l != len(b) {
+		return &LengthError{
+			Declared: l,
+			Actual:   len(b),
+		}
 	}
 
-	if len(bb[1]) == 0 {
+	if len(b) == 0 {
 		return nil
 	}
 
-	return json.Unmarshal(bb[1], v)
+	return json.Unmarshal(b, v)
 }
 
 type wsName struct {
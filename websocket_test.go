@@ -2,9 +2,15 @@ package edgemax
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+
+	"golang.org/x/net/websocket"
 )
 
 func Test_wsMarshal(t *testing.T) {
@@ -60,21 +66,67 @@ func Test_wsMarshal(t *testing.T) {
 
 func Test_wsUnmarshal(t *testing.T) {
 	var tests = []struct {
-		desc string
-		in   []byte
-		wsr  wsRequest
-		err  error
+		desc  string
+		in    []byte
+		pType byte
+		wsr   wsRequest
+		err   error
 	}{
 		{
-			desc: "incorrect number of newlines",
+			desc: "empty message",
+			err:  errors.New("empty websocket message"),
+		},
+		{
+			desc:  "binary message",
+			in:    []byte("2\n{}"),
+			pType: websocket.BinaryFrame,
+			err:   errors.New("unexpected binary websocket message"),
+		},
+		{
+			desc: "no length prefix",
 			in:   []byte("foo"),
-			err:  errors.New("incorrect number of elements in websocket message: 1"),
+			err:  errors.New("websocket message has no length prefix"),
+		},
+		{
+			desc: "empty length prefix",
+			in:   []byte("\n{}"),
+			err:  errors.New(`invalid websocket message length prefix: ""`),
+		},
+		{
+			desc: "long length prefix",
+			in:   []byte("12345678901\n{}"),
+			err:  errors.New(`invalid websocket message length prefix: "12345678901"`),
 		},
 		{
 			desc: "no JSON object present",
-			in:   []byte("3\n"),
+			in:   []byte("0\n"),
 			wsr:  wsRequest{},
 		},
+		{
+			desc: "non-numeric length prefix",
+			in:   []byte("foo\n{}"),
+			err:  errors.New(`invalid websocket message length prefix: "foo"`),
+		},
+		{
+			desc: "truncated payload",
+			in:   []byte("3\n"),
+			err:  &LengthError{Declared: 3},
+		},
+		{
+			desc: "oversized payload",
+			in:   []byte("1\n{}"),
+			err:  &LengthError{Declared: 1, Actual: 2},
+		},
+		{
+			desc: "negative length",
+			in:   []byte("-2\n{}"),
+			err:  errors.New(`invalid websocket message length prefix: "-2"`),
+		},
+		{
+			desc: "length too large",
+			in:   []byte("16777217\n{}"),
+			err:  &LengthError{Declared: 16777217, Actual: 2},
+		},
 		{
 			desc: "empty request with no length",
 			in:   []byte(`{"SUBSCRIBE":null,"UNSUBSCRIBE":null,"SESSION_ID":""}`),
@@ -112,7 +164,7 @@ func Test_wsUnmarshal(t *testing.T) {
 		t.Logf("[%02d] test %q", i, tt.desc)
 
 		var wsr wsRequest
-		err := wsUnmarshal(tt.in, 0, &wsr)
+		err := wsUnmarshal(tt.in, tt.pType, &wsr)
 		if want, got := errStr(tt.err), errStr(err); want != got {
 			t.Fatalf("unexpected error:\n- want: %v\n-  got: %v", want, got)
 		}
@@ -126,6 +178,47 @@ func Test_wsUnmarshal(t *testing.T) {
 	}
 }
 
+func Test_wsUnmarshalTruncated(t *testing.T) {
+	msg := `{"system-stats":{"cpu":"10","uptime":"60","mem":"20"}}`
+	in := []byte(strconv.Itoa(len(msg)) + "\n" + msg)
+
+	// No prefix of a valid message should cause a panic, and only the
+	// complete message is valid
+	for i := range in {
+		m := make(map[StatType]json.RawMessage)
+		if err := wsUnmarshal(in[:i], websocket.TextFrame, &m); err == nil {
+			t.Fatalf("expected an error for truncated message: %q", in[:i])
+		}
+	}
+
+	m := make(map[StatType]json.RawMessage)
+	if err := wsUnmarshal(in, websocket.TextFrame, &m); err != nil {
+		t.Fatalf("failed to unmarshal complete message: %v", err)
+	}
+}
+
+func Test_unmarshalStatsLengthError(t *testing.T) {
+	var m map[StatType]json.RawMessage
+	err := unmarshalStats([]byte("3\n{}"), websocket.TextFrame, &m)
+	if !isDecodeError(err) {
+		t.Fatalf("expected a decode error, but got: %v", err)
+	}
+
+	uerr, ok := err.(interface{ Unwrap() error })
+	if !ok {
+		t.Fatalf("decode error does not implement Unwrap: %T", err)
+	}
+
+	lerr, ok := uerr.Unwrap().(*LengthError)
+	if !ok {
+		t.Fatalf("expected *LengthError, but got: %T", uerr.Unwrap())
+	}
+
+	if want, got := (LengthError{Declared: 3, Actual: 2}), *lerr; want != got {
+		t.Fatalf("unexpected length error:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
 func errStr(err error) string {
 	if err == nil {
 		return "<nil>"
@@ -133,3 +226,80 @@ func errStr(err error) string {
 
 	return err.Error()
 }
+
+func Benchmark_wsMarshal(b *testing.B) {
+	wsr := wsRequest{
+		Subscribe: []wsName{
+			{Name: StatTypeDPIStats},
+			{Name: StatTypeInterfaces},
+			{Name: StatTypeSystemStats},
+		},
+		SessionID: "deadbeefdeadbeefdeadbeefdeadbeef",
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, _, err := wsMarshal(wsr); err != nil {
+			b.Fatalf("failed to marshal: %v", err)
+		}
+	}
+}
+
+func Benchmark_wsUnmarshal(b *testing.B) {
+	msg := `{"system-stats":{"cpu":"10","uptime":"60","mem":"20"}}`
+	in := []byte(strconv.Itoa(len(msg)) + "\n" + msg)
+
+	b.SetBytes(int64(len(in)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		m := make(map[StatType]json.RawMessage)
+		if err := wsUnmarshal(in, 0, &m); err != nil {
+			b.Fatalf("failed to unmarshal: %v", err)
+		}
+	}
+}
+
+func Test_receiveStatsAllocations(t *testing.T) {
+	const runs = 100
+
+	// A large message makes the cost of a buffer per message apparent
+	msg := fmt.Sprintf(`{"system-stats":{"cpu":"10","uptime":"60","mem":"20","pad":%q}}`,
+		strings.Repeat("x", 8<<10))
+	msg = strconv.Itoa(len(msg)) + "\n" + msg
+
+	// Each measurement receives a warm up message and then runs messages
+	msgs := make([]string, 2*(runs+1))
+	for i := range msgs {
+		msgs[i] = msg
+	}
+
+	wsc, done := testStatsWebsocket(t, msgs)
+	defer done()
+
+	m := make(map[StatType]json.RawMessage)
+	receive := func(fn func() error) float64 {
+		return testing.AllocsPerRun(runs, func() {
+			for k := range m {
+				delete(m, k)
+			}
+			if err := fn(); err != nil {
+				t.Fatalf("failed to receive stats: %v", err)
+			}
+		})
+	}
+
+	codec := websocket.Codec{Unmarshal: unmarshalStats}
+	perMessage := receive(func() error { return codec.Receive(wsc, &m) })
+	pooled := receive(func() error { return receiveStats(wsc, &m) })
+
+	t.Logf("allocations per message: codec: %v, pooled: %v", perMessage, pooled)
+
+	if pooled >= perMessage {
+		t.Fatalf("expected fewer allocations than websocket.Codec.Receive:\n- want: < %v\n-  got: %v",
+			perMessage, pooled)
+	}
+}